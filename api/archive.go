@@ -0,0 +1,122 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+	"shortURL/worker"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errNothingToRestore = fmt.Errorf("沒有可還原的短網址")
+
+// 封存短連結，封存後導向時顯示停用頁面
+func (server *Server) archiveURL(ctx *gin.Context) {
+	server.setURLArchived(ctx, true)
+}
+
+// 取消封存短連結
+func (server *Server) unarchiveURL(ctx *gin.Context) {
+	server.setURLArchived(ctx, false)
+}
+
+func (server *Server) setURLArchived(ctx *gin.Context, archived bool) {
+	url, domain, ok := server.getStoredURL(ctx)
+	if !ok {
+		return
+	}
+
+	url, err := server.store.SetURLArchivedTx(ctx, db.SetURLArchivedParams{
+		ID:       url.ID,
+		Archived: archived,
+	}, auditInfo(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// 清除快取，下次導向時重新讀取資料庫
+	err = server.redis.DelData(ctx, domainKey(domain.ID, url.ShortUrl))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.distributeEvent(ctx, util.EventLinkUpdated, worker.NewLinkEventData(url))
+
+	ctx.JSON(http.StatusOK, newURLResponse(url))
+}
+
+// 還原保留期限內刪除的短連結
+func (server *Server) restoreURL(ctx *gin.Context) {
+	var uri urlURIRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	domain, ok := server.queryDomain(ctx)
+	if !ok {
+		return
+	}
+
+	url, err := server.store.GetDeletedURL(ctx, db.GetDeletedURLParams{
+		DomainID: domain.ID,
+		ShortUrl: uri.ShortUrl,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errNothingToRestore))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	arg := db.RestoreURLParams{
+		ID:           url.ID,
+		DeletedAfter: time.Now().Add(-server.config.DeleteRetention),
+	}
+
+	url, err = server.store.RestoreURLTx(ctx, arg, auditInfo(ctx))
+	if err != nil {
+		// 已超過保留期限
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errNothingToRestore))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// 清除刪除前留下的快取，下次導向時重新讀取資料庫
+	err = server.redis.DelData(ctx, domainKey(domain.ID, url.ShortUrl))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	tags, err := server.store.ListURLTags(ctx, url.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.distributeEvent(ctx, util.EventLinkUpdated, worker.NewLinkEventData(url))
+
+	rsp := newURLResponse(url)
+	rsp.Tags = tags
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func (server *Server) renderDisabled(ctx *gin.Context) {
+	ctx.HTML(http.StatusGone, "disabled", nil)
+}
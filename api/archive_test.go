@@ -0,0 +1,339 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "shortURL/db/mock"
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+	"shortURL/worker"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_archiveURL(t *testing.T) {
+	url := db.Url{
+		ID:        util.RandomInt(1, 1000),
+		OriginUrl: "https://" + util.RandomLongURL(),
+		ShortUrl:  util.RandomString(6),
+	}
+
+	archivedUrl := url
+	archivedUrl.ArchivedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	testCases := []struct {
+		name          string
+		method        string
+		buildStubs    func(store *mockdb.MockStore)
+		buildStubs2   func(redis *mockdb.MockRedisQuerier)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "Archive",
+			method: http.MethodPost,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Eq(db.GetURLParams{ShortUrl: url.ShortUrl})).
+					Times(1).
+					Return(url, nil)
+				store.EXPECT().
+					SetURLArchivedTx(gomock.Any(), gomock.Eq(db.SetURLArchivedParams{ID: url.ID, Archived: true}), gomock.Any()).
+					Times(1).
+					Return(archivedUrl, nil)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					DelData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotURL urlResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotURL)
+				require.NoError(t, err)
+				require.True(t, gotURL.Archived)
+			},
+		},
+		{
+			name:   "Unarchive",
+			method: http.MethodDelete,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(archivedUrl, nil)
+				store.EXPECT().
+					SetURLArchivedTx(gomock.Any(), gomock.Eq(db.SetURLArchivedParams{ID: url.ID, Archived: false}), gomock.Any()).
+					Times(1).
+					Return(url, nil)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					DelData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotURL urlResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotURL)
+				require.NoError(t, err)
+				require.False(t, gotURL.Archived)
+			},
+		},
+		{
+			name:   "Not found",
+			method: http.MethodPost,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Url{}, sql.ErrNoRows)
+				store.EXPECT().
+					SetURLArchivedTx(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					DelData(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "InternalError",
+			method: http.MethodPost,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(url, nil)
+				store.EXPECT().
+					SetURLArchivedTx(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Url{}, sql.ErrConnDone)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					DelData(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			ctrl2 := gomock.NewController(t)
+			defer ctrl2.Finish()
+
+			mockQueries := mockdb.NewMockStore(ctrl)
+			mockRedis := mockdb.NewMockRedisQuerier(ctrl2)
+			tc.buildStubs(mockQueries)
+			tc.buildStubs2(mockRedis)
+
+			server := newTestServer(t, mockQueries, mockRedis)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(tc.method, "/api/urls/"+url.ShortUrl+"/archive", nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestServer_restoreURL(t *testing.T) {
+	url := db.Url{
+		ID:        util.RandomInt(1, 1000),
+		OriginUrl: "https://" + util.RandomLongURL(),
+		ShortUrl:  util.RandomString(6),
+	}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		buildStubs2   func(redis *mockdb.MockRedisQuerier)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Success case",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDeletedURL(gomock.Any(), gomock.Eq(db.GetDeletedURLParams{DomainID: defaultDomain.ID, ShortUrl: url.ShortUrl})).
+					Times(1).
+					Return(url, nil)
+				store.EXPECT().
+					RestoreURLTx(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.RestoreURLParams, audit db.AuditInfo) (db.Url, error) {
+						require.Equal(t, url.ID, arg.ID)
+						// 測試伺服器的保留期限為一天
+						require.WithinDuration(t, time.Now().Add(-24*time.Hour), arg.DeletedAfter, time.Second)
+						require.Equal(t, "alice", audit.Actor)
+						require.NotEmpty(t, audit.RequestID)
+						return url, nil
+					})
+				store.EXPECT().
+					ListURLTags(gomock.Any(), gomock.Eq(url.ID)).
+					Times(1).
+					Return([]string{"campaign"}, nil)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					DelData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `"tags":["campaign"]`)
+			},
+		},
+		{
+			name: "DelData error",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDeletedURL(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(url, nil)
+				store.EXPECT().
+					RestoreURLTx(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(url, nil)
+				store.EXPECT().
+					ListURLTags(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					DelData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(sql.ErrConnDone)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "Not deleted",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDeletedURL(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Url{}, sql.ErrNoRows)
+				store.EXPECT().
+					RestoreURLTx(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "Retention passed",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDeletedURL(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(url, nil)
+				store.EXPECT().
+					RestoreURLTx(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Url{}, sql.ErrNoRows)
+				store.EXPECT().
+					ListURLTags(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDeletedURL(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(url, nil)
+				store.EXPECT().
+					RestoreURLTx(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Url{}, sql.ErrConnDone)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			ctrl2 := gomock.NewController(t)
+			defer ctrl2.Finish()
+
+			mockQueries := mockdb.NewMockStore(ctrl)
+			mockRedis := mockdb.NewMockRedisQuerier(ctrl2)
+			tc.buildStubs(mockQueries)
+			tc.buildStubs2(mockRedis)
+
+			server := newTestServer(t, mockQueries, mockRedis)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/api/urls/"+url.ShortUrl+"/restore", nil)
+			require.NoError(t, err)
+			request.Header.Set(actorHeader, "alice")
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "shortURL/db/sqlc"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	actorHeader     = "X-Actor"
+	requestIDKey    = "request_id"
+)
+
+// 為每個請求設定編號，沿用呼叫端傳入的編號
+func requestID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(requestIDHeader)
+		if id == "" || len(id) > 64 {
+			b := make([]byte, 16)
+			if _, err := rand.Read(b); err == nil {
+				id = hex.EncodeToString(b)
+			}
+		}
+
+		ctx.Set(requestIDKey, id)
+		ctx.Header(requestIDHeader, id)
+		ctx.Next()
+	}
+}
+
+// 稽核紀錄的操作者資訊，目前沒有登入機制，操作者由標頭指定
+func auditInfo(ctx *gin.Context) db.AuditInfo {
+	return db.AuditInfo{
+		Actor:     ctx.GetHeader(actorHeader),
+		RequestID: ctx.GetString(requestIDKey),
+		ClientIP:  ctx.ClientIP(),
+	}
+}
+
+type listAuditEventsRequest struct {
+	Slug     string    `form:"slug"`
+	Actor    string    `form:"actor"`
+	From     time.Time `form:"from"`
+	To       time.Time `form:"to"`
+	PageID   int32     `form:"page_id" binding:"required,min=1"`
+	PageSize int32     `form:"page_size" binding:"required,min=5,max=50"`
+}
+
+type auditEventResponse struct {
+	ID        int64           `json:"id"`
+	ShortUrl  string          `json:"short_url"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	RequestID string          `json:"request_id"`
+	ClientIP  string          `json:"client_ip"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// 列出網域內的稽核紀錄，新的在前
+func (server *Server) listAuditEvents(ctx *gin.Context) {
+	var req listAuditEventsRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !req.From.IsZero() && !req.To.IsZero() && !req.From.Before(req.To) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("開始時間必須早於結束時間")))
+		return
+	}
+
+	domain, ok := server.queryDomain(ctx)
+	if !ok {
+		return
+	}
+
+	arg := db.ListAuditEventsParams{
+		DomainID: domain.ID,
+		ShortUrl: req.Slug,
+		Actor:    req.Actor,
+		CreatedAfter: sql.NullTime{
+			Time:  req.From,
+			Valid: !req.From.IsZero(),
+		},
+		CreatedBefore: sql.NullTime{
+			Time:  req.To,
+			Valid: !req.To.IsZero(),
+		},
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+
+	events, err := server.store.ListAuditEvents(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]auditEventResponse, 0, len(events))
+	for _, event := range events {
+		rsp = append(rsp, auditEventResponse{
+			ID:        event.ID,
+			ShortUrl:  event.ShortUrl,
+			Actor:     event.Actor,
+			Action:    event.Action,
+			Before:    event.Before,
+			After:     event.After,
+			RequestID: event.RequestID,
+			ClientIP:  event.ClientIp,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
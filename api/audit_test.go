@@ -0,0 +1,169 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "shortURL/db/mock"
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func randomAuditEvent() db.AuditEvent {
+	return db.AuditEvent{
+		ID:        util.RandomInt(1, 1000),
+		UrlID:     util.RandomInt(1, 1000),
+		ShortUrl:  util.RandomString(6),
+		Actor:     util.RandomString(6),
+		Action:    db.AuditActionUpdate,
+		Before:    json.RawMessage(`{"origin_url":"https://example.com"}`),
+		After:     json.RawMessage(`{"origin_url":"https://example.org"}`),
+		RequestID: util.RandomString(32),
+		ClientIp:  "127.0.0.1",
+		CreatedAt: time.Now().Truncate(time.Second),
+	}
+}
+
+func TestServer_listAuditEvents(t *testing.T) {
+	event := randomAuditEvent()
+	from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "Success case",
+			query: "page_id=1&page_size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Eq(db.ListAuditEventsParams{Limit: 5, Offset: 0})).
+					Times(1).
+					Return([]db.AuditEvent{event}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []auditEventResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Len(t, got, 1)
+				require.Equal(t, event.ShortUrl, got[0].ShortUrl)
+				require.Equal(t, event.ClientIp, got[0].ClientIP)
+				require.JSONEq(t, string(event.Before), string(got[0].Before))
+				require.JSONEq(t, string(event.After), string(got[0].After))
+			},
+		},
+		{
+			name:  "Filters",
+			query: "slug=abcdef&actor=alice&from=2022-01-01T00:00:00Z&to=2022-02-01T00:00:00Z&page_id=2&page_size=10",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAuditEventsParams{
+					ShortUrl:      "abcdef",
+					Actor:         "alice",
+					CreatedAfter:  sql.NullTime{Time: from, Valid: true},
+					CreatedBefore: sql.NullTime{Time: to, Valid: true},
+					Limit:         10,
+					Offset:        10,
+				}
+
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]db.AuditEvent{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "[]", recorder.Body.String())
+			},
+		},
+		{
+			name:  "Invalid time range",
+			query: "from=2022-02-01T00:00:00Z&to=2022-01-01T00:00:00Z&page_id=1&page_size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "Invalid page size",
+			query: "page_id=1&page_size=100",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: "page_id=1&page_size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockStore(ctrl)
+			mockRedis := mockdb.NewMockRedisQuerier(ctrl)
+			tc.buildStubs(mockQueries)
+
+			server := newTestServer(t, mockQueries, mockRedis)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/api/audit?"+tc.query, nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl), mockdb.NewMockRedisQuerier(ctrl))
+
+	// 沒有帶編號時產生新的編號
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/api/audit", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Len(t, recorder.Header().Get(requestIDHeader), 32)
+
+	// 沿用呼叫端的編號
+	recorder = httptest.NewRecorder()
+	request.Header.Set(requestIDHeader, "trace-1")
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, "trace-1", recorder.Header().Get(requestIDHeader))
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	db "shortURL/db/sqlc"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// 網域對應的快取時間
+const domainCacheTTL = time.Minute
+
+// 預設網域，未指定網域的連結皆屬於此網域
+var defaultDomain = db.Domain{ID: 0}
+
+type createDomainRequest struct {
+	Host        string `json:"host" binding:"required,hostname"`
+	FallbackUrl string `json:"fallbackUrl" binding:"omitempty,web_url"`
+}
+
+// 建立自訂網域
+func (server *Server) createDomain(ctx *gin.Context) {
+	var req createDomainRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.CreateDomainParams{
+		Host:        strings.ToLower(req.Host),
+		FallbackUrl: req.FallbackUrl,
+	}
+
+	domain, err := server.store.CreateDomain(ctx, arg)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				ctx.JSON(http.StatusConflict, errorResponse(err))
+				return
+			}
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, domain)
+}
+
+type listDomainsRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=50"`
+}
+
+// 列出自訂網域
+func (server *Server) listDomains(ctx *gin.Context) {
+	var req listDomainsRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.ListDomainsParams{
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+
+	domains, err := server.store.ListDomains(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, domains)
+}
+
+// 依照請求的 Host 取得網域，找不到時使用預設網域
+func (server *Server) requestDomain(ctx *gin.Context) (db.Domain, error) {
+	host := strings.ToLower(ctx.Request.Host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	if host == "" || host == server.baseHost {
+		return defaultDomain, nil
+	}
+
+	domain, err := server.getDomain(ctx, host)
+	if err == sql.ErrNoRows {
+		return defaultDomain, nil
+	}
+
+	return domain, err
+}
+
+// 依照 domain 查詢參數取得網域，未帶參數時使用預設網域，失敗時直接回應錯誤
+func (server *Server) queryDomain(ctx *gin.Context) (db.Domain, bool) {
+	host := strings.ToLower(ctx.Query("domain"))
+	if host == "" || host == server.baseHost {
+		return defaultDomain, true
+	}
+
+	domain, err := server.getDomain(ctx, host)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(fmt.Errorf("網域不存在")))
+			return db.Domain{}, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return db.Domain{}, false
+	}
+
+	return domain, true
+}
+
+// 先從記憶體快取取網域，沒有時再查資料庫
+// 查無網域的結果不快取，Host 由請求決定，快取未知的 Host 會讓快取無限增長
+func (server *Server) getDomain(ctx *gin.Context, host string) (db.Domain, error) {
+	if domain, ok := server.domains.get(host); ok {
+		return domain, nil
+	}
+
+	domain, err := server.store.GetDomainByHost(ctx, host)
+	if err != nil {
+		return db.Domain{}, err
+	}
+
+	server.domains.set(host, domain)
+	return domain, nil
+}
+
+// 以網域區分布隆過濾器與快取的 key，預設網域沿用原本的 key
+func domainKey(domainID int64, shortUrl string) string {
+	if domainID == defaultDomain.ID {
+		return shortUrl
+	}
+	return fmt.Sprintf("%d:%s", domainID, shortUrl)
+}
+
+type domainCacheEntry struct {
+	domain    db.Domain
+	expiredAt time.Time
+}
+
+// 網域的記憶體快取，只存放資料庫中存在的網域，數量不超過網域總數
+// 快取在每個執行個體各自維護，網域的變更最多要經過 domainCacheTTL 才會在所有執行個體生效
+type domainCache struct {
+	mu      sync.Mutex
+	entries map[string]domainCacheEntry
+}
+
+func newDomainCache() *domainCache {
+	return &domainCache{entries: make(map[string]domainCacheEntry)}
+}
+
+func (c *domainCache) get(host string) (db.Domain, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[host]
+	if !ok {
+		return db.Domain{}, false
+	}
+
+	// 過期的項目直接移除，已刪除的網域不會一直留在快取中
+	if time.Now().After(entry.expiredAt) {
+		delete(c.entries, host)
+		return db.Domain{}, false
+	}
+
+	return entry.domain, true
+}
+
+func (c *domainCache) set(host string, domain db.Domain) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[host] = domainCacheEntry{
+		domain:    domain,
+		expiredAt: time.Now().Add(domainCacheTTL),
+	}
+}
@@ -0,0 +1,380 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "shortURL/db/mock"
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+	"shortURL/worker"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func randomDomain() db.Domain {
+	return db.Domain{
+		ID:          util.RandomInt(1, 1000),
+		Host:        "go." + strings.ToLower(util.RandomString(6)) + ".com",
+		FallbackUrl: "https://" + util.RandomLongURL(),
+		CreatedAt:   time.Now(),
+	}
+}
+
+func TestServer_createDomain(t *testing.T) {
+	domain := randomDomain()
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Success case",
+			body: gin.H{
+				"host":        domain.Host,
+				"fallbackUrl": domain.FallbackUrl,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateDomainParams{
+					Host:        domain.Host,
+					FallbackUrl: domain.FallbackUrl,
+				}
+
+				store.EXPECT().
+					CreateDomain(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(domain, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotDomain db.Domain
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotDomain)
+				require.NoError(t, err)
+				require.Equal(t, domain.ID, gotDomain.ID)
+				require.Equal(t, domain.Host, gotDomain.Host)
+			},
+		},
+		{
+			name: "Invalid host",
+			body: gin.H{
+				"host": "https://" + domain.Host,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateDomain(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Duplicate host",
+			body: gin.H{
+				"host": domain.Host,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateDomain(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Domain{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"host": domain.Host,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateDomain(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Domain{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockStore(ctrl)
+			mockRedis := mockdb.NewMockRedisQuerier(ctrl)
+			tc.buildStubs(mockQueries)
+
+			server := newTestServer(t, mockQueries, mockRedis)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api/domains", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestServer_getDomainCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	domain := randomDomain()
+
+	// 存在的網域只查詢一次資料庫，查無網域的 Host 每次都查詢
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetDomainByHost(gomock.Any(), gomock.Eq(domain.Host)).
+		Times(1).
+		Return(domain, nil)
+	store.EXPECT().
+		GetDomainByHost(gomock.Any(), gomock.Eq("unknown.example.com")).
+		Times(2).
+		Return(db.Domain{}, sql.ErrNoRows)
+
+	server := newTestServer(t, store, mockdb.NewMockRedisQuerier(ctrl))
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	for i := 0; i < 2; i++ {
+		got, err := server.getDomain(ctx, domain.Host)
+		require.NoError(t, err)
+		require.Equal(t, domain, got)
+
+		_, err = server.getDomain(ctx, "unknown.example.com")
+		require.Equal(t, sql.ErrNoRows, err)
+	}
+
+	_, ok := server.domains.get("unknown.example.com")
+	require.False(t, ok)
+}
+
+func TestServer_listDomains(t *testing.T) {
+	domains := []db.Domain{randomDomain(), randomDomain()}
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "Success case",
+			query: "?page_id=2&page_size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListDomainsParams{
+					Limit:  5,
+					Offset: 5,
+				}
+
+				store.EXPECT().
+					ListDomains(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(domains, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotDomains []db.Domain
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotDomains)
+				require.NoError(t, err)
+				require.Len(t, gotDomains, len(domains))
+			},
+		},
+		{
+			name:  "Invalid page size",
+			query: "?page_id=1&page_size=100",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListDomains(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockStore(ctrl)
+			mockRedis := mockdb.NewMockRedisQuerier(ctrl)
+			tc.buildStubs(mockQueries)
+
+			server := newTestServer(t, mockQueries, mockRedis)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/api/domains"+tc.query, nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestServer_getRedirectCustomDomain(t *testing.T) {
+	domain := randomDomain()
+	url := db.Url{
+		OriginUrl: "https://" + util.RandomLongURL(),
+		ShortUrl:  util.RandomString(6),
+		DomainID:  domain.ID,
+	}
+	key := fmt.Sprintf("%d:%s", domain.ID, url.ShortUrl)
+
+	testCases := []struct {
+		name          string
+		host          string
+		buildStubs    func(store *mockdb.MockStore)
+		buildStubs2   func(redis *mockdb.MockRedisQuerier)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Success case",
+			host: domain.Host + ":8080",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDomainByHost(gomock.Any(), gomock.Eq(domain.Host)).
+					Times(1).
+					Return(domain, nil)
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Eq(db.GetURLParams{DomainID: domain.ID, ShortUrl: url.ShortUrl})).
+					Times(1).
+					Return(url, nil)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(key)).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Eq(key)).
+					Times(1).
+					Return(db.Url{}, false, nil)
+				redis.EXPECT().
+					SetData(gomock.Any(), gomock.Eq(key), gomock.Eq(url)).
+					Times(1).
+					Return(nil)
+				redis.EXPECT().
+					IncrClick(gomock.Any(), gomock.Eq(key), gomock.Any()).
+					Times(1).
+					Return(int64(1), nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusMovedPermanently, recorder.Code)
+				require.Equal(t, url.OriginUrl, recorder.Header().Get("Location"))
+			},
+		},
+		{
+			name: "Fallback url",
+			host: domain.Host,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDomainByHost(gomock.Any(), gomock.Eq(domain.Host)).
+					Times(1).
+					Return(domain, nil)
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(key)).
+					Times(1).
+					Return(false, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusFound, recorder.Code)
+				require.Equal(t, domain.FallbackUrl, recorder.Header().Get("Location"))
+			},
+		},
+		{
+			name: "Unknown host",
+			host: "unknown.example.com",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDomainByHost(gomock.Any(), gomock.Eq("unknown.example.com")).
+					Times(1).
+					Return(db.Domain{}, sql.ErrNoRows)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(false, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "Base host",
+			host: "localhost:8080",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDomainByHost(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(false, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockStore(ctrl)
+			mockRedis := mockdb.NewMockRedisQuerier(ctrl)
+			tc.buildStubs(mockQueries)
+			tc.buildStubs2(mockRedis)
+
+			server := newTestServer(t, mockQueries, mockRedis)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/"+url.ShortUrl, nil)
+			require.NoError(t, err)
+			request.Host = tc.host
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+
+	db "shortURL/db/sqlc"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+type createFolderRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// 建立資料夾
+func (server *Server) createFolder(ctx *gin.Context) {
+	var req createFolderRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	folder, err := server.store.CreateFolder(ctx, req.Name)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				ctx.JSON(http.StatusConflict, errorResponse(err))
+				return
+			}
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, folder)
+}
+
+type listFoldersRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=50"`
+}
+
+// 列出資料夾
+func (server *Server) listFolders(ctx *gin.Context) {
+	var req listFoldersRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.ListFoldersParams{
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+
+	folders, err := server.store.ListFolders(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, folders)
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "shortURL/db/mock"
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func randomFolder() db.Folder {
+	return db.Folder{
+		ID:        util.RandomInt(1, 1000),
+		Name:      util.RandomString(8),
+		CreatedAt: time.Now(),
+	}
+}
+
+func TestServer_createFolder(t *testing.T) {
+	folder := randomFolder()
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Success case",
+			body: gin.H{
+				"name": folder.Name,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateFolder(gomock.Any(), gomock.Eq(folder.Name)).
+					Times(1).
+					Return(folder, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotFolder db.Folder
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotFolder)
+				require.NoError(t, err)
+				require.Equal(t, folder.ID, gotFolder.ID)
+				require.Equal(t, folder.Name, gotFolder.Name)
+			},
+		},
+		{
+			name: "Name empty",
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateFolder(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Duplicate name",
+			body: gin.H{
+				"name": folder.Name,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateFolder(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Folder{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"name": folder.Name,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateFolder(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Folder{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockStore(ctrl)
+			mockRedis := mockdb.NewMockRedisQuerier(ctrl)
+			tc.buildStubs(mockQueries)
+
+			server := newTestServer(t, mockQueries, mockRedis)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api/folders", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestServer_listFolders(t *testing.T) {
+	folders := []db.Folder{randomFolder(), randomFolder()}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQueries := mockdb.NewMockStore(ctrl)
+	mockRedis := mockdb.NewMockRedisQuerier(ctrl)
+
+	mockQueries.EXPECT().
+		ListFolders(gomock.Any(), gomock.Eq(db.ListFoldersParams{Limit: 5, Offset: 5})).
+		Times(1).
+		Return(folders, nil)
+
+	server := newTestServer(t, mockQueries, mockRedis)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/api/folders?page_id=2&page_size=5", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var gotFolders []db.Folder
+	err = json.Unmarshal(recorder.Body.Bytes(), &gotFolders)
+	require.NoError(t, err)
+	require.Len(t, gotFolders, len(folders))
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"shortURL/db/redis"
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+	"shortURL/worker"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, store db.Store, redis redis.RedisQuerier) *Server {
+	config := util.Config{
+		BaseURL:             "http://localhost:8080",
+		DefaultRedirectType: util.RedirectMovedPermanently,
+		InterstitialDelay:   time.Second,
+		UnlockCookieSecret:  util.RandomString(32),
+		UnlockCookieMaxAge:  time.Hour,
+		VariantCookieMaxAge: time.Hour,
+		DeleteRetention:     24 * time.Hour,
+	}
+
+	server, err := NewServer(config, store, redis, nil, worker.NewRedisTaskDistributor(redis))
+	require.NoError(t, err)
+
+	return server
+}
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+
+	os.Exit(m.Run())
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	db "shortURL/db/sqlc"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 短網址結尾加上此字元時顯示預覽頁面
+const previewSuffix = "+"
+
+type previewURLRequest struct {
+	ShortUrl string `uri:"short_url" binding:"required,min=6"`
+}
+
+type previewURLResponse struct {
+	ShortUrl  string            `json:"shortUrl"`
+	OriginUrl string            `json:"originUrl"`
+	CreatedAt time.Time         `json:"createdAt"`
+	NotBefore *time.Time        `json:"notBefore"`
+	NotAfter  *time.Time        `json:"notAfter"`
+	Expired   bool              `json:"expired"`
+	Protected bool              `json:"protected"`
+	Clicks    int64             `json:"clicks"`
+	MaxClicks int64             `json:"maxClicks"`
+	Variants  []variantResponse `json:"variants,omitempty"`
+}
+
+type variantResponse struct {
+	Name        string `json:"name"`
+	Destination string `json:"destination,omitempty"`
+	Weight      int    `json:"weight"`
+	Clicks      int64  `json:"clicks"`
+}
+
+// 取得短連結預覽資訊，不導向也不記錄點擊
+func (server *Server) previewURL(ctx *gin.Context) {
+	var req previewURLRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	domain, ok := server.queryDomain(ctx)
+	if !ok {
+		return
+	}
+
+	preview, ok := server.getPreview(ctx, domain, req.ShortUrl)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, preview)
+}
+
+// 顯示短連結預覽頁面
+func (server *Server) renderPreview(ctx *gin.Context, domain db.Domain, shortUrl string) {
+	preview, ok := server.getPreview(ctx, domain, shortUrl)
+	if !ok {
+		return
+	}
+
+	ctx.HTML(http.StatusOK, "preview", preview)
+}
+
+func (server *Server) getPreview(ctx *gin.Context, domain db.Domain, shortUrl string) (previewURLResponse, bool) {
+	url, ok := server.getURL(ctx, domain, shortUrl)
+	if !ok {
+		return previewURLResponse{}, false
+	}
+
+	clicks, err := server.redis.GetClick(ctx, domainKey(domain.ID, shortUrl))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return previewURLResponse{}, false
+	}
+
+	preview := previewURLResponse{
+		ShortUrl:  server.shortLink(domain, url.ShortUrl),
+		OriginUrl: url.OriginUrl,
+		CreatedAt: url.CreatedAt,
+		Clicks:    clicks,
+		MaxClicks: url.MaxClicks,
+	}
+
+	// 計數器尚未建立或已被清除時，以資料庫的點擊數為準
+	if url.ClickCount > preview.Clicks {
+		preview.Clicks = url.ClickCount
+	}
+
+	variants, err := parseVariants(url.Variants)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return previewURLResponse{}, false
+	}
+
+	// 附上各分流版本的點擊數
+	if len(variants) > 0 {
+		variantClicks, err := server.redis.GetVariantClicks(ctx, domainKey(domain.ID, shortUrl))
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return previewURLResponse{}, false
+		}
+
+		for _, variant := range variants {
+			preview.Variants = append(preview.Variants, variantResponse{
+				Name:        variant.Name,
+				Destination: variant.Destination,
+				Weight:      variant.Weight,
+				Clicks:      variantClicks[variant.Name],
+			})
+		}
+	}
+
+	// 密碼保護的連結不透露目的網址
+	if url.PasswordHash != "" {
+		preview.OriginUrl = ""
+		preview.Protected = true
+
+		for i := range preview.Variants {
+			preview.Variants[i].Destination = ""
+		}
+	}
+
+	if url.NotBefore.Valid {
+		preview.NotBefore = &url.NotBefore.Time
+	}
+
+	if url.NotAfter.Valid {
+		preview.NotAfter = &url.NotAfter.Time
+		preview.Expired = time.Now().After(url.NotAfter.Time)
+	}
+
+	if url.MaxClicks > 0 && preview.Clicks >= url.MaxClicks {
+		preview.Expired = true
+	}
+
+	return preview, true
+}
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "shortURL/db/mock"
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_previewURL(t *testing.T) {
+	url := db.Url{
+		ID:        1,
+		OriginUrl: "https://" + util.RandomLongURL(),
+		ShortUrl:  util.RandomString(6),
+		CreatedAt: time.Now(),
+		NotAfter:  sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+	}
+
+	testCases := []struct {
+		name          string
+		shortUrl      string
+		buildStubs    func(store *mockdb.MockStore)
+		buildStubs2   func(redis *mockdb.MockRedisQuerier)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Success case",
+			shortUrl: url.ShortUrl,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Eq(db.GetURLParams{ShortUrl: url.ShortUrl})).
+					Times(1).
+					Return(url, nil)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(db.Url{}, false, nil)
+				redis.EXPECT().
+					SetData(gomock.Any(), gomock.Eq(url.ShortUrl), gomock.Eq(url)).
+					Times(1).
+					Return(nil)
+				redis.EXPECT().
+					GetClick(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(int64(5), nil)
+				redis.EXPECT().
+					IncrClick(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchPreview(t, recorder.Body, url, 5)
+			},
+		},
+		{
+			name:     "Not found",
+			shortUrl: url.ShortUrl,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(false, nil)
+				redis.EXPECT().
+					GetClick(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "shortURL too short",
+			shortUrl: util.RandomString(3),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "Variants",
+			shortUrl: url.ShortUrl,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				variantUrl := url
+				variantUrl.Variants = json.RawMessage(`[{"name":"a","destination":"https://example.com/a","weight":70},{"name":"b","destination":"https://example.com/b","weight":30}]`)
+
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(variantUrl, true, nil)
+				redis.EXPECT().
+					GetClick(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(10), nil)
+				redis.EXPECT().
+					GetVariantClicks(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(map[string]int64{"a": 7, "b": 3}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotPreview previewURLResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotPreview)
+				require.NoError(t, err)
+				require.Equal(t, []variantResponse{
+					{Name: "a", Destination: "https://example.com/a", Weight: 70, Clicks: 7},
+					{Name: "b", Destination: "https://example.com/b", Weight: 30, Clicks: 3},
+				}, gotPreview.Variants)
+			},
+		},
+		{
+			name:     "GetClick error",
+			shortUrl: url.ShortUrl,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(url, true, nil)
+				redis.EXPECT().
+					GetClick(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockStore(ctrl)
+			mockRedis := mockdb.NewMockRedisQuerier(ctrl)
+			tc.buildStubs(mockQueries)
+			tc.buildStubs2(mockRedis)
+
+			server := newTestServer(t, mockQueries, mockRedis)
+			recorder := httptest.NewRecorder()
+
+			apiUrl := fmt.Sprintf("/api/urls/%s/preview", tc.shortUrl)
+			request, err := http.NewRequest(http.MethodGet, apiUrl, nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func requireBodyMatchPreview(t *testing.T, body *bytes.Buffer, url db.Url, clicks int64) {
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	var gotPreview previewURLResponse
+	err = json.Unmarshal(data, &gotPreview)
+	require.NoError(t, err)
+
+	require.Equal(t, "http://localhost:8080/"+url.ShortUrl, gotPreview.ShortUrl)
+	require.Equal(t, url.OriginUrl, gotPreview.OriginUrl)
+	require.WithinDuration(t, url.CreatedAt, gotPreview.CreatedAt, time.Second)
+	require.NotNil(t, gotPreview.NotAfter)
+	require.WithinDuration(t, url.NotAfter.Time, *gotPreview.NotAfter, time.Second)
+	require.False(t, gotPreview.Expired)
+	require.Equal(t, clicks, gotPreview.Clicks)
+}
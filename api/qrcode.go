@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type getQRCodeRequest struct {
+	ShortUrl string `uri:"short_url" binding:"required,min=6"`
+}
+
+type getQRCodeQuery struct {
+	Format     string `form:"format,default=png" binding:"oneof=png svg"`
+	Size       int    `form:"size,default=256" binding:"min=64,max=2048"`
+	Margin     int    `form:"margin,default=4" binding:"min=0,max=16"`
+	Level      string `form:"level,default=M" binding:"oneof=L M Q H"`
+	Foreground string `form:"fg,default=000000" binding:"hex_color"`
+	Background string `form:"bg,default=ffffff" binding:"hex_color"`
+}
+
+// 取得短連結的 QR code
+func (server *Server) getQRCode(ctx *gin.Context) {
+	var req getQRCodeRequest
+	var query getQRCodeQuery
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	domain, ok := server.queryDomain(ctx)
+	if !ok {
+		return
+	}
+
+	url, ok := server.getURL(ctx, domain, req.ShortUrl)
+	if !ok {
+		return
+	}
+
+	opts := util.QRCodeOptions{
+		Format:     query.Format,
+		Size:       query.Size,
+		Margin:     query.Margin,
+		Level:      query.Level,
+		Foreground: query.Foreground,
+		Background: query.Background,
+	}
+
+	// redis 取圖檔
+	data, haveData, err := server.redis.GetQRCode(ctx, domainKey(domain.ID, url.ShortUrl), opts.Key())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !haveData {
+		data, err = util.RenderQRCode(server.shortLink(domain, url.ShortUrl), opts)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		// 放入 redis
+		err = server.redis.SetQRCode(ctx, domainKey(domain.ID, url.ShortUrl), opts.Key(), data)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	ctx.Data(http.StatusOK, opts.ContentType(), data)
+}
+
+// 組出完整的短網址，自訂網域沿用 BaseURL 的 scheme
+func (server *Server) shortLink(domain db.Domain, shortUrl string) string {
+	baseURL, err := url.Parse(server.config.BaseURL)
+	if err != nil || domain.ID == defaultDomain.ID {
+		return strings.TrimSuffix(server.config.BaseURL, "/") + "/" + shortUrl
+	}
+
+	return baseURL.Scheme + "://" + domain.Host + "/" + shortUrl
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockdb "shortURL/db/mock"
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_getQRCode(t *testing.T) {
+	url := db.Url{
+		OriginUrl: "https://" + util.RandomLongURL(),
+		ShortUrl:  util.RandomString(6),
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		buildStubs2   func(redis *mockdb.MockRedisQuerier)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "Success case",
+			query: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(url, true, nil)
+				redis.EXPECT().
+					GetQRCode(gomock.Any(), gomock.Eq(url.ShortUrl), gomock.Any()).
+					Times(1).
+					Return(nil, false, nil)
+				redis.EXPECT().
+					SetQRCode(gomock.Any(), gomock.Eq(url.ShortUrl), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "image/png", recorder.Header().Get("Content-Type"))
+				require.NotEmpty(t, recorder.Body.Bytes())
+			},
+		},
+		{
+			name:  "Cached SVG",
+			query: "?format=svg&size=128&margin=2&level=H&fg=ff0000&bg=00ff00",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(url, true, nil)
+				redis.EXPECT().
+					GetQRCode(gomock.Any(), gomock.Eq(url.ShortUrl), gomock.Eq("svg:128:2:h:ff0000:00ff00")).
+					Times(1).
+					Return([]byte("<svg></svg>"), true, nil)
+				redis.EXPECT().
+					SetQRCode(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "image/svg+xml", recorder.Header().Get("Content-Type"))
+				require.Equal(t, "<svg></svg>", recorder.Body.String())
+			},
+		},
+		{
+			name:  "Invalid options",
+			query: "?format=gif&fg=black",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "Prefixed hex color",
+			query: "?fg=0xffff",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "Not found",
+			query: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(false, nil)
+				redis.EXPECT().
+					GetQRCode(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(url, true, nil)
+				redis.EXPECT().
+					GetQRCode(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, false, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockStore(ctrl)
+			mockRedis := mockdb.NewMockRedisQuerier(ctrl)
+			tc.buildStubs(mockQueries)
+			tc.buildStubs2(mockRedis)
+
+			server := newTestServer(t, mockQueries, mockRedis)
+			recorder := httptest.NewRecorder()
+
+			apiUrl := fmt.Sprintf("/api/urls/%s/qr%s", url.ShortUrl, tc.query)
+			request, err := http.NewRequest(http.MethodGet, apiUrl, nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
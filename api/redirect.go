@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errUnsafeDestination = errors.New("目的網址必須是 http 或 https")
+
+// 依照連結設定的導向方式導向目的地，未設定時使用服務預設值
+func (server *Server) redirect(ctx *gin.Context, url db.Url, target redirectTarget) {
+	redirectType := url.RedirectType
+	if redirectType == "" {
+		redirectType = server.config.DefaultRedirectType
+	}
+
+	if redirectType == util.RedirectInterstitial {
+		server.renderInterstitial(ctx, url, target)
+		return
+	}
+
+	ctx.Redirect(util.RedirectStatus(redirectType), target.Destination)
+}
+
+// 附加連結的 UTM 參數，並依照連結設定帶入短網址的查詢參數
+func buildDestination(ctx *gin.Context, u db.Url, target redirectTarget) (string, error) {
+	var utm []util.QueryParam
+	if len(u.Utm) > 0 {
+		var template util.UTMTemplate
+		if err := json.Unmarshal(u.Utm, &template); err != nil {
+			return "", err
+		}
+		utm = template.Params(u.ShortUrl, target.Variant)
+	}
+
+	incoming := util.ParseQuery(ctx.Request.URL.RawQuery)
+
+	destination, err := util.BuildDestination(target.Destination, incoming, u.QueryMode, utm)
+	if err != nil {
+		return "", err
+	}
+
+	// 建立前就已驗證，這裡再檢查一次避免舊資料以 javascript: 等協定寫入頁面
+	if !util.IsWebURL(destination) {
+		return "", errUnsafeDestination
+	}
+
+	return destination, nil
+}
+
+// 回傳中繼頁面，讓追蹤像素在導向前執行
+func (server *Server) renderInterstitial(ctx *gin.Context, u db.Url, target redirectTarget) {
+	pixelURL := ""
+	if pixel, err := url.Parse(server.config.TrackingPixelURL); err == nil && server.config.TrackingPixelURL != "" {
+		query := pixel.Query()
+		query.Set("short_url", u.ShortUrl)
+		if target.Variant != "" {
+			query.Set("variant", target.Variant)
+		}
+		pixel.RawQuery = query.Encode()
+		pixelURL = pixel.String()
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.HTML(http.StatusOK, "interstitial", gin.H{
+		"URL":         target.Destination,
+		"Delay":       int(server.config.InterstitialDelay.Seconds()),
+		"DelayMillis": server.config.InterstitialDelay.Milliseconds(),
+		"PixelURL":    pixelURL,
+	})
+}
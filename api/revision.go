@@ -0,0 +1,111 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "shortURL/db/sqlc"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+var errRevisionNotFound = fmt.Errorf("版本不存在")
+
+type listURLRevisionsRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=50"`
+}
+
+type urlRevisionResponse struct {
+	Revision  int32     `json:"revision"`
+	OriginUrl string    `json:"origin_url"`
+	Actor     string    `json:"actor"`
+	Current   bool      `json:"current"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// 列出長連結的歷史版本，新的在前
+func (server *Server) listURLRevisions(ctx *gin.Context) {
+	var req listURLRevisionsRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	url, _, ok := server.getStoredURL(ctx)
+	if !ok {
+		return
+	}
+
+	arg := db.ListURLRevisionsParams{
+		UrlID:  url.ID,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+
+	revisions, err := server.store.ListURLRevisions(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// 第一頁的第一筆就是目前的版本
+	rsp := make([]urlRevisionResponse, 0, len(revisions))
+	for i, revision := range revisions {
+		rsp = append(rsp, urlRevisionResponse{
+			Revision:  revision.Revision,
+			OriginUrl: revision.OriginUrl,
+			Actor:     revision.Actor,
+			Current:   req.PageID == 1 && i == 0,
+			CreatedAt: revision.CreatedAt,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type revisionURIRequest struct {
+	Revision int32 `uri:"n" binding:"required,min=1"`
+}
+
+// 將長連結還原為指定版本，與一般更新相同會重新驗證並清除快取
+func (server *Server) restoreURLRevision(ctx *gin.Context) {
+	var uri revisionURIRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	url, domain, ok := server.getStoredURL(ctx)
+	if !ok {
+		return
+	}
+
+	revision, err := server.store.GetURLRevision(ctx, db.GetURLRevisionParams{
+		UrlID:    url.ID,
+		Revision: uri.Revision,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errRevisionNotFound))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	req := updateURLRequest{OriginUrl: &revision.OriginUrl}
+
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	server.applyURLUpdate(ctx, domain, url, req)
+}
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "shortURL/db/mock"
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+	"shortURL/worker"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_listURLRevisions(t *testing.T) {
+	url := db.Url{
+		ID:        util.RandomInt(1, 1000),
+		OriginUrl: "https://example.com/v2",
+		ShortUrl:  util.RandomString(6),
+	}
+	revisions := []db.UrlRevision{
+		{UrlID: url.ID, Revision: 2, OriginUrl: "https://example.com/v2", CreatedAt: time.Now()},
+		{UrlID: url.ID, Revision: 1, OriginUrl: "https://example.com/v1", CreatedAt: time.Now().Add(-time.Hour)},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQueries := mockdb.NewMockStore(ctrl)
+	mockRedis := mockdb.NewMockRedisQuerier(ctrl)
+
+	mockQueries.EXPECT().
+		GetURL(gomock.Any(), gomock.Eq(db.GetURLParams{ShortUrl: url.ShortUrl})).
+		Times(1).
+		Return(url, nil)
+	mockQueries.EXPECT().
+		ListURLRevisions(gomock.Any(), gomock.Eq(db.ListURLRevisionsParams{UrlID: url.ID, Limit: 5, Offset: 0})).
+		Times(1).
+		Return(revisions, nil)
+
+	server := newTestServer(t, mockQueries, mockRedis)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/api/urls/"+url.ShortUrl+"/revisions?page_id=1&page_size=5", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var got []urlRevisionResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	require.Len(t, got, 2)
+	require.True(t, got[0].Current)
+	require.False(t, got[1].Current)
+	require.Equal(t, "https://example.com/v1", got[1].OriginUrl)
+}
+
+func TestServer_restoreURLRevision(t *testing.T) {
+	url := db.Url{
+		ID:        util.RandomInt(1, 1000),
+		OriginUrl: "https://example.com/v2",
+		ShortUrl:  util.RandomString(6),
+		Rules:     json.RawMessage("[]"),
+	}
+	revision := db.UrlRevision{UrlID: url.ID, Revision: 1, OriginUrl: "https://example.com/v1"}
+
+	testCases := []struct {
+		name          string
+		revision      int32
+		buildStubs    func(store *mockdb.MockStore)
+		buildStubs2   func(redis *mockdb.MockRedisQuerier)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Success case",
+			revision: 1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(url, nil)
+				store.EXPECT().
+					GetURLRevision(gomock.Any(), gomock.Eq(db.GetURLRevisionParams{UrlID: url.ID, Revision: 1})).
+					Times(1).
+					Return(revision, nil)
+				store.EXPECT().
+					UpdateURLTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.UpdateURLTxParams) (db.URLTxResult, error) {
+						require.Equal(t, url.ID, arg.ID)
+						require.Equal(t, revision.OriginUrl, arg.OriginUrl)
+						require.Nil(t, arg.Tags)
+
+						updatedUrl := url
+						updatedUrl.OriginUrl = arg.OriginUrl
+						return db.URLTxResult{Url: updatedUrl, Tags: []string{}}, nil
+					})
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				// 與一般更新相同會清除快取並重新抓取目的網頁資訊
+				redis.EXPECT().
+					DelData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueFetchMetadata), gomock.Any()).
+					Times(1).
+					Return(nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), revision.OriginUrl)
+			},
+		},
+		{
+			name:     "Invalid revision url",
+			revision: 1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(url, nil)
+				store.EXPECT().
+					GetURLRevision(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UrlRevision{UrlID: url.ID, Revision: 1, OriginUrl: "not a url"}, nil)
+				store.EXPECT().
+					UpdateURLTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					DelData(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "Revision not found",
+			revision: 9,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(url, nil)
+				store.EXPECT().
+					GetURLRevision(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UrlRevision{}, sql.ErrNoRows)
+				store.EXPECT().
+					UpdateURLTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					DelData(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "URL not found",
+			revision: 1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Url{}, sql.ErrNoRows)
+				store.EXPECT().
+					GetURLRevision(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					DelData(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "Invalid revision",
+			revision: 0,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					DelData(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			ctrl2 := gomock.NewController(t)
+			defer ctrl2.Finish()
+
+			mockQueries := mockdb.NewMockStore(ctrl)
+			mockRedis := mockdb.NewMockRedisQuerier(ctrl2)
+			tc.buildStubs(mockQueries)
+			tc.buildStubs2(mockRedis)
+
+			server := newTestServer(t, mockQueries, mockRedis)
+			recorder := httptest.NewRecorder()
+
+			path := fmt.Sprintf("/api/urls/%s/revisions/%d/restore", url.ShortUrl, tc.revision)
+			request, err := http.NewRequest(http.MethodPost, path, nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	db "shortURL/db/sqlc"
+	"shortURL/rule"
+	"shortURL/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 導向的目的地，以及提供的分流版本
+type redirectTarget struct {
+	Destination string
+	Variant     string
+}
+
+// 依序以規則與分流版本決定導向目的地，都沒有時導向原始網址
+func (server *Server) resolveTarget(ctx *gin.Context, url db.Url) (redirectTarget, error) {
+	rules, err := parseRules(url.Rules)
+	if err != nil {
+		return redirectTarget{}, err
+	}
+
+	if len(rules) > 0 {
+		req := rule.Request{
+			UserAgent:      ctx.Request.UserAgent(),
+			AcceptLanguage: ctx.GetHeader("Accept-Language"),
+			Query:          ctx.Request.URL.Query(),
+			IP:             net.ParseIP(ctx.ClientIP()),
+			Time:           time.Now(),
+		}
+
+		if destination, ok := rule.Match(rules, req, server.countries); ok {
+			return redirectTarget{Destination: destination}, nil
+		}
+	}
+
+	variants, err := parseVariants(url.Variants)
+	if err != nil {
+		return redirectTarget{}, err
+	}
+
+	if variant, ok := server.pickVariant(ctx, url, variants); ok {
+		return redirectTarget{Destination: variant.Destination, Variant: variant.Name}, nil
+	}
+
+	return redirectTarget{Destination: url.OriginUrl}, nil
+}
+
+// 已分配過版本的訪客沿用 cookie 內的版本，否則以訪客識別碼的雜湊依權重分配
+func (server *Server) pickVariant(ctx *gin.Context, url db.Url, variants []rule.Variant) (rule.Variant, bool) {
+	if len(variants) == 0 {
+		return rule.Variant{}, false
+	}
+
+	cookieName := variantCookieName(url)
+
+	if name, err := ctx.Cookie(cookieName); err == nil {
+		if variant, ok := rule.FindVariant(variants, name); ok {
+			return variant, true
+		}
+	}
+
+	variant, ok := rule.PickVariant(variants, clientID(ctx, url))
+	if !ok {
+		return rule.Variant{}, false
+	}
+
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(
+		cookieName,
+		variant.Name,
+		int(server.config.VariantCookieMaxAge.Seconds()),
+		"/"+url.ShortUrl,
+		"",
+		strings.HasPrefix(server.config.BaseURL, "https://"),
+		true,
+	)
+
+	return variant, true
+}
+
+// 不接受 cookie 的訪客以 IP 與 User-Agent 識別，讓同一訪客分配到相同版本
+func clientID(ctx *gin.Context, url db.Url) string {
+	return domainKey(url.DomainID, url.ShortUrl) + "|" + ctx.ClientIP() + "|" + ctx.Request.UserAgent()
+}
+
+func variantCookieName(url db.Url) string {
+	return "variant_" + url.ShortUrl
+}
+
+func parseRules(data json.RawMessage) ([]rule.Rule, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var rules []rule.Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func parseVariants(data json.RawMessage) ([]rule.Variant, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var variants []rule.Variant
+	if err := json.Unmarshal(data, &variants); err != nil {
+		return nil, err
+	}
+
+	return variants, nil
+}
+
+// 規則未設定時存為空陣列
+func marshalRules(rules []rule.Rule) (json.RawMessage, error) {
+	if rules == nil {
+		rules = []rule.Rule{}
+	}
+
+	return json.Marshal(rules)
+}
+
+// 分流版本未設定時存為空陣列
+func marshalVariants(variants []rule.Variant) (json.RawMessage, error) {
+	if variants == nil {
+		variants = []rule.Variant{}
+	}
+
+	return json.Marshal(variants)
+}
+
+// UTM 範本未設定時存為空物件
+func marshalUTM(utm *util.UTMTemplate) (json.RawMessage, error) {
+	if utm == nil {
+		utm = &util.UTMTemplate{}
+	}
+
+	return json.Marshal(utm)
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+
+	db "shortURL/db/sqlc"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Constants for the link statuses used by search
+const (
+	statusActive    = "active"
+	statusScheduled = "scheduled"
+	statusExpired   = "expired"
+	statusBroken    = "broken"
+	statusArchived  = "archived"
+	statusDeleted   = "deleted"
+)
+
+type searchURLsRequest struct {
+	Query         string    `form:"q" binding:"max=200"`
+	Tag           string    `form:"tag" binding:"max=50"`
+	Owner         string    `form:"owner" binding:"max=100"`
+	FolderID      int64     `form:"folder_id" binding:"omitempty,min=1"`
+	CreatedAfter  time.Time `form:"created_after"`
+	CreatedBefore time.Time `form:"created_before"`
+	Status        string    `form:"status" binding:"omitempty,oneof=active scheduled expired broken archived deleted"`
+	PageID        int32     `form:"page_id" binding:"required,min=1"`
+	PageSize      int32     `form:"page_size" binding:"required,min=5,max=50"`
+}
+
+// 以全文搜尋目的網址、標題與標籤，並依標籤、擁有者、資料夾、建立時間與狀態篩選
+func (server *Server) searchURLs(ctx *gin.Context) {
+	var req searchURLsRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	domain, ok := server.queryDomain(ctx)
+	if !ok {
+		return
+	}
+
+	arg := db.SearchURLsParams{
+		DomainID: domain.ID,
+		Query:    strings.TrimSpace(req.Query),
+		Tag:      strings.ToLower(strings.TrimSpace(req.Tag)),
+		Owner:    req.Owner,
+		FolderID: sql.NullInt64{
+			Int64: req.FolderID,
+			Valid: req.FolderID != 0,
+		},
+		CreatedAfter: sql.NullTime{
+			Time:  req.CreatedAfter,
+			Valid: !req.CreatedAfter.IsZero(),
+		},
+		CreatedBefore: sql.NullTime{
+			Time:  req.CreatedBefore,
+			Valid: !req.CreatedBefore.IsZero(),
+		},
+		Status: req.Status,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+
+	urls, err := server.store.SearchURLs(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp, err := server.newURLResponses(ctx, urls)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// 一次查詢所有連結的標籤
+func (server *Server) newURLResponses(ctx *gin.Context, urls []db.Url) ([]urlResponse, error) {
+	rsp := make([]urlResponse, 0, len(urls))
+	if len(urls) == 0 {
+		return rsp, nil
+	}
+
+	ids := make([]int64, len(urls))
+	for i, url := range urls {
+		ids[i] = url.ID
+	}
+
+	urlTags, err := server.store.ListTagsByURLs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[int64][]string, len(urls))
+	for _, urlTag := range urlTags {
+		tags[urlTag.UrlID] = append(tags[urlTag.UrlID], urlTag.Tag)
+	}
+
+	for _, url := range urls {
+		r := newURLResponse(url)
+		if t, ok := tags[url.ID]; ok {
+			r.Tags = t
+		}
+		rsp = append(rsp, r)
+	}
+
+	return rsp, nil
+}
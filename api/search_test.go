@@ -0,0 +1,203 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "shortURL/db/mock"
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_searchURLs(t *testing.T) {
+	urls := []db.Url{
+		{ID: 1, OriginUrl: "https://" + util.RandomLongURL(), ShortUrl: util.RandomString(6), Title: "Spring sale"},
+		{ID: 2, OriginUrl: "https://" + util.RandomLongURL(), ShortUrl: util.RandomString(6)},
+	}
+
+	createdAfter := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "Success case",
+			query: "?q=spring+sale&tag=Campaign&owner=marketing&folder_id=3&created_after=2022-01-01T00:00:00Z&status=active&page_id=1&page_size=10",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.SearchURLsParams{
+					Query:        "spring sale",
+					Tag:          "campaign",
+					Owner:        "marketing",
+					FolderID:     sql.NullInt64{Int64: 3, Valid: true},
+					CreatedAfter: sql.NullTime{Time: createdAfter, Valid: true},
+					Status:       statusActive,
+					Limit:        10,
+					Offset:       0,
+				}
+
+				store.EXPECT().
+					SearchURLs(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(urls, nil)
+				store.EXPECT().
+					ListTagsByURLs(gomock.Any(), gomock.Eq([]int64{1, 2})).
+					Times(1).
+					Return([]db.UrlTag{
+						{UrlID: 1, Tag: "campaign"},
+						{UrlID: 1, Tag: "sale"},
+					}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotURLs []urlResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotURLs)
+				require.NoError(t, err)
+				require.Len(t, gotURLs, 2)
+				require.Equal(t, []string{"campaign", "sale"}, gotURLs[0].Tags)
+				require.Equal(t, []string{}, gotURLs[1].Tags)
+			},
+		},
+		{
+			name:  "Broken links",
+			query: "?status=broken&page_id=1&page_size=10",
+			buildStubs: func(store *mockdb.MockStore) {
+				brokenUrl := urls[1]
+				brokenUrl.LastStatusCode = http.StatusNotFound
+				brokenUrl.RedirectChain = json.RawMessage(`[{"url":"https://example.com/old","status_code":404}]`)
+				brokenUrl.CheckFailures = 3
+				brokenUrl.BrokenAt = sql.NullTime{Time: createdAfter, Valid: true}
+
+				store.EXPECT().
+					SearchURLs(gomock.Any(), gomock.Eq(db.SearchURLsParams{Status: statusBroken, Limit: 10})).
+					Times(1).
+					Return([]db.Url{brokenUrl}, nil)
+				store.EXPECT().
+					ListTagsByURLs(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.UrlTag{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotURLs []urlResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotURLs)
+				require.NoError(t, err)
+				require.Len(t, gotURLs, 1)
+				require.True(t, gotURLs[0].Broken)
+				require.Equal(t, int32(http.StatusNotFound), gotURLs[0].LastStatusCode)
+				require.JSONEq(t, `[{"url":"https://example.com/old","status_code":404}]`, string(gotURLs[0].RedirectChain))
+			},
+		},
+		{
+			name:  "Deleted links",
+			query: "?status=deleted&page_id=1&page_size=10",
+			buildStubs: func(store *mockdb.MockStore) {
+				deletedUrl := urls[0]
+				deletedUrl.DeletedAt = sql.NullTime{Time: createdAfter, Valid: true}
+
+				store.EXPECT().
+					SearchURLs(gomock.Any(), gomock.Eq(db.SearchURLsParams{Status: statusDeleted, Limit: 10})).
+					Times(1).
+					Return([]db.Url{deletedUrl}, nil)
+				store.EXPECT().
+					ListTagsByURLs(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.UrlTag{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotURLs []urlResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotURLs)
+				require.NoError(t, err)
+				require.Len(t, gotURLs, 1)
+				require.True(t, gotURLs[0].DeletedAt.Valid)
+			},
+		},
+		{
+			name:  "No results",
+			query: "?q=nothing&page_id=1&page_size=10",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchURLs(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Url{}, nil)
+				store.EXPECT().
+					ListTagsByURLs(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "[]", recorder.Body.String())
+			},
+		},
+		{
+			name:  "Invalid status",
+			query: "?status=purged&page_id=1&page_size=10",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchURLs(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "Invalid created_after",
+			query: "?created_after=yesterday&page_id=1&page_size=10",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchURLs(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: "?page_id=1&page_size=10",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchURLs(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockStore(ctrl)
+			mockRedis := mockdb.NewMockRedisQuerier(ctrl)
+			tc.buildStubs(mockQueries)
+
+			server := newTestServer(t, mockQueries, mockRedis)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/api/urls"+tc.query, nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
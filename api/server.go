@@ -1,36 +1,112 @@
 package api
 
 import (
+	"fmt"
+	"net/url"
+	"strings"
+
 	"shortURL/db/redis"
 	db "shortURL/db/sqlc"
+	"shortURL/rule"
+	"shortURL/util"
+	"shortURL/worker"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 )
 
+// minUnlockSecretSize is the minimum length of the key signing the unlock cookies.
+const minUnlockSecretSize = 32
+
 type Server struct {
-	store  db.Querier
-	redis  redis.RedisQuerier
-	router *gin.Engine
+	config      util.Config
+	store       db.Store
+	redis       redis.RedisQuerier
+	countries   rule.CountryResolver
+	distributor worker.TaskDistributor
+	router      *gin.Engine
+	baseHost    string
+	domains     *domainCache
 }
 
 // NewServer creates a new HTTP server and set up routing.
-func NewServer(store db.Querier, redis redis.RedisQuerier) *Server {
+// The country resolver is optional, rules matching on country never match without it.
+// The task distributor enqueues fetching the destination metadata of created links.
+func NewServer(config util.Config, store db.Store, redis redis.RedisQuerier, countries rule.CountryResolver, distributor worker.TaskDistributor) (*Server, error) {
+	if len(config.UnlockCookieSecret) < minUnlockSecretSize {
+		return nil, fmt.Errorf("UNLOCK_COOKIE_SECRET must be at least %d characters", minUnlockSecretSize)
+	}
+
 	server := &Server{
-		store: store,
-		redis: redis,
+		config:      config,
+		store:       store,
+		redis:       redis,
+		countries:   countries,
+		distributor: distributor,
+		domains:     newDomainCache(),
 	}
 
-	server.setupRouter()
-	return server
+	if baseURL, err := url.Parse(config.BaseURL); err == nil {
+		server.baseHost = strings.ToLower(baseURL.Hostname())
+	}
+
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation("redirect_type", validRedirectType)
+		v.RegisterValidation("query_mode", validQueryMode)
+		v.RegisterValidation("webhook_event", validWebhookEvent)
+		v.RegisterValidation("web_url", validWebURL)
+		v.RegisterValidation("hex_color", validHexColor)
+	}
+
+	err := server.setupRouter()
+	if err != nil {
+		return nil, err
+	}
+
+	return server, nil
 }
 
-func (server *Server) setupRouter() {
+func (server *Server) setupRouter() error {
 	router := gin.Default()
 
-	router.POST("/short", server.createShortURL)  // 建立短連結
-	router.GET("/:short_url", server.getRedirect) // 導向長連結
+	// 只採用信任的代理伺服器轉送的 X-Forwarded-For，其他請求以連線的來源位址作為用戶端 IP
+	err := router.SetTrustedProxies(server.config.TrustedProxies)
+	if err != nil {
+		return fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+
+	router.SetHTMLTemplate(newTemplates())
+	router.Use(requestID())
+
+	router.POST("/short", server.createShortURL)                                                // 建立短連結
+	router.GET("/:short_url", server.getRedirect)                                               // 導向長連結，結尾加上 + 時顯示預覽
+	router.GET("/:short_url/*path", server.getRedirect)                                         // 前綴連結，將剩餘路徑接在長連結後
+	router.POST("/:short_url", server.unlockURL)                                                // 解鎖密碼保護的連結
+	router.POST("/:short_url/*path", server.unlockURL)                                          // 解鎖密碼保護的前綴連結
+	router.GET("/api/urls", server.searchURLs)                                                  // 搜尋短連結
+	router.PATCH("/api/urls/:short_url", server.updateURL)                                      // 更新短連結設定
+	router.DELETE("/api/urls/:short_url", server.deleteURL)                                     // 刪除短連結，保留期限內可以還原
+	router.POST("/api/urls/:short_url/restore", server.restoreURL)                              // 還原刪除的短連結
+	router.POST("/api/urls/:short_url/archive", server.archiveURL)                              // 封存短連結
+	router.DELETE("/api/urls/:short_url/archive", server.unarchiveURL)                          // 取消封存短連結
+	router.GET("/api/urls/:short_url/revisions", server.listURLRevisions)                       // 列出長連結的歷史版本
+	router.POST("/api/urls/:short_url/revisions/:n/restore", server.restoreURLRevision)         // 還原長連結的歷史版本
+	router.GET("/api/urls/:short_url/preview", server.previewURL)                               // 預覽短連結
+	router.GET("/api/urls/:short_url/qr", server.getQRCode)                                     // 取得短連結 QR code
+	router.POST("/api/domains", server.createDomain)                                            // 建立自訂網域
+	router.GET("/api/domains", server.listDomains)                                              // 列出自訂網域
+	router.POST("/api/folders", server.createFolder)                                            // 建立資料夾
+	router.GET("/api/folders", server.listFolders)                                              // 列出資料夾
+	router.POST("/api/webhooks", server.createWebhook)                                          // 建立 webhook
+	router.GET("/api/webhooks", server.listWebhooks)                                            // 列出 webhook
+	router.DELETE("/api/webhooks/:id", server.deleteWebhook)                                    // 刪除 webhook
+	router.GET("/api/webhooks/:id/deliveries", server.listWebhookDeliveries)                    // 列出投遞紀錄
+	router.POST("/api/webhooks/:id/deliveries/:delivery_id/redeliver", server.redeliverWebhook) // 重新投遞
+	router.GET("/api/audit", server.listAuditEvents)                                            // 列出稽核紀錄
 
 	server.router = router
+	return nil
 }
 
 // Start runs the HTTP server on a specific address.
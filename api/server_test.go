@@ -0,0 +1,120 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockdb "shortURL/db/mock"
+	"shortURL/util"
+	"shortURL/worker"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewServer(t *testing.T) {
+	testCases := []struct {
+		name           string
+		secret         string
+		trustedProxies []string
+		checkResult    func(t *testing.T, server *Server, err error)
+	}{
+		{
+			name:   "OK",
+			secret: util.RandomString(32),
+			checkResult: func(t *testing.T, server *Server, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, server)
+			},
+		},
+		{
+			name:   "Short secret",
+			secret: util.RandomString(16),
+			checkResult: func(t *testing.T, server *Server, err error) {
+				require.Error(t, err)
+				require.Nil(t, server)
+			},
+		},
+		{
+			name:   "Empty secret",
+			secret: "",
+			checkResult: func(t *testing.T, server *Server, err error) {
+				require.Error(t, err)
+				require.Nil(t, server)
+			},
+		},
+		{
+			name:           "Invalid trusted proxy",
+			secret:         util.RandomString(32),
+			trustedProxies: []string{"not-an-ip"},
+			checkResult: func(t *testing.T, server *Server, err error) {
+				require.Error(t, err)
+				require.Nil(t, server)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			redis := mockdb.NewMockRedisQuerier(ctrl)
+			config := util.Config{
+				UnlockCookieSecret: tc.secret,
+				TrustedProxies:     tc.trustedProxies,
+			}
+
+			server, err := NewServer(config, mockdb.NewMockStore(ctrl), redis, nil, worker.NewRedisTaskDistributor(redis))
+			tc.checkResult(t, server, err)
+		})
+	}
+}
+
+func TestServerClientIP(t *testing.T) {
+	testCases := []struct {
+		name           string
+		trustedProxies []string
+		clientIP       string
+	}{
+		{
+			name:     "No trusted proxy",
+			clientIP: "192.0.2.1",
+		},
+		{
+			name:           "Trusted proxy",
+			trustedProxies: []string{"192.0.2.0/24"},
+			clientIP:       "198.51.100.7",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			redis := mockdb.NewMockRedisQuerier(ctrl)
+			config := util.Config{
+				UnlockCookieSecret: util.RandomString(32),
+				TrustedProxies:     tc.trustedProxies,
+			}
+
+			server, err := NewServer(config, mockdb.NewMockStore(ctrl), redis, nil, worker.NewRedisTaskDistributor(redis))
+			require.NoError(t, err)
+
+			server.router.GET("/test/client-ip", func(ctx *gin.Context) {
+				ctx.String(http.StatusOK, ctx.ClientIP())
+			})
+
+			request := httptest.NewRequest(http.MethodGet, "/test/client-ip", nil)
+			request.RemoteAddr = "192.0.2.1:1234"
+			request.Header.Set("X-Forwarded-For", "198.51.100.7")
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.clientIP, recorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"sort"
+	"strings"
+)
+
+// 標籤一律轉為小寫並去除重複
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	normalized := []string{}
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	sort.Strings(normalized)
+	return normalized
+}
@@ -0,0 +1,80 @@
+package api
+
+import "html/template"
+
+// 中繼頁面，先執行追蹤像素再透過 meta refresh 與 JS 導向
+const interstitialTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="{{.Delay}};url={{.URL}}">
+<title>Redirecting…</title>
+</head>
+<body>
+{{if .PixelURL}}<img src="{{.PixelURL}}" width="1" height="1" alt="" style="display:none">{{end}}
+<p>Redirecting to <a href="{{.URL}}">{{.URL}}</a></p>
+<script>
+setTimeout(function () { window.location.replace({{.URL}}); }, {{.DelayMillis}});
+</script>
+</body>
+</html>`
+
+// 預覽頁面，只顯示連結資訊不導向
+const previewTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>Preview {{.ShortUrl}}</title>
+</head>
+<body>
+<h1>{{.ShortUrl}}</h1>
+<dl>
+<dt>Destination</dt><dd>{{if .Protected}}password protected{{else}}<a href="{{.OriginUrl}}" rel="noopener noreferrer nofollow">{{.OriginUrl}}</a>{{end}}</dd>
+<dt>Created</dt><dd>{{.CreatedAt.Format "2006-01-02 15:04:05 MST"}}</dd>
+<dt>Expires</dt><dd>{{if .NotAfter}}{{.NotAfter.Format "2006-01-02 15:04:05 MST"}}{{if .Expired}} (expired){{end}}{{else}}never{{end}}</dd>
+{{if .NotBefore}}<dt>Opens</dt><dd>{{.NotBefore.Format "2006-01-02 15:04:05 MST"}}</dd>{{end}}
+<dt>Clicks</dt><dd>{{.Clicks}}{{if .MaxClicks}} / {{.MaxClicks}}{{end}}</dd>
+</dl>
+</body>
+</html>`
+
+// 密碼保護連結的解鎖表單
+const unlockTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>Password required</title>
+</head>
+<body>
+<form method="post" action="{{.Action}}">
+<p>This link is password protected.</p>
+{{if .Message}}<p>{{.Message}}</p>{{end}}
+<input type="password" name="password" autofocus required>
+<button type="submit">Unlock</button>
+</form>
+</body>
+</html>`
+
+// 封存連結的停用頁面
+const disabledTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>Link disabled</title>
+</head>
+<body>
+<p>This link has been disabled.</p>
+</body>
+</html>`
+
+func newTemplates() *template.Template {
+	tmpl := template.Must(template.New("interstitial").Parse(interstitialTemplate))
+	template.Must(tmpl.New("preview").Parse(previewTemplate))
+	template.Must(tmpl.New("unlock").Parse(unlockTemplate))
+	template.Must(tmpl.New("disabled").Parse(disabledTemplate))
+
+	return tmpl
+}
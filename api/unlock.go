@@ -0,0 +1,150 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// 時間窗內允許的解鎖嘗試次數
+	maxUnlockAttempts   = 5
+	unlockAttemptWindow = 15 * time.Minute
+)
+
+type unlockURLRequest struct {
+	Password string `form:"password" binding:"required"`
+}
+
+// 驗證密碼並發出解鎖 cookie
+func (server *Server) unlockURL(ctx *gin.Context) {
+	var uri getRedirectRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	domain, err := server.requestDomain(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	url, err := server.lookupURL(ctx, domain, uri.ShortUrl)
+	if err != nil {
+		if errors.Is(err, errURLNotFound) {
+			server.notFound(ctx, domain, err)
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if url.PasswordHash == "" {
+		ctx.Redirect(http.StatusSeeOther, ctx.Request.URL.Path)
+		return
+	}
+
+	var req unlockURLRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		server.renderUnlock(ctx, http.StatusBadRequest, "請輸入密碼")
+		return
+	}
+
+	// 以連結與來源 IP 計算嘗試次數，避免暴力破解
+	attemptKey := domainKey(domain.ID, url.ShortUrl) + ":" + ctx.ClientIP()
+	attempts, err := server.redis.IncrUnlockAttempt(ctx, attemptKey, unlockAttemptWindow)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if attempts > maxUnlockAttempts {
+		server.renderUnlock(ctx, http.StatusTooManyRequests, "嘗試次數過多，請稍後再試")
+		return
+	}
+
+	if err := util.CheckPassword(req.Password, url.PasswordHash); err != nil {
+		server.renderUnlock(ctx, http.StatusUnauthorized, "密碼錯誤")
+		return
+	}
+
+	err = server.redis.DelUnlockAttempt(ctx, attemptKey)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	expiredAt := time.Now().Add(server.config.UnlockCookieMaxAge)
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(
+		unlockCookieName(url),
+		server.unlockToken(url, expiredAt),
+		int(server.config.UnlockCookieMaxAge.Seconds()),
+		"/"+url.ShortUrl,
+		"",
+		strings.HasPrefix(server.config.BaseURL, "https://"),
+		true,
+	)
+
+	ctx.Redirect(http.StatusSeeOther, ctx.Request.URL.Path)
+}
+
+// 顯示解鎖表單
+func (server *Server) renderUnlock(ctx *gin.Context, code int, message string) {
+	ctx.Header("Cache-Control", "no-store")
+	ctx.HTML(code, "unlock", gin.H{
+		"Action":  ctx.Request.URL.Path,
+		"Message": message,
+	})
+}
+
+// 檢查請求是否帶有此連結有效的解鎖 cookie
+func (server *Server) unlocked(ctx *gin.Context, url db.Url) bool {
+	token, err := ctx.Cookie(unlockCookieName(url))
+	if err != nil {
+		return false
+	}
+
+	expiredAtText, _, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+
+	expiredAtUnix, err := strconv.ParseInt(expiredAtText, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	expiredAt := time.Unix(expiredAtUnix, 0)
+	if time.Now().After(expiredAt) {
+		return false
+	}
+
+	return hmac.Equal([]byte(token), []byte(server.unlockToken(url, expiredAt)))
+}
+
+// 簽署解鎖 token，密碼變更後舊的 token 即失效
+func (server *Server) unlockToken(url db.Url, expiredAt time.Time) string {
+	mac := hmac.New(sha256.New, []byte(server.config.UnlockCookieSecret))
+	fmt.Fprintf(mac, "%d:%s:%s:%d", url.DomainID, url.ShortUrl, url.PasswordHash, expiredAt.Unix())
+
+	return fmt.Sprintf("%d.%s", expiredAt.Unix(), base64.RawURLEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+func unlockCookieName(url db.Url) string {
+	return "unlock_" + url.ShortUrl
+}
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	mockdb "shortURL/db/mock"
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+	"shortURL/worker"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func randomProtectedURL(t *testing.T) (db.Url, string) {
+	password := util.RandomString(8)
+
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+
+	url := db.Url{
+		OriginUrl:    "https://" + util.RandomLongURL(),
+		ShortUrl:     util.RandomString(6),
+		PasswordHash: hashedPassword,
+	}
+
+	return url, password
+}
+
+func newUnlockRequest(t *testing.T, shortUrl string, password string) *http.Request {
+	form := url.Values{"password": {password}}
+
+	request, err := http.NewRequest(http.MethodPost, "/"+shortUrl, strings.NewReader(form.Encode()))
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.RemoteAddr = "192.0.2.1:1234"
+	// 沒有設定信任的代理伺服器時，用戶端送出的 X-Forwarded-For 不影響來源 IP
+	request.Header.Set("X-Forwarded-For", fmt.Sprintf("198.51.100.%d", util.RandomInt(1, 254)))
+
+	return request
+}
+
+func TestServer_unlockURL(t *testing.T) {
+	protectedUrl, password := randomProtectedURL(t)
+
+	testCases := []struct {
+		name          string
+		password      string
+		buildStubs    func(redis *mockdb.MockRedisQuerier)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Success case",
+			password: password,
+			buildStubs: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					IncrUnlockAttempt(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(1), nil)
+				redis.EXPECT().
+					DelUnlockAttempt(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusSeeOther, recorder.Code)
+				require.Equal(t, "/"+protectedUrl.ShortUrl, recorder.Header().Get("Location"))
+				require.Contains(t, recorder.Header().Get("Set-Cookie"), unlockCookieName(protectedUrl))
+				require.Contains(t, recorder.Header().Get("Set-Cookie"), "HttpOnly")
+			},
+		},
+		{
+			name:     "Wrong password",
+			password: "wrong-" + password,
+			buildStubs: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					IncrUnlockAttempt(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(2), nil)
+				redis.EXPECT().
+					DelUnlockAttempt(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Empty(t, recorder.Header().Get("Set-Cookie"))
+			},
+		},
+		{
+			name:     "Too many attempts",
+			password: password,
+			buildStubs: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					IncrUnlockAttempt(gomock.Any(), gomock.Eq(domainKey(defaultDomain.ID, protectedUrl.ShortUrl)+":192.0.2.1"), gomock.Any()).
+					Times(1).
+					Return(int64(maxUnlockAttempts+1), nil)
+				redis.EXPECT().
+					DelUnlockAttempt(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.Empty(t, recorder.Header().Get("Set-Cookie"))
+			},
+		},
+		{
+			name:     "Missing password",
+			password: "",
+			buildStubs: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					IncrUnlockAttempt(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InternalError",
+			password: password,
+			buildStubs: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					IncrUnlockAttempt(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockStore(ctrl)
+			mockRedis := mockdb.NewMockRedisQuerier(ctrl)
+
+			mockRedis.EXPECT().
+				ExistBloom(gomock.Any(), gomock.Eq(protectedUrl.ShortUrl)).
+				Times(1).
+				Return(true, nil)
+			mockRedis.EXPECT().
+				GetData(gomock.Any(), gomock.Eq(protectedUrl.ShortUrl)).
+				Times(1).
+				Return(protectedUrl, true, nil)
+			tc.buildStubs(mockRedis)
+
+			server := newTestServer(t, mockQueries, mockRedis)
+			recorder := httptest.NewRecorder()
+
+			server.router.ServeHTTP(recorder, newUnlockRequest(t, protectedUrl.ShortUrl, tc.password))
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestServer_getRedirectProtected(t *testing.T) {
+	protectedUrl, password := randomProtectedURL(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQueries := mockdb.NewMockStore(ctrl)
+	mockRedis := mockdb.NewMockRedisQuerier(ctrl)
+
+	mockRedis.EXPECT().
+		ExistBloom(gomock.Any(), gomock.Eq(protectedUrl.ShortUrl)).
+		AnyTimes().
+		Return(true, nil)
+	mockRedis.EXPECT().
+		GetData(gomock.Any(), gomock.Eq(protectedUrl.ShortUrl)).
+		AnyTimes().
+		Return(protectedUrl, true, nil)
+	mockRedis.EXPECT().
+		IncrUnlockAttempt(gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(int64(1), nil)
+	mockRedis.EXPECT().
+		DelUnlockAttempt(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil)
+	mockRedis.EXPECT().
+		IncrClick(gomock.Any(), gomock.Eq(protectedUrl.ShortUrl), gomock.Any()).
+		Times(1).
+		Return(int64(1), nil)
+	mockRedis.EXPECT().
+		PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+		Times(1).
+		Return(nil)
+
+	server := newTestServer(t, mockQueries, mockRedis)
+
+	// 沒有 cookie 時顯示解鎖表單
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/"+protectedUrl.ShortUrl, nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.Contains(t, recorder.Body.String(), `name="password"`)
+	require.NotContains(t, recorder.Body.String(), protectedUrl.OriginUrl)
+
+	// 解鎖後帶著 cookie 再次請求即可導向
+	recorder = httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, newUnlockRequest(t, protectedUrl.ShortUrl, password))
+	require.Equal(t, http.StatusSeeOther, recorder.Code)
+
+	cookies := recorder.Result().Cookies()
+	require.Len(t, cookies, 1)
+
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, "/"+protectedUrl.ShortUrl, nil)
+	require.NoError(t, err)
+	request.AddCookie(cookies[0])
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusMovedPermanently, recorder.Code)
+	require.Equal(t, protectedUrl.OriginUrl, recorder.Header().Get("Location"))
+
+	// 竄改過的 cookie 無效
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, "/"+protectedUrl.ShortUrl, nil)
+	require.NoError(t, err)
+	request.AddCookie(&http.Cookie{Name: cookies[0].Name, Value: cookies[0].Value + "x"})
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
@@ -1,18 +1,123 @@
 package api
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	db "shortURL/db/sqlc"
+	"shortURL/rule"
 	"shortURL/util"
+	"shortURL/worker"
 
 	"github.com/gin-gonic/gin"
 )
 
+var errURLNotFound = errors.New("短網址不存在")
+
 type createShortURLRequest struct {
-	OriginUrl string `json:"originUrl" binding:"required,url"`
+	OriginUrl    string            `json:"originUrl" binding:"required,web_url"`
+	RedirectType string            `json:"redirectType" binding:"omitempty,redirect_type"`
+	NotBefore    time.Time         `json:"notBefore"`
+	NotAfter     time.Time         `json:"notAfter" binding:"omitempty,gt,gtfield=NotBefore"`
+	MaxClicks    int64             `json:"maxClicks" binding:"omitempty,min=1"`
+	Domain       string            `json:"domain" binding:"omitempty,hostname"`
+	Password     string            `json:"password" binding:"omitempty,min=6"`
+	Rules        []rule.Rule       `json:"rules" binding:"omitempty,max=20,dive"`
+	Variants     []rule.Variant    `json:"variants" binding:"omitempty,max=10,unique=Name,dive"`
+	QueryMode    string            `json:"queryMode" binding:"omitempty,query_mode"`
+	Utm          *util.UTMTemplate `json:"utm"`
+	Prefix       bool              `json:"prefix"`
+	Title        string            `json:"title" binding:"max=200"`
+	Description  string            `json:"description" binding:"max=1000"`
+	Owner        string            `json:"owner" binding:"max=100"`
+	FolderID     int64             `json:"folderId" binding:"omitempty,min=1"`
+	Tags         []string          `json:"tags" binding:"omitempty,max=20,dive,max=50"`
+}
+
+type urlResponse struct {
+	ID                int64           `json:"id"`
+	OriginUrl         string          `json:"origin_url"`
+	ShortUrl          string          `json:"short_url"`
+	CreatedAt         time.Time       `json:"created_at"`
+	RedirectType      string          `json:"redirect_type"`
+	NotBefore         sql.NullTime    `json:"not_before"`
+	NotAfter          sql.NullTime    `json:"not_after"`
+	MaxClicks         int64           `json:"max_clicks"`
+	ClickCount        int64           `json:"click_count"`
+	DomainID          int64           `json:"domain_id"`
+	PasswordProtected bool            `json:"password_protected"`
+	Rules             json.RawMessage `json:"rules"`
+	Variants          json.RawMessage `json:"variants"`
+	QueryMode         string          `json:"query_mode"`
+	Utm               json.RawMessage `json:"utm"`
+	IsPrefix          bool            `json:"is_prefix"`
+	Title             string          `json:"title"`
+	Description       string          `json:"description"`
+	Owner             string          `json:"owner"`
+	FolderID          sql.NullInt64   `json:"folder_id"`
+	Tags              []string        `json:"tags"`
+	PageTitle         string          `json:"page_title"`
+	PageDescription   string          `json:"page_description"`
+	FaviconUrl        string          `json:"favicon_url"`
+	OgImageUrl        string          `json:"og_image_url"`
+	MetadataFetchedAt sql.NullTime    `json:"metadata_fetched_at"`
+	LastStatusCode    int32           `json:"last_status_code"`
+	RedirectChain     json.RawMessage `json:"redirect_chain"`
+	CheckError        string          `json:"check_error"`
+	LastCheckedAt     sql.NullTime    `json:"last_checked_at"`
+	Broken            bool            `json:"broken"`
+	BrokenAt          sql.NullTime    `json:"broken_at"`
+	Archived          bool            `json:"archived"`
+	ArchivedAt        sql.NullTime    `json:"archived_at"`
+	DeletedAt         sql.NullTime    `json:"deleted_at"`
+}
+
+// 回應時不帶出密碼雜湊
+func newURLResponse(url db.Url) urlResponse {
+	return urlResponse{
+		ID:                url.ID,
+		OriginUrl:         url.OriginUrl,
+		ShortUrl:          url.ShortUrl,
+		CreatedAt:         url.CreatedAt,
+		RedirectType:      url.RedirectType,
+		NotBefore:         url.NotBefore,
+		NotAfter:          url.NotAfter,
+		MaxClicks:         url.MaxClicks,
+		ClickCount:        url.ClickCount,
+		DomainID:          url.DomainID,
+		PasswordProtected: url.PasswordHash != "",
+		Rules:             url.Rules,
+		Variants:          url.Variants,
+		QueryMode:         url.QueryMode,
+		Utm:               url.Utm,
+		IsPrefix:          url.IsPrefix,
+		Title:             url.Title,
+		Description:       url.Description,
+		Owner:             url.Owner,
+		FolderID:          url.FolderID,
+		Tags:              []string{},
+		PageTitle:         url.PageTitle,
+		PageDescription:   url.PageDescription,
+		FaviconUrl:        url.FaviconUrl,
+		OgImageUrl:        url.OgImageUrl,
+		MetadataFetchedAt: url.MetadataFetchedAt,
+		LastStatusCode:    url.LastStatusCode,
+		RedirectChain:     url.RedirectChain,
+		CheckError:        url.CheckError,
+		LastCheckedAt:     url.LastCheckedAt,
+		Broken:            url.BrokenAt.Valid,
+		BrokenAt:          url.BrokenAt,
+		Archived:          url.ArchivedAt.Valid,
+		ArchivedAt:        url.ArchivedAt,
+		DeletedAt:         url.DeletedAt,
+	}
 }
 
 // 建立短連結
@@ -24,48 +129,450 @@ func (server *Server) createShortURL(ctx *gin.Context) {
 		return
 	}
 
+	domain := defaultDomain
+	if req.Domain != "" && strings.ToLower(req.Domain) != server.baseHost {
+		var err error
+		domain, err = server.getDomain(ctx, strings.ToLower(req.Domain))
+		if err != nil {
+			if err == sql.ErrNoRows {
+				ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("網域不存在")))
+				return
+			}
+
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	if req.FolderID != 0 && !server.checkFolder(ctx, req.FolderID) {
+		return
+	}
+
+	var passwordHash string
+	if req.Password != "" {
+		var err error
+		passwordHash, err = util.HashPassword(req.Password)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	rules, err := marshalRules(req.Rules)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	variants, err := marshalVariants(req.Variants)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	utm, err := marshalUTM(req.Utm)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	arg := db.CreateURLTxParams{
+		CreateURLParams: db.CreateURLParams{
+			OriginUrl:    req.OriginUrl,
+			RedirectType: req.RedirectType,
+			NotAfter: sql.NullTime{
+				Time:  req.NotAfter,
+				Valid: !req.NotAfter.IsZero(),
+			},
+			DomainID:     domain.ID,
+			PasswordHash: passwordHash,
+			NotBefore: sql.NullTime{
+				Time:  req.NotBefore,
+				Valid: !req.NotBefore.IsZero(),
+			},
+			MaxClicks:   req.MaxClicks,
+			Rules:       rules,
+			Variants:    variants,
+			QueryMode:   req.QueryMode,
+			Utm:         utm,
+			IsPrefix:    req.Prefix,
+			Title:       req.Title,
+			Description: req.Description,
+			Owner:       req.Owner,
+			FolderID: sql.NullInt64{
+				Int64: req.FolderID,
+				Valid: req.FolderID != 0,
+			},
+		},
+		Tags:  normalizeTags(req.Tags),
+		Audit: auditInfo(ctx),
+	}
+
 	// 產生短網址
-	var shortUrl string
-	retry := 1
+	var result db.URLTxResult
 
-	for {
+	for retry := 1; ; retry++ {
 		if retry > 5 {
 			ctx.JSON(http.StatusInternalServerError, errorResponse(fmt.Errorf("產生短網址失敗超過次數")))
 			return
 		}
 
-		shortUrl = util.RandomString(6)
+		arg.ShortUrl = util.RandomString(6)
 
-		// 設置布隆過濾器
-		exist, err := server.redis.SetBloom(ctx, shortUrl)
+		// 設置布隆過濾器，各網域分開計算
+		exist, err := server.redis.SetBloom(ctx, domainKey(domain.ID, arg.ShortUrl))
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 			return
 		}
 
-		if exist {
+		if !exist {
+			continue
+		}
+
+		if server.config.WriteBehind {
+			var queued bool
+			result, queued, err = server.queueURL(ctx, domainKey(domain.ID, arg.ShortUrl), arg)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+
+			if queued {
+				break
+			}
+			continue
+		}
+
+		result, err = server.store.CreateURLTx(ctx, arg)
+		if err == nil {
 			break
 		}
 
-		retry++
+		// 布隆過濾器競爭或 Redis 資料遺失時由資料庫的唯一索引擋下，換一個短網址重試
+		var conflict *db.ShortURLConflictError
+		if errors.As(err, &conflict) {
+			continue
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// 延遲寫入的連結由 URLFlusher 在寫入資料庫後送出背景工作
+	url := result.Url
+	if !server.config.WriteBehind {
+		server.distributeFetchMetadata(ctx, url)
+		server.distributeEvent(ctx, util.EventLinkCreated, worker.NewLinkEventData(url))
+	}
+
+	rsp := newURLResponse(url)
+	rsp.Tags = result.Tags
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// 延遲寫入模式先將連結保留在 redis 並放入快取，由 URLFlusher 批次寫入資料庫，
+// 寫入前連結的 ID 為 0。短網址已在等待寫入時回傳 false
+func (server *Server) queueURL(ctx context.Context, key string, arg db.CreateURLTxParams) (db.URLTxResult, bool, error) {
+	// 資料庫只保存到微秒，寫入後才能以建立時間辨識同一個連結
+	arg.CreatedAt = time.Now().Truncate(time.Microsecond)
+	payload := worker.NewPayloadCreateURL(arg)
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return db.URLTxResult{}, false, err
+	}
+
+	// 排入佇列與快取在同一個操作完成，不會有排入後沒有快取的連結
+	queued, err := server.redis.EnqueuePendingURL(ctx, key, data, payload.Url)
+	if err != nil || !queued {
+		return db.URLTxResult{}, false, err
+	}
+
+	return db.URLTxResult{Url: payload.Url, Tags: payload.Tags}, true, nil
+}
+
+// 在背景抓取目的網頁資訊，失敗時不影響連結本身
+func (server *Server) distributeFetchMetadata(ctx context.Context, url db.Url) {
+	payload := &worker.PayloadFetchMetadata{
+		URLID:     url.ID,
+		OriginUrl: url.OriginUrl,
 	}
 
-	arg := db.CreateURLParams{
-		OriginUrl: req.OriginUrl,
-		ShortUrl:  shortUrl,
+	err := server.distributor.DistributeFetchMetadata(ctx, payload)
+	if err != nil {
+		log.Println("cannot distribute fetch metadata task:", err)
 	}
+}
 
-	account, err := server.store.CreateURL(ctx, arg)
+// 發送 webhook 事件，失敗時不影響請求本身
+func (server *Server) distributeEvent(ctx context.Context, event string, data interface{}) {
+	payload, err := worker.NewPayloadWebhookEvent(event, data)
 	if err != nil {
+		log.Println("cannot create webhook event:", err)
+		return
+	}
+
+	err = server.distributor.DistributeWebhookEvent(ctx, payload)
+	if err != nil {
+		log.Println("cannot distribute webhook event:", err)
+	}
+}
+
+// 確認資料夾存在，失敗時直接回應錯誤
+func (server *Server) checkFolder(ctx *gin.Context, folderID int64) bool {
+	_, err := server.store.GetFolder(ctx, folderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("資料夾不存在")))
+			return false
+		}
+
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	return true
+}
+
+type urlURIRequest struct {
+	ShortUrl string `uri:"short_url" binding:"required,min=6"`
+}
+
+// 欄位未帶入時維持原本的設定
+type updateURLRequest struct {
+	OriginUrl    *string           `json:"originUrl" binding:"omitempty,web_url"`
+	RedirectType *string           `json:"redirectType" binding:"omitempty,redirect_type"`
+	Rules        *[]rule.Rule      `json:"rules" binding:"omitempty,max=20,dive"`
+	Variants     *[]rule.Variant   `json:"variants" binding:"omitempty,max=10,unique=Name,dive"`
+	QueryMode    *string           `json:"queryMode" binding:"omitempty,query_mode"`
+	Utm          *util.UTMTemplate `json:"utm"`
+	Prefix       *bool             `json:"prefix"`
+	Title        *string           `json:"title" binding:"omitempty,max=200"`
+	Description  *string           `json:"description" binding:"omitempty,max=1000"`
+	FolderID     *int64            `json:"folderId" binding:"omitempty,min=0"`
+	Tags         *[]string         `json:"tags" binding:"omitempty,max=20,dive,max=50"`
+}
+
+// 更新短連結設定
+func (server *Server) updateURL(ctx *gin.Context) {
+	var uri urlURIRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateURLRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	domain, ok := server.queryDomain(ctx)
+	if !ok {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	// 直接從主資料庫取得，避免用快取或副本內的舊資料覆蓋
+	url, err := server.store.GetURL(db.ReadPrimary(ctx), db.GetURLParams{
+		DomainID: domain.ID,
+		ShortUrl: uri.ShortUrl,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errURLNotFound))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.applyURLUpdate(ctx, domain, url, req)
+}
+
+// 套用更新並清除快取，請求內容必須已經驗證過
+func (server *Server) applyURLUpdate(ctx *gin.Context, domain db.Domain, url db.Url, req updateURLRequest) {
+	var err error
+
+	originUrl := url.OriginUrl
+	arg := db.UpdateURLSettingsParams{
+		ID:           url.ID,
+		OriginUrl:    url.OriginUrl,
+		RedirectType: url.RedirectType,
+		Rules:        url.Rules,
+		Variants:     url.Variants,
+		QueryMode:    url.QueryMode,
+		Utm:          url.Utm,
+		IsPrefix:     url.IsPrefix,
+		Title:        url.Title,
+		Description:  url.Description,
+		FolderID:     url.FolderID,
+	}
+
+	if req.OriginUrl != nil {
+		arg.OriginUrl = *req.OriginUrl
+	}
+
+	if req.RedirectType != nil {
+		arg.RedirectType = *req.RedirectType
+	}
+
+	if req.Rules != nil {
+		arg.Rules, err = marshalRules(*req.Rules)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	if req.Variants != nil {
+		arg.Variants, err = marshalVariants(*req.Variants)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	if req.QueryMode != nil {
+		arg.QueryMode = *req.QueryMode
+	}
+
+	if req.Utm != nil {
+		arg.Utm, err = marshalUTM(req.Utm)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	if req.Prefix != nil {
+		arg.IsPrefix = *req.Prefix
+	}
+
+	if req.Title != nil {
+		arg.Title = *req.Title
+	}
+
+	if req.Description != nil {
+		arg.Description = *req.Description
+	}
+
+	// 資料夾編號為 0 時移出資料夾
+	if req.FolderID != nil {
+		if *req.FolderID != 0 && !server.checkFolder(ctx, *req.FolderID) {
+			return
+		}
+
+		arg.FolderID = sql.NullInt64{
+			Int64: *req.FolderID,
+			Valid: *req.FolderID != 0,
+		}
+	}
+
+	txArg := db.UpdateURLTxParams{
+		UpdateURLSettingsParams: arg,
+		Audit:                   auditInfo(ctx),
+	}
+
+	if req.Tags != nil {
+		tags := normalizeTags(*req.Tags)
+		txArg.Tags = &tags
+	}
+
+	result, err := server.store.UpdateURLTx(ctx, txArg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	url = result.Url
+
+	// 清除快取，下次導向時重新讀取資料庫
+	err = server.redis.DelData(ctx, domainKey(domain.ID, url.ShortUrl))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// 長連結改變時重新抓取目的網頁資訊
+	if url.OriginUrl != originUrl {
+		server.distributeFetchMetadata(ctx, url)
+	}
+
+	server.distributeEvent(ctx, util.EventLinkUpdated, worker.NewLinkEventData(url))
+
+	rsp := newURLResponse(url)
+	rsp.Tags = result.Tags
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// 刪除短連結，保留期限內可以還原，之後由背景工作永久刪除
+func (server *Server) deleteURL(ctx *gin.Context) {
+	url, domain, ok := server.getStoredURL(ctx)
+	if !ok {
+		return
+	}
+
+	url, err := server.store.SoftDeleteURLTx(ctx, url.ID, auditInfo(ctx))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errURLNotFound))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// 清除快取後查詢資料庫時就會找不到
+	err = server.redis.DelData(ctx, domainKey(domain.ID, url.ShortUrl))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.distributeEvent(ctx, util.EventLinkDeleted, worker.NewLinkEventData(url))
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// 直接從主資料庫取得網址列中的連結，失敗時直接回應錯誤
+// 取得的連結會被寫回，不能讀取可能落後的副本
+func (server *Server) getStoredURL(ctx *gin.Context) (db.Url, db.Domain, bool) {
+	var uri urlURIRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.Url{}, db.Domain{}, false
+	}
+
+	domain, ok := server.queryDomain(ctx)
+	if !ok {
+		return db.Url{}, db.Domain{}, false
+	}
+
+	url, err := server.store.GetURL(db.ReadPrimary(ctx), db.GetURLParams{
+		DomainID: domain.ID,
+		ShortUrl: uri.ShortUrl,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errURLNotFound))
+			return db.Url{}, db.Domain{}, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return db.Url{}, db.Domain{}, false
+	}
+
+	return url, domain, true
 }
 
 type getRedirectRequest struct {
 	ShortUrl string `uri:"short_url" binding:"required,min=6"`
+	Path     string `uri:"path"`
 }
 
 // 取得導向的長連結
@@ -77,47 +584,233 @@ func (server *Server) getRedirect(ctx *gin.Context) {
 		return
 	}
 
-	// 檢查布隆過濾器
-	exist, err := server.redis.ExistBloom(ctx, req.ShortUrl)
+	domain, err := server.requestDomain(ctx)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	if !exist {
-		ctx.JSON(http.StatusNotFound, errorResponse(fmt.Errorf("布隆過濾器內無資料")))
+	// 結尾為 + 時只顯示預覽，不導向也不記錄點擊
+	if strings.HasSuffix(req.ShortUrl, previewSuffix) {
+		server.renderPreview(ctx, domain, strings.TrimSuffix(req.ShortUrl, previewSuffix))
 		return
 	}
 
-	// redis 取資料
-	redisUrl, haveData, err := server.redis.GetData(ctx, req.ShortUrl)
+	url, err := server.lookupURL(ctx, domain, req.ShortUrl)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, errorResponse(err))
+		if errors.Is(err, errURLNotFound) {
+			server.notFound(ctx, domain, err)
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	if haveData {
-		ctx.Redirect(http.StatusMovedPermanently, redisUrl.OriginUrl)
+	// 只有前綴連結可以帶剩餘路徑
+	suffix := forwardedPath(ctx, req)
+	if suffix != "" && suffix != "/" && !url.IsPrefix {
+		server.notFound(ctx, domain, errURLNotFound)
+		return
+	}
+
+	// 封存的連結顯示停用頁面
+	if url.ArchivedAt.Valid {
+		server.renderDisabled(ctx)
 		return
 	}
 
-	url, err := server.store.GetURL(ctx, req.ShortUrl)
+	// 檢查開放時間
+	now := time.Now()
+	if url.NotBefore.Valid && now.Before(url.NotBefore.Time) {
+		server.unavailable(ctx, http.StatusNotFound, fmt.Errorf("短網址尚未開放"))
+		return
+	}
+
+	if url.NotAfter.Valid && now.After(url.NotAfter.Time) {
+		server.unavailable(ctx, http.StatusGone, fmt.Errorf("短網址已過期"))
+		return
+	}
+
+	// 有密碼保護且沒有有效的解鎖 cookie 時顯示解鎖表單
+	if url.PasswordHash != "" && !server.unlocked(ctx, url) {
+		server.renderUnlock(ctx, http.StatusUnauthorized, "")
+		return
+	}
+
+	// 依照規則與分流版本決定導向目的地
+	target, err := server.resolveTarget(ctx, url)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// 前綴連結將剩餘路徑接在目的網址後
+	if url.IsPrefix {
+		target.Destination, err = util.JoinPath(target.Destination, suffix)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
 			return
 		}
+	}
 
+	// 附加 UTM 參數與短網址的查詢參數
+	target.Destination, err = buildDestination(ctx, url, target)
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	// 放入 redis
-	err = server.redis.SetData(ctx, req.ShortUrl, url)
+	// 記錄點擊數，超過點擊上限的請求不導向
+	clicks, err := server.redis.IncrClick(ctx, domainKey(domain.ID, req.ShortUrl), url)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.Redirect(http.StatusMovedPermanently, url.OriginUrl)
+	if url.MaxClicks > 0 && clicks > url.MaxClicks {
+		server.unavailable(ctx, http.StatusGone, fmt.Errorf("短網址已達點擊上限"))
+		return
+	}
+
+	// 記錄提供的分流版本，用來比較各版本的成效
+	if target.Variant != "" {
+		err = server.redis.IncrVariantClick(ctx, domainKey(domain.ID, req.ShortUrl), target.Variant)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	server.distributeEvent(ctx, util.EventLinkClicked, worker.ClickEventData{
+		Link:        worker.NewLinkEventData(url),
+		Destination: target.Destination,
+		Variant:     target.Variant,
+		UserAgent:   ctx.Request.UserAgent(),
+		Referer:     ctx.Request.Referer(),
+	})
+
+	server.redirect(ctx, url, target)
+}
+
+// 取得短網址後的剩餘路徑，保留原本的編碼
+func forwardedPath(ctx *gin.Context, req getRedirectRequest) string {
+	if req.Path == "" {
+		return ""
+	}
+
+	return strings.TrimPrefix(ctx.Request.URL.EscapedPath(), "/"+req.ShortUrl)
+}
+
+// 連結無法使用時，有設定備用網址就導向備用網址
+func (server *Server) unavailable(ctx *gin.Context, code int, err error) {
+	if server.config.UnavailableURL != "" {
+		ctx.Redirect(http.StatusFound, server.config.UnavailableURL)
+		return
+	}
+
+	ctx.JSON(code, errorResponse(err))
+}
+
+// 找不到連結時，有設定網域的備用網址就導向備用網址
+func (server *Server) notFound(ctx *gin.Context, domain db.Domain, err error) {
+	if domain.FallbackUrl != "" {
+		ctx.Redirect(http.StatusFound, domain.FallbackUrl)
+		return
+	}
+
+	ctx.JSON(http.StatusNotFound, errorResponse(err))
+}
+
+// 取得連結，失敗時直接回應錯誤
+func (server *Server) getURL(ctx *gin.Context, domain db.Domain, shortUrl string) (db.Url, bool) {
+	url, err := server.lookupURL(ctx, domain, shortUrl)
+	if err != nil {
+		if errors.Is(err, errURLNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return db.Url{}, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return db.Url{}, false
+	}
+
+	return url, true
+}
+
+// 依序透過布隆過濾器、redis 與資料庫取得連結
+func (server *Server) lookupURL(ctx context.Context, domain db.Domain, shortUrl string) (db.Url, error) {
+	key := domainKey(domain.ID, shortUrl)
+
+	// 檢查布隆過濾器
+	exist, err := server.redis.ExistBloom(ctx, key)
+	if err != nil {
+		return db.Url{}, err
+	}
+
+	if !exist {
+		return db.Url{}, fmt.Errorf("布隆過濾器內無資料: %w", errURLNotFound)
+	}
+
+	// redis 取資料
+	redisUrl, haveData, err := server.redis.GetData(ctx, key)
+	if err != nil {
+		return db.Url{}, err
+	}
+
+	if haveData {
+		return redisUrl, nil
+	}
+
+	arg := db.GetURLParams{
+		DomainID: domain.ID,
+		ShortUrl: shortUrl,
+	}
+
+	// 查到的連結會放入 redis 快取，從主資料庫讀取，避免落後的副本把舊的或已刪除的連結重新快取
+	url, err := server.store.GetURL(db.ReadPrimary(ctx), arg)
+	if err == sql.ErrNoRows && server.config.WriteBehind {
+		// 快取過期時，尚未寫入資料庫的連結從等待寫入的內容取得
+		var found bool
+		url, found, err = server.getPendingURL(ctx, key)
+		if err != nil {
+			return db.Url{}, err
+		}
+
+		if found {
+			return url, nil
+		}
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.Url{}, fmt.Errorf("%s: %w", err, errURLNotFound)
+		}
+
+		return db.Url{}, err
+	}
+
+	// 放入 redis
+	err = server.redis.SetData(ctx, key, url)
+	if err != nil {
+		return db.Url{}, err
+	}
+
+	return url, nil
+}
+
+// 取得尚未寫入資料庫的連結
+func (server *Server) getPendingURL(ctx context.Context, key string) (db.Url, bool, error) {
+	data, found, err := server.redis.GetPendingURL(ctx, key)
+	if err != nil || !found {
+		return db.Url{}, false, err
+	}
+
+	var payload worker.PayloadCreateURL
+	err = json.Unmarshal(data, &payload)
+	if err != nil {
+		return db.Url{}, false, err
+	}
+
+	return payload.Url, true, nil
 }
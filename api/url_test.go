@@ -2,75 +2,1593 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"testing"
+	"time"
 
 	mockdb "shortURL/db/mock"
+	"shortURL/db/redis"
 	db "shortURL/db/sqlc"
 	"shortURL/util"
+	"shortURL/worker"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 )
 
-func TestMain(m *testing.M) {
-	gin.SetMode(gin.TestMode)
+func TestServer_createShortURL(t *testing.T) {
+	url := db.Url{
+		OriginUrl: "https://" + util.RandomLongURL(),
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		buildStubs2   func(redis *mockdb.MockRedisQuerier)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Success case",
+			body: gin.H{
+				"originUrl": url.OriginUrl,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateURLTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.URLTxResult{Url: url}, nil)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					SetBloom(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueFetchMetadata), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "OriginUrl empty",
+			body: gin.H{
+				"originUrl": "",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateURLTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					SetBloom(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "OriginUrl javascript",
+			body: gin.H{
+				"originUrl": "javascript:alert(document.cookie)",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateURLTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					SetBloom(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "OriginUrl not acceptable Url",
+			body: gin.H{
+				"originUrl": "www.google.com",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateURLTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					SetBloom(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "RedirectType not supported",
+			body: gin.H{
+				"originUrl":    url.OriginUrl,
+				"redirectType": "200",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateURLTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					SetBloom(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotAfter in the past",
+			body: gin.H{
+				"originUrl": url.OriginUrl,
+				"notAfter":  time.Now().Add(-time.Hour),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateURLTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					SetBloom(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Domain not found",
+			body: gin.H{
+				"originUrl": url.OriginUrl,
+				"domain":    "go.example.com",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDomainByHost(gomock.Any(), gomock.Eq("go.example.com")).
+					Times(1).
+					Return(db.Domain{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreateURLTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					SetBloom(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Password protected",
+			body: gin.H{
+				"originUrl": url.OriginUrl,
+				"password":  "secret-password",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateURLTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateURLTxParams) (db.URLTxResult, error) {
+						require.NoError(t, util.CheckPassword("secret-password", arg.PasswordHash))
+
+						protectedUrl := url
+						protectedUrl.PasswordHash = arg.PasswordHash
+						return db.URLTxResult{Url: protectedUrl}, nil
+					})
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					SetBloom(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueFetchMetadata), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "$2a$")
+				require.Contains(t, recorder.Body.String(), `"password_protected":true`)
+			},
+		},
+		{
+			name: "Password too short",
+			body: gin.H{
+				"originUrl": url.OriginUrl,
+				"password":  "123",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateURLTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					SetBloom(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Rules",
+			body: gin.H{
+				"originUrl": url.OriginUrl,
+				"rules": []gin.H{
+					{"destination": "https://apps.apple.com/app/id1", "os": []string{"ios"}},
+					{"destination": "https://play.google.com/store/apps", "os": []string{"android"}},
+				},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateURLTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateURLTxParams) (db.URLTxResult, error) {
+						require.Contains(t, string(arg.Rules), "https://apps.apple.com/app/id1")
+						return db.URLTxResult{Url: url}, nil
+					})
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					SetBloom(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueFetchMetadata), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Rule without destination",
+			body: gin.H{
+				"originUrl": url.OriginUrl,
+				"rules": []gin.H{
+					{"os": []string{"ios"}},
+				},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateURLTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					SetBloom(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Variants",
+			body: gin.H{
+				"originUrl": url.OriginUrl,
+				"variants": []gin.H{
+					{"name": "a", "destination": "https://example.com/a", "weight": 70},
+					{"name": "b", "destination": "https://example.com/b", "weight": 30},
+				},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateURLTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateURLTxParams) (db.URLTxResult, error) {
+						require.JSONEq(t, `[{"name":"a","destination":"https://example.com/a","weight":70},{"name":"b","destination":"https://example.com/b","weight":30}]`, string(arg.Variants))
+						return db.URLTxResult{Url: url}, nil
+					})
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					SetBloom(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueFetchMetadata), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Duplicate variant names",
+			body: gin.H{
+				"originUrl": url.OriginUrl,
+				"variants": []gin.H{
+					{"name": "a", "destination": "https://example.com/a", "weight": 70},
+					{"name": "a", "destination": "https://example.com/b", "weight": 30},
+				},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateURLTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					SetBloom(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Unsupported query mode",
+			body: gin.H{
+				"originUrl": url.OriginUrl,
+				"queryMode": "append",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateURLTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					SetBloom(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Query mode and UTM",
+			body: gin.H{
+				"originUrl": url.OriginUrl,
+				"queryMode": util.QueryPassthroughMerge,
+				"utm": gin.H{
+					"source":   "newsletter",
+					"campaign": "{slug}",
+				},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateURLTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateURLTxParams) (db.URLTxResult, error) {
+						require.Equal(t, util.QueryPassthroughMerge, arg.QueryMode)
+						require.JSONEq(t, `{"source":"newsletter","campaign":"{slug}"}`, string(arg.Utm))
+						return db.URLTxResult{Url: url}, nil
+					})
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					SetBloom(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueFetchMetadata), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Metadata and tags",
+			body: gin.H{
+				"originUrl":   url.OriginUrl,
+				"title":       "Docs",
+				"description": "Product documentation",
+				"owner":       "docs-team",
+				"folderId":    2,
+				"tags":        []string{"Docs", "product"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFolder(gomock.Any(), gomock.Eq(int64(2))).
+					Times(1).
+					Return(db.Folder{ID: 2, Name: "docs"}, nil)
+				store.EXPECT().
+					CreateURLTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateURLTxParams) (db.URLTxResult, error) {
+						require.Equal(t, "Docs", arg.Title)
+						require.Equal(t, "Product documentation", arg.Description)
+						require.Equal(t, "docs-team", arg.Owner)
+						require.Equal(t, sql.NullInt64{Int64: 2, Valid: true}, arg.FolderID)
+						require.Equal(t, []string{"docs", "product"}, arg.Tags)
+						return db.URLTxResult{Url: url, Tags: arg.Tags}, nil
+					})
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					SetBloom(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueFetchMetadata), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `"tags":["docs","product"]`)
+			},
+		},
+		{
+			name: "RedirectType found",
+			body: gin.H{
+				"originUrl":    url.OriginUrl,
+				"redirectType": util.RedirectFound,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateURLTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateURLTxParams) (db.URLTxResult, error) {
+						require.Equal(t, util.RedirectFound, arg.RedirectType)
+						return db.URLTxResult{Url: url}, nil
+					})
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					SetBloom(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueFetchMetadata), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Distribute task error",
+			body: gin.H{
+				"originUrl": url.OriginUrl,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateURLTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.URLTxResult{Url: url}, nil)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					SetBloom(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueFetchMetadata), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, _ string, payload []byte) error {
+						require.JSONEq(t, fmt.Sprintf(`{"url_id":0,"origin_url":%q}`, url.OriginUrl), string(payload))
+						return sql.ErrConnDone
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				// 背景工作失敗不影響建立連結
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Short url conflict",
+			body: gin.H{
+				"originUrl": url.OriginUrl,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				var shortUrls []string
+
+				// 資料庫已有相同短網址時換一個重試
+				gomock.InOrder(
+					store.EXPECT().
+						CreateURLTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						DoAndReturn(func(_ interface{}, arg db.CreateURLTxParams) (db.URLTxResult, error) {
+							shortUrls = append(shortUrls, arg.ShortUrl)
+							return db.URLTxResult{}, &db.ShortURLConflictError{ShortUrl: arg.ShortUrl}
+						}),
+					store.EXPECT().
+						CreateURLTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						DoAndReturn(func(_ interface{}, arg db.CreateURLTxParams) (db.URLTxResult, error) {
+							require.NotEqual(t, shortUrls[0], arg.ShortUrl)
+							return db.URLTxResult{Url: url}, nil
+						}),
+				)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					SetBloom(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(true, nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueFetchMetadata), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Retry exceeded",
+			body: gin.H{
+				"originUrl": url.OriginUrl,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateURLTx(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(db.URLTxResult{}, &db.ShortURLConflictError{})
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				// 布隆過濾器判定已存在的短網址不會寫入資料庫
+				gomock.InOrder(
+					redis.EXPECT().
+						SetBloom(gomock.Any(), gomock.Any()).
+						Times(3).
+						Return(false, nil),
+					redis.EXPECT().
+						SetBloom(gomock.Any(), gomock.Any()).
+						Times(2).
+						Return(true, nil),
+				)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"originUrl": url.OriginUrl,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateURLTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.URLTxResult{}, sql.ErrConnDone)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					SetBloom(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(true, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// go mock
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			ctrl2 := gomock.NewController(t)
+			defer ctrl2.Finish()
+
+			mockQueries := mockdb.NewMockStore(ctrl)
+			mockRedis := mockdb.NewMockRedisQuerier(ctrl2)
+			tc.buildStubs(mockQueries)
+			tc.buildStubs2(mockRedis)
+
+			// start test server and send request
+			server := newTestServer(t, mockQueries, mockRedis)
+			recorder := httptest.NewRecorder()
+
+			// Marshal body data to JSON
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			apiUrl := "/short"
+			request, err := http.NewRequest(http.MethodPost, apiUrl, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestServer_createShortURLWriteBehind(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// 延遲寫入時建立與轉址都不寫入資料庫
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		CreateURLTx(gomock.Any(), gomock.Any()).
+		Times(0)
+	store.EXPECT().
+		GetURL(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.Url{}, sql.ErrNoRows)
+
+	memory := redis.NewMemoryQuery()
+	server := newTestServer(t, store, memory)
+	server.config.WriteBehind = true
+
+	originUrl := "https://" + util.RandomLongURL()
+	data, err := json.Marshal(gin.H{"originUrl": originUrl, "tags": []string{"launch"}})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/short", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp urlResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Zero(t, rsp.ID)
+	require.Equal(t, originUrl, rsp.OriginUrl)
+	require.Equal(t, []string{"launch"}, rsp.Tags)
+
+	payload, found, err := memory.GetPendingURL(context.Background(), rsp.ShortUrl)
+	require.NoError(t, err)
+	require.True(t, found)
+
+	var pending worker.PayloadCreateURL
+	require.NoError(t, json.Unmarshal(payload, &pending))
+	require.Equal(t, originUrl, pending.Url.OriginUrl)
+	require.Equal(t, []string{"launch"}, pending.Tags)
+
+	// 寫入資料庫後才送出背景工作
+	_, found, err = memory.PopTask(context.Background(), time.Millisecond, worker.QueueFetchMetadata, worker.QueueWebhookEvent)
+	require.NoError(t, err)
+	require.False(t, found)
+
+	// 排入時一併快取連結
+	cached, found, err := memory.GetData(context.Background(), rsp.ShortUrl)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, originUrl, cached.OriginUrl)
+
+	// 快取過期後仍然可以轉址
+	require.NoError(t, memory.DelData(context.Background(), rsp.ShortUrl))
+
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, "/"+rsp.ShortUrl, nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusMovedPermanently, recorder.Code)
+	require.Equal(t, originUrl, recorder.Header().Get("Location"))
+
+	// 寫入前的點擊以 ID 0 記錄
+	dirty, err := memory.PopDirtyClicks(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{rsp.ShortUrl: 0}, dirty)
+}
+
+func TestServer_createShortURLWriteBehindTaken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	mockRedis := mockdb.NewMockRedisQuerier(ctrl)
+
+	// 短網址已在等待寫入時換一個短網址
+	mockRedis.EXPECT().
+		SetBloom(gomock.Any(), gomock.Any()).
+		Times(2).
+		Return(true, nil)
+	gomock.InOrder(
+		mockRedis.EXPECT().
+			EnqueuePendingURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(false, nil),
+		mockRedis.EXPECT().
+			EnqueuePendingURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(true, nil),
+	)
+	mockRedis.EXPECT().
+		SetData(gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+
+	server := newTestServer(t, store, mockRedis)
+	server.config.WriteBehind = true
+
+	data, err := json.Marshal(gin.H{"originUrl": "https://" + util.RandomLongURL()})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/short", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestServer_getRedirect(t *testing.T) {
+	url := db.Url{
+		OriginUrl: "https://" + util.RandomLongURL(),
+		ShortUrl:  util.RandomString(6),
+	}
+
+	testCases := []struct {
+		name          string
+		shortUrl      string
+		buildStubs    func(store *mockdb.MockStore)
+		buildStubs2   func(redis *mockdb.MockRedisQuerier)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Success case",
+			shortUrl: url.ShortUrl,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(url, true, nil)
+				redis.EXPECT().
+					IncrClick(gomock.Any(), gomock.Eq(url.ShortUrl), gomock.Any()).
+					Times(1).
+					Return(int64(1), nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, _ string, data []byte) error {
+						var payload worker.PayloadWebhookEvent
+						require.NoError(t, json.Unmarshal(data, &payload))
+						require.Equal(t, util.EventLinkClicked, payload.Event)
+
+						var event worker.ClickEventData
+						require.NoError(t, json.Unmarshal(payload.Data, &event))
+						require.Equal(t, url.ShortUrl, event.Link.ShortUrl)
+						require.Equal(t, url.OriginUrl, event.Destination)
+						return nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusMovedPermanently, recorder.Code)
+			},
+		},
+		{
+			name:     "RedirectType temporary",
+			shortUrl: url.ShortUrl,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				temporaryUrl := url
+				temporaryUrl.RedirectType = util.RedirectTemporaryRedirect
+
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(temporaryUrl, true, nil)
+				redis.EXPECT().
+					IncrClick(gomock.Any(), gomock.Eq(url.ShortUrl), gomock.Any()).
+					Times(1).
+					Return(int64(1), nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTemporaryRedirect, recorder.Code)
+				require.Contains(t, recorder.Header().Get("Location"), url.OriginUrl)
+			},
+		},
+		{
+			name:     "Rule matched",
+			shortUrl: url.ShortUrl + "?campaign=spring",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				ruleUrl := url
+				ruleUrl.Rules = json.RawMessage(`[{"destination":"https://example.com/spring","query":{"campaign":"spring"}}]`)
+
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(ruleUrl, true, nil)
+				redis.EXPECT().
+					IncrClick(gomock.Any(), gomock.Eq(url.ShortUrl), gomock.Any()).
+					Times(1).
+					Return(int64(1), nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusMovedPermanently, recorder.Code)
+				require.Equal(t, "https://example.com/spring", recorder.Header().Get("Location"))
+			},
+		},
+		{
+			name:     "Variant served",
+			shortUrl: url.ShortUrl,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				variantUrl := url
+				variantUrl.Variants = json.RawMessage(`[{"name":"b","destination":"https://example.com/b","weight":1}]`)
+
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(variantUrl, true, nil)
+				redis.EXPECT().
+					IncrClick(gomock.Any(), gomock.Eq(url.ShortUrl), gomock.Any()).
+					Times(1).
+					Return(int64(1), nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+				redis.EXPECT().
+					IncrVariantClick(gomock.Any(), gomock.Eq(url.ShortUrl), gomock.Eq("b")).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusMovedPermanently, recorder.Code)
+				require.Equal(t, "https://example.com/b", recorder.Header().Get("Location"))
+				require.Contains(t, recorder.Header().Get("Set-Cookie"), "variant_"+url.ShortUrl+"=b")
+			},
+		},
+		{
+			name:     "Query passthrough with UTM",
+			shortUrl: url.ShortUrl + "?ref=newsletter&page=2",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				queryUrl := url
+				queryUrl.OriginUrl = "https://example.com/landing?page=1#pricing"
+				queryUrl.QueryMode = util.QueryPassthroughOverride
+				queryUrl.Utm = json.RawMessage(`{"source":"shorturl","campaign":"{slug}"}`)
+
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(queryUrl, true, nil)
+				redis.EXPECT().
+					IncrClick(gomock.Any(), gomock.Eq(url.ShortUrl), gomock.Any()).
+					Times(1).
+					Return(int64(1), nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusMovedPermanently, recorder.Code)
+				require.Equal(t,
+					"https://example.com/landing?ref=newsletter&page=2&utm_source=shorturl&utm_campaign="+url.ShortUrl+"#pricing",
+					recorder.Header().Get("Location"))
+			},
+		},
+		{
+			name:     "Prefix link",
+			shortUrl: url.ShortUrl + "/getting-started/a%2Fb?ref=docs",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				prefixUrl := url
+				prefixUrl.OriginUrl = "https://docs.example.com/guide/"
+				prefixUrl.IsPrefix = true
+				prefixUrl.QueryMode = util.QueryPassthroughMerge
+
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(prefixUrl, true, nil)
+				redis.EXPECT().
+					IncrClick(gomock.Any(), gomock.Eq(url.ShortUrl), gomock.Any()).
+					Times(1).
+					Return(int64(1), nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusMovedPermanently, recorder.Code)
+				require.Equal(t, "https://docs.example.com/guide/getting-started/a%2Fb?ref=docs", recorder.Header().Get("Location"))
+			},
+		},
+		{
+			name:     "Prefix link unsafe path",
+			shortUrl: url.ShortUrl + "/%2e%2e/admin",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				prefixUrl := url
+				prefixUrl.OriginUrl = "https://docs.example.com/guide/"
+				prefixUrl.IsPrefix = true
+
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(prefixUrl, true, nil)
+				redis.EXPECT().
+					IncrClick(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "Path on non-prefix link",
+			shortUrl: url.ShortUrl + "/getting-started",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(url, true, nil)
+				redis.EXPECT().
+					IncrClick(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "Rule not matched",
+			shortUrl: url.ShortUrl,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				ruleUrl := url
+				ruleUrl.Rules = json.RawMessage(`[{"destination":"https://example.com/spring","query":{"campaign":"spring"}}]`)
+
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(ruleUrl, true, nil)
+				redis.EXPECT().
+					IncrClick(gomock.Any(), gomock.Eq(url.ShortUrl), gomock.Any()).
+					Times(1).
+					Return(int64(1), nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusMovedPermanently, recorder.Code)
+				require.Contains(t, recorder.Header().Get("Location"), url.OriginUrl)
+			},
+		},
+		{
+			name:     "RedirectType interstitial",
+			shortUrl: url.ShortUrl,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				interstitialUrl := url
+				interstitialUrl.RedirectType = util.RedirectInterstitial
+
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(interstitialUrl, true, nil)
+				redis.EXPECT().
+					IncrClick(gomock.Any(), gomock.Eq(url.ShortUrl), gomock.Any()).
+					Times(1).
+					Return(int64(1), nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `http-equiv="refresh"`)
+				require.Contains(t, recorder.Body.String(), url.OriginUrl)
+			},
+		},
+		{
+			name:     "Interstitial javascript destination",
+			shortUrl: url.ShortUrl,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				// 驗證前建立的連結仍然不能寫入頁面
+				unsafeUrl := url
+				unsafeUrl.OriginUrl = "javascript:alert(document.cookie)"
+				unsafeUrl.RedirectType = util.RedirectInterstitial
+
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(unsafeUrl, true, nil)
+				redis.EXPECT().
+					IncrClick(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "alert")
+				require.Empty(t, recorder.Header().Get("Location"))
+			},
+		},
+		{
+			name:     "Archived",
+			shortUrl: url.ShortUrl,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				archivedUrl := url
+				archivedUrl.ArchivedAt = sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}
+
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(archivedUrl, true, nil)
+				redis.EXPECT().
+					IncrClick(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusGone, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "This link has been disabled.")
+			},
+		},
+		{
+			name:     "Expired",
+			shortUrl: url.ShortUrl,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				expiredUrl := url
+				expiredUrl.NotAfter = sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}
+
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(expiredUrl, true, nil)
+				redis.EXPECT().
+					IncrClick(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusGone, recorder.Code)
+			},
+		},
+		{
+			name:     "Not yet open",
+			shortUrl: url.ShortUrl,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				futureUrl := url
+				futureUrl.NotBefore = sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true}
+
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(futureUrl, true, nil)
+				redis.EXPECT().
+					IncrClick(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "Max clicks reached",
+			shortUrl: url.ShortUrl,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				limitedUrl := url
+				limitedUrl.MaxClicks = 1
+
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(limitedUrl, true, nil)
+				redis.EXPECT().
+					IncrClick(gomock.Any(), gomock.Eq(url.ShortUrl), gomock.Eq(limitedUrl)).
+					Times(1).
+					Return(int64(2), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusGone, recorder.Code)
+			},
+		},
+		{
+			name:     "Last allowed click",
+			shortUrl: url.ShortUrl,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				limitedUrl := url
+				limitedUrl.MaxClicks = 1
+
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(limitedUrl, true, nil)
+				redis.EXPECT().
+					IncrClick(gomock.Any(), gomock.Eq(url.ShortUrl), gomock.Eq(limitedUrl)).
+					Times(1).
+					Return(int64(1), nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusMovedPermanently, recorder.Code)
+			},
+		},
+		{
+			name:     "Preview suffix",
+			shortUrl: url.ShortUrl + previewSuffix,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(url, true, nil)
+				redis.EXPECT().
+					GetClick(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(int64(3), nil)
+				redis.EXPECT().
+					IncrClick(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), url.OriginUrl)
+			},
+		},
+		{
+			name:     "shortURL too short",
+			shortUrl: util.RandomString(3),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Any()).
+					Times(0)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "Not found",
+			shortUrl: url.ShortUrl,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(false, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "InternalError",
+			shortUrl: url.ShortUrl,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Url{}, sql.ErrConnDone)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(true, nil)
+				redis.EXPECT().
+					GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(db.Url{}, false, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// go mock
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		ctrl2 := gomock.NewController(t)
+		defer ctrl2.Finish()
+
+		mockQueries := mockdb.NewMockStore(ctrl)
+		mockRedis := mockdb.NewMockRedisQuerier(ctrl2)
+		tc.buildStubs(mockQueries)
+		tc.buildStubs2(mockRedis)
+
+		// start test server and send request
+		server := newTestServer(t, mockQueries, mockRedis)
+		recorder := httptest.NewRecorder()
+
+		apiUrl := fmt.Sprintf("/%s", tc.shortUrl)
+		request, err := http.NewRequest(http.MethodGet, apiUrl, nil)
+		require.NoError(t, err)
 
-	os.Exit(m.Run())
+		server.router.ServeHTTP(recorder, request)
+		tc.checkResponse(recorder)
+	}
 }
 
-func TestServer_createShortURL(t *testing.T) {
+func TestServer_updateURL(t *testing.T) {
 	url := db.Url{
+		ID:        util.RandomInt(1, 1000),
 		OriginUrl: "https://" + util.RandomLongURL(),
+		ShortUrl:  util.RandomString(6),
+		Rules:     json.RawMessage("[]"),
 	}
 
 	testCases := []struct {
 		name          string
 		body          gin.H
-		buildStubs    func(store *mockdb.MockQuerier)
+		buildStubs    func(store *mockdb.MockStore)
 		buildStubs2   func(redis *mockdb.MockRedisQuerier)
 		checkResponse func(recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name: "Success case",
 			body: gin.H{
-				"originUrl": url.OriginUrl,
+				"rules": []gin.H{
+					{"destination": "https://example.com/mobile", "devices": []string{"mobile", "tablet"}},
+				},
 			},
-			buildStubs: func(store *mockdb.MockQuerier) {
+			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateURL(gomock.Any(), gomock.Any()).
+					GetURL(gomock.Any(), gomock.Eq(db.GetURLParams{ShortUrl: url.ShortUrl})).
 					Times(1).
 					Return(url, nil)
+				store.EXPECT().
+					UpdateURLTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.UpdateURLTxParams) (db.URLTxResult, error) {
+						require.Equal(t, url.ID, arg.ID)
+						require.Equal(t, url.OriginUrl, arg.OriginUrl)
+						require.Contains(t, string(arg.Rules), "https://example.com/mobile")
+
+						require.Nil(t, arg.Tags)
+
+						updatedUrl := url
+						updatedUrl.Rules = arg.Rules
+						return db.URLTxResult{Url: updatedUrl, Tags: []string{"campaign"}}, nil
+					})
 			},
 			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
 				redis.EXPECT().
-					SetBloom(gomock.Any(), gomock.Any()).
+					DelData(gomock.Any(), gomock.Eq(url.ShortUrl)).
 					Times(1).
-					Return(true, nil)
+					Return(nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "https://example.com/mobile")
+				require.Contains(t, recorder.Body.String(), `"tags":["campaign"]`)
 			},
 		},
 		{
-			name: "OriginUrl empty",
+			name: "Replace tags and folder",
 			body: gin.H{
-				"originUrl": "",
+				"title":    "Spring sale",
+				"folderId": 3,
+				"tags":     []string{"Sale", "spring", "sale"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(url, nil)
+				store.EXPECT().
+					GetFolder(gomock.Any(), gomock.Eq(int64(3))).
+					Times(1).
+					Return(db.Folder{ID: 3, Name: "campaigns"}, nil)
+				store.EXPECT().
+					UpdateURLTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.UpdateURLTxParams) (db.URLTxResult, error) {
+						require.Equal(t, "Spring sale", arg.Title)
+						require.Equal(t, sql.NullInt64{Int64: 3, Valid: true}, arg.FolderID)
+
+						require.Equal(t, &[]string{"sale", "spring"}, arg.Tags)
+
+						updatedUrl := url
+						updatedUrl.Title = arg.Title
+						updatedUrl.FolderID = arg.FolderID
+						return db.URLTxResult{Url: updatedUrl, Tags: *arg.Tags}, nil
+					})
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					DelData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `"tags":["sale","spring"]`)
+			},
+		},
+		{
+			name: "Change origin url",
+			body: gin.H{
+				"originUrl": "https://example.com/new",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(url, nil)
+				store.EXPECT().
+					UpdateURLTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.UpdateURLTxParams) (db.URLTxResult, error) {
+						updatedUrl := url
+						updatedUrl.OriginUrl = arg.OriginUrl
+						return db.URLTxResult{Url: updatedUrl, Tags: []string{}}, nil
+					})
 			},
-			buildStubs: func(store *mockdb.MockQuerier) {
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					DelData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					Times(1).
+					Return(nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+					Times(1).
+					Return(nil)
+				redis.EXPECT().
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueFetchMetadata), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, _ string, payload []byte) error {
+						require.JSONEq(t, fmt.Sprintf(`{"url_id":%d,"origin_url":"https://example.com/new"}`, url.ID), string(payload))
+						return nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Folder not found",
+			body: gin.H{
+				"folderId": 3,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(url, nil)
+				store.EXPECT().
+					GetFolder(gomock.Any(), gomock.Eq(int64(3))).
+					Times(1).
+					Return(db.Folder{}, sql.ErrNoRows)
 				store.EXPECT().
-					CreateURL(gomock.Any(), gomock.Any()).
+					UpdateURLTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
 				redis.EXPECT().
-					SetBloom(gomock.Any(), gomock.Any()).
+					DelData(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -78,18 +1596,20 @@ func TestServer_createShortURL(t *testing.T) {
 			},
 		},
 		{
-			name: "OriginUrl not acceptable Url",
+			name: "Invalid device",
 			body: gin.H{
-				"originUrl": "www.google.com",
+				"rules": []gin.H{
+					{"destination": "https://example.com/tv", "devices": []string{"tv"}},
+				},
 			},
-			buildStubs: func(store *mockdb.MockQuerier) {
+			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateURL(gomock.Any(), gomock.Any()).
+					GetURL(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
 				redis.EXPECT().
-					SetBloom(gomock.Any(), gomock.Any()).
+					DelData(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -97,21 +1617,47 @@ func TestServer_createShortURL(t *testing.T) {
 			},
 		},
 		{
-			name: "InternalError",
+			name: "Not found",
 			body: gin.H{
-				"originUrl": url.OriginUrl,
+				"originUrl": "https://example.com",
 			},
-			buildStubs: func(store *mockdb.MockQuerier) {
+			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateURL(gomock.Any(), gomock.Any()).
+					GetURL(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(db.Url{}, sql.ErrConnDone)
+					Return(db.Url{}, sql.ErrNoRows)
+				store.EXPECT().
+					UpdateURLTx(gomock.Any(), gomock.Any()).
+					Times(0)
 			},
 			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
 				redis.EXPECT().
-					SetBloom(gomock.Any(), gomock.Any()).
+					DelData(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"originUrl": "https://example.com",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetURL(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(true, nil)
+					Return(url, nil)
+				store.EXPECT().
+					UpdateURLTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.URLTxResult{}, sql.ErrConnDone)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					DelData(gomock.Any(), gomock.Any()).
+					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
@@ -121,27 +1667,24 @@ func TestServer_createShortURL(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			// go mock
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 			ctrl2 := gomock.NewController(t)
 			defer ctrl2.Finish()
 
-			mockQueries := mockdb.NewMockQuerier(ctrl)
+			mockQueries := mockdb.NewMockStore(ctrl)
 			mockRedis := mockdb.NewMockRedisQuerier(ctrl2)
 			tc.buildStubs(mockQueries)
 			tc.buildStubs2(mockRedis)
 
-			// start test server and send request
-			server := NewServer(mockQueries, mockRedis)
+			server := newTestServer(t, mockQueries, mockRedis)
 			recorder := httptest.NewRecorder()
 
-			// Marshal body data to JSON
 			data, err := json.Marshal(tc.body)
 			require.NoError(t, err)
 
-			apiUrl := "/short"
-			request, err := http.NewRequest(http.MethodPost, apiUrl, bytes.NewReader(data))
+			apiUrl := fmt.Sprintf("/api/urls/%s", url.ShortUrl)
+			request, err := http.NewRequest(http.MethodPatch, apiUrl, bytes.NewReader(data))
 			require.NoError(t, err)
 
 			server.router.ServeHTTP(recorder, request)
@@ -150,100 +1693,110 @@ func TestServer_createShortURL(t *testing.T) {
 	}
 }
 
-func TestServer_getRedirect(t *testing.T) {
+func TestServer_deleteURL(t *testing.T) {
 	url := db.Url{
-		OriginUrl: util.RandomLongURL(),
+		ID:        util.RandomInt(1, 1000),
+		OriginUrl: "https://" + util.RandomLongURL(),
 		ShortUrl:  util.RandomString(6),
 	}
 
+	deletedUrl := url
+	deletedUrl.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
 	testCases := []struct {
 		name          string
 		shortUrl      string
-		buildStubs    func(store *mockdb.MockQuerier)
+		buildStubs    func(store *mockdb.MockStore)
 		buildStubs2   func(redis *mockdb.MockRedisQuerier)
 		checkResponse func(recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name:     "Success case",
 			shortUrl: url.ShortUrl,
-			buildStubs: func(store *mockdb.MockQuerier) {
+			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					GetURL(gomock.Any(), gomock.Any()).
-					Times(0)
+					GetURL(gomock.Any(), gomock.Eq(db.GetURLParams{ShortUrl: url.ShortUrl})).
+					Times(1).
+					Return(url, nil)
+				store.EXPECT().
+					SoftDeleteURLTx(gomock.Any(), gomock.Eq(url.ID), gomock.Any()).
+					Times(1).
+					Return(deletedUrl, nil)
 			},
 			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
 				redis.EXPECT().
-					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					DelData(gomock.Any(), gomock.Eq(url.ShortUrl)).
 					Times(1).
-					Return(true, nil)
+					Return(nil)
 				redis.EXPECT().
-					GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+					PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
 					Times(1).
-					Return(url, true, nil)
+					DoAndReturn(func(_ interface{}, _ string, data []byte) error {
+						var payload worker.PayloadWebhookEvent
+						require.NoError(t, json.Unmarshal(data, &payload))
+						require.Equal(t, util.EventLinkDeleted, payload.Event)
+						return nil
+					})
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusMovedPermanently, recorder.Code)
+				require.Equal(t, http.StatusNoContent, recorder.Code)
 			},
 		},
 		{
-			name:     "shortURL too short",
-			shortUrl: util.RandomString(3),
-			buildStubs: func(store *mockdb.MockQuerier) {
+			name:     "Not found",
+			shortUrl: url.ShortUrl,
+			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					GetURL(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Url{}, sql.ErrNoRows)
+				store.EXPECT().
+					SoftDeleteURLTx(gomock.Any(), gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
 				redis.EXPECT().
-					ExistBloom(gomock.Any(), gomock.Any()).
-					Times(0)
-				redis.EXPECT().
-					GetData(gomock.Any(), gomock.Any()).
+					DelData(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Equal(t, http.StatusNotFound, recorder.Code)
 			},
 		},
 		{
-			name:     "Not found",
-			shortUrl: url.ShortUrl,
-			buildStubs: func(store *mockdb.MockQuerier) {
+			name:     "shortURL too short",
+			shortUrl: util.RandomString(3),
+			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					GetURL(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
 				redis.EXPECT().
-					ExistBloom(gomock.Any(), gomock.Any()).
-					Times(1).
-					Return(false, nil)
-				redis.EXPECT().
-					GetData(gomock.Any(), gomock.Any()).
+					DelData(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
 		{
 			name:     "InternalError",
 			shortUrl: url.ShortUrl,
-			buildStubs: func(store *mockdb.MockQuerier) {
+			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					GetURL(gomock.Any(), gomock.Any()).
 					Times(1).
+					Return(url, nil)
+				store.EXPECT().
+					SoftDeleteURLTx(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
 					Return(db.Url{}, sql.ErrConnDone)
 			},
 			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
 				redis.EXPECT().
-					ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
-					Times(1).
-					Return(true, nil)
-				redis.EXPECT().
-					GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
-					Times(1).
-					Return(db.Url{}, false, nil)
+					DelData(gomock.Any(), gomock.Any()).
+					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
@@ -252,26 +1805,128 @@ func TestServer_getRedirect(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
-		// go mock
-		ctrl := gomock.NewController(t)
-		defer ctrl.Finish()
-		ctrl2 := gomock.NewController(t)
-		defer ctrl2.Finish()
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			ctrl2 := gomock.NewController(t)
+			defer ctrl2.Finish()
 
-		mockQueries := mockdb.NewMockQuerier(ctrl)
-		mockRedis := mockdb.NewMockRedisQuerier(ctrl2)
-		tc.buildStubs(mockQueries)
-		tc.buildStubs2(mockRedis)
+			mockQueries := mockdb.NewMockStore(ctrl)
+			mockRedis := mockdb.NewMockRedisQuerier(ctrl2)
+			tc.buildStubs(mockQueries)
+			tc.buildStubs2(mockRedis)
 
-		// start test server and send request
-		server := NewServer(mockQueries, mockRedis)
-		recorder := httptest.NewRecorder()
+			server := newTestServer(t, mockQueries, mockRedis)
+			recorder := httptest.NewRecorder()
 
-		apiUrl := fmt.Sprintf("/%s", tc.shortUrl)
-		request, err := http.NewRequest(http.MethodGet, apiUrl, nil)
-		require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodDelete, "/api/urls/"+tc.shortUrl, nil)
+			require.NoError(t, err)
 
-		server.router.ServeHTTP(recorder, request)
-		tc.checkResponse(recorder)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestServer_getRedirectVariantCookie(t *testing.T) {
+	url := db.Url{
+		OriginUrl: "https://" + util.RandomLongURL(),
+		ShortUrl:  util.RandomString(6),
+		Variants:  json.RawMessage(`[{"name":"a","destination":"https://example.com/a","weight":1000},{"name":"b","destination":"https://example.com/b","weight":1}]`),
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQueries := mockdb.NewMockStore(ctrl)
+	mockRedis := mockdb.NewMockRedisQuerier(ctrl)
+
+	mockRedis.EXPECT().
+		ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+		Times(1).
+		Return(true, nil)
+	mockRedis.EXPECT().
+		GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+		Times(1).
+		Return(url, true, nil)
+	mockRedis.EXPECT().
+		IncrClick(gomock.Any(), gomock.Eq(url.ShortUrl), gomock.Any()).
+		Times(1).
+		Return(int64(1), nil)
+	mockRedis.EXPECT().
+		PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+		Times(1).
+		Return(nil)
+	mockRedis.EXPECT().
+		IncrVariantClick(gomock.Any(), gomock.Eq(url.ShortUrl), gomock.Eq("b")).
+		Times(1).
+		Return(nil)
+
+	server := newTestServer(t, mockQueries, mockRedis)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/"+url.ShortUrl, nil)
+	require.NoError(t, err)
+	request.AddCookie(&http.Cookie{Name: "variant_" + url.ShortUrl, Value: "b"})
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusMovedPermanently, recorder.Code)
+	require.Equal(t, "https://example.com/b", recorder.Header().Get("Location"))
+}
+
+func TestServer_getRedirectReadsPrimary(t *testing.T) {
+	url := db.Url{
+		OriginUrl: "https://" + util.RandomLongURL(),
+		ShortUrl:  util.RandomString(6),
 	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// 快取未命中時即使有副本也從主資料庫讀取，副本的結果不會被放入 redis
+	primary := mockdb.NewMockStore(ctrl)
+	primary.EXPECT().
+		GetURL(gomock.Any(), gomock.Eq(db.GetURLParams{ShortUrl: url.ShortUrl})).
+		Times(1).
+		Return(url, nil)
+
+	replica := mockdb.NewMockQuerier(ctrl)
+	replica.EXPECT().
+		GetURL(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	router := db.NewRoutingQuerier(primary, time.Minute, time.Second)
+	router.AddReplica(replica, nil)
+
+	mockRedis := mockdb.NewMockRedisQuerier(ctrl)
+	mockRedis.EXPECT().
+		ExistBloom(gomock.Any(), gomock.Eq(url.ShortUrl)).
+		Times(1).
+		Return(true, nil)
+	mockRedis.EXPECT().
+		GetData(gomock.Any(), gomock.Eq(url.ShortUrl)).
+		Times(1).
+		Return(db.Url{}, false, nil)
+	mockRedis.EXPECT().
+		SetData(gomock.Any(), gomock.Eq(url.ShortUrl), gomock.Eq(url)).
+		Times(1).
+		Return(nil)
+	mockRedis.EXPECT().
+		IncrClick(gomock.Any(), gomock.Eq(url.ShortUrl), gomock.Any()).
+		Times(1).
+		Return(int64(1), nil)
+	mockRedis.EXPECT().
+		PushTask(gomock.Any(), gomock.Eq(worker.QueueWebhookEvent), gomock.Any()).
+		Times(1).
+		Return(nil)
+
+	server := newTestServer(t, db.NewTxStore(router), mockRedis)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/"+url.ShortUrl, nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusMovedPermanently, recorder.Code)
+	require.Equal(t, url.OriginUrl, recorder.Header().Get("Location"))
 }
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"shortURL/util"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var validRedirectType validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if redirectType, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsSupportedRedirectType(redirectType)
+	}
+	return false
+}
+
+var validQueryMode validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if mode, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsSupportedQueryMode(mode)
+	}
+	return false
+}
+
+var validWebhookEvent validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if event, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsSupportedWebhookEvent(event)
+	}
+	return false
+}
+
+var validWebURL validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if rawURL, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsWebURL(rawURL)
+	}
+	return false
+}
+
+var validHexColor validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if hex, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsHexColor(hex)
+	}
+	return false
+}
@@ -0,0 +1,249 @@
+package api
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	db "shortURL/db/sqlc"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errWebhookNotFound  = fmt.Errorf("webhook 不存在")
+	errDeliveryNotFound = fmt.Errorf("投遞紀錄不存在")
+)
+
+type createWebhookRequest struct {
+	Url    string   `json:"url" binding:"required,url"`
+	Events []string `json:"events" binding:"required,min=1,max=10,unique,dive,webhook_event"`
+}
+
+type webhookResponse struct {
+	ID        int64     `json:"id"`
+	Url       string    `json:"url"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// 密鑰只在建立時回傳
+type createWebhookResponse struct {
+	webhookResponse
+	Secret string `json:"secret"`
+}
+
+func newWebhookResponse(webhook db.Webhook) webhookResponse {
+	return webhookResponse{
+		ID:        webhook.ID,
+		Url:       webhook.Url,
+		Events:    webhook.Events,
+		CreatedAt: webhook.CreatedAt,
+	}
+}
+
+// 建立 webhook，新的訂閱最多 30 秒後開始收到事件
+func (server *Server) createWebhook(ctx *gin.Context) {
+	var req createWebhookRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if u, err := url.Parse(req.Url); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("webhook 網址必須為 http 或 https")))
+		return
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	arg := db.CreateWebhookParams{
+		Url:    req.Url,
+		Secret: secret,
+		Events: req.Events,
+	}
+
+	webhook, err := server.store.CreateWebhook(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, createWebhookResponse{
+		webhookResponse: newWebhookResponse(webhook),
+		Secret:          webhook.Secret,
+	})
+}
+
+// 產生簽署 webhook 內容用的密鑰
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+type listWebhooksRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=50"`
+}
+
+// 列出 webhook
+func (server *Server) listWebhooks(ctx *gin.Context) {
+	var req listWebhooksRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.ListWebhooksParams{
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+
+	webhooks, err := server.store.ListWebhooks(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]webhookResponse, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		rsp = append(rsp, newWebhookResponse(webhook))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type webhookURIRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// 刪除 webhook，投遞紀錄一併刪除
+func (server *Server) deleteWebhook(ctx *gin.Context) {
+	var uri webhookURIRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.getWebhook(ctx, uri.ID); !ok {
+		return
+	}
+
+	err := server.store.DeleteWebhook(ctx, uri.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// 取得 webhook，失敗時直接回應錯誤
+func (server *Server) getWebhook(ctx *gin.Context, id int64) (db.Webhook, bool) {
+	webhook, err := server.store.GetWebhook(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errWebhookNotFound))
+			return db.Webhook{}, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return db.Webhook{}, false
+	}
+
+	return webhook, true
+}
+
+type listWebhookDeliveriesRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=50"`
+}
+
+// 列出 webhook 的投遞紀錄，新的在前
+func (server *Server) listWebhookDeliveries(ctx *gin.Context) {
+	var uri webhookURIRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listWebhookDeliveriesRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, ok := server.getWebhook(ctx, uri.ID); !ok {
+		return
+	}
+
+	arg := db.ListWebhookDeliveriesParams{
+		WebhookID: uri.ID,
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	}
+
+	deliveries, err := server.store.ListWebhookDeliveries(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, deliveries)
+}
+
+type redeliverWebhookRequest struct {
+	ID         int64 `uri:"id" binding:"required,min=1"`
+	DeliveryID int64 `uri:"delivery_id" binding:"required,min=1"`
+}
+
+// 重新投遞，已送達或已放棄的投遞紀錄都可以重送
+func (server *Server) redeliverWebhook(ctx *gin.Context) {
+	var uri redeliverWebhookRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	delivery, err := server.store.GetWebhookDelivery(ctx, uri.DeliveryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errDeliveryNotFound))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if delivery.WebhookID != uri.ID {
+		ctx.JSON(http.StatusNotFound, errorResponse(errDeliveryNotFound))
+		return
+	}
+
+	delivery, err = server.store.RedeliverWebhookDelivery(ctx, delivery.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, delivery)
+}
@@ -0,0 +1,407 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "shortURL/db/mock"
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+	"shortURL/worker"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func randomWebhook() db.Webhook {
+	return db.Webhook{
+		ID:        util.RandomInt(1, 1000),
+		Url:       "https://" + util.RandomLongURL(),
+		Secret:    util.RandomString(64),
+		Events:    []string{util.EventLinkCreated, util.EventLinkClicked},
+		CreatedAt: time.Now(),
+	}
+}
+
+func TestServer_createWebhook(t *testing.T) {
+	webhook := randomWebhook()
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Success case",
+			body: gin.H{
+				"url":    webhook.Url,
+				"events": webhook.Events,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhook(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateWebhookParams) (db.Webhook, error) {
+						require.Equal(t, webhook.Url, arg.Url)
+						require.Equal(t, webhook.Events, arg.Events)
+						require.Len(t, arg.Secret, 64)
+
+						createdWebhook := webhook
+						createdWebhook.Secret = arg.Secret
+						return createdWebhook, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotWebhook createWebhookResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotWebhook)
+				require.NoError(t, err)
+				require.Equal(t, webhook.ID, gotWebhook.ID)
+				require.Equal(t, webhook.Events, gotWebhook.Events)
+				require.Len(t, gotWebhook.Secret, 64)
+			},
+		},
+		{
+			name: "Unsupported event",
+			body: gin.H{
+				"url":    webhook.Url,
+				"events": []string{"link.renamed"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhook(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Events empty",
+			body: gin.H{
+				"url":    webhook.Url,
+				"events": []string{},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhook(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Unsupported scheme",
+			body: gin.H{
+				"url":    "ftp://example.com/hook",
+				"events": webhook.Events,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhook(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"url":    webhook.Url,
+				"events": webhook.Events,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhook(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Webhook{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockStore(ctrl)
+			mockRedis := mockdb.NewMockRedisQuerier(ctrl)
+			tc.buildStubs(mockQueries)
+
+			server := newTestServer(t, mockQueries, mockRedis)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api/webhooks", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestServer_listWebhooks(t *testing.T) {
+	webhooks := []db.Webhook{randomWebhook(), randomWebhook()}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQueries := mockdb.NewMockStore(ctrl)
+	mockRedis := mockdb.NewMockRedisQuerier(ctrl)
+
+	mockQueries.EXPECT().
+		ListWebhooks(gomock.Any(), gomock.Eq(db.ListWebhooksParams{Limit: 5, Offset: 0})).
+		Times(1).
+		Return(webhooks, nil)
+
+	server := newTestServer(t, mockQueries, mockRedis)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/api/webhooks?page_id=1&page_size=5", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// 列表不回傳密鑰
+	require.NotContains(t, recorder.Body.String(), "secret")
+
+	var gotWebhooks []webhookResponse
+	err = json.Unmarshal(recorder.Body.Bytes(), &gotWebhooks)
+	require.NoError(t, err)
+	require.Len(t, gotWebhooks, len(webhooks))
+}
+
+func TestServer_deleteWebhook(t *testing.T) {
+	webhook := randomWebhook()
+
+	testCases := []struct {
+		name          string
+		id            int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Success case",
+			id:   webhook.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhook(gomock.Any(), gomock.Eq(webhook.ID)).
+					Times(1).
+					Return(webhook, nil)
+				store.EXPECT().
+					DeleteWebhook(gomock.Any(), gomock.Eq(webhook.ID)).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name: "Not found",
+			id:   webhook.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhook(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Webhook{}, sql.ErrNoRows)
+				store.EXPECT().
+					DeleteWebhook(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "Invalid id",
+			id:   0,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhook(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockStore(ctrl)
+			mockRedis := mockdb.NewMockRedisQuerier(ctrl)
+			tc.buildStubs(mockQueries)
+
+			server := newTestServer(t, mockQueries, mockRedis)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/api/webhooks/%d", tc.id), nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestServer_listWebhookDeliveries(t *testing.T) {
+	webhook := randomWebhook()
+	deliveries := []db.WebhookDelivery{
+		{ID: 2, WebhookID: webhook.ID, Event: util.EventLinkClicked, Status: worker.DeliveryStatusPending},
+		{ID: 1, WebhookID: webhook.ID, Event: util.EventLinkCreated, Status: worker.DeliveryStatusDelivered},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQueries := mockdb.NewMockStore(ctrl)
+	mockRedis := mockdb.NewMockRedisQuerier(ctrl)
+
+	mockQueries.EXPECT().
+		GetWebhook(gomock.Any(), gomock.Eq(webhook.ID)).
+		Times(1).
+		Return(webhook, nil)
+	mockQueries.EXPECT().
+		ListWebhookDeliveries(gomock.Any(), gomock.Eq(db.ListWebhookDeliveriesParams{
+			WebhookID: webhook.ID,
+			Limit:     10,
+			Offset:    10,
+		})).
+		Times(1).
+		Return(deliveries, nil)
+
+	server := newTestServer(t, mockQueries, mockRedis)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/api/webhooks/%d/deliveries?page_id=2&page_size=10", webhook.ID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var gotDeliveries []db.WebhookDelivery
+	err = json.Unmarshal(recorder.Body.Bytes(), &gotDeliveries)
+	require.NoError(t, err)
+	require.Len(t, gotDeliveries, len(deliveries))
+}
+
+func TestServer_redeliverWebhook(t *testing.T) {
+	webhook := randomWebhook()
+	delivery := db.WebhookDelivery{
+		ID:        util.RandomInt(1, 1000),
+		WebhookID: webhook.ID,
+		Event:     util.EventLinkCreated,
+		Status:    worker.DeliveryStatusDead,
+		Attempts:  8,
+	}
+
+	testCases := []struct {
+		name          string
+		webhookID     int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "Success case",
+			webhookID: webhook.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).
+					Times(1).
+					Return(delivery, nil)
+
+				redelivered := delivery
+				redelivered.Status = worker.DeliveryStatusPending
+				redelivered.Attempts = 0
+				store.EXPECT().
+					RedeliverWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).
+					Times(1).
+					Return(redelivered, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotDelivery db.WebhookDelivery
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotDelivery)
+				require.NoError(t, err)
+				require.Equal(t, worker.DeliveryStatusPending, gotDelivery.Status)
+			},
+		},
+		{
+			name:      "Other webhook",
+			webhookID: webhook.ID + 1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).
+					Times(1).
+					Return(delivery, nil)
+				store.EXPECT().
+					RedeliverWebhookDelivery(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "Not found",
+			webhookID: webhook.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookDelivery(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.WebhookDelivery{}, sql.ErrNoRows)
+				store.EXPECT().
+					RedeliverWebhookDelivery(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockStore(ctrl)
+			mockRedis := mockdb.NewMockRedisQuerier(ctrl)
+			tc.buildStubs(mockQueries)
+
+			server := newTestServer(t, mockQueries, mockRedis)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/webhooks/%d/deliveries/%d/redeliver", tc.webhookID, delivery.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
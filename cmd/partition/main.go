@@ -0,0 +1,95 @@
+// Command partition maintains the partitions of the urls table.
+//
+//	partition list
+//	partition create -months 3
+//	partition detach -before 2024-01-01
+//
+// create and detach only apply when urls is partitioned by created_at range,
+// run create regularly so that new links always have a partition.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+
+	_ "github.com/lib/pq"
+)
+
+const usage = "usage: partition list | create [-months n] | detach -before yyyy-mm-dd"
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	config, err := util.LoadConfig(".")
+	if err != nil {
+		log.Fatal("cannot load config:", err)
+	}
+
+	conn, err := sql.Open(config.DBDriver, config.DBSource)
+	if err != nil {
+		log.Fatal("cannot connect to db:", err)
+	}
+	defer conn.Close()
+
+	queries := db.NewQuery(conn)
+	ctx := context.Background()
+
+	switch os.Args[1] {
+	case "list":
+		partitions, err := queries.ListURLPartitions(ctx)
+		if err != nil {
+			log.Fatal("cannot list partitions:", err)
+		}
+		for _, partition := range partitions {
+			fmt.Printf("%s\t%s\n", partition.Name, partition.Bound)
+		}
+	case "create":
+		flags := flag.NewFlagSet("create", flag.ExitOnError)
+		months := flags.Int("months", 3, "create partitions up to this many months ahead")
+		flags.Parse(os.Args[2:])
+
+		names, err := queries.CreateURLPartitions(ctx, int32(*months))
+		if err != nil {
+			log.Fatal("cannot create partitions:", err)
+		}
+		printNames("created", names)
+	case "detach":
+		flags := flag.NewFlagSet("detach", flag.ExitOnError)
+		before := flags.String("before", "", "detach partitions whose range ends by this date")
+		flags.Parse(os.Args[2:])
+
+		olderThan, err := time.Parse("2006-01-02", *before)
+		if err != nil {
+			log.Fatal(usage)
+		}
+
+		names, err := queries.DetachURLPartitions(ctx, olderThan)
+		if err != nil {
+			log.Fatal("cannot detach partitions:", err)
+		}
+		printNames("detached", names)
+	default:
+		log.Fatal(usage)
+	}
+}
+
+func printNames(action string, names []string) {
+	if len(names) == 0 {
+		fmt.Printf("no partitions %s\n", action)
+		return
+	}
+
+	for _, name := range names {
+		fmt.Printf("%s %s\n", action, name)
+	}
+}
@@ -0,0 +1,118 @@
+// Command reshard moves the links between the shards, DB_SOURCE is shard 0 and DB_SHARD_SOURCES are the others.
+//
+//	reshard sync
+//	reshard move [-dry-run] [-batch n]
+//
+// After appending a shard to DB_SHARD_SOURCES, run sync to copy the domains and folders of shard 0 to it,
+// restart the servers with DB_SHARD_RESHARDING=true and then run move to move the links to the shard that owns their short url.
+// The links keep resolving while they move, but the moved links get new ids.
+// Once move has run, restart the servers with DB_SHARD_RESHARDING=false.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"shortURL/db/redis"
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+
+	goredis "github.com/go-redis/redis/v8"
+	_ "github.com/lib/pq"
+)
+
+const usage = "usage: reshard sync | move [-dry-run] [-batch n]"
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	config, err := util.LoadConfig(".")
+	if err != nil {
+		log.Fatal("cannot load config:", err)
+	}
+
+	sources := append([]string{config.DBSource}, config.DBShardSources...)
+	shards := make([]db.TxQuerier, len(sources))
+	for i, source := range sources {
+		conn, err := sql.Open(config.DBDriver, source)
+		if err != nil {
+			log.Fatal("cannot connect to db:", err)
+		}
+		defer conn.Close()
+
+		shards[i] = db.NewSQLStore(conn)
+	}
+
+	ctx := context.Background()
+
+	switch os.Args[1] {
+	case "sync":
+		err = db.SyncShardCatalog(ctx, shards)
+		if err != nil {
+			log.Fatal("cannot copy domains and folders:", err)
+		}
+		fmt.Printf("copied domains and folders to %d shards\n", len(shards)-1)
+	case "move":
+		flags := flag.NewFlagSet("move", flag.ExitOnError)
+		dryRun := flags.Bool("dry-run", false, "only list the links to move")
+		batch := flags.Int("batch", 500, "number of links read from a shard at a time")
+		flags.Parse(os.Args[2:])
+
+		moveURLs(ctx, config, shards, int32(*batch), *dryRun)
+	default:
+		log.Fatal(usage)
+	}
+}
+
+func moveURLs(ctx context.Context, config util.Config, shards []db.TxQuerier, batch int32, dryRun bool) {
+	// 搬移後的連結 id 改變，需清除快取
+	var cache redis.RedisQuerier
+	if config.RedisAddress != "" && !dryRun {
+		cache = redis.NewRedisQuery(goredis.NewClient(&goredis.Options{Addr: config.RedisAddress}))
+	}
+
+	moved, conflicts := 0, 0
+	err := db.MoveURLs(ctx, shards, batch, dryRun, func(move db.URLMove) {
+		if move.Err != nil {
+			conflicts++
+			fmt.Printf("skipped %s: %v\n", move.Url.ShortUrl, move.Err)
+			return
+		}
+
+		moved++
+		if dryRun {
+			fmt.Printf("%s\tshard %d -> %d\n", move.Url.ShortUrl, move.From, move.To)
+			return
+		}
+		fmt.Printf("%s\tshard %d -> %d\tid %d -> %d\n", move.Url.ShortUrl, move.From, move.To, move.OldID, move.NewID)
+
+		if cache != nil {
+			if err := cache.DelData(ctx, cacheKey(move.Url)); err != nil {
+				log.Printf("cannot invalidate cache of %s: %v", move.Url.ShortUrl, err)
+			}
+		}
+	})
+	if err != nil {
+		log.Fatal("cannot move links:", err)
+	}
+
+	action := "moved"
+	if dryRun {
+		action = "to move"
+	}
+	fmt.Printf("%d links %s, %d skipped\n", moved, action, conflicts)
+}
+
+// cacheKey is the key of the link in the redirect cache, the same as the api package uses.
+func cacheKey(url db.Url) string {
+	if url.DomainID == 0 {
+		return url.ShortUrl
+	}
+	return fmt.Sprintf("%d:%s", url.DomainID, url.ShortUrl)
+}
@@ -0,0 +1,31 @@
+package dbtest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// concurrency is how many goroutines the concurrency tests start.
+const concurrency = 10
+
+// runConcurrently runs fn in concurrent goroutines and waits for all of them.
+func runConcurrently(t *testing.T, fn func(i int) error) {
+	errs := make(chan error, concurrency)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- fn(i)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+}
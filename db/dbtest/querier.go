@@ -0,0 +1,924 @@
+// Package dbtest contains conformance tests that every db.Store and redis.RedisQuerier implementation must pass.
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+// RunQuerierSuite runs the conformance tests against the stores created by newStore.
+// The store may already contain data, the tests only look at the rows they create.
+func RunQuerierSuite(t *testing.T, newStore func(t *testing.T) db.Store) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, store db.Store)
+	}{
+		{"URL", testURL},
+		{"URLNotFound", testURLNotFound},
+		{"URLConstraints", testURLConstraints},
+		{"SoftDeleteAndRestore", testSoftDeleteAndRestore},
+		{"PurgeDeletedURLsTx", testPurgeDeletedURLsTx},
+		{"ClickCount", testClickCount},
+		{"Tags", testTags},
+		{"Revisions", testRevisions},
+		{"Domains", testDomains},
+		{"Folders", testFolders},
+		{"Webhooks", testWebhooks},
+		{"AuditEvents", testAuditEvents},
+		{"Search", testSearch},
+		{"ExecTxRollback", testExecTxRollback},
+		{"CreateURLTxConflict", testCreateURLTxConflict},
+		{"CreateURLBatchTx", testCreateURLBatchTx},
+		{"Expiry", testExpiry},
+		{"ClaimWebhookDeliveries", testClaimWebhookDeliveries},
+		{"ConcurrentCreateURL", testConcurrentCreateURL},
+		{"ConcurrentUpdates", testConcurrentUpdates},
+		{"Import", testImport},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, newStore(t))
+		})
+	}
+}
+
+func createDomain(t *testing.T, store db.Store) db.Domain {
+	domain, err := store.CreateDomain(context.Background(), db.CreateDomainParams{
+		Host: util.RandomString(10) + ".example.com",
+	})
+	require.NoError(t, err)
+
+	return domain
+}
+
+func createURL(t *testing.T, store db.Store, domainID int64) db.Url {
+	arg := db.CreateURLParams{
+		OriginUrl: "https://" + util.RandomLongURL(),
+		ShortUrl:  util.RandomString(8),
+		DomainID:  domainID,
+		Rules:     json.RawMessage("[]"),
+		Variants:  json.RawMessage("[]"),
+		Utm:       json.RawMessage("{}"),
+	}
+
+	url, err := store.CreateURL(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, url.ID)
+	require.Equal(t, arg.OriginUrl, url.OriginUrl)
+	require.Equal(t, arg.ShortUrl, url.ShortUrl)
+	require.Equal(t, domainID, url.DomainID)
+	require.WithinDuration(t, time.Now(), url.CreatedAt, time.Minute)
+
+	return url
+}
+
+func requirePqError(t *testing.T, err error, name string) {
+	var pqErr *pq.Error
+	require.True(t, errors.As(err, &pqErr), "expected %s, got %v", name, err)
+	require.Equal(t, name, pqErr.Code.Name())
+}
+
+func testURL(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	url := createURL(t, store, 0)
+
+	got, err := store.GetURL(ctx, db.GetURLParams{ShortUrl: url.ShortUrl})
+	require.NoError(t, err)
+	require.Equal(t, url.ID, got.ID)
+	require.Equal(t, url.OriginUrl, got.OriginUrl)
+	require.JSONEq(t, "[]", string(got.Rules))
+	require.JSONEq(t, "{}", string(got.Utm))
+	require.WithinDuration(t, url.CreatedAt, got.CreatedAt, time.Millisecond)
+
+	got, err = store.GetURLForUpdate(ctx, url.ID)
+	require.NoError(t, err)
+	require.Equal(t, url.ShortUrl, got.ShortUrl)
+
+	newShortUrl := util.RandomString(8)
+	updated, err := store.UpdateURL(ctx, db.UpdateURLParams{ID: url.ID, ShortUrl: newShortUrl})
+	require.NoError(t, err)
+	require.Equal(t, newShortUrl, updated.ShortUrl)
+
+	// 舊的短網址不再指向這個連結
+	_, err = store.GetURL(ctx, db.GetURLParams{ShortUrl: url.ShortUrl})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	updated, err = store.UpdateURLSettings(ctx, db.UpdateURLSettingsParams{
+		ID:        url.ID,
+		OriginUrl: "https://example.com/new",
+		Rules:     json.RawMessage("[]"),
+		Variants:  json.RawMessage("[]"),
+		Utm:       json.RawMessage(`{"utm_source":"test"}`),
+		Title:     "new title",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/new", updated.OriginUrl)
+	require.Equal(t, "new title", updated.Title)
+	require.Equal(t, newShortUrl, updated.ShortUrl)
+
+	archived, err := store.SetURLArchived(ctx, db.SetURLArchivedParams{ID: url.ID, Archived: true})
+	require.NoError(t, err)
+	require.True(t, archived.ArchivedAt.Valid)
+
+	err = store.DeleteURL(ctx, url.ID)
+	require.NoError(t, err)
+
+	_, err = store.GetURLForUpdate(ctx, url.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testURLNotFound(t *testing.T, store db.Store) {
+	ctx := context.Background()
+
+	_, err := store.GetURL(ctx, db.GetURLParams{ShortUrl: util.RandomString(12)})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = store.UpdateURL(ctx, db.UpdateURLParams{ID: -1, ShortUrl: util.RandomString(8)})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = store.SoftDeleteURL(ctx, -1)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// 沒有資料列的 :exec 查詢不是錯誤
+	require.NoError(t, store.DeleteURL(ctx, -1))
+	require.NoError(t, store.UpdateURLClickCount(ctx, db.UpdateURLClickCountParams{ID: -1, ClickCount: 1}))
+}
+
+func testURLConstraints(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	domain := createDomain(t, store)
+	url := createURL(t, store, domain.ID)
+	other := createURL(t, store, domain.ID)
+
+	// 同一網域內的短網址已被使用時不會新增資料
+	_, err := store.CreateURL(ctx, db.CreateURLParams{
+		OriginUrl: "https://example.com",
+		ShortUrl:  url.ShortUrl,
+		DomainID:  domain.ID,
+		Rules:     json.RawMessage("[]"),
+		Variants:  json.RawMessage("[]"),
+		Utm:       json.RawMessage("{}"),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// 其他網域可以使用相同的短網址
+	sameSlug, err := store.CreateURL(ctx, db.CreateURLParams{
+		OriginUrl: "https://example.com",
+		ShortUrl:  url.ShortUrl,
+		DomainID:  0,
+		Rules:     json.RawMessage("[]"),
+		Variants:  json.RawMessage("[]"),
+		Utm:       json.RawMessage("{}"),
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, url.ID, sameSlug.ID)
+
+	_, err = store.UpdateURL(ctx, db.UpdateURLParams{ID: other.ID, ShortUrl: url.ShortUrl})
+	requirePqError(t, err, "unique_violation")
+
+	_, err = store.CreateURL(ctx, db.CreateURLParams{
+		OriginUrl: "https://example.com",
+		ShortUrl:  util.RandomString(8),
+		DomainID:  -1,
+		Rules:     json.RawMessage("[]"),
+		Variants:  json.RawMessage("[]"),
+		Utm:       json.RawMessage("{}"),
+	})
+	requirePqError(t, err, "foreign_key_violation")
+}
+
+func testSoftDeleteAndRestore(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	url := createURL(t, store, 0)
+
+	deleted, err := store.SoftDeleteURL(ctx, url.ID)
+	require.NoError(t, err)
+	require.True(t, deleted.DeletedAt.Valid)
+
+	_, err = store.SoftDeleteURL(ctx, url.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = store.GetURL(ctx, db.GetURLParams{ShortUrl: url.ShortUrl})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	got, err := store.GetDeletedURL(ctx, db.GetDeletedURLParams{ShortUrl: url.ShortUrl})
+	require.NoError(t, err)
+	require.Equal(t, url.ID, got.ID)
+
+	// 超過保留期限的連結不能還原
+	_, err = store.RestoreURL(ctx, db.RestoreURLParams{ID: url.ID, DeletedAfter: time.Now().Add(time.Minute)})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	restored, err := store.RestoreURL(ctx, db.RestoreURLParams{ID: url.ID, DeletedAfter: time.Now().Add(-time.Minute)})
+	require.NoError(t, err)
+	require.False(t, restored.DeletedAt.Valid)
+
+	_, err = store.GetURL(ctx, db.GetURLParams{ShortUrl: url.ShortUrl})
+	require.NoError(t, err)
+}
+
+func testPurgeDeletedURLsTx(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	domain := createDomain(t, store)
+	deleted := createURL(t, store, domain.ID)
+	kept := createURL(t, store, domain.ID)
+
+	_, err := store.SoftDeleteURLTx(ctx, deleted.ID, db.AuditInfo{Actor: "tester"})
+	require.NoError(t, err)
+
+	urls, err := store.PurgeDeletedURLsTx(ctx, db.PurgeDeletedURLsParams{
+		DeletedBefore: time.Now().Add(time.Minute),
+		Limit:         1000,
+	})
+	require.NoError(t, err)
+
+	purged := make(map[int64]bool)
+	for _, url := range urls {
+		purged[url.ID] = true
+	}
+	require.True(t, purged[deleted.ID])
+	require.False(t, purged[kept.ID])
+
+	// 永久刪除在同一個交易留下稽核紀錄
+	events, err := store.ListAuditEvents(ctx, db.ListAuditEventsParams{
+		DomainID: domain.ID,
+		ShortUrl: deleted.ShortUrl,
+		Limit:    5,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, db.AuditActionPurge, events[0].Action)
+	require.Equal(t, db.PurgeActor, events[0].Actor)
+	require.Equal(t, deleted.ID, events[0].UrlID)
+	require.JSONEq(t, "null", string(events[0].After))
+	require.Contains(t, string(events[0].Before), deleted.OriginUrl)
+}
+
+func testClickCount(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	url := createURL(t, store, 0)
+
+	err := store.UpdateURLClickCount(ctx, db.UpdateURLClickCountParams{ID: url.ID, ClickCount: 10})
+	require.NoError(t, err)
+
+	// 點擊數不會變少
+	err = store.UpdateURLClickCount(ctx, db.UpdateURLClickCountParams{ID: url.ID, ClickCount: 3})
+	require.NoError(t, err)
+
+	got, err := store.GetURLForUpdate(ctx, url.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(10), got.ClickCount)
+}
+
+func testTags(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	url1 := createURL(t, store, 0)
+	url2 := createURL(t, store, 0)
+
+	for _, tag := range []string{"b", "a", "a"} {
+		err := store.AddURLTag(ctx, db.AddURLTagParams{UrlID: url1.ID, Tag: tag})
+		require.NoError(t, err)
+	}
+	err := store.AddURLTag(ctx, db.AddURLTagParams{UrlID: url2.ID, Tag: "c"})
+	require.NoError(t, err)
+
+	tags, err := store.ListURLTags(ctx, url1.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, tags)
+
+	// 依連結 id 與標籤排序，分片的 id 不一定依建立順序
+	expected := []db.UrlTag{
+		{UrlID: url1.ID, Tag: "a"},
+		{UrlID: url1.ID, Tag: "b"},
+		{UrlID: url2.ID, Tag: "c"},
+	}
+	if url2.ID < url1.ID {
+		expected = []db.UrlTag{expected[2], expected[0], expected[1]}
+	}
+
+	urlTags, err := store.ListTagsByURLs(ctx, []int64{url2.ID, url1.ID})
+	require.NoError(t, err)
+	require.Equal(t, expected, urlTags)
+
+	err = store.AddURLTag(ctx, db.AddURLTagParams{UrlID: -1, Tag: "a"})
+	requirePqError(t, err, "foreign_key_violation")
+
+	err = store.DeleteURLTags(ctx, url1.ID)
+	require.NoError(t, err)
+
+	tags, err = store.ListURLTags(ctx, url1.ID)
+	require.NoError(t, err)
+	require.Empty(t, tags)
+	require.NotNil(t, tags)
+
+	// 刪除連結時一併刪除標籤
+	err = store.DeleteURL(ctx, url2.ID)
+	require.NoError(t, err)
+
+	tags, err = store.ListURLTags(ctx, url2.ID)
+	require.NoError(t, err)
+	require.Empty(t, tags)
+}
+
+func testRevisions(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	url := createURL(t, store, 0)
+
+	for i, origin := range []string{"https://example.com/1", "https://example.com/2"} {
+		revision, err := store.CreateURLRevision(ctx, db.CreateURLRevisionParams{
+			UrlID:     url.ID,
+			OriginUrl: origin,
+			Actor:     "tester",
+		})
+		require.NoError(t, err)
+		require.Equal(t, int32(i+1), revision.Revision)
+	}
+
+	revision, err := store.GetURLRevision(ctx, db.GetURLRevisionParams{UrlID: url.ID, Revision: 1})
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/1", revision.OriginUrl)
+	require.Equal(t, "tester", revision.Actor)
+
+	_, err = store.GetURLRevision(ctx, db.GetURLRevisionParams{UrlID: url.ID, Revision: 3})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	revisions, err := store.ListURLRevisions(ctx, db.ListURLRevisionsParams{UrlID: url.ID, Limit: 1, Offset: 0})
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	require.Equal(t, int32(2), revisions[0].Revision)
+
+	revisions, err = store.ListURLRevisions(ctx, db.ListURLRevisionsParams{UrlID: url.ID, Limit: 5, Offset: 1})
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	require.Equal(t, int32(1), revisions[0].Revision)
+
+	_, err = store.CreateURLRevision(ctx, db.CreateURLRevisionParams{UrlID: -1, OriginUrl: "https://example.com"})
+	requirePqError(t, err, "foreign_key_violation")
+}
+
+func testDomains(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	domain := createDomain(t, store)
+
+	got, err := store.GetDomainByHost(ctx, domain.Host)
+	require.NoError(t, err)
+	require.Equal(t, domain.ID, got.ID)
+
+	_, err = store.GetDomainByHost(ctx, util.RandomString(12))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = store.CreateDomain(ctx, db.CreateDomainParams{Host: domain.Host})
+	requirePqError(t, err, "unique_violation")
+
+	domains, err := store.ListDomains(ctx, db.ListDomainsParams{Limit: 1000, Offset: 0})
+	require.NoError(t, err)
+	require.NotEmpty(t, domains)
+	for _, d := range domains {
+		// 預設網域不會列出
+		require.NotZero(t, d.ID)
+	}
+}
+
+func testFolders(t *testing.T, store db.Store) {
+	ctx := context.Background()
+
+	folder, err := store.CreateFolder(ctx, util.RandomString(10))
+	require.NoError(t, err)
+
+	got, err := store.GetFolder(ctx, folder.ID)
+	require.NoError(t, err)
+	require.Equal(t, folder.Name, got.Name)
+
+	_, err = store.GetFolder(ctx, -1)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = store.CreateFolder(ctx, folder.Name)
+	requirePqError(t, err, "unique_violation")
+
+	folders, err := store.ListFolders(ctx, db.ListFoldersParams{Limit: 1000, Offset: 0})
+	require.NoError(t, err)
+	for i := 1; i < len(folders); i++ {
+		require.Less(t, folders[i-1].Name, folders[i].Name)
+	}
+
+	_, err = store.UpdateURLSettings(ctx, db.UpdateURLSettingsParams{
+		ID:        createURL(t, store, 0).ID,
+		OriginUrl: "https://example.com",
+		Rules:     json.RawMessage("[]"),
+		Variants:  json.RawMessage("[]"),
+		Utm:       json.RawMessage("{}"),
+		FolderID:  sql.NullInt64{Int64: -1, Valid: true},
+	})
+	requirePqError(t, err, "foreign_key_violation")
+}
+
+func testWebhooks(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	event := "test." + util.RandomString(8)
+
+	webhook, err := store.CreateWebhook(ctx, db.CreateWebhookParams{
+		Url:    "https://example.com/hook",
+		Secret: util.RandomString(16),
+		Events: []string{event},
+	})
+	require.NoError(t, err)
+
+	got, err := store.GetWebhook(ctx, webhook.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{event}, got.Events)
+
+	webhooks, err := store.ListWebhooksForEvent(ctx, event)
+	require.NoError(t, err)
+	require.Len(t, webhooks, 1)
+	require.Equal(t, webhook.ID, webhooks[0].ID)
+
+	delivery, err := store.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+		WebhookID: webhook.ID,
+		Event:     event,
+		Payload:   json.RawMessage(`{"id":1}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "pending", delivery.Status)
+
+	updated, err := store.UpdateWebhookDelivery(ctx, db.UpdateWebhookDeliveryParams{
+		ID:             delivery.ID,
+		Status:         "failed",
+		Attempts:       3,
+		NextAttemptAt:  time.Now(),
+		LastStatusCode: 500,
+		LastError:      "boom",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "failed", updated.Status)
+	require.Equal(t, int32(3), updated.Attempts)
+
+	redelivered, err := store.RedeliverWebhookDelivery(ctx, delivery.ID)
+	require.NoError(t, err)
+	require.Equal(t, "pending", redelivered.Status)
+	require.Zero(t, redelivered.Attempts)
+	require.Empty(t, redelivered.LastError)
+
+	deliveries, err := store.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{WebhookID: webhook.ID, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+
+	_, err = store.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+		WebhookID: -1,
+		Event:     event,
+		Payload:   json.RawMessage(`{}`),
+	})
+	requirePqError(t, err, "foreign_key_violation")
+
+	// 刪除 webhook 時一併刪除投遞紀錄
+	err = store.DeleteWebhook(ctx, webhook.ID)
+	require.NoError(t, err)
+
+	_, err = store.GetWebhook(ctx, webhook.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = store.GetWebhookDelivery(ctx, delivery.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testAuditEvents(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	domain := createDomain(t, store)
+	shortUrl := util.RandomString(8)
+
+	for _, actor := range []string{"alice", "bob"} {
+		_, err := store.CreateAuditEvent(ctx, db.CreateAuditEventParams{
+			UrlID:    1,
+			DomainID: domain.ID,
+			ShortUrl: shortUrl,
+			Actor:    actor,
+			Action:   db.AuditActionCreate,
+			Before:   json.RawMessage("null"),
+			After:    json.RawMessage(`{"id":1}`),
+		})
+		require.NoError(t, err)
+	}
+
+	events, err := store.ListAuditEvents(ctx, db.ListAuditEventsParams{
+		DomainID: domain.ID,
+		ShortUrl: shortUrl,
+		Limit:    5,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, "bob", events[0].Actor)
+	require.Greater(t, events[0].ID, events[1].ID)
+
+	events, err = store.ListAuditEvents(ctx, db.ListAuditEventsParams{
+		DomainID:      domain.ID,
+		Actor:         "alice",
+		CreatedBefore: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+		Limit:         5,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.JSONEq(t, `{"id":1}`, string(events[0].After))
+}
+
+func testSearch(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	domain := createDomain(t, store)
+	word := util.RandomString(10)
+
+	titled := createURL(t, store, domain.ID)
+	_, err := store.UpdateURLSettings(ctx, db.UpdateURLSettingsParams{
+		ID:        titled.ID,
+		OriginUrl: titled.OriginUrl,
+		Rules:     json.RawMessage("[]"),
+		Variants:  json.RawMessage("[]"),
+		Utm:       json.RawMessage("{}"),
+		Title:     "about " + word,
+	})
+	require.NoError(t, err)
+
+	tagged := createURL(t, store, domain.ID)
+	err = store.AddURLTag(ctx, db.AddURLTagParams{UrlID: tagged.ID, Tag: "campaign"})
+	require.NoError(t, err)
+
+	createURL(t, store, domain.ID)
+
+	urls, err := store.SearchURLs(ctx, db.SearchURLsParams{DomainID: domain.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, urls, 3)
+	require.Greater(t, urls[0].ID, urls[1].ID)
+
+	urls, err = store.SearchURLs(ctx, db.SearchURLsParams{DomainID: domain.ID, Query: word, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, urls, 1)
+	require.Equal(t, titled.ID, urls[0].ID)
+
+	urls, err = store.SearchURLs(ctx, db.SearchURLsParams{DomainID: domain.ID, Tag: "campaign", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, urls, 1)
+	require.Equal(t, tagged.ID, urls[0].ID)
+
+	_, err = store.SoftDeleteURL(ctx, tagged.ID)
+	require.NoError(t, err)
+
+	urls, err = store.SearchURLs(ctx, db.SearchURLsParams{DomainID: domain.ID, Status: "deleted", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, urls, 1)
+	require.Equal(t, tagged.ID, urls[0].ID)
+
+	urls, err = store.SearchURLs(ctx, db.SearchURLsParams{DomainID: domain.ID, Status: "active", Limit: 10, Offset: 1})
+	require.NoError(t, err)
+	require.Len(t, urls, 1)
+}
+
+func testExecTxRollback(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	shortUrl := util.RandomString(8)
+	errRollback := errors.New("rollback")
+
+	err := store.ExecTx(ctx, func(q db.Querier) error {
+		_, err := q.CreateURL(ctx, db.CreateURLParams{
+			OriginUrl: "https://example.com",
+			ShortUrl:  shortUrl,
+			Rules:     json.RawMessage("[]"),
+			Variants:  json.RawMessage("[]"),
+			Utm:       json.RawMessage("{}"),
+		})
+		require.NoError(t, err)
+
+		return errRollback
+	})
+	require.ErrorIs(t, err, errRollback)
+
+	_, err = store.GetURL(ctx, db.GetURLParams{ShortUrl: shortUrl})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testCreateURLTxConflict(t *testing.T, store db.Store) {
+	ctx := context.Background()
+
+	arg := db.CreateURLTxParams{
+		CreateURLParams: db.CreateURLParams{
+			OriginUrl: "https://example.com",
+			ShortUrl:  util.RandomString(8),
+			Rules:     json.RawMessage("[]"),
+			Variants:  json.RawMessage("[]"),
+			Utm:       json.RawMessage("{}"),
+		},
+		Tags:  []string{"a", "b"},
+		Audit: db.AuditInfo{Actor: "tester"},
+	}
+
+	result, err := store.CreateURLTx(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, result.Tags)
+
+	revisions, err := store.ListURLRevisions(ctx, db.ListURLRevisionsParams{UrlID: result.Url.ID, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+
+	_, err = store.CreateURLTx(ctx, arg)
+	var conflict *db.ShortURLConflictError
+	require.True(t, errors.As(err, &conflict))
+	require.Equal(t, arg.ShortUrl, conflict.ShortUrl)
+}
+
+func testCreateURLBatchTx(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	taken := createURL(t, store, 0)
+	createdAt := time.Now().Add(-time.Minute).UTC().Truncate(time.Millisecond)
+
+	args := make([]db.CreateURLTxParams, 5)
+	for i := range args {
+		args[i] = db.CreateURLTxParams{
+			CreateURLParams: db.CreateURLParams{
+				OriginUrl: "https://" + util.RandomLongURL(),
+				ShortUrl:  util.RandomString(8),
+				Rules:     json.RawMessage("[]"),
+				Variants:  json.RawMessage("[]"),
+				Utm:       json.RawMessage("{}"),
+			},
+			Tags:      []string{"batch"},
+			Audit:     db.AuditInfo{Actor: "tester"},
+			CreatedAt: createdAt,
+		}
+	}
+	args[2].ShortUrl = taken.ShortUrl
+
+	results, err := store.CreateURLBatchTx(ctx, args)
+	require.NoError(t, err)
+	require.Len(t, results, len(args))
+
+	for i, result := range results {
+		if i == 2 {
+			// 已使用的短網址不影響其他連結
+			var conflict *db.ShortURLConflictError
+			require.True(t, errors.As(result.Err, &conflict))
+			require.Equal(t, taken.ShortUrl, conflict.ShortUrl)
+			continue
+		}
+
+		require.NoError(t, result.Err)
+		require.Equal(t, []string{"batch"}, result.Tags)
+
+		url, err := store.GetURL(ctx, db.GetURLParams{ShortUrl: args[i].ShortUrl})
+		require.NoError(t, err)
+		require.Equal(t, result.Url.ID, url.ID)
+		require.Equal(t, args[i].OriginUrl, url.OriginUrl)
+		require.WithinDuration(t, createdAt, url.CreatedAt, time.Millisecond)
+
+		revisions, err := store.ListURLRevisions(ctx, db.ListURLRevisionsParams{UrlID: url.ID, Limit: 5})
+		require.NoError(t, err)
+		require.Len(t, revisions, 1)
+	}
+
+	got, err := store.GetURL(ctx, db.GetURLParams{ShortUrl: taken.ShortUrl})
+	require.NoError(t, err)
+	require.Equal(t, taken.OriginUrl, got.OriginUrl)
+}
+
+func testExpiry(t *testing.T, store db.Store) {
+	ctx := context.Background()
+
+	url, err := store.CreateURL(ctx, db.CreateURLParams{
+		OriginUrl: "https://example.com",
+		ShortUrl:  util.RandomString(8),
+		NotAfter:  sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true},
+		Rules:     json.RawMessage("[]"),
+		Variants:  json.RawMessage("[]"),
+		Utm:       json.RawMessage("{}"),
+	})
+	require.NoError(t, err)
+
+	scheduled, err := store.CreateURL(ctx, db.CreateURLParams{
+		OriginUrl: "https://example.com",
+		ShortUrl:  util.RandomString(8),
+		NotBefore: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+		Rules:     json.RawMessage("[]"),
+		Variants:  json.RawMessage("[]"),
+		Utm:       json.RawMessage("{}"),
+	})
+	require.NoError(t, err)
+
+	// 過期的連結只會被標記一次
+	marked, err := store.MarkExpiredURLs(ctx, 1000)
+	require.NoError(t, err)
+	require.True(t, containsURL(marked, url.ID))
+	require.False(t, containsURL(marked, scheduled.ID))
+
+	marked, err = store.MarkExpiredURLs(ctx, 1000)
+	require.NoError(t, err)
+	require.False(t, containsURL(marked, url.ID))
+
+	got, err := store.GetURLForUpdate(ctx, url.ID)
+	require.NoError(t, err)
+	require.True(t, got.ExpiryNotifiedAt.Valid)
+}
+
+func testClaimWebhookDeliveries(t *testing.T, store db.Store) {
+	ctx := context.Background()
+
+	webhook, err := store.CreateWebhook(ctx, db.CreateWebhookParams{
+		Url:    "https://example.com/hook",
+		Secret: util.RandomString(16),
+		Events: []string{"test"},
+	})
+	require.NoError(t, err)
+
+	delivery, err := store.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+		WebhookID: webhook.ID,
+		Event:     "test",
+		Payload:   json.RawMessage(`{}`),
+	})
+	require.NoError(t, err)
+
+	leaseUntil := time.Now().Add(time.Minute)
+	claimed, err := store.ClaimWebhookDeliveries(ctx, db.ClaimWebhookDeliveriesParams{LeaseUntil: leaseUntil, Limit: 1000})
+	require.NoError(t, err)
+	require.True(t, containsDelivery(claimed, delivery.ID))
+
+	// 租約到期前不會再被取出
+	claimed, err = store.ClaimWebhookDeliveries(ctx, db.ClaimWebhookDeliveriesParams{LeaseUntil: leaseUntil, Limit: 1000})
+	require.NoError(t, err)
+	require.False(t, containsDelivery(claimed, delivery.ID))
+
+	got, err := store.GetWebhookDelivery(ctx, delivery.ID)
+	require.NoError(t, err)
+	require.WithinDuration(t, leaseUntil, got.NextAttemptAt, time.Millisecond)
+}
+
+func testConcurrentCreateURL(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	shortUrl := util.RandomString(8)
+
+	var mu sync.Mutex
+	var created []db.Url
+	runConcurrently(t, func(i int) error {
+		url, err := store.CreateURL(ctx, db.CreateURLParams{
+			OriginUrl: fmt.Sprintf("https://example.com/%d", i),
+			ShortUrl:  shortUrl,
+			Rules:     json.RawMessage("[]"),
+			Variants:  json.RawMessage("[]"),
+			Utm:       json.RawMessage("{}"),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		created = append(created, url)
+		mu.Unlock()
+		return nil
+	})
+
+	// 同一個短網址只會建立一次
+	require.Len(t, created, 1)
+
+	got, err := store.GetURL(ctx, db.GetURLParams{ShortUrl: shortUrl})
+	require.NoError(t, err)
+	require.Equal(t, created[0].ID, got.ID)
+	require.Equal(t, created[0].OriginUrl, got.OriginUrl)
+}
+
+func testConcurrentUpdates(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	url := createURL(t, store, 0)
+
+	runConcurrently(t, func(i int) error {
+		err := store.UpdateURLClickCount(ctx, db.UpdateURLClickCountParams{ID: url.ID, ClickCount: int64(i + 1)})
+		if err != nil {
+			return err
+		}
+
+		return store.AddURLTag(ctx, db.AddURLTagParams{UrlID: url.ID, Tag: fmt.Sprintf("tag-%02d", i)})
+	})
+
+	got, err := store.GetURLForUpdate(ctx, url.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(concurrency), got.ClickCount)
+
+	tags, err := store.ListURLTags(ctx, url.ID)
+	require.NoError(t, err)
+	require.Len(t, tags, concurrency)
+}
+
+func testImport(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	createdAt := time.Now().Add(-24 * time.Hour)
+
+	// 複製的資料保留原本的 id
+	domainID := util.RandomInt(1<<40, 1<<41)
+	copyDomain := db.CopyDomainParams{
+		ID:        domainID,
+		Host:      util.RandomString(10) + ".example.com",
+		CreatedAt: createdAt,
+	}
+	require.NoError(t, store.CopyDomain(ctx, copyDomain))
+	require.NoError(t, store.CopyDomain(ctx, copyDomain))
+
+	domain, err := store.GetDomainByHost(ctx, copyDomain.Host)
+	require.NoError(t, err)
+	require.Equal(t, domainID, domain.ID)
+
+	folderID := util.RandomInt(1<<40, 1<<41)
+	copyFolder := db.CopyFolderParams{ID: folderID, Name: util.RandomString(10), CreatedAt: createdAt}
+	require.NoError(t, store.CopyFolder(ctx, copyFolder))
+	require.NoError(t, store.CopyFolder(ctx, copyFolder))
+
+	folder, err := store.GetFolder(ctx, folderID)
+	require.NoError(t, err)
+	require.Equal(t, copyFolder.Name, folder.Name)
+
+	arg := db.ImportURLParams{
+		OriginUrl:     "https://" + util.RandomLongURL(),
+		ShortUrl:      util.RandomString(8),
+		CreatedAt:     createdAt,
+		DomainID:      domainID,
+		ClickCount:    7,
+		Rules:         json.RawMessage("[]"),
+		Variants:      json.RawMessage("[]"),
+		Utm:           json.RawMessage("{}"),
+		Title:         "imported",
+		FolderID:      sql.NullInt64{Int64: folderID, Valid: true},
+		RedirectChain: json.RawMessage("[]"),
+		ArchivedAt:    sql.NullTime{Time: createdAt, Valid: true},
+	}
+	url, err := store.ImportURL(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ShortUrl, url.ShortUrl)
+	require.Equal(t, int64(7), url.ClickCount)
+	require.Equal(t, "imported", url.Title)
+	require.True(t, url.ArchivedAt.Valid)
+	require.WithinDuration(t, createdAt, url.CreatedAt, time.Millisecond)
+
+	got, err := store.GetURL(ctx, db.GetURLParams{DomainID: domainID, ShortUrl: arg.ShortUrl})
+	require.NoError(t, err)
+	require.Equal(t, url.ID, got.ID)
+
+	_, err = store.ImportURL(ctx, arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	revision, err := store.ImportURLRevision(ctx, db.ImportURLRevisionParams{
+		UrlID:     url.ID,
+		Revision:  3,
+		OriginUrl: arg.OriginUrl,
+		Actor:     "tester",
+		CreatedAt: createdAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, url.ID, revision.UrlID)
+	require.Equal(t, int32(3), revision.Revision)
+	require.WithinDuration(t, createdAt, revision.CreatedAt, time.Millisecond)
+
+	// 新的版本號接在匯入的版本之後
+	revision, err = store.CreateURLRevision(ctx, db.CreateURLRevisionParams{UrlID: url.ID, OriginUrl: arg.OriginUrl})
+	require.NoError(t, err)
+	require.Equal(t, int32(4), revision.Revision)
+
+	urls, err := store.ListURLs(ctx, db.ListURLsParams{AfterID: url.ID - 1, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, urls, 1)
+	require.Equal(t, url.ID, urls[0].ID)
+
+	createURL(t, store, 0)
+	urls, err = store.ListURLs(ctx, db.ListURLsParams{AfterID: 0, Limit: 100})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(urls), 2)
+	for i := 1; i < len(urls); i++ {
+		require.Less(t, urls[i-1].ID, urls[i].ID)
+	}
+}
+
+func containsURL(urls []db.Url, id int64) bool {
+	for _, url := range urls {
+		if url.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsDelivery(deliveries []db.WebhookDelivery, id int64) bool {
+	for _, delivery := range deliveries {
+		if delivery.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
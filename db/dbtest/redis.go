@@ -0,0 +1,478 @@
+package dbtest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"shortURL/db/redis"
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+// CacheExpirer reports how long the caches of a RedisQuerier are kept, the suite checks the expiry with it.
+type CacheExpirer interface {
+	DataTTL(ctx context.Context, shortUrl string) (time.Duration, error)
+	QRCodeTTL(ctx context.Context, shortUrl string) (time.Duration, error)
+}
+
+// RunRedisQuerierSuite runs the conformance tests against the queriers created by newQuerier.
+// The tests pop dirty clicks and claim pending links, so every querier must see only its own data,
+// a querier on a shared Redis server needs its own key prefix.
+// The queriers must implement CacheExpirer.
+func RunRedisQuerierSuite(t *testing.T, newQuerier func(t *testing.T) redis.RedisQuerier) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, q redis.RedisQuerier)
+	}{
+		{"Bloom", testBloom},
+		{"Data", testData},
+		{"Clicks", testClicks},
+		{"VariantClicks", testVariantClicks},
+		{"QRCode", testQRCode},
+		{"UnlockAttemptWindow", testUnlockAttemptWindow},
+		{"TaskQueue", testTaskQueue},
+		{"TaskQueueTimeout", testTaskQueueTimeout},
+		{"PendingURLs", testPendingURLs},
+		{"PendingURLLease", testPendingURLLease},
+		{"ConcurrentBloom", testConcurrentBloom},
+		{"ConcurrentClicks", testConcurrentClicks},
+		{"ConcurrentTasks", testConcurrentTasks},
+		{"ConcurrentPendingURLs", testConcurrentPendingURLs},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, newQuerier(t))
+		})
+	}
+}
+
+func testBloom(t *testing.T, q redis.RedisQuerier) {
+	ctx := context.Background()
+	shortUrl := util.RandomString(12)
+
+	added, err := q.SetBloom(ctx, shortUrl)
+	require.NoError(t, err)
+	require.True(t, added)
+
+	added, err = q.SetBloom(ctx, shortUrl)
+	require.NoError(t, err)
+	require.False(t, added)
+
+	// 布隆過濾器不會有偽陰性
+	found, err := q.ExistBloom(ctx, shortUrl)
+	require.NoError(t, err)
+	require.True(t, found)
+}
+
+func testData(t *testing.T, q redis.RedisQuerier) {
+	ctx := context.Background()
+	shortUrl := util.RandomString(12)
+
+	_, found, err := q.GetData(ctx, shortUrl)
+	require.NoError(t, err)
+	require.False(t, found)
+
+	url := db.Url{
+		ID:        util.RandomInt(1, 1000),
+		OriginUrl: util.RandomLongURL(),
+		ShortUrl:  shortUrl,
+		CreatedAt: time.Now().Truncate(time.Microsecond),
+	}
+	require.NoError(t, q.SetData(ctx, shortUrl, url))
+
+	got, found, err := q.GetData(ctx, shortUrl)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, url.ID, got.ID)
+	require.Equal(t, url.OriginUrl, got.OriginUrl)
+	require.True(t, url.CreatedAt.Equal(got.CreatedAt))
+	requireCacheTTL(t, q, shortUrl, CacheExpirer.DataTTL)
+
+	require.NoError(t, q.DelData(ctx, shortUrl))
+
+	_, found, err = q.GetData(ctx, shortUrl)
+	require.NoError(t, err)
+	require.False(t, found)
+
+	// 刪除不存在的資料不是錯誤
+	require.NoError(t, q.DelData(ctx, shortUrl))
+}
+
+func testClicks(t *testing.T, q redis.RedisQuerier) {
+	ctx := context.Background()
+	url := db.Url{ID: util.RandomInt(1, 1000), ShortUrl: util.RandomString(12), ClickCount: 10}
+
+	count, err := q.GetClick(ctx, url.ShortUrl)
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	// 計數器從資料庫的點擊數開始累計
+	count, err = q.IncrClick(ctx, url.ShortUrl, url)
+	require.NoError(t, err)
+	require.Equal(t, int64(11), count)
+
+	count, err = q.IncrClick(ctx, url.ShortUrl, url)
+	require.NoError(t, err)
+	require.Equal(t, int64(12), count)
+
+	count, err = q.GetClick(ctx, url.ShortUrl)
+	require.NoError(t, err)
+	require.Equal(t, int64(12), count)
+
+	dirty, err := q.PopDirtyClicks(ctx)
+	require.NoError(t, err)
+	require.Equal(t, url.ID, dirty[url.ShortUrl])
+
+	dirty, err = q.PopDirtyClicks(ctx)
+	require.NoError(t, err)
+	require.NotContains(t, dirty, url.ShortUrl)
+
+	require.NoError(t, q.MarkDirtyClick(ctx, url.ShortUrl, url.ID))
+
+	dirty, err = q.PopDirtyClicks(ctx)
+	require.NoError(t, err)
+	require.Equal(t, url.ID, dirty[url.ShortUrl])
+}
+
+func testVariantClicks(t *testing.T, q redis.RedisQuerier) {
+	ctx := context.Background()
+	shortUrl := util.RandomString(12)
+
+	clicks, err := q.GetVariantClicks(ctx, shortUrl)
+	require.NoError(t, err)
+	require.Empty(t, clicks)
+
+	for _, variant := range []string{"a", "b", "a"} {
+		require.NoError(t, q.IncrVariantClick(ctx, shortUrl, variant))
+	}
+
+	clicks, err = q.GetVariantClicks(ctx, shortUrl)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"a": 2, "b": 1}, clicks)
+}
+
+func testQRCode(t *testing.T, q redis.RedisQuerier) {
+	ctx := context.Background()
+	shortUrl := util.RandomString(12)
+
+	_, found, err := q.GetQRCode(ctx, shortUrl, "png:256")
+	require.NoError(t, err)
+	require.False(t, found)
+
+	require.NoError(t, q.SetQRCode(ctx, shortUrl, "png:256", []byte{1, 2, 3}))
+	require.NoError(t, q.SetQRCode(ctx, shortUrl, "svg", []byte("<svg/>")))
+
+	data, found, err := q.GetQRCode(ctx, shortUrl, "png:256")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, []byte{1, 2, 3}, data)
+
+	data, found, err = q.GetQRCode(ctx, shortUrl, "svg")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, []byte("<svg/>"), data)
+
+	// 所有尺寸與格式一起過期
+	requireCacheTTL(t, q, shortUrl, CacheExpirer.QRCodeTTL)
+}
+
+// requireCacheTTL checks that the cache read by ttl expires after redis.CacheTTL.
+func requireCacheTTL(t *testing.T, q redis.RedisQuerier, shortUrl string, ttl func(CacheExpirer, context.Context, string) (time.Duration, error)) {
+	expirer, ok := q.(CacheExpirer)
+	require.True(t, ok, "%T does not implement CacheExpirer", q)
+
+	got, err := ttl(expirer, context.Background(), shortUrl)
+	require.NoError(t, err)
+	require.Greater(t, got, redis.CacheTTL-time.Minute)
+	require.LessOrEqual(t, got, redis.CacheTTL)
+}
+
+func testUnlockAttemptWindow(t *testing.T, q redis.RedisQuerier) {
+	ctx := context.Background()
+	key := util.RandomString(12)
+	window := 200 * time.Millisecond
+
+	for i := int64(1); i <= 3; i++ {
+		count, err := q.IncrUnlockAttempt(ctx, key, window)
+		require.NoError(t, err)
+		require.Equal(t, i, count)
+	}
+
+	// 時間窗過後重新計算
+	time.Sleep(2 * window)
+
+	count, err := q.IncrUnlockAttempt(ctx, key, window)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+
+	require.NoError(t, q.DelUnlockAttempt(ctx, key))
+
+	count, err = q.IncrUnlockAttempt(ctx, key, window)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+}
+
+func testTaskQueue(t *testing.T, q redis.RedisQuerier) {
+	ctx := context.Background()
+	low := "test-queue:" + util.RandomString(8)
+	high := "test-queue:" + util.RandomString(8)
+
+	require.NoError(t, q.PushTask(ctx, low, []byte("1")))
+	require.NoError(t, q.PushTask(ctx, low, []byte("2")))
+	require.NoError(t, q.PushTask(ctx, high, []byte("3")))
+
+	// 依照佇列的順序取出，同一佇列先放入的先取出
+	for _, want := range []redis.Task{
+		{Queue: high, Payload: []byte("3")},
+		{Queue: low, Payload: []byte("1")},
+		{Queue: low, Payload: []byte("2")},
+	} {
+		task, found, err := q.PopTask(ctx, time.Second, high, low)
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, want, task)
+	}
+}
+
+func testTaskQueueTimeout(t *testing.T, q redis.RedisQuerier) {
+	ctx := context.Background()
+	queue := "test-queue:" + util.RandomString(8)
+
+	start := time.Now()
+	_, found, err := q.PopTask(ctx, time.Second, queue)
+	require.NoError(t, err)
+	require.False(t, found)
+	require.GreaterOrEqual(t, time.Since(start), 900*time.Millisecond)
+
+	// 等待中放入的工作會被取出
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		q.PushTask(ctx, queue, []byte("late"))
+	}()
+
+	task, found, err := q.PopTask(ctx, 5*time.Second, queue)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, []byte("late"), task.Payload)
+}
+
+// claimPendingURLs claims the due pending links by short url.
+func claimPendingURLs(t *testing.T, q redis.RedisQuerier, lease time.Duration) map[string]redis.PendingURL {
+	claimed, err := q.ClaimPendingURLs(context.Background(), 10, lease)
+	require.NoError(t, err)
+
+	urls := make(map[string]redis.PendingURL)
+	for _, pending := range claimed {
+		urls[pending.ShortUrl] = pending
+	}
+
+	return urls
+}
+
+func testPendingURLs(t *testing.T, q redis.RedisQuerier) {
+	ctx := context.Background()
+	first := util.RandomString(12)
+	second := util.RandomString(12)
+
+	pending, failed, err := q.CountPendingURLs(ctx)
+	require.NoError(t, err)
+
+	_, found, err := q.GetPendingURL(ctx, first)
+	require.NoError(t, err)
+	require.False(t, found)
+
+	url := db.Url{ShortUrl: first, OriginUrl: "https://" + util.RandomLongURL()}
+	ok, err := q.EnqueuePendingURL(ctx, first, []byte("1"), url)
+	require.NoError(t, err)
+	require.True(t, ok)
+	defer q.DelData(ctx, first)
+
+	// 排入時一併快取連結
+	cached, found, err := q.GetData(ctx, first)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, url.OriginUrl, cached.OriginUrl)
+	requireCacheTTL(t, q, first, CacheExpirer.DataTTL)
+
+	ok, err = q.EnqueuePendingURL(ctx, second, []byte("2"), db.Url{ShortUrl: second})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// 已保留的短網址不能再排入，也不會覆蓋快取
+	ok, err = q.EnqueuePendingURL(ctx, first, []byte("3"), db.Url{ShortUrl: first})
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	cached, _, err = q.GetData(ctx, first)
+	require.NoError(t, err)
+	require.Equal(t, url.OriginUrl, cached.OriginUrl)
+
+	payload, found, err := q.GetPendingURL(ctx, first)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, []byte("1"), payload)
+
+	count, _, err := q.CountPendingURLs(ctx)
+	require.NoError(t, err)
+	require.Equal(t, pending+2, count)
+
+	claimed := claimPendingURLs(t, q, time.Minute)
+	require.Equal(t, redis.PendingURL{ShortUrl: first, Payload: []byte("1"), Attempts: 1}, claimed[first])
+	require.Equal(t, redis.PendingURL{ShortUrl: second, Payload: []byte("2"), Attempts: 1}, claimed[second])
+
+	require.NoError(t, q.AckPendingURLs(ctx, first))
+	require.NoError(t, q.FailPendingURL(ctx, second))
+
+	_, found, err = q.GetPendingURL(ctx, first)
+	require.NoError(t, err)
+	require.False(t, found)
+
+	// 寫入失敗的連結保留內容，短網址仍然保留
+	payload, found, err = q.GetPendingURL(ctx, second)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, []byte("2"), payload)
+
+	ok, err = q.EnqueuePendingURL(ctx, second, []byte("4"), db.Url{ShortUrl: second})
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	count, failedCount, err := q.CountPendingURLs(ctx)
+	require.NoError(t, err)
+	require.Equal(t, pending, count)
+	require.Equal(t, failed+1, failedCount)
+
+	require.NoError(t, q.AckPendingURLs(ctx, second))
+	_, failedCount, err = q.CountPendingURLs(ctx)
+	require.NoError(t, err)
+	require.Equal(t, failed, failedCount)
+}
+
+func testPendingURLLease(t *testing.T, q redis.RedisQuerier) {
+	ctx := context.Background()
+	shortUrl := util.RandomString(12)
+
+	ok, err := q.EnqueuePendingURL(ctx, shortUrl, []byte("1"), db.Url{ShortUrl: shortUrl})
+	require.NoError(t, err)
+	require.True(t, ok)
+	defer q.AckPendingURLs(ctx, shortUrl)
+
+	claimed := claimPendingURLs(t, q, 200*time.Millisecond)
+	require.Contains(t, claimed, shortUrl)
+
+	// 租約結束前不會再被取出
+	claimed = claimPendingURLs(t, q, 200*time.Millisecond)
+	require.NotContains(t, claimed, shortUrl)
+
+	time.Sleep(300 * time.Millisecond)
+
+	claimed = claimPendingURLs(t, q, 200*time.Millisecond)
+	require.Contains(t, claimed, shortUrl)
+	require.Equal(t, int64(2), claimed[shortUrl].Attempts)
+}
+
+func testConcurrentBloom(t *testing.T, q redis.RedisQuerier) {
+	ctx := context.Background()
+	shortUrl := util.RandomString(12)
+
+	var added int64
+	var mu sync.Mutex
+	runConcurrently(t, func(int) error {
+		ok, err := q.SetBloom(ctx, shortUrl)
+		if ok {
+			mu.Lock()
+			added++
+			mu.Unlock()
+		}
+		return err
+	})
+
+	// 只有一個請求能取得短網址
+	require.Equal(t, int64(1), added)
+}
+
+func testConcurrentClicks(t *testing.T, q redis.RedisQuerier) {
+	ctx := context.Background()
+	url := db.Url{ID: util.RandomInt(1, 1000), ShortUrl: util.RandomString(12), ClickCount: 5}
+
+	var mu sync.Mutex
+	seen := make(map[int64]bool)
+	runConcurrently(t, func(int) error {
+		count, err := q.IncrClick(ctx, url.ShortUrl, url)
+		mu.Lock()
+		seen[count] = true
+		mu.Unlock()
+		return err
+	})
+
+	// 每次點擊都拿到不同的累計數
+	require.Len(t, seen, concurrency)
+
+	count, err := q.GetClick(ctx, url.ShortUrl)
+	require.NoError(t, err)
+	require.Equal(t, url.ClickCount+concurrency, count)
+}
+
+func testConcurrentTasks(t *testing.T, q redis.RedisQuerier) {
+	ctx := context.Background()
+	queue := "test-queue:" + util.RandomString(8)
+
+	runConcurrently(t, func(i int) error {
+		return q.PushTask(ctx, queue, []byte(fmt.Sprint(i)))
+	})
+
+	var mu sync.Mutex
+	popped := make(map[string]int)
+	runConcurrently(t, func(int) error {
+		task, found, err := q.PopTask(ctx, time.Second, queue)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("queue %s is empty", queue)
+		}
+
+		mu.Lock()
+		popped[string(task.Payload)]++
+		mu.Unlock()
+		return nil
+	})
+
+	// 每個工作只會被取出一次
+	require.Len(t, popped, concurrency)
+	for payload, n := range popped {
+		require.Equal(t, 1, n, payload)
+	}
+}
+
+func testConcurrentPendingURLs(t *testing.T, q redis.RedisQuerier) {
+	ctx := context.Background()
+	shortUrl := util.RandomString(12)
+	defer q.AckPendingURLs(ctx, shortUrl)
+
+	var reserved []string
+	var mu sync.Mutex
+	runConcurrently(t, func(i int) error {
+		ok, err := q.EnqueuePendingURL(ctx, shortUrl, []byte(fmt.Sprint(i)), db.Url{ShortUrl: shortUrl})
+		if ok {
+			mu.Lock()
+			reserved = append(reserved, fmt.Sprint(i))
+			mu.Unlock()
+		}
+		return err
+	})
+
+	// 只有一個請求能保留短網址，內容不會被覆寫
+	require.Len(t, reserved, 1)
+
+	payload, found, err := q.GetPendingURL(ctx, shortUrl)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, reserved[0], string(payload))
+}
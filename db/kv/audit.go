@@ -0,0 +1,57 @@
+package kv
+
+import (
+	"context"
+
+	db "shortURL/db/sqlc"
+)
+
+func (q *Queries) CreateAuditEvent(ctx context.Context, arg db.CreateAuditEventParams) (db.AuditEvent, error) {
+	var event db.AuditEvent
+
+	err := q.update(func(tx Tx) error {
+		id, err := tx.NextSequence(bucketAuditEvents)
+		if err != nil {
+			return err
+		}
+
+		event = db.AuditEvent{
+			ID:        id,
+			UrlID:     arg.UrlID,
+			DomainID:  arg.DomainID,
+			ShortUrl:  arg.ShortUrl,
+			Actor:     arg.Actor,
+			Action:    arg.Action,
+			Before:    jsonOrDefault(arg.Before, "null"),
+			After:     jsonOrDefault(arg.After, "null"),
+			RequestID: arg.RequestID,
+			ClientIp:  arg.ClientIp,
+			CreatedAt: now(),
+		}
+		return putRecord(tx, bucketAuditEvents, itob(id), event)
+	})
+
+	return event, err
+}
+
+func (q *Queries) ListAuditEvents(ctx context.Context, arg db.ListAuditEventsParams) ([]db.AuditEvent, error) {
+	var items []db.AuditEvent
+
+	err := q.view(func(tx Tx) error {
+		var err error
+		items, err = listRecords(tx, bucketAuditEvents, nil, func(event db.AuditEvent) bool {
+			return event.DomainID == arg.DomainID &&
+				(arg.ShortUrl == "" || event.ShortUrl == arg.ShortUrl) &&
+				(arg.Actor == "" || event.Actor == arg.Actor) &&
+				(!arg.CreatedAfter.Valid || !event.CreatedAt.Before(arg.CreatedAfter.Time)) &&
+				(!arg.CreatedBefore.Valid || event.CreatedAt.Before(arg.CreatedBefore.Time))
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reverse(items)
+	return paginate(items, arg.Limit, arg.Offset), nil
+}
@@ -0,0 +1,90 @@
+package kv
+
+import (
+	"encoding/binary"
+)
+
+// Buckets used to store the tables and their unique indexes.
+const (
+	bucketURLs              = "urls"
+	bucketURLSlugs          = "url_slugs"
+	bucketURLTags           = "url_tags"
+	bucketURLRevisions      = "url_revisions"
+	bucketDomains           = "domains"
+	bucketDomainHosts       = "domain_hosts"
+	bucketFolders           = "folders"
+	bucketFolderNames       = "folder_names"
+	bucketWebhooks          = "webhooks"
+	bucketWebhookDeliveries = "webhook_deliveries"
+	bucketAuditEvents       = "audit_events"
+)
+
+var buckets = []string{
+	bucketURLs,
+	bucketURLSlugs,
+	bucketURLTags,
+	bucketURLRevisions,
+	bucketDomains,
+	bucketDomainHosts,
+	bucketFolders,
+	bucketFolderNames,
+	bucketWebhooks,
+	bucketWebhookDeliveries,
+	bucketAuditEvents,
+}
+
+// Backend is an ordered key-value database with transactions.
+type Backend interface {
+	// View runs fn in a read-only transaction.
+	View(fn func(Tx) error) error
+	// Update runs fn in a read-write transaction, the transaction is rolled back when fn returns an error.
+	// Update transactions do not run concurrently with each other.
+	Update(fn func(Tx) error) error
+	Close() error
+}
+
+// Tx reads and writes keys inside a transaction.
+type Tx interface {
+	// Get returns nil when the key does not exist.
+	Get(bucket string, key []byte) ([]byte, error)
+	Put(bucket string, key, value []byte) error
+	Delete(bucket string, key []byte) error
+	// ForEach calls fn for the keys starting with prefix in key order.
+	ForEach(bucket string, prefix []byte, fn func(key, value []byte) error) error
+	// NextSequence returns the next id of the bucket, starting from 1.
+	NextSequence(bucket string) (int64, error)
+}
+
+func itob(id int64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(id))
+	return key
+}
+
+func btoi(key []byte) int64 {
+	return int64(binary.BigEndian.Uint64(key))
+}
+
+// joinKey builds a composite key, the id keeps the keys of the same parent together.
+func joinKey(id int64, suffix []byte) []byte {
+	return append(itob(id), suffix...)
+}
+
+// prefixEnd returns the smallest key greater than every key starting with prefix,
+// nil means there is no upper bound.
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte(nil), prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+
+	return nil
+}
+
+// stringKey builds the key of a unique string column, the prefix keeps the empty string a valid key.
+func stringKey(s string) []byte {
+	return append([]byte{0}, s...)
+}
@@ -0,0 +1,95 @@
+package kv
+
+import (
+	"bytes"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltBackend stores the data in a bbolt database file.
+type BoltBackend struct {
+	db *bolt.DB
+}
+
+// OpenBolt opens or creates the bbolt database file.
+func OpenBolt(path string) (*BoltBackend, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range buckets {
+			_, err := tx.CreateBucketIfNotExists([]byte(name))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltBackend{db: db}, nil
+}
+
+// View runs fn in a read-only transaction.
+func (b *BoltBackend) View(fn func(Tx) error) error {
+	return b.db.View(func(tx *bolt.Tx) error {
+		return fn(boltTx{tx})
+	})
+}
+
+// Update runs fn in a read-write transaction.
+func (b *BoltBackend) Update(fn func(Tx) error) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return fn(boltTx{tx})
+	})
+}
+
+// Close closes the database file.
+func (b *BoltBackend) Close() error {
+	return b.db.Close()
+}
+
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+func (t boltTx) Get(bucket string, key []byte) ([]byte, error) {
+	value := t.tx.Bucket([]byte(bucket)).Get(key)
+	if value == nil {
+		return nil, nil
+	}
+
+	// bbolt 回傳的資料只在交易內有效
+	return append([]byte(nil), value...), nil
+}
+
+func (t boltTx) Put(bucket string, key, value []byte) error {
+	return t.tx.Bucket([]byte(bucket)).Put(key, value)
+}
+
+func (t boltTx) Delete(bucket string, key []byte) error {
+	return t.tx.Bucket([]byte(bucket)).Delete(key)
+}
+
+func (t boltTx) ForEach(bucket string, prefix []byte, fn func(key, value []byte) error) error {
+	c := t.tx.Bucket([]byte(bucket)).Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		err := fn(append([]byte(nil), k...), append([]byte(nil), v...))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t boltTx) NextSequence(bucket string) (int64, error) {
+	id, err := t.tx.Bucket([]byte(bucket)).NextSequence()
+	return int64(id), err
+}
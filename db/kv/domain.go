@@ -0,0 +1,94 @@
+package kv
+
+import (
+	"context"
+
+	db "shortURL/db/sqlc"
+)
+
+func putDomain(tx Tx, domain db.Domain) error {
+	err := putRecord(tx, bucketDomains, itob(domain.ID), domain)
+	if err != nil {
+		return err
+	}
+
+	return tx.Put(bucketDomainHosts, stringKey(domain.Host), itob(domain.ID))
+}
+
+func (q *Queries) CreateDomain(ctx context.Context, arg db.CreateDomainParams) (db.Domain, error) {
+	var domain db.Domain
+
+	err := q.update(func(tx Tx) error {
+		taken, err := exists(tx, bucketDomainHosts, stringKey(arg.Host))
+		if err != nil {
+			return err
+		}
+		if taken {
+			return uniqueViolation("domains_host_key")
+		}
+
+		id, err := tx.NextSequence(bucketDomains)
+		if err != nil {
+			return err
+		}
+
+		domain = db.Domain{
+			ID:          id,
+			Host:        arg.Host,
+			FallbackUrl: arg.FallbackUrl,
+			CreatedAt:   now(),
+		}
+		return putDomain(tx, domain)
+	})
+
+	return domain, err
+}
+
+// CopyDomain keeps the id of the domain and does nothing when the id or the host already exists.
+func (q *Queries) CopyDomain(ctx context.Context, arg db.CopyDomainParams) error {
+	return q.update(func(tx Tx) error {
+		taken, err := exists(tx, bucketDomains, itob(arg.ID))
+		if err != nil || taken {
+			return err
+		}
+
+		taken, err = exists(tx, bucketDomainHosts, stringKey(arg.Host))
+		if err != nil || taken {
+			return err
+		}
+
+		return putDomain(tx, db.Domain{
+			ID:          arg.ID,
+			Host:        arg.Host,
+			FallbackUrl: arg.FallbackUrl,
+			CreatedAt:   arg.CreatedAt,
+		})
+	})
+}
+
+func (q *Queries) GetDomainByHost(ctx context.Context, host string) (db.Domain, error) {
+	var domain db.Domain
+
+	err := q.view(func(tx Tx) error {
+		return getRecordBy(tx, bucketDomainHosts, stringKey(host), bucketDomains, &domain)
+	})
+
+	return domain, err
+}
+
+func (q *Queries) ListDomains(ctx context.Context, arg db.ListDomainsParams) ([]db.Domain, error) {
+	var items []db.Domain
+
+	err := q.view(func(tx Tx) error {
+		var err error
+		items, err = listRecords(tx, bucketDomains, nil, func(domain db.Domain) bool {
+			return domain.ID > 0
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return paginate(items, arg.Limit, arg.Offset), nil
+}
@@ -0,0 +1,98 @@
+package kv
+
+import (
+	"context"
+
+	db "shortURL/db/sqlc"
+)
+
+func (q *Queries) CreateFolder(ctx context.Context, name string) (db.Folder, error) {
+	var folder db.Folder
+
+	err := q.update(func(tx Tx) error {
+		taken, err := exists(tx, bucketFolderNames, stringKey(name))
+		if err != nil {
+			return err
+		}
+		if taken {
+			return uniqueViolation("folders_name_key")
+		}
+
+		id, err := tx.NextSequence(bucketFolders)
+		if err != nil {
+			return err
+		}
+
+		folder = db.Folder{
+			ID:        id,
+			Name:      name,
+			CreatedAt: now(),
+		}
+
+		err = putRecord(tx, bucketFolders, itob(id), folder)
+		if err != nil {
+			return err
+		}
+
+		return tx.Put(bucketFolderNames, stringKey(name), itob(id))
+	})
+
+	return folder, err
+}
+
+// CopyFolder keeps the id of the folder and does nothing when the id or the name already exists.
+func (q *Queries) CopyFolder(ctx context.Context, arg db.CopyFolderParams) error {
+	return q.update(func(tx Tx) error {
+		taken, err := exists(tx, bucketFolders, itob(arg.ID))
+		if err != nil || taken {
+			return err
+		}
+
+		taken, err = exists(tx, bucketFolderNames, stringKey(arg.Name))
+		if err != nil || taken {
+			return err
+		}
+
+		err = putRecord(tx, bucketFolders, itob(arg.ID), db.Folder{
+			ID:        arg.ID,
+			Name:      arg.Name,
+			CreatedAt: arg.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		return tx.Put(bucketFolderNames, stringKey(arg.Name), itob(arg.ID))
+	})
+}
+
+func (q *Queries) GetFolder(ctx context.Context, id int64) (db.Folder, error) {
+	var folder db.Folder
+
+	err := q.view(func(tx Tx) error {
+		return getRecord(tx, bucketFolders, itob(id), &folder)
+	})
+
+	return folder, err
+}
+
+// ListFolders walks the name index so the folders come back ordered by name.
+func (q *Queries) ListFolders(ctx context.Context, arg db.ListFoldersParams) ([]db.Folder, error) {
+	items := []db.Folder{}
+
+	err := q.view(func(tx Tx) error {
+		return tx.ForEach(bucketFolderNames, nil, func(_, id []byte) error {
+			var folder db.Folder
+			if err := getRecord(tx, bucketFolders, id, &folder); err != nil {
+				return err
+			}
+			items = append(items, folder)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return paginate(items, arg.Limit, arg.Offset), nil
+}
@@ -0,0 +1,122 @@
+package kv
+
+import (
+	"context"
+	"encoding/binary"
+
+	db "shortURL/db/sqlc"
+)
+
+func urlRevisionKey(urlID int64, revision int32) []byte {
+	key := make([]byte, 4)
+	binary.BigEndian.PutUint32(key, uint32(revision))
+	return joinKey(urlID, key)
+}
+
+func (q *Queries) CreateURLRevision(ctx context.Context, arg db.CreateURLRevisionParams) (db.UrlRevision, error) {
+	var revision db.UrlRevision
+
+	err := q.update(func(tx Tx) error {
+		found, err := exists(tx, bucketURLs, itob(arg.UrlID))
+		if err != nil {
+			return err
+		}
+		if !found {
+			return foreignKeyViolation("url_revisions_url_id_fkey")
+		}
+
+		// 以目前最大的版本號加一
+		var last int32
+		err = forEachRecord(tx, bucketURLRevisions, itob(arg.UrlID), func(r db.UrlRevision) error {
+			last = r.Revision
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		id, err := tx.NextSequence(bucketURLRevisions)
+		if err != nil {
+			return err
+		}
+
+		revision = db.UrlRevision{
+			ID:        id,
+			UrlID:     arg.UrlID,
+			Revision:  last + 1,
+			OriginUrl: arg.OriginUrl,
+			Actor:     arg.Actor,
+			CreatedAt: now(),
+		}
+		return putRecord(tx, bucketURLRevisions, urlRevisionKey(arg.UrlID, revision.Revision), revision)
+	})
+
+	return revision, err
+}
+
+// ImportURLRevision keeps the revision number and the creation time of a revision copied from another database.
+func (q *Queries) ImportURLRevision(ctx context.Context, arg db.ImportURLRevisionParams) (db.UrlRevision, error) {
+	var revision db.UrlRevision
+
+	err := q.update(func(tx Tx) error {
+		found, err := exists(tx, bucketURLs, itob(arg.UrlID))
+		if err != nil {
+			return err
+		}
+		if !found {
+			return foreignKeyViolation("url_revisions_url_id_fkey")
+		}
+
+		key := urlRevisionKey(arg.UrlID, arg.Revision)
+		taken, err := exists(tx, bucketURLRevisions, key)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return uniqueViolation("url_revisions_url_id_revision_idx")
+		}
+
+		id, err := tx.NextSequence(bucketURLRevisions)
+		if err != nil {
+			return err
+		}
+
+		revision = db.UrlRevision{
+			ID:        id,
+			UrlID:     arg.UrlID,
+			Revision:  arg.Revision,
+			OriginUrl: arg.OriginUrl,
+			Actor:     arg.Actor,
+			CreatedAt: arg.CreatedAt,
+		}
+		return putRecord(tx, bucketURLRevisions, key, revision)
+	})
+
+	return revision, err
+}
+
+func (q *Queries) GetURLRevision(ctx context.Context, arg db.GetURLRevisionParams) (db.UrlRevision, error) {
+	var revision db.UrlRevision
+
+	err := q.view(func(tx Tx) error {
+		return getRecord(tx, bucketURLRevisions, urlRevisionKey(arg.UrlID, arg.Revision), &revision)
+	})
+
+	return revision, err
+}
+
+func (q *Queries) ListURLRevisions(ctx context.Context, arg db.ListURLRevisionsParams) ([]db.UrlRevision, error) {
+	var items []db.UrlRevision
+
+	err := q.view(func(tx Tx) error {
+		var err error
+		items, err = listRecords[db.UrlRevision](tx, bucketURLRevisions, itob(arg.UrlID), nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reverse(items)
+	return paginate(items, arg.Limit, arg.Offset), nil
+}
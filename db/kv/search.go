@@ -0,0 +1,174 @@
+package kv
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	db "shortURL/db/sqlc"
+)
+
+// Weights of the search document fields, like setweight 'A' and 'B' in the url_search trigger.
+const (
+	searchWeightTitle  = 1.0
+	searchWeightOrigin = 0.4
+)
+
+// SearchURLs approximates the Postgres full-text search with the 'simple' configuration:
+// every word of the query must appear in the title, the tags or the origin url,
+// words starting with "-" must not appear, and the links are ranked by the weight of the matched fields.
+func (q *Queries) SearchURLs(ctx context.Context, arg db.SearchURLsParams) ([]db.Url, error) {
+	type result struct {
+		url  db.Url
+		rank float64
+	}
+	var results []result
+
+	include, exclude := parseSearchQuery(arg.Query)
+
+	err := q.view(func(tx Tx) error {
+		t := now()
+
+		return forEachRecord(tx, bucketURLs, nil, func(url db.Url) error {
+			if !matchSearchFilters(url, arg, t) {
+				return nil
+			}
+
+			tags, err := listTags(tx, url.ID)
+			if err != nil {
+				return err
+			}
+			if arg.Tag != "" && !containsString(tags, arg.Tag) {
+				return nil
+			}
+
+			rank := 0.0
+			if arg.Query != "" {
+				var ok bool
+				rank, ok = rankSearch(url, tags, include, exclude)
+				if !ok {
+					return nil
+				}
+			}
+
+			results = append(results, result{url: url, rank: rank})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].rank != results[j].rank {
+			return results[i].rank > results[j].rank
+		}
+		return results[i].url.ID > results[j].url.ID
+	})
+
+	items := make([]db.Url, len(results))
+	for i, r := range results {
+		items[i] = r.url
+	}
+
+	return paginate(items, arg.Limit, arg.Offset), nil
+}
+
+func matchSearchFilters(url db.Url, arg db.SearchURLsParams, t time.Time) bool {
+	if url.DomainID != arg.DomainID ||
+		(arg.Owner != "" && url.Owner != arg.Owner) ||
+		(arg.FolderID.Valid && (!url.FolderID.Valid || url.FolderID.Int64 != arg.FolderID.Int64)) ||
+		(arg.CreatedAfter.Valid && url.CreatedAt.Before(arg.CreatedAfter.Time)) ||
+		(arg.CreatedBefore.Valid && !url.CreatedAt.Before(arg.CreatedBefore.Time)) {
+		return false
+	}
+
+	// 已刪除的連結只會出現在 deleted 狀態
+	if url.DeletedAt.Valid != (arg.Status == "deleted") {
+		return false
+	}
+
+	switch arg.Status {
+	case "", "deleted":
+		return true
+	case "active":
+		return !url.ArchivedAt.Valid &&
+			(!url.NotBefore.Valid || !url.NotBefore.Time.After(t)) &&
+			(!url.NotAfter.Valid || url.NotAfter.Time.After(t)) &&
+			(url.MaxClicks == 0 || url.ClickCount < url.MaxClicks)
+	case "scheduled":
+		return url.NotBefore.Valid && url.NotBefore.Time.After(t)
+	case "expired":
+		return isExpired(url, t)
+	case "broken":
+		return url.BrokenAt.Valid
+	case "archived":
+		return url.ArchivedAt.Valid
+	}
+
+	return false
+}
+
+func parseSearchQuery(query string) (include, exclude []string) {
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		if word == "or" {
+			continue
+		}
+
+		negate := strings.HasPrefix(word, "-")
+		for _, token := range searchTokens(word) {
+			if negate {
+				exclude = append(exclude, token)
+			} else {
+				include = append(include, token)
+			}
+		}
+	}
+
+	return include, exclude
+}
+
+// rankSearch reports whether the link matches the query and how well.
+func rankSearch(url db.Url, tags []string, include, exclude []string) (float64, bool) {
+	primary := searchTokens(url.Title + " " + strings.Join(tags, " "))
+	secondary := searchTokens(url.OriginUrl)
+
+	for _, token := range exclude {
+		if containsString(primary, token) || containsString(secondary, token) {
+			return 0, false
+		}
+	}
+
+	rank := 0.0
+	for _, token := range include {
+		switch {
+		case containsString(primary, token):
+			rank += searchWeightTitle
+		case containsString(secondary, token):
+			rank += searchWeightOrigin
+		default:
+			return 0, false
+		}
+	}
+
+	return rank, true
+}
+
+// searchTokens splits text into lower case words, punctuation separates words like in the url_search document.
+func searchTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+func containsString(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
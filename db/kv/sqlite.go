@@ -0,0 +1,186 @@
+package kv
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	// 純 Go 的 SQLite 驅動，不需要 cgo
+	_ "github.com/glebarez/go-sqlite"
+)
+
+// SQLiteDriverName is the database/sql driver used by OpenSQLite.
+const SQLiteDriverName = "sqlite"
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS kv (
+  bucket TEXT NOT NULL,
+  key BLOB NOT NULL,
+  value BLOB NOT NULL,
+  PRIMARY KEY (bucket, key)
+) WITHOUT ROWID;
+
+CREATE TABLE IF NOT EXISTS kv_sequences (
+  bucket TEXT PRIMARY KEY,
+  value INTEGER NOT NULL
+);
+`
+
+// SQLiteBackend stores the data in a SQLite database, each bucket is a range of rows in one table.
+type SQLiteBackend struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens or creates the SQLite database file.
+func OpenSQLite(source string) (*SQLiteBackend, error) {
+	db, err := sql.Open(SQLiteDriverName, source)
+	if err != nil {
+		return nil, err
+	}
+
+	backend, err := NewSQLiteBackend(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return backend, nil
+}
+
+// NewSQLiteBackend creates the tables in db when they do not exist.
+func NewSQLiteBackend(db *sql.DB) (*SQLiteBackend, error) {
+	// SQLite 同時只允許一個寫入者，只用一條連線以免交易回傳 SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	_, err := db.Exec(sqliteSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SQLiteBackend{db: db}, nil
+}
+
+// View runs fn in a transaction, the connection is shared with the writes.
+func (b *SQLiteBackend) View(fn func(Tx) error) error {
+	return b.Update(fn)
+}
+
+// Update runs fn in a read-write transaction.
+func (b *SQLiteBackend) Update(fn func(Tx) error) error {
+	ctx := context.Background()
+
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = fn(sqliteTx{ctx: ctx, tx: tx})
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("tx err: %w, rb err: %v", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Close closes the database.
+func (b *SQLiteBackend) Close() error {
+	return b.db.Close()
+}
+
+type sqliteTx struct {
+	ctx context.Context
+	tx  *sql.Tx
+}
+
+func (t sqliteTx) Get(bucket string, key []byte) ([]byte, error) {
+	var value []byte
+
+	err := t.tx.QueryRowContext(t.ctx, `SELECT value FROM kv WHERE bucket = ? AND key = ?`, bucket, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 空值也代表鍵存在
+	if value == nil {
+		value = []byte{}
+	}
+
+	return value, nil
+}
+
+func (t sqliteTx) Put(bucket string, key, value []byte) error {
+	if value == nil {
+		value = []byte{}
+	}
+
+	_, err := t.tx.ExecContext(t.ctx, `INSERT INTO kv (bucket, key, value) VALUES (?, ?, ?)
+ON CONFLICT (bucket, key) DO UPDATE SET value = excluded.value`, bucket, key, value)
+	return err
+}
+
+func (t sqliteTx) Delete(bucket string, key []byte) error {
+	_, err := t.tx.ExecContext(t.ctx, `DELETE FROM kv WHERE bucket = ? AND key = ?`, bucket, key)
+	return err
+}
+
+func (t sqliteTx) ForEach(bucket string, prefix []byte, fn func(key, value []byte) error) error {
+	// nil 會被當成 NULL，無法比較大小
+	if prefix == nil {
+		prefix = []byte{}
+	}
+
+	query := `SELECT key, value FROM kv WHERE bucket = ? AND key >= ?`
+	args := []interface{}{bucket, prefix}
+	if end := prefixEnd(prefix); end != nil {
+		query += ` AND key < ?`
+		args = append(args, end)
+	}
+	query += ` ORDER BY key`
+
+	rows, err := t.tx.QueryContext(t.ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// 先讀完所有資料，fn 可能會在同一個交易內再查詢
+	var keys, values [][]byte
+	for rows.Next() {
+		var key, value []byte
+		if err := rows.Scan(&key, &value); err != nil {
+			return err
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range keys {
+		err := fn(keys[i], values[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t sqliteTx) NextSequence(bucket string) (int64, error) {
+	var id int64
+
+	err := t.tx.QueryRowContext(t.ctx, `INSERT INTO kv_sequences (bucket, value) VALUES (?, 1)
+ON CONFLICT (bucket) DO UPDATE SET value = value + 1
+RETURNING value`, bucket).Scan(&id)
+	return id, err
+}
@@ -0,0 +1,24 @@
+package kv
+
+import (
+	"path/filepath"
+	"testing"
+
+	"shortURL/db/dbtest"
+	db "shortURL/db/sqlc"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteStore(t *testing.T) {
+	dbtest.RunQuerierSuite(t, func(t *testing.T) db.Store {
+		backend, err := OpenSQLite(filepath.Join(t.TempDir(), "short_url.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { backend.Close() })
+
+		store, err := NewStore(backend)
+		require.NoError(t, err)
+
+		return store
+	})
+}
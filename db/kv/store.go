@@ -0,0 +1,195 @@
+package kv
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	db "shortURL/db/sqlc"
+
+	"github.com/lib/pq"
+)
+
+// Queries implements db.Querier on top of a Backend.
+// The records are stored as JSON and the SQL semantics of the queries are implemented in Go,
+// including the unique and foreign key constraints, which are reported as *pq.Error like Postgres does.
+type Queries struct {
+	backend Backend
+	tx      Tx
+}
+
+var _ db.TxQuerier = (*Queries)(nil)
+
+// NewQueries creates queries that run each call in its own transaction.
+func NewQueries(backend Backend) *Queries {
+	return &Queries{backend: backend}
+}
+
+// WithTx returns queries that run inside tx.
+func (q *Queries) WithTx(tx Tx) *Queries {
+	return &Queries{backend: q.backend, tx: tx}
+}
+
+// NewStore creates a store on top of the backend and creates the default domain.
+func NewStore(backend Backend) (db.Store, error) {
+	q := NewQueries(backend)
+
+	err := q.update(func(tx Tx) error {
+		value, err := tx.Get(bucketDomains, itob(0))
+		if err != nil || value != nil {
+			return err
+		}
+
+		// 預設網域，未指定網域的連結皆屬於此網域
+		return putDomain(tx, db.Domain{ID: 0, Host: "", CreatedAt: now()})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return db.NewTxStore(q), nil
+}
+
+// ExecTx executes a function within a transaction.
+// Write transactions of a backend never run concurrently, so there are no conflicts to retry.
+func (q *Queries) ExecTx(ctx context.Context, fn func(db.Querier) error) error {
+	if q.tx != nil {
+		return fn(q)
+	}
+
+	return q.backend.Update(func(tx Tx) error {
+		return fn(q.WithTx(tx))
+	})
+}
+
+func (q *Queries) view(fn func(Tx) error) error {
+	if q.tx != nil {
+		return fn(q.tx)
+	}
+
+	return q.backend.View(fn)
+}
+
+func (q *Queries) update(fn func(Tx) error) error {
+	if q.tx != nil {
+		return fn(q.tx)
+	}
+
+	return q.backend.Update(fn)
+}
+
+// now matches the precision of Postgres timestamps.
+func now() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+func getRecord(tx Tx, bucket string, key []byte, record interface{}) error {
+	value, err := tx.Get(bucket, key)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return sql.ErrNoRows
+	}
+
+	return json.Unmarshal(value, record)
+}
+
+func putRecord(tx Tx, bucket string, key []byte, record interface{}) error {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	return tx.Put(bucket, key, value)
+}
+
+func exists(tx Tx, bucket string, key []byte) (bool, error) {
+	value, err := tx.Get(bucket, key)
+	return value != nil, err
+}
+
+// forEachRecord decodes the records starting with prefix in key order.
+func forEachRecord[T any](tx Tx, bucket string, prefix []byte, fn func(T) error) error {
+	return tx.ForEach(bucket, prefix, func(_, value []byte) error {
+		var record T
+		if err := json.Unmarshal(value, &record); err != nil {
+			return err
+		}
+		return fn(record)
+	})
+}
+
+// listRecords returns the records starting with prefix that match the filter in key order.
+func listRecords[T any](tx Tx, bucket string, prefix []byte, match func(T) bool) ([]T, error) {
+	items := []T{}
+
+	err := forEachRecord(tx, bucket, prefix, func(record T) error {
+		if match == nil || match(record) {
+			items = append(items, record)
+		}
+		return nil
+	})
+
+	return items, err
+}
+
+// paginate applies LIMIT and OFFSET.
+func paginate[T any](items []T, limit, offset int32) []T {
+	if int(offset) >= len(items) {
+		return []T{}
+	}
+	items = items[offset:]
+
+	if int(limit) < len(items) {
+		items = items[:limit]
+	}
+
+	return items
+}
+
+func reverse[T any](items []T) {
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+}
+
+func uniqueViolation(constraint string) error {
+	return &pq.Error{
+		Code:       "23505",
+		Message:    fmt.Sprintf("duplicate key value violates unique constraint %q", constraint),
+		Constraint: constraint,
+	}
+}
+
+func foreignKeyViolation(constraint string) error {
+	return &pq.Error{
+		Code:       "23503",
+		Message:    fmt.Sprintf("insert or update violates foreign key constraint %q", constraint),
+		Constraint: constraint,
+	}
+}
+
+// jsonOrDefault returns the column default for an empty JSON value.
+func jsonOrDefault(value json.RawMessage, def string) json.RawMessage {
+	if len(value) == 0 {
+		return json.RawMessage(def)
+	}
+
+	return value
+}
+
+// getRecordBy looks up the id in a unique index and decodes the record it points to.
+func getRecordBy(tx Tx, index string, key []byte, bucket string, record interface{}) error {
+	id, err := tx.Get(index, key)
+	if err != nil {
+		return err
+	}
+	if id == nil {
+		return sql.ErrNoRows
+	}
+
+	return getRecord(tx, bucket, id, record)
+}
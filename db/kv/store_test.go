@@ -0,0 +1,55 @@
+package kv
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"shortURL/db/dbtest"
+	db "shortURL/db/sqlc"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoltStore(t *testing.T) {
+	dbtest.RunQuerierSuite(t, func(t *testing.T) db.Store {
+		backend, err := OpenBolt(filepath.Join(t.TempDir(), "short_url.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { backend.Close() })
+
+		store, err := NewStore(backend)
+		require.NoError(t, err)
+
+		return store
+	})
+}
+
+func TestBoltStoreReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "short_url.db")
+
+	backend, err := OpenBolt(path)
+	require.NoError(t, err)
+
+	store, err := NewStore(backend)
+	require.NoError(t, err)
+
+	domain, err := store.CreateDomain(context.Background(), db.CreateDomainParams{Host: "example.com"})
+	require.NoError(t, err)
+	require.NoError(t, backend.Close())
+
+	// 重新開啟後資料與序號都會保留
+	backend, err = OpenBolt(path)
+	require.NoError(t, err)
+	defer backend.Close()
+
+	store, err = NewStore(backend)
+	require.NoError(t, err)
+
+	got, err := store.GetDomainByHost(context.Background(), "example.com")
+	require.NoError(t, err)
+	require.Equal(t, domain.ID, got.ID)
+
+	next, err := store.CreateDomain(context.Background(), db.CreateDomainParams{Host: "example.org"})
+	require.NoError(t, err)
+	require.Greater(t, next.ID, domain.ID)
+}
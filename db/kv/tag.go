@@ -0,0 +1,105 @@
+package kv
+
+import (
+	"context"
+	"sort"
+
+	db "shortURL/db/sqlc"
+)
+
+func (q *Queries) AddURLTag(ctx context.Context, arg db.AddURLTagParams) error {
+	return q.update(func(tx Tx) error {
+		found, err := exists(tx, bucketURLs, itob(arg.UrlID))
+		if err != nil {
+			return err
+		}
+		if !found {
+			return foreignKeyViolation("url_tags_url_id_fkey")
+		}
+
+		return tx.Put(bucketURLTags, joinKey(arg.UrlID, []byte(arg.Tag)), nil)
+	})
+}
+
+func (q *Queries) DeleteURLTags(ctx context.Context, urlID int64) error {
+	return q.update(func(tx Tx) error {
+		return deletePrefix(tx, bucketURLTags, itob(urlID))
+	})
+}
+
+func (q *Queries) ListTagsByURLs(ctx context.Context, urlIds []int64) ([]db.UrlTag, error) {
+	ids := append([]int64(nil), urlIds...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	items := []db.UrlTag{}
+
+	err := q.view(func(tx Tx) error {
+		for i, id := range ids {
+			if i > 0 && ids[i-1] == id {
+				continue
+			}
+
+			tags, err := listTags(tx, id)
+			if err != nil {
+				return err
+			}
+			for _, tag := range tags {
+				items = append(items, db.UrlTag{UrlID: id, Tag: tag})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (q *Queries) ListURLTags(ctx context.Context, urlID int64) ([]string, error) {
+	var items []string
+
+	err := q.view(func(tx Tx) error {
+		var err error
+		items, err = listTags(tx, urlID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// listTags returns the tags of a link ordered by tag.
+func listTags(tx Tx, urlID int64) ([]string, error) {
+	items := []string{}
+
+	err := tx.ForEach(bucketURLTags, itob(urlID), func(key, _ []byte) error {
+		items = append(items, string(key[8:]))
+		return nil
+	})
+
+	return items, err
+}
+
+// deletePrefix deletes the keys starting with prefix.
+func deletePrefix(tx Tx, bucket string, prefix []byte) error {
+	var keys [][]byte
+
+	err := tx.ForEach(bucket, prefix, func(key, _ []byte) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err := tx.Delete(bucket, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,518 @@
+package kv
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	db "shortURL/db/sqlc"
+)
+
+func urlSlugKey(domainID int64, shortUrl string) []byte {
+	return joinKey(domainID, []byte(shortUrl))
+}
+
+// putURL stores the link and keeps the short url of the domain unique, like the url_slugs table.
+func putURL(tx Tx, url db.Url) error {
+	err := putRecord(tx, bucketURLs, itob(url.ID), url)
+	if err != nil {
+		return err
+	}
+
+	return tx.Put(bucketURLSlugs, urlSlugKey(url.DomainID, url.ShortUrl), itob(url.ID))
+}
+
+func getURL(tx Tx, id int64) (db.Url, error) {
+	var url db.Url
+	err := getRecord(tx, bucketURLs, itob(id), &url)
+	return url, err
+}
+
+// deleteURL deletes the link together with its tags and revisions.
+func deleteURL(tx Tx, url db.Url) error {
+	err := deletePrefix(tx, bucketURLTags, itob(url.ID))
+	if err != nil {
+		return err
+	}
+
+	err = deletePrefix(tx, bucketURLRevisions, itob(url.ID))
+	if err != nil {
+		return err
+	}
+
+	err = tx.Delete(bucketURLSlugs, urlSlugKey(url.DomainID, url.ShortUrl))
+	if err != nil {
+		return err
+	}
+
+	return tx.Delete(bucketURLs, itob(url.ID))
+}
+
+func checkURLReferences(tx Tx, domainID int64, folderID sql.NullInt64) error {
+	found, err := exists(tx, bucketDomains, itob(domainID))
+	if err != nil {
+		return err
+	}
+	if !found {
+		return foreignKeyViolation("urls_domain_id_fkey")
+	}
+
+	if !folderID.Valid {
+		return nil
+	}
+
+	found, err = exists(tx, bucketFolders, itob(folderID.Int64))
+	if err != nil {
+		return err
+	}
+	if !found {
+		return foreignKeyViolation("urls_folder_id_fkey")
+	}
+
+	return nil
+}
+
+// CreateURL returns sql.ErrNoRows when the short url is already taken in the domain.
+func (q *Queries) CreateURL(ctx context.Context, arg db.CreateURLParams) (db.Url, error) {
+	var url db.Url
+
+	err := q.update(func(tx Tx) error {
+		taken, err := exists(tx, bucketURLSlugs, urlSlugKey(arg.DomainID, arg.ShortUrl))
+		if err != nil {
+			return err
+		}
+		if taken {
+			return sql.ErrNoRows
+		}
+
+		err = checkURLReferences(tx, arg.DomainID, arg.FolderID)
+		if err != nil {
+			return err
+		}
+
+		id, err := tx.NextSequence(bucketURLs)
+		if err != nil {
+			return err
+		}
+
+		url = db.Url{
+			ID:            id,
+			OriginUrl:     arg.OriginUrl,
+			ShortUrl:      arg.ShortUrl,
+			CreatedAt:     now(),
+			RedirectType:  arg.RedirectType,
+			NotAfter:      arg.NotAfter,
+			DomainID:      arg.DomainID,
+			PasswordHash:  arg.PasswordHash,
+			NotBefore:     arg.NotBefore,
+			MaxClicks:     arg.MaxClicks,
+			Rules:         jsonOrDefault(arg.Rules, "[]"),
+			Variants:      jsonOrDefault(arg.Variants, "[]"),
+			QueryMode:     arg.QueryMode,
+			Utm:           jsonOrDefault(arg.Utm, "{}"),
+			IsPrefix:      arg.IsPrefix,
+			Title:         arg.Title,
+			Description:   arg.Description,
+			Owner:         arg.Owner,
+			FolderID:      arg.FolderID,
+			RedirectChain: jsonOrDefault(nil, "[]"),
+		}
+		return putURL(tx, url)
+	})
+
+	return url, err
+}
+
+// ImportURL keeps every column except the id, it returns sql.ErrNoRows when the short url is already taken in the domain.
+func (q *Queries) ImportURL(ctx context.Context, arg db.ImportURLParams) (db.Url, error) {
+	var url db.Url
+
+	err := q.update(func(tx Tx) error {
+		taken, err := exists(tx, bucketURLSlugs, urlSlugKey(arg.DomainID, arg.ShortUrl))
+		if err != nil {
+			return err
+		}
+		if taken {
+			return sql.ErrNoRows
+		}
+
+		err = checkURLReferences(tx, arg.DomainID, arg.FolderID)
+		if err != nil {
+			return err
+		}
+
+		id, err := tx.NextSequence(bucketURLs)
+		if err != nil {
+			return err
+		}
+
+		url = db.Url{
+			ID:                id,
+			OriginUrl:         arg.OriginUrl,
+			ShortUrl:          arg.ShortUrl,
+			CreatedAt:         arg.CreatedAt,
+			RedirectType:      arg.RedirectType,
+			NotAfter:          arg.NotAfter,
+			DomainID:          arg.DomainID,
+			PasswordHash:      arg.PasswordHash,
+			NotBefore:         arg.NotBefore,
+			MaxClicks:         arg.MaxClicks,
+			ClickCount:        arg.ClickCount,
+			Rules:             jsonOrDefault(arg.Rules, "[]"),
+			Variants:          jsonOrDefault(arg.Variants, "[]"),
+			QueryMode:         arg.QueryMode,
+			Utm:               jsonOrDefault(arg.Utm, "{}"),
+			IsPrefix:          arg.IsPrefix,
+			Title:             arg.Title,
+			Description:       arg.Description,
+			Owner:             arg.Owner,
+			FolderID:          arg.FolderID,
+			PageTitle:         arg.PageTitle,
+			PageDescription:   arg.PageDescription,
+			FaviconUrl:        arg.FaviconUrl,
+			OgImageUrl:        arg.OgImageUrl,
+			MetadataFetchedAt: arg.MetadataFetchedAt,
+			LastStatusCode:    arg.LastStatusCode,
+			RedirectChain:     jsonOrDefault(arg.RedirectChain, "[]"),
+			CheckError:        arg.CheckError,
+			CheckFailures:     arg.CheckFailures,
+			LastCheckedAt:     arg.LastCheckedAt,
+			BrokenAt:          arg.BrokenAt,
+			ExpiryNotifiedAt:  arg.ExpiryNotifiedAt,
+			ArchivedAt:        arg.ArchivedAt,
+			DeletedAt:         arg.DeletedAt,
+		}
+		return putURL(tx, url)
+	})
+
+	return url, err
+}
+
+func (q *Queries) GetURL(ctx context.Context, arg db.GetURLParams) (db.Url, error) {
+	return q.getURLBySlug(arg.DomainID, arg.ShortUrl, false)
+}
+
+func (q *Queries) GetDeletedURL(ctx context.Context, arg db.GetDeletedURLParams) (db.Url, error) {
+	return q.getURLBySlug(arg.DomainID, arg.ShortUrl, true)
+}
+
+func (q *Queries) getURLBySlug(domainID int64, shortUrl string, deleted bool) (db.Url, error) {
+	var url db.Url
+
+	err := q.view(func(tx Tx) error {
+		err := getRecordBy(tx, bucketURLSlugs, urlSlugKey(domainID, shortUrl), bucketURLs, &url)
+		if err != nil {
+			return err
+		}
+		if url.DeletedAt.Valid != deleted {
+			return sql.ErrNoRows
+		}
+		return nil
+	})
+
+	return url, err
+}
+
+// GetURLForUpdate needs no row lock, write transactions of a backend are serialized.
+func (q *Queries) GetURLForUpdate(ctx context.Context, id int64) (db.Url, error) {
+	var url db.Url
+
+	err := q.view(func(tx Tx) error {
+		var err error
+		url, err = getURL(tx, id)
+		return err
+	})
+
+	return url, err
+}
+
+func (q *Queries) UpdateURL(ctx context.Context, arg db.UpdateURLParams) (db.Url, error) {
+	var url db.Url
+
+	err := q.update(func(tx Tx) error {
+		var err error
+		url, err = getURL(tx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		newKey := urlSlugKey(url.DomainID, arg.ShortUrl)
+		owner, err := tx.Get(bucketURLSlugs, newKey)
+		if err != nil {
+			return err
+		}
+		if owner != nil && btoi(owner) != url.ID {
+			return uniqueViolation("url_slugs_pkey")
+		}
+
+		err = tx.Delete(bucketURLSlugs, urlSlugKey(url.DomainID, url.ShortUrl))
+		if err != nil {
+			return err
+		}
+
+		url.ShortUrl = arg.ShortUrl
+		return putURL(tx, url)
+	})
+
+	return url, err
+}
+
+func (q *Queries) UpdateURLSettings(ctx context.Context, arg db.UpdateURLSettingsParams) (db.Url, error) {
+	return q.updateURL(arg.ID, func(tx Tx, url *db.Url) (bool, error) {
+		err := checkURLReferences(tx, url.DomainID, arg.FolderID)
+		if err != nil {
+			return false, err
+		}
+
+		url.OriginUrl = arg.OriginUrl
+		url.RedirectType = arg.RedirectType
+		url.Rules = arg.Rules
+		url.Variants = arg.Variants
+		url.QueryMode = arg.QueryMode
+		url.Utm = arg.Utm
+		url.IsPrefix = arg.IsPrefix
+		url.Title = arg.Title
+		url.Description = arg.Description
+		url.FolderID = arg.FolderID
+		return true, nil
+	})
+}
+
+func (q *Queries) UpdateURLMetadata(ctx context.Context, arg db.UpdateURLMetadataParams) error {
+	_, err := q.updateURL(arg.ID, func(_ Tx, url *db.Url) (bool, error) {
+		url.PageTitle = arg.PageTitle
+		url.PageDescription = arg.PageDescription
+		url.FaviconUrl = arg.FaviconUrl
+		url.OgImageUrl = arg.OgImageUrl
+		url.MetadataFetchedAt = sql.NullTime{Time: now(), Valid: true}
+		return true, nil
+	})
+	if err == sql.ErrNoRows {
+		return nil
+	}
+
+	return err
+}
+
+// UpdateURLClickCount never lowers the click count.
+func (q *Queries) UpdateURLClickCount(ctx context.Context, arg db.UpdateURLClickCountParams) error {
+	_, err := q.updateURL(arg.ID, func(_ Tx, url *db.Url) (bool, error) {
+		if arg.ClickCount > url.ClickCount {
+			url.ClickCount = arg.ClickCount
+		}
+		return true, nil
+	})
+	if err == sql.ErrNoRows {
+		return nil
+	}
+
+	return err
+}
+
+func (q *Queries) UpdateURLCheck(ctx context.Context, arg db.UpdateURLCheckParams) (db.Url, error) {
+	return q.updateURL(arg.ID, func(_ Tx, url *db.Url) (bool, error) {
+		t := now()
+
+		url.LastStatusCode = arg.LastStatusCode
+		url.RedirectChain = arg.RedirectChain
+		url.CheckError = arg.CheckError
+		url.LastCheckedAt = sql.NullTime{Time: t, Valid: true}
+
+		switch {
+		case !arg.Failed:
+			url.CheckFailures = 0
+			url.BrokenAt = sql.NullTime{}
+		case !url.BrokenAt.Valid && url.CheckFailures+1 >= arg.FailureThreshold:
+			url.CheckFailures++
+			url.BrokenAt = sql.NullTime{Time: t, Valid: true}
+		default:
+			url.CheckFailures++
+		}
+		return true, nil
+	})
+}
+
+func (q *Queries) SetURLArchived(ctx context.Context, arg db.SetURLArchivedParams) (db.Url, error) {
+	return q.updateURL(arg.ID, func(_ Tx, url *db.Url) (bool, error) {
+		switch {
+		case !arg.Archived:
+			url.ArchivedAt = sql.NullTime{}
+		case !url.ArchivedAt.Valid:
+			url.ArchivedAt = sql.NullTime{Time: now(), Valid: true}
+		}
+		return true, nil
+	})
+}
+
+func (q *Queries) SoftDeleteURL(ctx context.Context, id int64) (db.Url, error) {
+	return q.updateURL(id, func(_ Tx, url *db.Url) (bool, error) {
+		if url.DeletedAt.Valid {
+			return false, nil
+		}
+
+		url.DeletedAt = sql.NullTime{Time: now(), Valid: true}
+		return true, nil
+	})
+}
+
+func (q *Queries) RestoreURL(ctx context.Context, arg db.RestoreURLParams) (db.Url, error) {
+	return q.updateURL(arg.ID, func(_ Tx, url *db.Url) (bool, error) {
+		if !url.DeletedAt.Valid || !url.DeletedAt.Time.After(arg.DeletedAfter) {
+			return false, nil
+		}
+
+		url.DeletedAt = sql.NullTime{}
+		return true, nil
+	})
+}
+
+// updateURL applies set to the link, set returns false when the link does not match the WHERE clause.
+func (q *Queries) updateURL(id int64, set func(tx Tx, url *db.Url) (bool, error)) (db.Url, error) {
+	var url db.Url
+
+	err := q.update(func(tx Tx) error {
+		var err error
+		url, err = getURL(tx, id)
+		if err != nil {
+			return err
+		}
+
+		ok, err := set(tx, &url)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return sql.ErrNoRows
+		}
+
+		return putURL(tx, url)
+	})
+	if err != nil {
+		return db.Url{}, err
+	}
+
+	return url, nil
+}
+
+func (q *Queries) ListURLs(ctx context.Context, arg db.ListURLsParams) ([]db.Url, error) {
+	var items []db.Url
+
+	err := q.view(func(tx Tx) error {
+		var err error
+		items, err = listRecords(tx, bucketURLs, nil, func(url db.Url) bool {
+			return url.ID > arg.AfterID
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return paginate(items, arg.Limit, 0), nil
+}
+
+func (q *Queries) ListURLsToCheck(ctx context.Context, arg db.ListURLsToCheckParams) ([]db.Url, error) {
+	var items []db.Url
+
+	err := q.view(func(tx Tx) error {
+		var err error
+		items, err = listRecords(tx, bucketURLs, nil, func(url db.Url) bool {
+			return !url.DeletedAt.Valid &&
+				!url.ArchivedAt.Valid &&
+				(!url.LastCheckedAt.Valid || url.LastCheckedAt.Time.Before(arg.CheckedBefore))
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 從未檢查過的連結優先
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i].LastCheckedAt, items[j].LastCheckedAt
+		if a.Valid != b.Valid {
+			return !a.Valid
+		}
+		return a.Time.Before(b.Time)
+	})
+
+	return paginate(items, arg.Limit, 0), nil
+}
+
+// MarkExpiredURLs marks the links that expired since the last call, each link is returned once.
+func (q *Queries) MarkExpiredURLs(ctx context.Context, limit int32) ([]db.Url, error) {
+	var items []db.Url
+
+	err := q.update(func(tx Tx) error {
+		t := now()
+
+		var err error
+		items, err = listRecords(tx, bucketURLs, nil, func(url db.Url) bool {
+			return !url.ExpiryNotifiedAt.Valid && !url.DeletedAt.Valid && isExpired(url, t)
+		})
+		if err != nil {
+			return err
+		}
+		items = paginate(items, limit, 0)
+
+		for i := range items {
+			items[i].ExpiryNotifiedAt = sql.NullTime{Time: t, Valid: true}
+			if err := putURL(tx, items[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (q *Queries) PurgeDeletedURLs(ctx context.Context, arg db.PurgeDeletedURLsParams) ([]db.Url, error) {
+	var items []db.Url
+
+	err := q.update(func(tx Tx) error {
+		var err error
+		items, err = listRecords(tx, bucketURLs, nil, func(url db.Url) bool {
+			return url.DeletedAt.Valid && url.DeletedAt.Time.Before(arg.DeletedBefore)
+		})
+		if err != nil {
+			return err
+		}
+		items = paginate(items, arg.Limit, 0)
+
+		for _, url := range items {
+			if err := deleteURL(tx, url); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (q *Queries) DeleteURL(ctx context.Context, id int64) error {
+	return q.update(func(tx Tx) error {
+		url, err := getURL(tx, id)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		return deleteURL(tx, url)
+	})
+}
+
+func isExpired(url db.Url, t time.Time) bool {
+	return (url.NotAfter.Valid && !url.NotAfter.Time.After(t)) ||
+		(url.MaxClicks > 0 && url.ClickCount >= url.MaxClicks)
+}
@@ -0,0 +1,234 @@
+package kv
+
+import (
+	"context"
+	"sort"
+
+	db "shortURL/db/sqlc"
+)
+
+func (q *Queries) CreateWebhook(ctx context.Context, arg db.CreateWebhookParams) (db.Webhook, error) {
+	var webhook db.Webhook
+
+	err := q.update(func(tx Tx) error {
+		id, err := tx.NextSequence(bucketWebhooks)
+		if err != nil {
+			return err
+		}
+
+		events := arg.Events
+		if events == nil {
+			events = []string{}
+		}
+
+		webhook = db.Webhook{
+			ID:        id,
+			Url:       arg.Url,
+			Secret:    arg.Secret,
+			Events:    events,
+			CreatedAt: now(),
+		}
+		return putRecord(tx, bucketWebhooks, itob(id), webhook)
+	})
+
+	return webhook, err
+}
+
+func (q *Queries) GetWebhook(ctx context.Context, id int64) (db.Webhook, error) {
+	var webhook db.Webhook
+
+	err := q.view(func(tx Tx) error {
+		return getRecord(tx, bucketWebhooks, itob(id), &webhook)
+	})
+
+	return webhook, err
+}
+
+func (q *Queries) ListWebhooks(ctx context.Context, arg db.ListWebhooksParams) ([]db.Webhook, error) {
+	var items []db.Webhook
+
+	err := q.view(func(tx Tx) error {
+		var err error
+		items, err = listRecords[db.Webhook](tx, bucketWebhooks, nil, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return paginate(items, arg.Limit, arg.Offset), nil
+}
+
+func (q *Queries) ListWebhooksForEvent(ctx context.Context, event string) ([]db.Webhook, error) {
+	var items []db.Webhook
+
+	err := q.view(func(tx Tx) error {
+		var err error
+		items, err = listRecords(tx, bucketWebhooks, nil, func(webhook db.Webhook) bool {
+			for _, e := range webhook.Events {
+				if e == event {
+					return true
+				}
+			}
+			return false
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// DeleteWebhook deletes the webhook together with its deliveries.
+func (q *Queries) DeleteWebhook(ctx context.Context, id int64) error {
+	return q.update(func(tx Tx) error {
+		deliveries, err := listRecords(tx, bucketWebhookDeliveries, nil, func(delivery db.WebhookDelivery) bool {
+			return delivery.WebhookID == id
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, delivery := range deliveries {
+			if err := tx.Delete(bucketWebhookDeliveries, itob(delivery.ID)); err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(bucketWebhooks, itob(id))
+	})
+}
+
+func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg db.CreateWebhookDeliveryParams) (db.WebhookDelivery, error) {
+	var delivery db.WebhookDelivery
+
+	err := q.update(func(tx Tx) error {
+		found, err := exists(tx, bucketWebhooks, itob(arg.WebhookID))
+		if err != nil {
+			return err
+		}
+		if !found {
+			return foreignKeyViolation("webhook_deliveries_webhook_id_fkey")
+		}
+
+		id, err := tx.NextSequence(bucketWebhookDeliveries)
+		if err != nil {
+			return err
+		}
+
+		createdAt := now()
+		delivery = db.WebhookDelivery{
+			ID:            id,
+			WebhookID:     arg.WebhookID,
+			Event:         arg.Event,
+			Payload:       arg.Payload,
+			Status:        "pending",
+			NextAttemptAt: createdAt,
+			CreatedAt:     createdAt,
+		}
+		return putRecord(tx, bucketWebhookDeliveries, itob(id), delivery)
+	})
+
+	return delivery, err
+}
+
+func (q *Queries) GetWebhookDelivery(ctx context.Context, id int64) (db.WebhookDelivery, error) {
+	var delivery db.WebhookDelivery
+
+	err := q.view(func(tx Tx) error {
+		return getRecord(tx, bucketWebhookDeliveries, itob(id), &delivery)
+	})
+
+	return delivery, err
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg db.ListWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
+	var items []db.WebhookDelivery
+
+	err := q.view(func(tx Tx) error {
+		var err error
+		items, err = listRecords(tx, bucketWebhookDeliveries, nil, func(delivery db.WebhookDelivery) bool {
+			return delivery.WebhookID == arg.WebhookID
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reverse(items)
+	return paginate(items, arg.Limit, arg.Offset), nil
+}
+
+// ClaimWebhookDeliveries leases the due deliveries until arg.LeaseUntil.
+func (q *Queries) ClaimWebhookDeliveries(ctx context.Context, arg db.ClaimWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
+	var items []db.WebhookDelivery
+
+	err := q.update(func(tx Tx) error {
+		t := now()
+
+		var err error
+		items, err = listRecords(tx, bucketWebhookDeliveries, nil, func(delivery db.WebhookDelivery) bool {
+			return delivery.Status == "pending" && !delivery.NextAttemptAt.After(t)
+		})
+		if err != nil {
+			return err
+		}
+
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].NextAttemptAt.Before(items[j].NextAttemptAt)
+		})
+		items = paginate(items, arg.Limit, 0)
+
+		for i := range items {
+			items[i].NextAttemptAt = arg.LeaseUntil
+			if err := putRecord(tx, bucketWebhookDeliveries, itob(items[i].ID), items[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (q *Queries) RedeliverWebhookDelivery(ctx context.Context, id int64) (db.WebhookDelivery, error) {
+	return q.updateWebhookDelivery(id, func(delivery *db.WebhookDelivery) {
+		delivery.Status = "pending"
+		delivery.Attempts = 0
+		delivery.NextAttemptAt = now()
+		delivery.LastError = ""
+	})
+}
+
+func (q *Queries) UpdateWebhookDelivery(ctx context.Context, arg db.UpdateWebhookDeliveryParams) (db.WebhookDelivery, error) {
+	return q.updateWebhookDelivery(arg.ID, func(delivery *db.WebhookDelivery) {
+		delivery.Status = arg.Status
+		delivery.Attempts = arg.Attempts
+		delivery.NextAttemptAt = arg.NextAttemptAt
+		delivery.LastStatusCode = arg.LastStatusCode
+		delivery.LastError = arg.LastError
+		delivery.DeliveredAt = arg.DeliveredAt
+	})
+}
+
+func (q *Queries) updateWebhookDelivery(id int64, set func(*db.WebhookDelivery)) (db.WebhookDelivery, error) {
+	var delivery db.WebhookDelivery
+
+	err := q.update(func(tx Tx) error {
+		err := getRecord(tx, bucketWebhookDeliveries, itob(id), &delivery)
+		if err != nil {
+			return err
+		}
+
+		set(&delivery)
+		return putRecord(tx, bucketWebhookDeliveries, itob(id), delivery)
+	})
+
+	return delivery, err
+}
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS "urls_domain_id_short_url_idx";
+
+CREATE INDEX ON "urls" ("short_url");
+
+ALTER TABLE IF EXISTS "urls" DROP CONSTRAINT IF EXISTS "urls_domain_id_fkey";
+
+ALTER TABLE "urls" DROP COLUMN IF EXISTS "domain_id";
+
+DROP TABLE IF EXISTS domains;
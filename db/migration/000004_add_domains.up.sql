@@ -0,0 +1,18 @@
+CREATE TABLE "domains" (
+  "id" bigserial PRIMARY KEY,
+  "host" varchar UNIQUE NOT NULL,
+  "fallback_url" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- 預設網域，未指定網域的連結皆屬於此網域
+INSERT INTO "domains" ("id", "host") VALUES (0, '');
+
+ALTER TABLE "urls" ADD COLUMN "domain_id" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "urls" ADD FOREIGN KEY ("domain_id") REFERENCES "domains" ("id");
+
+DROP INDEX IF EXISTS "urls_short_url_idx";
+
+-- 同一網域內的短網址不可重複，domain_id 不可為 NULL，未指定網域的連結也受到限制
+CREATE UNIQUE INDEX ON "urls" ("domain_id", "short_url");
@@ -0,0 +1 @@
+ALTER TABLE "urls" ADD COLUMN "password_hash" varchar NOT NULL DEFAULT '';
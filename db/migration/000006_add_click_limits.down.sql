@@ -0,0 +1,7 @@
+ALTER TABLE "urls" DROP COLUMN IF EXISTS "click_count";
+
+ALTER TABLE "urls" DROP COLUMN IF EXISTS "max_clicks";
+
+ALTER TABLE "urls" DROP COLUMN IF EXISTS "not_before";
+
+ALTER TABLE "urls" RENAME COLUMN "not_after" TO "expired_at";
@@ -0,0 +1,7 @@
+ALTER TABLE "urls" RENAME COLUMN "expired_at" TO "not_after";
+
+ALTER TABLE "urls" ADD COLUMN "not_before" timestamptz;
+
+ALTER TABLE "urls" ADD COLUMN "max_clicks" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "urls" ADD COLUMN "click_count" bigint NOT NULL DEFAULT 0;
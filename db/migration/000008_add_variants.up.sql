@@ -0,0 +1 @@
+ALTER TABLE "urls" ADD COLUMN "variants" jsonb NOT NULL DEFAULT '[]';
@@ -0,0 +1,3 @@
+ALTER TABLE "urls" DROP COLUMN IF EXISTS "utm";
+
+ALTER TABLE "urls" DROP COLUMN IF EXISTS "query_mode";
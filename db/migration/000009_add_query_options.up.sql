@@ -0,0 +1,3 @@
+ALTER TABLE "urls" ADD COLUMN "query_mode" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "urls" ADD COLUMN "utm" jsonb NOT NULL DEFAULT '{}';
@@ -0,0 +1 @@
+ALTER TABLE "urls" ADD COLUMN "is_prefix" boolean NOT NULL DEFAULT false;
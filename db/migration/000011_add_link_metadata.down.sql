@@ -0,0 +1,25 @@
+DROP TRIGGER IF EXISTS url_tags_search_update ON "url_tags";
+
+DROP TRIGGER IF EXISTS urls_search_update ON "urls";
+
+DROP FUNCTION IF EXISTS url_tags_search_trigger();
+
+DROP FUNCTION IF EXISTS urls_search_trigger();
+
+DROP FUNCTION IF EXISTS refresh_url_search(bigint);
+
+DROP TABLE IF EXISTS url_search;
+
+DROP TABLE IF EXISTS url_tags;
+
+ALTER TABLE IF EXISTS "urls" DROP CONSTRAINT IF EXISTS "urls_folder_id_fkey";
+
+ALTER TABLE "urls" DROP COLUMN IF EXISTS "folder_id";
+
+ALTER TABLE "urls" DROP COLUMN IF EXISTS "owner";
+
+ALTER TABLE "urls" DROP COLUMN IF EXISTS "description";
+
+ALTER TABLE "urls" DROP COLUMN IF EXISTS "title";
+
+DROP TABLE IF EXISTS folders;
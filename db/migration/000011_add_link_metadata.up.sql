@@ -0,0 +1,80 @@
+CREATE TABLE "folders" (
+  "id" bigserial PRIMARY KEY,
+  "name" varchar UNIQUE NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "urls" ADD COLUMN "title" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "urls" ADD COLUMN "description" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "urls" ADD COLUMN "owner" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "urls" ADD COLUMN "folder_id" bigint;
+
+ALTER TABLE "urls" ADD FOREIGN KEY ("folder_id") REFERENCES "folders" ("id") ON DELETE SET NULL;
+
+CREATE INDEX ON "urls" ("owner");
+
+CREATE INDEX ON "urls" ("folder_id");
+
+CREATE INDEX ON "urls" ("created_at");
+
+CREATE TABLE "url_tags" (
+  "url_id" bigint NOT NULL REFERENCES "urls" ("id") ON DELETE CASCADE,
+  "tag" varchar NOT NULL,
+  PRIMARY KEY ("url_id", "tag")
+);
+
+CREATE INDEX ON "url_tags" ("tag");
+
+-- 全文搜尋的文件另外存放，由觸發器在連結或標籤變更時更新
+CREATE TABLE "url_search" (
+  "url_id" bigint PRIMARY KEY REFERENCES "urls" ("id") ON DELETE CASCADE,
+  "document" tsvector NOT NULL
+);
+
+CREATE INDEX ON "url_search" USING GIN ("document");
+
+CREATE FUNCTION refresh_url_search(target_id bigint) RETURNS void AS $$
+  INSERT INTO url_search (url_id, document)
+  SELECT
+    urls.id,
+    setweight(to_tsvector('simple', urls.title), 'A') ||
+    setweight(to_tsvector('simple', coalesce(string_agg(url_tags.tag, ' '), '')), 'A') ||
+    setweight(to_tsvector('simple', regexp_replace(urls.origin_url, '[[:punct:]]+', ' ', 'g')), 'B')
+  FROM urls
+  LEFT JOIN url_tags ON url_tags.url_id = urls.id
+  WHERE urls.id = target_id
+  GROUP BY urls.id
+  ON CONFLICT (url_id) DO UPDATE SET document = EXCLUDED.document;
+$$ LANGUAGE sql;
+
+CREATE FUNCTION urls_search_trigger() RETURNS trigger AS $$
+BEGIN
+  PERFORM refresh_url_search(NEW.id);
+  RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER urls_search_update
+AFTER INSERT OR UPDATE OF origin_url, title ON "urls"
+FOR EACH ROW EXECUTE FUNCTION urls_search_trigger();
+
+CREATE FUNCTION url_tags_search_trigger() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    PERFORM refresh_url_search(OLD.url_id);
+  ELSE
+    PERFORM refresh_url_search(NEW.url_id);
+  END IF;
+  RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER url_tags_search_update
+AFTER INSERT OR DELETE ON "url_tags"
+FOR EACH ROW EXECUTE FUNCTION url_tags_search_trigger();
+
+-- 建立既有連結的搜尋文件
+SELECT refresh_url_search(id) FROM "urls";
@@ -0,0 +1,9 @@
+ALTER TABLE "urls" DROP COLUMN IF EXISTS "metadata_fetched_at";
+
+ALTER TABLE "urls" DROP COLUMN IF EXISTS "og_image_url";
+
+ALTER TABLE "urls" DROP COLUMN IF EXISTS "favicon_url";
+
+ALTER TABLE "urls" DROP COLUMN IF EXISTS "page_description";
+
+ALTER TABLE "urls" DROP COLUMN IF EXISTS "page_title";
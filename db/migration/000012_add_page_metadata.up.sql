@@ -0,0 +1,9 @@
+ALTER TABLE "urls" ADD COLUMN "page_title" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "urls" ADD COLUMN "page_description" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "urls" ADD COLUMN "favicon_url" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "urls" ADD COLUMN "og_image_url" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "urls" ADD COLUMN "metadata_fetched_at" timestamptz;
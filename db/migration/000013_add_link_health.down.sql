@@ -0,0 +1,11 @@
+ALTER TABLE "urls" DROP COLUMN IF EXISTS "broken_at";
+
+ALTER TABLE "urls" DROP COLUMN IF EXISTS "last_checked_at";
+
+ALTER TABLE "urls" DROP COLUMN IF EXISTS "check_failures";
+
+ALTER TABLE "urls" DROP COLUMN IF EXISTS "check_error";
+
+ALTER TABLE "urls" DROP COLUMN IF EXISTS "redirect_chain";
+
+ALTER TABLE "urls" DROP COLUMN IF EXISTS "last_status_code";
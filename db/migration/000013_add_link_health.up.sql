@@ -0,0 +1,15 @@
+ALTER TABLE "urls" ADD COLUMN "last_status_code" integer NOT NULL DEFAULT 0;
+
+ALTER TABLE "urls" ADD COLUMN "redirect_chain" jsonb NOT NULL DEFAULT '[]';
+
+ALTER TABLE "urls" ADD COLUMN "check_error" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "urls" ADD COLUMN "check_failures" integer NOT NULL DEFAULT 0;
+
+ALTER TABLE "urls" ADD COLUMN "last_checked_at" timestamptz;
+
+ALTER TABLE "urls" ADD COLUMN "broken_at" timestamptz;
+
+CREATE INDEX ON "urls" ("last_checked_at");
+
+CREATE INDEX ON "urls" ("broken_at");
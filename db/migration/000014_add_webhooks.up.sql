@@ -0,0 +1,29 @@
+CREATE TABLE "webhooks" (
+  "id" bigserial PRIMARY KEY,
+  "url" varchar NOT NULL,
+  "secret" varchar NOT NULL,
+  "events" varchar[] NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "webhook_deliveries" (
+  "id" bigserial PRIMARY KEY,
+  "webhook_id" bigint NOT NULL,
+  "event" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" integer NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamptz NOT NULL DEFAULT (now()),
+  "last_status_code" integer NOT NULL DEFAULT 0,
+  "last_error" varchar NOT NULL DEFAULT '',
+  "delivered_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("webhook_id") REFERENCES "webhooks" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "webhook_deliveries" ("webhook_id");
+
+CREATE INDEX ON "webhook_deliveries" ("status", "next_attempt_at");
+
+ALTER TABLE "urls" ADD COLUMN "expiry_notified_at" timestamptz;
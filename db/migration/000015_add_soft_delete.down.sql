@@ -0,0 +1,3 @@
+ALTER TABLE "urls" DROP COLUMN IF EXISTS "deleted_at";
+
+ALTER TABLE "urls" DROP COLUMN IF EXISTS "archived_at";
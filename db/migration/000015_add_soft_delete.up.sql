@@ -0,0 +1,5 @@
+ALTER TABLE "urls" ADD COLUMN "archived_at" timestamptz;
+
+ALTER TABLE "urls" ADD COLUMN "deleted_at" timestamptz;
+
+CREATE INDEX ON "urls" ("deleted_at");
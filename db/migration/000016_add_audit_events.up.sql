@@ -0,0 +1,29 @@
+CREATE TABLE "audit_events" (
+  "id" bigserial PRIMARY KEY,
+  "url_id" bigint NOT NULL,
+  "domain_id" bigint NOT NULL,
+  "short_url" varchar NOT NULL,
+  "actor" varchar NOT NULL DEFAULT '',
+  "action" varchar NOT NULL,
+  "before" jsonb NOT NULL DEFAULT 'null',
+  "after" jsonb NOT NULL DEFAULT 'null',
+  "request_id" varchar NOT NULL DEFAULT '',
+  "client_ip" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "audit_events" ("domain_id", "short_url", "created_at");
+
+CREATE INDEX ON "audit_events" ("actor", "created_at");
+
+CREATE INDEX ON "audit_events" ("created_at");
+
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_events is append-only';
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only
+BEFORE UPDATE OR DELETE ON "audit_events"
+FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
@@ -0,0 +1,16 @@
+CREATE TABLE "url_revisions" (
+  "id" bigserial PRIMARY KEY,
+  "url_id" bigint NOT NULL,
+  "revision" int NOT NULL,
+  "origin_url" varchar NOT NULL,
+  "actor" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "url_revisions" ADD FOREIGN KEY ("url_id") REFERENCES "urls" ("id") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX ON "url_revisions" ("url_id", "revision");
+
+-- 既有連結以目前的長連結作為第一個版本
+INSERT INTO "url_revisions" ("url_id", "revision", "origin_url", "created_at")
+SELECT "id", 1, "origin_url", "created_at" FROM "urls";
@@ -0,0 +1,74 @@
+-- 沒有分區時 urls 維持原本的資料表，卸離的分區不會轉回 urls
+DO $$
+BEGIN
+  IF NOT EXISTS (SELECT 1 FROM pg_partitioned_table WHERE partrelid = 'urls'::regclass) THEN
+    RETURN;
+  END IF;
+
+  DROP TRIGGER IF EXISTS url_revisions_url_id_check ON "url_revisions";
+
+  DROP TRIGGER IF EXISTS url_search_url_id_check ON "url_search";
+
+  DROP TRIGGER IF EXISTS url_tags_url_id_check ON "url_tags";
+
+  ALTER TABLE "urls" RENAME TO "urls_partitioned";
+
+  ALTER SEQUENCE "urls_id_seq" OWNED BY NONE;
+
+  CREATE TABLE "urls" (LIKE "urls_partitioned" INCLUDING DEFAULTS);
+
+  INSERT INTO "urls" SELECT * FROM "urls_partitioned";
+
+  DROP TABLE "urls_partitioned";
+
+  ALTER SEQUENCE "urls_id_seq" OWNED BY "urls"."id";
+
+  ALTER TABLE "urls" ADD PRIMARY KEY ("id");
+
+  ALTER TABLE "urls" ADD FOREIGN KEY ("domain_id") REFERENCES "domains" ("id");
+
+  ALTER TABLE "urls" ADD FOREIGN KEY ("folder_id") REFERENCES "folders" ("id") ON DELETE SET NULL;
+
+  CREATE UNIQUE INDEX ON "urls" ("domain_id", "short_url");
+
+  CREATE INDEX ON "urls" ("owner");
+
+  CREATE INDEX ON "urls" ("folder_id");
+
+  CREATE INDEX ON "urls" ("created_at");
+
+  CREATE INDEX ON "urls" ("last_checked_at");
+
+  CREATE INDEX ON "urls" ("broken_at");
+
+  CREATE INDEX ON "urls" ("deleted_at");
+
+  CREATE TRIGGER urls_search_update
+  AFTER INSERT OR UPDATE OF origin_url, title ON "urls"
+  FOR EACH ROW EXECUTE FUNCTION urls_search_trigger();
+
+  DELETE FROM "url_tags" WHERE "url_id" NOT IN (SELECT "id" FROM "urls");
+
+  DELETE FROM "url_search" WHERE "url_id" NOT IN (SELECT "id" FROM "urls");
+
+  DELETE FROM "url_revisions" WHERE "url_id" NOT IN (SELECT "id" FROM "urls");
+
+  ALTER TABLE "url_tags" ADD FOREIGN KEY ("url_id") REFERENCES "urls" ("id") ON DELETE CASCADE;
+
+  ALTER TABLE "url_search" ADD FOREIGN KEY ("url_id") REFERENCES "urls" ("id") ON DELETE CASCADE;
+
+  ALTER TABLE "url_revisions" ADD FOREIGN KEY ("url_id") REFERENCES "urls" ("id") ON DELETE CASCADE;
+END
+$$;
+
+DROP FUNCTION IF EXISTS check_url_exists();
+
+DROP FUNCTION IF EXISTS urls_delete_cascade();
+
+DROP FUNCTION IF EXISTS urls_reserve_slug();
+
+DROP FUNCTION IF EXISTS detach_url_partitions(timestamptz);
+
+DROP FUNCTION IF EXISTS create_url_partitions(timestamptz, int);
+
+DROP TABLE IF EXISTS "url_slugs";
@@ -0,0 +1,228 @@
+-- 建立 since 所在月份到現在起 months_ahead 個月的每月分區，回傳新建立的分區
+CREATE FUNCTION create_url_partitions(since timestamptz, months_ahead int) RETURNS SETOF text AS $$
+DECLARE
+  bound timestamp := date_trunc('month', since AT TIME ZONE 'UTC');
+  last_bound timestamp := date_trunc('month', now() AT TIME ZONE 'UTC') + make_interval(months => months_ahead);
+  partition_name text;
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1 FROM pg_partitioned_table
+    WHERE partrelid = 'urls'::regclass AND partstrat = 'r'
+  ) THEN
+    RAISE EXCEPTION 'urls is not partitioned by created_at range';
+  END IF;
+
+  WHILE bound <= last_bound LOOP
+    partition_name := 'urls_' || to_char(bound, 'YYYY_MM');
+    -- 已存在或已卸離的分區不重新建立
+    IF to_regclass(partition_name) IS NULL THEN
+      EXECUTE format(
+        'CREATE TABLE %I PARTITION OF urls FOR VALUES FROM (%L) TO (%L)',
+        partition_name, bound AT TIME ZONE 'UTC', (bound + interval '1 month') AT TIME ZONE 'UTC'
+      );
+      RETURN NEXT partition_name;
+    END IF;
+    bound := bound + interval '1 month';
+  END LOOP;
+END
+$$ LANGUAGE plpgsql;
+
+-- 卸離範圍結束時間不晚於 older_than 的分區，回傳卸離的分區
+-- 卸離的分區保留為一般資料表，其中的連結不再能轉址，可另外封存或刪除
+CREATE FUNCTION detach_url_partitions(older_than timestamptz) RETURNS SETOF text AS $$
+DECLARE
+  part record;
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1 FROM pg_partitioned_table
+    WHERE partrelid = 'urls'::regclass AND partstrat = 'r'
+  ) THEN
+    RAISE EXCEPTION 'urls is not partitioned by created_at range';
+  END IF;
+
+  FOR part IN
+    SELECT
+      child.relname::text AS name,
+      substring(pg_get_expr(child.relpartbound, child.oid) FROM 'TO \(''([^'']+)''\)')::timestamptz AS upper_bound
+    FROM pg_inherits
+    JOIN pg_class child ON child.oid = pg_inherits.inhrelid
+    WHERE pg_inherits.inhparent = 'urls'::regclass
+    ORDER BY child.relname
+  LOOP
+    IF part.upper_bound <= older_than THEN
+      EXECUTE format('ALTER TABLE urls DETACH PARTITION %I', part.name);
+      RETURN NEXT part.name;
+    END IF;
+  END LOOP;
+END
+$$ LANGUAGE plpgsql;
+
+-- 新增連結前保留短網址，短網址已被使用時略過這筆資料，與 ON CONFLICT DO NOTHING 相同
+-- 更新短網址時改保留新的短網址，已被使用時回傳 unique_violation
+CREATE FUNCTION urls_reserve_slug() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'INSERT' THEN
+    INSERT INTO url_slugs (domain_id, short_url, url_id)
+    VALUES (NEW.domain_id, NEW.short_url, NEW.id)
+    ON CONFLICT (domain_id, short_url) DO NOTHING;
+
+    -- 更新短網址使資料搬到其他分區時會再觸發 INSERT，此時短網址已在 UPDATE 時保留
+    IF NOT FOUND AND NOT EXISTS (
+      SELECT 1 FROM url_slugs
+      WHERE domain_id = NEW.domain_id AND short_url = NEW.short_url AND url_id = NEW.id
+    ) THEN
+      RETURN NULL;
+    END IF;
+  ELSIF NEW.domain_id <> OLD.domain_id OR NEW.short_url <> OLD.short_url THEN
+    INSERT INTO url_slugs (domain_id, short_url, url_id)
+    VALUES (NEW.domain_id, NEW.short_url, NEW.id);
+
+    DELETE FROM url_slugs
+    WHERE domain_id = OLD.domain_id AND short_url = OLD.short_url AND url_id = OLD.id;
+  END IF;
+
+  RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+-- 刪除連結時一併刪除短網址、標籤、搜尋文件與版本紀錄
+-- 更新短網址使資料搬到其他分區時也會觸發 DELETE，此時連結仍存在
+CREATE FUNCTION urls_delete_cascade() RETURNS trigger AS $$
+BEGIN
+  IF EXISTS (SELECT 1 FROM urls WHERE id = OLD.id) THEN
+    RETURN NULL;
+  END IF;
+
+  DELETE FROM url_slugs WHERE url_id = OLD.id;
+  DELETE FROM url_tags WHERE url_id = OLD.id;
+  DELETE FROM url_search WHERE url_id = OLD.id;
+  DELETE FROM url_revisions WHERE url_id = OLD.id;
+  RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+-- 取代參照 urls 的外鍵，連結不存在時回傳 foreign_key_violation
+CREATE FUNCTION check_url_exists() RETURNS trigger AS $$
+BEGIN
+  PERFORM 1 FROM urls WHERE id = NEW.url_id FOR KEY SHARE;
+  IF NOT FOUND THEN
+    RAISE EXCEPTION 'insert or update on table "%" violates foreign key constraint "%_url_id_fkey"', TG_TABLE_NAME, TG_TABLE_NAME
+      USING
+        ERRCODE = 'foreign_key_violation',
+        DETAIL = format('Key (url_id)=(%s) is not present in table "urls".', NEW.url_id),
+        TABLE = TG_TABLE_NAME,
+        CONSTRAINT = TG_TABLE_NAME || '_url_id_fkey';
+  END IF;
+
+  RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+-- 將 urls 轉為分區表，分區方式由 shorturl.urls_partitioning 設定，未設定時不分區：
+-- hash 依 short_url 分成 shorturl.urls_hash_partitions 個分區（預設 16），
+-- range 依 created_at 每月一個分區，之後的分區需定期建立
+DO $$
+DECLARE
+  strategy text := coalesce(nullif(current_setting('shorturl.urls_partitioning', true), ''), 'none');
+  partitions int := coalesce(nullif(current_setting('shorturl.urls_hash_partitions', true), ''), '16')::int;
+  since timestamptz;
+BEGIN
+  IF strategy = 'none' THEN
+    RETURN;
+  ELSIF strategy NOT IN ('hash', 'range') THEN
+    RAISE EXCEPTION 'unknown urls partitioning "%", use none, hash or range', strategy;
+  END IF;
+
+  -- 分區表的唯一索引必須包含分區鍵，依 created_at 分區時無法保證短網址不重複，
+  -- 因此改由 url_slugs 保留短網址
+  CREATE TABLE "url_slugs" (
+    "domain_id" bigint NOT NULL,
+    "short_url" varchar NOT NULL,
+    "url_id" bigint NOT NULL,
+    PRIMARY KEY ("domain_id", "short_url")
+  );
+
+  CREATE INDEX ON "url_slugs" ("url_id");
+
+  INSERT INTO "url_slugs" ("domain_id", "short_url", "url_id")
+  SELECT "domain_id", "short_url", "id" FROM "urls";
+
+  -- 分區表的 id 沒有單獨的唯一索引，無法被外鍵參照，改由觸發器檢查與串聯刪除
+  ALTER TABLE "url_tags" DROP CONSTRAINT IF EXISTS "url_tags_url_id_fkey";
+
+  ALTER TABLE "url_search" DROP CONSTRAINT IF EXISTS "url_search_url_id_fkey";
+
+  ALTER TABLE "url_revisions" DROP CONSTRAINT IF EXISTS "url_revisions_url_id_fkey";
+
+  ALTER TABLE urls RENAME TO urls_unpartitioned;
+  ALTER SEQUENCE urls_id_seq OWNED BY NONE;
+
+  IF strategy = 'hash' THEN
+    CREATE TABLE urls (LIKE urls_unpartitioned INCLUDING DEFAULTS) PARTITION BY HASH (short_url);
+    FOR i IN 0..partitions - 1 LOOP
+      EXECUTE format(
+        'CREATE TABLE %I PARTITION OF urls FOR VALUES WITH (MODULUS %s, REMAINDER %s)',
+        'urls_p' || i, partitions, i
+      );
+    END LOOP;
+  ELSE
+    CREATE TABLE urls (LIKE urls_unpartitioned INCLUDING DEFAULTS) PARTITION BY RANGE (created_at);
+    SELECT coalesce(min(created_at), now()) INTO since FROM urls_unpartitioned;
+    PERFORM create_url_partitions(since, 3);
+  END IF;
+
+  INSERT INTO urls SELECT * FROM urls_unpartitioned;
+
+  DROP TABLE urls_unpartitioned;
+  ALTER SEQUENCE urls_id_seq OWNED BY urls.id;
+
+  -- 主鍵必須包含分區鍵
+  IF strategy = 'hash' THEN
+    ALTER TABLE urls ADD PRIMARY KEY (id, short_url);
+  ELSE
+    ALTER TABLE urls ADD PRIMARY KEY (id, created_at);
+  END IF;
+
+  ALTER TABLE "urls" ADD CONSTRAINT "urls_domain_id_fkey" FOREIGN KEY ("domain_id") REFERENCES "domains" ("id");
+
+  ALTER TABLE "urls" ADD CONSTRAINT "urls_folder_id_fkey" FOREIGN KEY ("folder_id") REFERENCES "folders" ("id") ON DELETE SET NULL;
+
+  CREATE INDEX ON "urls" ("domain_id", "short_url");
+
+  CREATE INDEX ON "urls" ("owner");
+
+  CREATE INDEX ON "urls" ("folder_id");
+
+  CREATE INDEX ON "urls" ("created_at");
+
+  CREATE INDEX ON "urls" ("last_checked_at");
+
+  CREATE INDEX ON "urls" ("broken_at");
+
+  CREATE INDEX ON "urls" ("deleted_at");
+
+  CREATE TRIGGER urls_search_update
+  AFTER INSERT OR UPDATE OF origin_url, title ON "urls"
+  FOR EACH ROW EXECUTE FUNCTION urls_search_trigger();
+
+  CREATE TRIGGER urls_reserve_slug
+  BEFORE INSERT OR UPDATE OF domain_id, short_url ON "urls"
+  FOR EACH ROW EXECUTE FUNCTION urls_reserve_slug();
+
+  CREATE TRIGGER urls_delete_cascade
+  AFTER DELETE ON "urls"
+  FOR EACH ROW EXECUTE FUNCTION urls_delete_cascade();
+
+  CREATE TRIGGER url_tags_url_id_check
+  BEFORE INSERT OR UPDATE OF url_id ON "url_tags"
+  FOR EACH ROW EXECUTE FUNCTION check_url_exists();
+
+  CREATE TRIGGER url_search_url_id_check
+  BEFORE INSERT OR UPDATE OF url_id ON "url_search"
+  FOR EACH ROW EXECUTE FUNCTION check_url_exists();
+
+  CREATE TRIGGER url_revisions_url_id_check
+  BEFORE INSERT OR UPDATE OF url_id ON "url_revisions"
+  FOR EACH ROW EXECUTE FUNCTION check_url_exists();
+END
+$$;
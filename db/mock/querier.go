@@ -35,6 +35,108 @@ func (m *MockQuerier) EXPECT() *MockQuerierMockRecorder {
 	return m.recorder
 }
 
+// AddURLTag mocks base method.
+func (m *MockQuerier) AddURLTag(ctx context.Context, arg db.AddURLTagParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddURLTag", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddURLTag indicates an expected call of AddURLTag.
+func (mr *MockQuerierMockRecorder) AddURLTag(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddURLTag", reflect.TypeOf((*MockQuerier)(nil).AddURLTag), ctx, arg)
+}
+
+// ClaimWebhookDeliveries mocks base method.
+func (m *MockQuerier) ClaimWebhookDeliveries(ctx context.Context, arg db.ClaimWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimWebhookDeliveries", ctx, arg)
+	ret0, _ := ret[0].([]db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimWebhookDeliveries indicates an expected call of ClaimWebhookDeliveries.
+func (mr *MockQuerierMockRecorder) ClaimWebhookDeliveries(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimWebhookDeliveries", reflect.TypeOf((*MockQuerier)(nil).ClaimWebhookDeliveries), ctx, arg)
+}
+
+// CopyDomain mocks base method.
+func (m *MockQuerier) CopyDomain(ctx context.Context, arg db.CopyDomainParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CopyDomain", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CopyDomain indicates an expected call of CopyDomain.
+func (mr *MockQuerierMockRecorder) CopyDomain(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyDomain", reflect.TypeOf((*MockQuerier)(nil).CopyDomain), ctx, arg)
+}
+
+// CopyFolder mocks base method.
+func (m *MockQuerier) CopyFolder(ctx context.Context, arg db.CopyFolderParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CopyFolder", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CopyFolder indicates an expected call of CopyFolder.
+func (mr *MockQuerierMockRecorder) CopyFolder(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyFolder", reflect.TypeOf((*MockQuerier)(nil).CopyFolder), ctx, arg)
+}
+
+// CreateAuditEvent mocks base method.
+func (m *MockQuerier) CreateAuditEvent(ctx context.Context, arg db.CreateAuditEventParams) (db.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditEvent", ctx, arg)
+	ret0, _ := ret[0].(db.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAuditEvent indicates an expected call of CreateAuditEvent.
+func (mr *MockQuerierMockRecorder) CreateAuditEvent(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditEvent", reflect.TypeOf((*MockQuerier)(nil).CreateAuditEvent), ctx, arg)
+}
+
+// CreateDomain mocks base method.
+func (m *MockQuerier) CreateDomain(ctx context.Context, arg db.CreateDomainParams) (db.Domain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDomain", ctx, arg)
+	ret0, _ := ret[0].(db.Domain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDomain indicates an expected call of CreateDomain.
+func (mr *MockQuerierMockRecorder) CreateDomain(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDomain", reflect.TypeOf((*MockQuerier)(nil).CreateDomain), ctx, arg)
+}
+
+// CreateFolder mocks base method.
+func (m *MockQuerier) CreateFolder(ctx context.Context, name string) (db.Folder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFolder", ctx, name)
+	ret0, _ := ret[0].(db.Folder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFolder indicates an expected call of CreateFolder.
+func (mr *MockQuerierMockRecorder) CreateFolder(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFolder", reflect.TypeOf((*MockQuerier)(nil).CreateFolder), ctx, name)
+}
+
 // CreateURL mocks base method.
 func (m *MockQuerier) CreateURL(ctx context.Context, arg db.CreateURLParams) (db.Url, error) {
 	m.ctrl.T.Helper()
@@ -50,6 +152,51 @@ func (mr *MockQuerierMockRecorder) CreateURL(ctx, arg interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateURL", reflect.TypeOf((*MockQuerier)(nil).CreateURL), ctx, arg)
 }
 
+// CreateURLRevision mocks base method.
+func (m *MockQuerier) CreateURLRevision(ctx context.Context, arg db.CreateURLRevisionParams) (db.UrlRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateURLRevision", ctx, arg)
+	ret0, _ := ret[0].(db.UrlRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateURLRevision indicates an expected call of CreateURLRevision.
+func (mr *MockQuerierMockRecorder) CreateURLRevision(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateURLRevision", reflect.TypeOf((*MockQuerier)(nil).CreateURLRevision), ctx, arg)
+}
+
+// CreateWebhook mocks base method.
+func (m *MockQuerier) CreateWebhook(ctx context.Context, arg db.CreateWebhookParams) (db.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", ctx, arg)
+	ret0, _ := ret[0].(db.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockQuerierMockRecorder) CreateWebhook(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockQuerier)(nil).CreateWebhook), ctx, arg)
+}
+
+// CreateWebhookDelivery mocks base method.
+func (m *MockQuerier) CreateWebhookDelivery(ctx context.Context, arg db.CreateWebhookDeliveryParams) (db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookDelivery", ctx, arg)
+	ret0, _ := ret[0].(db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhookDelivery indicates an expected call of CreateWebhookDelivery.
+func (mr *MockQuerierMockRecorder) CreateWebhookDelivery(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookDelivery", reflect.TypeOf((*MockQuerier)(nil).CreateWebhookDelivery), ctx, arg)
+}
+
 // DeleteURL mocks base method.
 func (m *MockQuerier) DeleteURL(ctx context.Context, id int64) error {
 	m.ctrl.T.Helper()
@@ -64,19 +211,452 @@ func (mr *MockQuerierMockRecorder) DeleteURL(ctx, id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteURL", reflect.TypeOf((*MockQuerier)(nil).DeleteURL), ctx, id)
 }
 
+// DeleteURLTags mocks base method.
+func (m *MockQuerier) DeleteURLTags(ctx context.Context, urlID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteURLTags", ctx, urlID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteURLTags indicates an expected call of DeleteURLTags.
+func (mr *MockQuerierMockRecorder) DeleteURLTags(ctx, urlID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteURLTags", reflect.TypeOf((*MockQuerier)(nil).DeleteURLTags), ctx, urlID)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockQuerier) DeleteWebhook(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockQuerierMockRecorder) DeleteWebhook(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockQuerier)(nil).DeleteWebhook), ctx, id)
+}
+
+// GetDeletedURL mocks base method.
+func (m *MockQuerier) GetDeletedURL(ctx context.Context, arg db.GetDeletedURLParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedURL", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedURL indicates an expected call of GetDeletedURL.
+func (mr *MockQuerierMockRecorder) GetDeletedURL(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedURL", reflect.TypeOf((*MockQuerier)(nil).GetDeletedURL), ctx, arg)
+}
+
+// GetDomainByHost mocks base method.
+func (m *MockQuerier) GetDomainByHost(ctx context.Context, host string) (db.Domain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDomainByHost", ctx, host)
+	ret0, _ := ret[0].(db.Domain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDomainByHost indicates an expected call of GetDomainByHost.
+func (mr *MockQuerierMockRecorder) GetDomainByHost(ctx, host interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDomainByHost", reflect.TypeOf((*MockQuerier)(nil).GetDomainByHost), ctx, host)
+}
+
+// GetFolder mocks base method.
+func (m *MockQuerier) GetFolder(ctx context.Context, id int64) (db.Folder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFolder", ctx, id)
+	ret0, _ := ret[0].(db.Folder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFolder indicates an expected call of GetFolder.
+func (mr *MockQuerierMockRecorder) GetFolder(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFolder", reflect.TypeOf((*MockQuerier)(nil).GetFolder), ctx, id)
+}
+
 // GetURL mocks base method.
-func (m *MockQuerier) GetURL(ctx context.Context, shortUrl string) (db.Url, error) {
+func (m *MockQuerier) GetURL(ctx context.Context, arg db.GetURLParams) (db.Url, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetURL", ctx, shortUrl)
+	ret := m.ctrl.Call(m, "GetURL", ctx, arg)
 	ret0, _ := ret[0].(db.Url)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetURL indicates an expected call of GetURL.
-func (mr *MockQuerierMockRecorder) GetURL(ctx, shortUrl interface{}) *gomock.Call {
+func (mr *MockQuerierMockRecorder) GetURL(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURL", reflect.TypeOf((*MockQuerier)(nil).GetURL), ctx, arg)
+}
+
+// GetURLForUpdate mocks base method.
+func (m *MockQuerier) GetURLForUpdate(ctx context.Context, id int64) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetURLForUpdate", ctx, id)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetURLForUpdate indicates an expected call of GetURLForUpdate.
+func (mr *MockQuerierMockRecorder) GetURLForUpdate(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURLForUpdate", reflect.TypeOf((*MockQuerier)(nil).GetURLForUpdate), ctx, id)
+}
+
+// GetURLRevision mocks base method.
+func (m *MockQuerier) GetURLRevision(ctx context.Context, arg db.GetURLRevisionParams) (db.UrlRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetURLRevision", ctx, arg)
+	ret0, _ := ret[0].(db.UrlRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetURLRevision indicates an expected call of GetURLRevision.
+func (mr *MockQuerierMockRecorder) GetURLRevision(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURLRevision", reflect.TypeOf((*MockQuerier)(nil).GetURLRevision), ctx, arg)
+}
+
+// GetWebhook mocks base method.
+func (m *MockQuerier) GetWebhook(ctx context.Context, id int64) (db.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhook", ctx, id)
+	ret0, _ := ret[0].(db.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhook indicates an expected call of GetWebhook.
+func (mr *MockQuerierMockRecorder) GetWebhook(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhook", reflect.TypeOf((*MockQuerier)(nil).GetWebhook), ctx, id)
+}
+
+// GetWebhookDelivery mocks base method.
+func (m *MockQuerier) GetWebhookDelivery(ctx context.Context, id int64) (db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDelivery", ctx, id)
+	ret0, _ := ret[0].(db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDelivery indicates an expected call of GetWebhookDelivery.
+func (mr *MockQuerierMockRecorder) GetWebhookDelivery(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDelivery", reflect.TypeOf((*MockQuerier)(nil).GetWebhookDelivery), ctx, id)
+}
+
+// ImportURL mocks base method.
+func (m *MockQuerier) ImportURL(ctx context.Context, arg db.ImportURLParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportURL", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportURL indicates an expected call of ImportURL.
+func (mr *MockQuerierMockRecorder) ImportURL(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportURL", reflect.TypeOf((*MockQuerier)(nil).ImportURL), ctx, arg)
+}
+
+// ImportURLRevision mocks base method.
+func (m *MockQuerier) ImportURLRevision(ctx context.Context, arg db.ImportURLRevisionParams) (db.UrlRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportURLRevision", ctx, arg)
+	ret0, _ := ret[0].(db.UrlRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportURLRevision indicates an expected call of ImportURLRevision.
+func (mr *MockQuerierMockRecorder) ImportURLRevision(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportURLRevision", reflect.TypeOf((*MockQuerier)(nil).ImportURLRevision), ctx, arg)
+}
+
+// ListAuditEvents mocks base method.
+func (m *MockQuerier) ListAuditEvents(ctx context.Context, arg db.ListAuditEventsParams) ([]db.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditEvents", ctx, arg)
+	ret0, _ := ret[0].([]db.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockQuerierMockRecorder) ListAuditEvents(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockQuerier)(nil).ListAuditEvents), ctx, arg)
+}
+
+// ListDomains mocks base method.
+func (m *MockQuerier) ListDomains(ctx context.Context, arg db.ListDomainsParams) ([]db.Domain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDomains", ctx, arg)
+	ret0, _ := ret[0].([]db.Domain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDomains indicates an expected call of ListDomains.
+func (mr *MockQuerierMockRecorder) ListDomains(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDomains", reflect.TypeOf((*MockQuerier)(nil).ListDomains), ctx, arg)
+}
+
+// ListFolders mocks base method.
+func (m *MockQuerier) ListFolders(ctx context.Context, arg db.ListFoldersParams) ([]db.Folder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFolders", ctx, arg)
+	ret0, _ := ret[0].([]db.Folder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFolders indicates an expected call of ListFolders.
+func (mr *MockQuerierMockRecorder) ListFolders(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFolders", reflect.TypeOf((*MockQuerier)(nil).ListFolders), ctx, arg)
+}
+
+// ListTagsByURLs mocks base method.
+func (m *MockQuerier) ListTagsByURLs(ctx context.Context, urlIds []int64) ([]db.UrlTag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTagsByURLs", ctx, urlIds)
+	ret0, _ := ret[0].([]db.UrlTag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTagsByURLs indicates an expected call of ListTagsByURLs.
+func (mr *MockQuerierMockRecorder) ListTagsByURLs(ctx, urlIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTagsByURLs", reflect.TypeOf((*MockQuerier)(nil).ListTagsByURLs), ctx, urlIds)
+}
+
+// ListURLRevisions mocks base method.
+func (m *MockQuerier) ListURLRevisions(ctx context.Context, arg db.ListURLRevisionsParams) ([]db.UrlRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListURLRevisions", ctx, arg)
+	ret0, _ := ret[0].([]db.UrlRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListURLRevisions indicates an expected call of ListURLRevisions.
+func (mr *MockQuerierMockRecorder) ListURLRevisions(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListURLRevisions", reflect.TypeOf((*MockQuerier)(nil).ListURLRevisions), ctx, arg)
+}
+
+// ListURLTags mocks base method.
+func (m *MockQuerier) ListURLTags(ctx context.Context, urlID int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListURLTags", ctx, urlID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListURLTags indicates an expected call of ListURLTags.
+func (mr *MockQuerierMockRecorder) ListURLTags(ctx, urlID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListURLTags", reflect.TypeOf((*MockQuerier)(nil).ListURLTags), ctx, urlID)
+}
+
+// ListURLs mocks base method.
+func (m *MockQuerier) ListURLs(ctx context.Context, arg db.ListURLsParams) ([]db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListURLs", ctx, arg)
+	ret0, _ := ret[0].([]db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListURLs indicates an expected call of ListURLs.
+func (mr *MockQuerierMockRecorder) ListURLs(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListURLs", reflect.TypeOf((*MockQuerier)(nil).ListURLs), ctx, arg)
+}
+
+// ListURLsToCheck mocks base method.
+func (m *MockQuerier) ListURLsToCheck(ctx context.Context, arg db.ListURLsToCheckParams) ([]db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListURLsToCheck", ctx, arg)
+	ret0, _ := ret[0].([]db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListURLsToCheck indicates an expected call of ListURLsToCheck.
+func (mr *MockQuerierMockRecorder) ListURLsToCheck(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListURLsToCheck", reflect.TypeOf((*MockQuerier)(nil).ListURLsToCheck), ctx, arg)
+}
+
+// ListWebhookDeliveries mocks base method.
+func (m *MockQuerier) ListWebhookDeliveries(ctx context.Context, arg db.ListWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", ctx, arg)
+	ret0, _ := ret[0].([]db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockQuerierMockRecorder) ListWebhookDeliveries(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockQuerier)(nil).ListWebhookDeliveries), ctx, arg)
+}
+
+// ListWebhooks mocks base method.
+func (m *MockQuerier) ListWebhooks(ctx context.Context, arg db.ListWebhooksParams) ([]db.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhooks", ctx, arg)
+	ret0, _ := ret[0].([]db.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhooks indicates an expected call of ListWebhooks.
+func (mr *MockQuerierMockRecorder) ListWebhooks(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhooks", reflect.TypeOf((*MockQuerier)(nil).ListWebhooks), ctx, arg)
+}
+
+// ListWebhooksForEvent mocks base method.
+func (m *MockQuerier) ListWebhooksForEvent(ctx context.Context, event string) ([]db.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhooksForEvent", ctx, event)
+	ret0, _ := ret[0].([]db.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhooksForEvent indicates an expected call of ListWebhooksForEvent.
+func (mr *MockQuerierMockRecorder) ListWebhooksForEvent(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhooksForEvent", reflect.TypeOf((*MockQuerier)(nil).ListWebhooksForEvent), ctx, event)
+}
+
+// MarkExpiredURLs mocks base method.
+func (m *MockQuerier) MarkExpiredURLs(ctx context.Context, limit int32) ([]db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkExpiredURLs", ctx, limit)
+	ret0, _ := ret[0].([]db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkExpiredURLs indicates an expected call of MarkExpiredURLs.
+func (mr *MockQuerierMockRecorder) MarkExpiredURLs(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkExpiredURLs", reflect.TypeOf((*MockQuerier)(nil).MarkExpiredURLs), ctx, limit)
+}
+
+// PurgeDeletedURLs mocks base method.
+func (m *MockQuerier) PurgeDeletedURLs(ctx context.Context, arg db.PurgeDeletedURLsParams) ([]db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeletedURLs", ctx, arg)
+	ret0, _ := ret[0].([]db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeletedURLs indicates an expected call of PurgeDeletedURLs.
+func (mr *MockQuerierMockRecorder) PurgeDeletedURLs(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeletedURLs", reflect.TypeOf((*MockQuerier)(nil).PurgeDeletedURLs), ctx, arg)
+}
+
+// RedeliverWebhookDelivery mocks base method.
+func (m *MockQuerier) RedeliverWebhookDelivery(ctx context.Context, id int64) (db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedeliverWebhookDelivery", ctx, id)
+	ret0, _ := ret[0].(db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RedeliverWebhookDelivery indicates an expected call of RedeliverWebhookDelivery.
+func (mr *MockQuerierMockRecorder) RedeliverWebhookDelivery(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeliverWebhookDelivery", reflect.TypeOf((*MockQuerier)(nil).RedeliverWebhookDelivery), ctx, id)
+}
+
+// RestoreURL mocks base method.
+func (m *MockQuerier) RestoreURL(ctx context.Context, arg db.RestoreURLParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreURL", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreURL indicates an expected call of RestoreURL.
+func (mr *MockQuerierMockRecorder) RestoreURL(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreURL", reflect.TypeOf((*MockQuerier)(nil).RestoreURL), ctx, arg)
+}
+
+// SearchURLs mocks base method.
+func (m *MockQuerier) SearchURLs(ctx context.Context, arg db.SearchURLsParams) ([]db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchURLs", ctx, arg)
+	ret0, _ := ret[0].([]db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchURLs indicates an expected call of SearchURLs.
+func (mr *MockQuerierMockRecorder) SearchURLs(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchURLs", reflect.TypeOf((*MockQuerier)(nil).SearchURLs), ctx, arg)
+}
+
+// SetURLArchived mocks base method.
+func (m *MockQuerier) SetURLArchived(ctx context.Context, arg db.SetURLArchivedParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetURLArchived", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetURLArchived indicates an expected call of SetURLArchived.
+func (mr *MockQuerierMockRecorder) SetURLArchived(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetURLArchived", reflect.TypeOf((*MockQuerier)(nil).SetURLArchived), ctx, arg)
+}
+
+// SoftDeleteURL mocks base method.
+func (m *MockQuerier) SoftDeleteURL(ctx context.Context, id int64) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SoftDeleteURL", ctx, id)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SoftDeleteURL indicates an expected call of SoftDeleteURL.
+func (mr *MockQuerierMockRecorder) SoftDeleteURL(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURL", reflect.TypeOf((*MockQuerier)(nil).GetURL), ctx, shortUrl)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftDeleteURL", reflect.TypeOf((*MockQuerier)(nil).SoftDeleteURL), ctx, id)
 }
 
 // UpdateURL mocks base method.
@@ -93,3 +673,76 @@ func (mr *MockQuerierMockRecorder) UpdateURL(ctx, arg interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateURL", reflect.TypeOf((*MockQuerier)(nil).UpdateURL), ctx, arg)
 }
+
+// UpdateURLCheck mocks base method.
+func (m *MockQuerier) UpdateURLCheck(ctx context.Context, arg db.UpdateURLCheckParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateURLCheck", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateURLCheck indicates an expected call of UpdateURLCheck.
+func (mr *MockQuerierMockRecorder) UpdateURLCheck(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateURLCheck", reflect.TypeOf((*MockQuerier)(nil).UpdateURLCheck), ctx, arg)
+}
+
+// UpdateURLClickCount mocks base method.
+func (m *MockQuerier) UpdateURLClickCount(ctx context.Context, arg db.UpdateURLClickCountParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateURLClickCount", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateURLClickCount indicates an expected call of UpdateURLClickCount.
+func (mr *MockQuerierMockRecorder) UpdateURLClickCount(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateURLClickCount", reflect.TypeOf((*MockQuerier)(nil).UpdateURLClickCount), ctx, arg)
+}
+
+// UpdateURLMetadata mocks base method.
+func (m *MockQuerier) UpdateURLMetadata(ctx context.Context, arg db.UpdateURLMetadataParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateURLMetadata", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateURLMetadata indicates an expected call of UpdateURLMetadata.
+func (mr *MockQuerierMockRecorder) UpdateURLMetadata(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateURLMetadata", reflect.TypeOf((*MockQuerier)(nil).UpdateURLMetadata), ctx, arg)
+}
+
+// UpdateURLSettings mocks base method.
+func (m *MockQuerier) UpdateURLSettings(ctx context.Context, arg db.UpdateURLSettingsParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateURLSettings", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateURLSettings indicates an expected call of UpdateURLSettings.
+func (mr *MockQuerierMockRecorder) UpdateURLSettings(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateURLSettings", reflect.TypeOf((*MockQuerier)(nil).UpdateURLSettings), ctx, arg)
+}
+
+// UpdateWebhookDelivery mocks base method.
+func (m *MockQuerier) UpdateWebhookDelivery(ctx context.Context, arg db.UpdateWebhookDeliveryParams) (db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookDelivery", ctx, arg)
+	ret0, _ := ret[0].(db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWebhookDelivery indicates an expected call of UpdateWebhookDelivery.
+func (mr *MockQuerierMockRecorder) UpdateWebhookDelivery(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookDelivery", reflect.TypeOf((*MockQuerier)(nil).UpdateWebhookDelivery), ctx, arg)
+}
@@ -7,7 +7,9 @@ package mockdb
 import (
 	context "context"
 	reflect "reflect"
+	redis "shortURL/db/redis"
 	db "shortURL/db/sqlc"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -35,6 +37,99 @@ func (m *MockRedisQuerier) EXPECT() *MockRedisQuerierMockRecorder {
 	return m.recorder
 }
 
+// AckPendingURLs mocks base method.
+func (m *MockRedisQuerier) AckPendingURLs(ctx context.Context, shortUrls ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range shortUrls {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "AckPendingURLs", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AckPendingURLs indicates an expected call of AckPendingURLs.
+func (mr *MockRedisQuerierMockRecorder) AckPendingURLs(ctx interface{}, shortUrls ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, shortUrls...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AckPendingURLs", reflect.TypeOf((*MockRedisQuerier)(nil).AckPendingURLs), varargs...)
+}
+
+// ClaimPendingURLs mocks base method.
+func (m *MockRedisQuerier) ClaimPendingURLs(ctx context.Context, limit int64, lease time.Duration) ([]redis.PendingURL, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimPendingURLs", ctx, limit, lease)
+	ret0, _ := ret[0].([]redis.PendingURL)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimPendingURLs indicates an expected call of ClaimPendingURLs.
+func (mr *MockRedisQuerierMockRecorder) ClaimPendingURLs(ctx, limit, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimPendingURLs", reflect.TypeOf((*MockRedisQuerier)(nil).ClaimPendingURLs), ctx, limit, lease)
+}
+
+// CountPendingURLs mocks base method.
+func (m *MockRedisQuerier) CountPendingURLs(ctx context.Context) (int64, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPendingURLs", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CountPendingURLs indicates an expected call of CountPendingURLs.
+func (mr *MockRedisQuerierMockRecorder) CountPendingURLs(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPendingURLs", reflect.TypeOf((*MockRedisQuerier)(nil).CountPendingURLs), ctx)
+}
+
+// DelData mocks base method.
+func (m *MockRedisQuerier) DelData(ctx context.Context, shortUrl string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DelData", ctx, shortUrl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DelData indicates an expected call of DelData.
+func (mr *MockRedisQuerierMockRecorder) DelData(ctx, shortUrl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DelData", reflect.TypeOf((*MockRedisQuerier)(nil).DelData), ctx, shortUrl)
+}
+
+// DelUnlockAttempt mocks base method.
+func (m *MockRedisQuerier) DelUnlockAttempt(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DelUnlockAttempt", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DelUnlockAttempt indicates an expected call of DelUnlockAttempt.
+func (mr *MockRedisQuerierMockRecorder) DelUnlockAttempt(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DelUnlockAttempt", reflect.TypeOf((*MockRedisQuerier)(nil).DelUnlockAttempt), ctx, key)
+}
+
+// EnqueuePendingURL mocks base method.
+func (m *MockRedisQuerier) EnqueuePendingURL(ctx context.Context, shortUrl string, payload []byte, url db.Url) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnqueuePendingURL", ctx, shortUrl, payload, url)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnqueuePendingURL indicates an expected call of EnqueuePendingURL.
+func (mr *MockRedisQuerierMockRecorder) EnqueuePendingURL(ctx, shortUrl, payload, url interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueuePendingURL", reflect.TypeOf((*MockRedisQuerier)(nil).EnqueuePendingURL), ctx, shortUrl, payload, url)
+}
+
 // ExistBloom mocks base method.
 func (m *MockRedisQuerier) ExistBloom(ctx context.Context, shortUrl string) (bool, error) {
 	m.ctrl.T.Helper()
@@ -50,6 +145,35 @@ func (mr *MockRedisQuerierMockRecorder) ExistBloom(ctx, shortUrl interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExistBloom", reflect.TypeOf((*MockRedisQuerier)(nil).ExistBloom), ctx, shortUrl)
 }
 
+// FailPendingURL mocks base method.
+func (m *MockRedisQuerier) FailPendingURL(ctx context.Context, shortUrl string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailPendingURL", ctx, shortUrl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FailPendingURL indicates an expected call of FailPendingURL.
+func (mr *MockRedisQuerierMockRecorder) FailPendingURL(ctx, shortUrl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailPendingURL", reflect.TypeOf((*MockRedisQuerier)(nil).FailPendingURL), ctx, shortUrl)
+}
+
+// GetClick mocks base method.
+func (m *MockRedisQuerier) GetClick(ctx context.Context, shortUrl string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClick", ctx, shortUrl)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClick indicates an expected call of GetClick.
+func (mr *MockRedisQuerierMockRecorder) GetClick(ctx, shortUrl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClick", reflect.TypeOf((*MockRedisQuerier)(nil).GetClick), ctx, shortUrl)
+}
+
 // GetData mocks base method.
 func (m *MockRedisQuerier) GetData(ctx context.Context, shortUrl string) (db.Url, bool, error) {
 	m.ctrl.T.Helper()
@@ -66,6 +190,161 @@ func (mr *MockRedisQuerierMockRecorder) GetData(ctx, shortUrl interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetData", reflect.TypeOf((*MockRedisQuerier)(nil).GetData), ctx, shortUrl)
 }
 
+// GetPendingURL mocks base method.
+func (m *MockRedisQuerier) GetPendingURL(ctx context.Context, shortUrl string) ([]byte, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingURL", ctx, shortUrl)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetPendingURL indicates an expected call of GetPendingURL.
+func (mr *MockRedisQuerierMockRecorder) GetPendingURL(ctx, shortUrl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingURL", reflect.TypeOf((*MockRedisQuerier)(nil).GetPendingURL), ctx, shortUrl)
+}
+
+// GetQRCode mocks base method.
+func (m *MockRedisQuerier) GetQRCode(ctx context.Context, shortUrl, key string) ([]byte, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQRCode", ctx, shortUrl, key)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetQRCode indicates an expected call of GetQRCode.
+func (mr *MockRedisQuerierMockRecorder) GetQRCode(ctx, shortUrl, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQRCode", reflect.TypeOf((*MockRedisQuerier)(nil).GetQRCode), ctx, shortUrl, key)
+}
+
+// GetVariantClicks mocks base method.
+func (m *MockRedisQuerier) GetVariantClicks(ctx context.Context, shortUrl string) (map[string]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVariantClicks", ctx, shortUrl)
+	ret0, _ := ret[0].(map[string]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVariantClicks indicates an expected call of GetVariantClicks.
+func (mr *MockRedisQuerierMockRecorder) GetVariantClicks(ctx, shortUrl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVariantClicks", reflect.TypeOf((*MockRedisQuerier)(nil).GetVariantClicks), ctx, shortUrl)
+}
+
+// IncrClick mocks base method.
+func (m *MockRedisQuerier) IncrClick(ctx context.Context, shortUrl string, url db.Url) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrClick", ctx, shortUrl, url)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrClick indicates an expected call of IncrClick.
+func (mr *MockRedisQuerierMockRecorder) IncrClick(ctx, shortUrl, url interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrClick", reflect.TypeOf((*MockRedisQuerier)(nil).IncrClick), ctx, shortUrl, url)
+}
+
+// IncrUnlockAttempt mocks base method.
+func (m *MockRedisQuerier) IncrUnlockAttempt(ctx context.Context, key string, window time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrUnlockAttempt", ctx, key, window)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrUnlockAttempt indicates an expected call of IncrUnlockAttempt.
+func (mr *MockRedisQuerierMockRecorder) IncrUnlockAttempt(ctx, key, window interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrUnlockAttempt", reflect.TypeOf((*MockRedisQuerier)(nil).IncrUnlockAttempt), ctx, key, window)
+}
+
+// IncrVariantClick mocks base method.
+func (m *MockRedisQuerier) IncrVariantClick(ctx context.Context, shortUrl, variant string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrVariantClick", ctx, shortUrl, variant)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IncrVariantClick indicates an expected call of IncrVariantClick.
+func (mr *MockRedisQuerierMockRecorder) IncrVariantClick(ctx, shortUrl, variant interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrVariantClick", reflect.TypeOf((*MockRedisQuerier)(nil).IncrVariantClick), ctx, shortUrl, variant)
+}
+
+// MarkDirtyClick mocks base method.
+func (m *MockRedisQuerier) MarkDirtyClick(ctx context.Context, shortUrl string, urlID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkDirtyClick", ctx, shortUrl, urlID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkDirtyClick indicates an expected call of MarkDirtyClick.
+func (mr *MockRedisQuerierMockRecorder) MarkDirtyClick(ctx, shortUrl, urlID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDirtyClick", reflect.TypeOf((*MockRedisQuerier)(nil).MarkDirtyClick), ctx, shortUrl, urlID)
+}
+
+// PopDirtyClicks mocks base method.
+func (m *MockRedisQuerier) PopDirtyClicks(ctx context.Context) (map[string]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PopDirtyClicks", ctx)
+	ret0, _ := ret[0].(map[string]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PopDirtyClicks indicates an expected call of PopDirtyClicks.
+func (mr *MockRedisQuerierMockRecorder) PopDirtyClicks(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PopDirtyClicks", reflect.TypeOf((*MockRedisQuerier)(nil).PopDirtyClicks), ctx)
+}
+
+// PopTask mocks base method.
+func (m *MockRedisQuerier) PopTask(ctx context.Context, timeout time.Duration, queues ...string) (redis.Task, bool, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, timeout}
+	for _, a := range queues {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PopTask", varargs...)
+	ret0, _ := ret[0].(redis.Task)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// PopTask indicates an expected call of PopTask.
+func (mr *MockRedisQuerierMockRecorder) PopTask(ctx, timeout interface{}, queues ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, timeout}, queues...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PopTask", reflect.TypeOf((*MockRedisQuerier)(nil).PopTask), varargs...)
+}
+
+// PushTask mocks base method.
+func (m *MockRedisQuerier) PushTask(ctx context.Context, queue string, payload []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PushTask", ctx, queue, payload)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PushTask indicates an expected call of PushTask.
+func (mr *MockRedisQuerierMockRecorder) PushTask(ctx, queue, payload interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PushTask", reflect.TypeOf((*MockRedisQuerier)(nil).PushTask), ctx, queue, payload)
+}
+
 // SetBloom mocks base method.
 func (m *MockRedisQuerier) SetBloom(ctx context.Context, shortUrl string) (bool, error) {
 	m.ctrl.T.Helper()
@@ -94,3 +373,17 @@ func (mr *MockRedisQuerierMockRecorder) SetData(ctx, shortUrl, url interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetData", reflect.TypeOf((*MockRedisQuerier)(nil).SetData), ctx, shortUrl, url)
 }
+
+// SetQRCode mocks base method.
+func (m *MockRedisQuerier) SetQRCode(ctx context.Context, shortUrl, key string, data []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetQRCode", ctx, shortUrl, key, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetQRCode indicates an expected call of SetQRCode.
+func (mr *MockRedisQuerierMockRecorder) SetQRCode(ctx, shortUrl, key, data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetQRCode", reflect.TypeOf((*MockRedisQuerier)(nil).SetQRCode), ctx, shortUrl, key, data)
+}
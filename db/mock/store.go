@@ -0,0 +1,1616 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./db/sqlc/store.go
+
+// Package mockdb is a generated GoMock package.
+package mockdb
+
+import (
+	context "context"
+	reflect "reflect"
+	db "shortURL/db/sqlc"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockTxQuerier is a mock of TxQuerier interface.
+type MockTxQuerier struct {
+	ctrl     *gomock.Controller
+	recorder *MockTxQuerierMockRecorder
+}
+
+// MockTxQuerierMockRecorder is the mock recorder for MockTxQuerier.
+type MockTxQuerierMockRecorder struct {
+	mock *MockTxQuerier
+}
+
+// NewMockTxQuerier creates a new mock instance.
+func NewMockTxQuerier(ctrl *gomock.Controller) *MockTxQuerier {
+	mock := &MockTxQuerier{ctrl: ctrl}
+	mock.recorder = &MockTxQuerierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTxQuerier) EXPECT() *MockTxQuerierMockRecorder {
+	return m.recorder
+}
+
+// AddURLTag mocks base method.
+func (m *MockTxQuerier) AddURLTag(ctx context.Context, arg db.AddURLTagParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddURLTag", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddURLTag indicates an expected call of AddURLTag.
+func (mr *MockTxQuerierMockRecorder) AddURLTag(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddURLTag", reflect.TypeOf((*MockTxQuerier)(nil).AddURLTag), ctx, arg)
+}
+
+// ClaimWebhookDeliveries mocks base method.
+func (m *MockTxQuerier) ClaimWebhookDeliveries(ctx context.Context, arg db.ClaimWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimWebhookDeliveries", ctx, arg)
+	ret0, _ := ret[0].([]db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimWebhookDeliveries indicates an expected call of ClaimWebhookDeliveries.
+func (mr *MockTxQuerierMockRecorder) ClaimWebhookDeliveries(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimWebhookDeliveries", reflect.TypeOf((*MockTxQuerier)(nil).ClaimWebhookDeliveries), ctx, arg)
+}
+
+// CopyDomain mocks base method.
+func (m *MockTxQuerier) CopyDomain(ctx context.Context, arg db.CopyDomainParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CopyDomain", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CopyDomain indicates an expected call of CopyDomain.
+func (mr *MockTxQuerierMockRecorder) CopyDomain(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyDomain", reflect.TypeOf((*MockTxQuerier)(nil).CopyDomain), ctx, arg)
+}
+
+// CopyFolder mocks base method.
+func (m *MockTxQuerier) CopyFolder(ctx context.Context, arg db.CopyFolderParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CopyFolder", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CopyFolder indicates an expected call of CopyFolder.
+func (mr *MockTxQuerierMockRecorder) CopyFolder(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyFolder", reflect.TypeOf((*MockTxQuerier)(nil).CopyFolder), ctx, arg)
+}
+
+// CreateAuditEvent mocks base method.
+func (m *MockTxQuerier) CreateAuditEvent(ctx context.Context, arg db.CreateAuditEventParams) (db.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditEvent", ctx, arg)
+	ret0, _ := ret[0].(db.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAuditEvent indicates an expected call of CreateAuditEvent.
+func (mr *MockTxQuerierMockRecorder) CreateAuditEvent(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditEvent", reflect.TypeOf((*MockTxQuerier)(nil).CreateAuditEvent), ctx, arg)
+}
+
+// CreateDomain mocks base method.
+func (m *MockTxQuerier) CreateDomain(ctx context.Context, arg db.CreateDomainParams) (db.Domain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDomain", ctx, arg)
+	ret0, _ := ret[0].(db.Domain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDomain indicates an expected call of CreateDomain.
+func (mr *MockTxQuerierMockRecorder) CreateDomain(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDomain", reflect.TypeOf((*MockTxQuerier)(nil).CreateDomain), ctx, arg)
+}
+
+// CreateFolder mocks base method.
+func (m *MockTxQuerier) CreateFolder(ctx context.Context, name string) (db.Folder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFolder", ctx, name)
+	ret0, _ := ret[0].(db.Folder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFolder indicates an expected call of CreateFolder.
+func (mr *MockTxQuerierMockRecorder) CreateFolder(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFolder", reflect.TypeOf((*MockTxQuerier)(nil).CreateFolder), ctx, name)
+}
+
+// CreateURL mocks base method.
+func (m *MockTxQuerier) CreateURL(ctx context.Context, arg db.CreateURLParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateURL", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateURL indicates an expected call of CreateURL.
+func (mr *MockTxQuerierMockRecorder) CreateURL(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateURL", reflect.TypeOf((*MockTxQuerier)(nil).CreateURL), ctx, arg)
+}
+
+// CreateURLRevision mocks base method.
+func (m *MockTxQuerier) CreateURLRevision(ctx context.Context, arg db.CreateURLRevisionParams) (db.UrlRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateURLRevision", ctx, arg)
+	ret0, _ := ret[0].(db.UrlRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateURLRevision indicates an expected call of CreateURLRevision.
+func (mr *MockTxQuerierMockRecorder) CreateURLRevision(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateURLRevision", reflect.TypeOf((*MockTxQuerier)(nil).CreateURLRevision), ctx, arg)
+}
+
+// CreateWebhook mocks base method.
+func (m *MockTxQuerier) CreateWebhook(ctx context.Context, arg db.CreateWebhookParams) (db.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", ctx, arg)
+	ret0, _ := ret[0].(db.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockTxQuerierMockRecorder) CreateWebhook(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockTxQuerier)(nil).CreateWebhook), ctx, arg)
+}
+
+// CreateWebhookDelivery mocks base method.
+func (m *MockTxQuerier) CreateWebhookDelivery(ctx context.Context, arg db.CreateWebhookDeliveryParams) (db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookDelivery", ctx, arg)
+	ret0, _ := ret[0].(db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhookDelivery indicates an expected call of CreateWebhookDelivery.
+func (mr *MockTxQuerierMockRecorder) CreateWebhookDelivery(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookDelivery", reflect.TypeOf((*MockTxQuerier)(nil).CreateWebhookDelivery), ctx, arg)
+}
+
+// DeleteURL mocks base method.
+func (m *MockTxQuerier) DeleteURL(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteURL", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteURL indicates an expected call of DeleteURL.
+func (mr *MockTxQuerierMockRecorder) DeleteURL(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteURL", reflect.TypeOf((*MockTxQuerier)(nil).DeleteURL), ctx, id)
+}
+
+// DeleteURLTags mocks base method.
+func (m *MockTxQuerier) DeleteURLTags(ctx context.Context, urlID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteURLTags", ctx, urlID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteURLTags indicates an expected call of DeleteURLTags.
+func (mr *MockTxQuerierMockRecorder) DeleteURLTags(ctx, urlID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteURLTags", reflect.TypeOf((*MockTxQuerier)(nil).DeleteURLTags), ctx, urlID)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockTxQuerier) DeleteWebhook(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockTxQuerierMockRecorder) DeleteWebhook(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockTxQuerier)(nil).DeleteWebhook), ctx, id)
+}
+
+// ExecTx mocks base method.
+func (m *MockTxQuerier) ExecTx(ctx context.Context, fn func(db.Querier) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExecTx indicates an expected call of ExecTx.
+func (mr *MockTxQuerierMockRecorder) ExecTx(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecTx", reflect.TypeOf((*MockTxQuerier)(nil).ExecTx), ctx, fn)
+}
+
+// GetDeletedURL mocks base method.
+func (m *MockTxQuerier) GetDeletedURL(ctx context.Context, arg db.GetDeletedURLParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedURL", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedURL indicates an expected call of GetDeletedURL.
+func (mr *MockTxQuerierMockRecorder) GetDeletedURL(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedURL", reflect.TypeOf((*MockTxQuerier)(nil).GetDeletedURL), ctx, arg)
+}
+
+// GetDomainByHost mocks base method.
+func (m *MockTxQuerier) GetDomainByHost(ctx context.Context, host string) (db.Domain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDomainByHost", ctx, host)
+	ret0, _ := ret[0].(db.Domain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDomainByHost indicates an expected call of GetDomainByHost.
+func (mr *MockTxQuerierMockRecorder) GetDomainByHost(ctx, host interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDomainByHost", reflect.TypeOf((*MockTxQuerier)(nil).GetDomainByHost), ctx, host)
+}
+
+// GetFolder mocks base method.
+func (m *MockTxQuerier) GetFolder(ctx context.Context, id int64) (db.Folder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFolder", ctx, id)
+	ret0, _ := ret[0].(db.Folder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFolder indicates an expected call of GetFolder.
+func (mr *MockTxQuerierMockRecorder) GetFolder(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFolder", reflect.TypeOf((*MockTxQuerier)(nil).GetFolder), ctx, id)
+}
+
+// GetURL mocks base method.
+func (m *MockTxQuerier) GetURL(ctx context.Context, arg db.GetURLParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetURL", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetURL indicates an expected call of GetURL.
+func (mr *MockTxQuerierMockRecorder) GetURL(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURL", reflect.TypeOf((*MockTxQuerier)(nil).GetURL), ctx, arg)
+}
+
+// GetURLForUpdate mocks base method.
+func (m *MockTxQuerier) GetURLForUpdate(ctx context.Context, id int64) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetURLForUpdate", ctx, id)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetURLForUpdate indicates an expected call of GetURLForUpdate.
+func (mr *MockTxQuerierMockRecorder) GetURLForUpdate(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURLForUpdate", reflect.TypeOf((*MockTxQuerier)(nil).GetURLForUpdate), ctx, id)
+}
+
+// GetURLRevision mocks base method.
+func (m *MockTxQuerier) GetURLRevision(ctx context.Context, arg db.GetURLRevisionParams) (db.UrlRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetURLRevision", ctx, arg)
+	ret0, _ := ret[0].(db.UrlRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetURLRevision indicates an expected call of GetURLRevision.
+func (mr *MockTxQuerierMockRecorder) GetURLRevision(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURLRevision", reflect.TypeOf((*MockTxQuerier)(nil).GetURLRevision), ctx, arg)
+}
+
+// GetWebhook mocks base method.
+func (m *MockTxQuerier) GetWebhook(ctx context.Context, id int64) (db.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhook", ctx, id)
+	ret0, _ := ret[0].(db.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhook indicates an expected call of GetWebhook.
+func (mr *MockTxQuerierMockRecorder) GetWebhook(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhook", reflect.TypeOf((*MockTxQuerier)(nil).GetWebhook), ctx, id)
+}
+
+// GetWebhookDelivery mocks base method.
+func (m *MockTxQuerier) GetWebhookDelivery(ctx context.Context, id int64) (db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDelivery", ctx, id)
+	ret0, _ := ret[0].(db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDelivery indicates an expected call of GetWebhookDelivery.
+func (mr *MockTxQuerierMockRecorder) GetWebhookDelivery(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDelivery", reflect.TypeOf((*MockTxQuerier)(nil).GetWebhookDelivery), ctx, id)
+}
+
+// ImportURL mocks base method.
+func (m *MockTxQuerier) ImportURL(ctx context.Context, arg db.ImportURLParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportURL", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportURL indicates an expected call of ImportURL.
+func (mr *MockTxQuerierMockRecorder) ImportURL(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportURL", reflect.TypeOf((*MockTxQuerier)(nil).ImportURL), ctx, arg)
+}
+
+// ImportURLRevision mocks base method.
+func (m *MockTxQuerier) ImportURLRevision(ctx context.Context, arg db.ImportURLRevisionParams) (db.UrlRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportURLRevision", ctx, arg)
+	ret0, _ := ret[0].(db.UrlRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportURLRevision indicates an expected call of ImportURLRevision.
+func (mr *MockTxQuerierMockRecorder) ImportURLRevision(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportURLRevision", reflect.TypeOf((*MockTxQuerier)(nil).ImportURLRevision), ctx, arg)
+}
+
+// ListAuditEvents mocks base method.
+func (m *MockTxQuerier) ListAuditEvents(ctx context.Context, arg db.ListAuditEventsParams) ([]db.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditEvents", ctx, arg)
+	ret0, _ := ret[0].([]db.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockTxQuerierMockRecorder) ListAuditEvents(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockTxQuerier)(nil).ListAuditEvents), ctx, arg)
+}
+
+// ListDomains mocks base method.
+func (m *MockTxQuerier) ListDomains(ctx context.Context, arg db.ListDomainsParams) ([]db.Domain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDomains", ctx, arg)
+	ret0, _ := ret[0].([]db.Domain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDomains indicates an expected call of ListDomains.
+func (mr *MockTxQuerierMockRecorder) ListDomains(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDomains", reflect.TypeOf((*MockTxQuerier)(nil).ListDomains), ctx, arg)
+}
+
+// ListFolders mocks base method.
+func (m *MockTxQuerier) ListFolders(ctx context.Context, arg db.ListFoldersParams) ([]db.Folder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFolders", ctx, arg)
+	ret0, _ := ret[0].([]db.Folder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFolders indicates an expected call of ListFolders.
+func (mr *MockTxQuerierMockRecorder) ListFolders(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFolders", reflect.TypeOf((*MockTxQuerier)(nil).ListFolders), ctx, arg)
+}
+
+// ListTagsByURLs mocks base method.
+func (m *MockTxQuerier) ListTagsByURLs(ctx context.Context, urlIds []int64) ([]db.UrlTag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTagsByURLs", ctx, urlIds)
+	ret0, _ := ret[0].([]db.UrlTag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTagsByURLs indicates an expected call of ListTagsByURLs.
+func (mr *MockTxQuerierMockRecorder) ListTagsByURLs(ctx, urlIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTagsByURLs", reflect.TypeOf((*MockTxQuerier)(nil).ListTagsByURLs), ctx, urlIds)
+}
+
+// ListURLRevisions mocks base method.
+func (m *MockTxQuerier) ListURLRevisions(ctx context.Context, arg db.ListURLRevisionsParams) ([]db.UrlRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListURLRevisions", ctx, arg)
+	ret0, _ := ret[0].([]db.UrlRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListURLRevisions indicates an expected call of ListURLRevisions.
+func (mr *MockTxQuerierMockRecorder) ListURLRevisions(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListURLRevisions", reflect.TypeOf((*MockTxQuerier)(nil).ListURLRevisions), ctx, arg)
+}
+
+// ListURLTags mocks base method.
+func (m *MockTxQuerier) ListURLTags(ctx context.Context, urlID int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListURLTags", ctx, urlID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListURLTags indicates an expected call of ListURLTags.
+func (mr *MockTxQuerierMockRecorder) ListURLTags(ctx, urlID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListURLTags", reflect.TypeOf((*MockTxQuerier)(nil).ListURLTags), ctx, urlID)
+}
+
+// ListURLs mocks base method.
+func (m *MockTxQuerier) ListURLs(ctx context.Context, arg db.ListURLsParams) ([]db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListURLs", ctx, arg)
+	ret0, _ := ret[0].([]db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListURLs indicates an expected call of ListURLs.
+func (mr *MockTxQuerierMockRecorder) ListURLs(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListURLs", reflect.TypeOf((*MockTxQuerier)(nil).ListURLs), ctx, arg)
+}
+
+// ListURLsToCheck mocks base method.
+func (m *MockTxQuerier) ListURLsToCheck(ctx context.Context, arg db.ListURLsToCheckParams) ([]db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListURLsToCheck", ctx, arg)
+	ret0, _ := ret[0].([]db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListURLsToCheck indicates an expected call of ListURLsToCheck.
+func (mr *MockTxQuerierMockRecorder) ListURLsToCheck(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListURLsToCheck", reflect.TypeOf((*MockTxQuerier)(nil).ListURLsToCheck), ctx, arg)
+}
+
+// ListWebhookDeliveries mocks base method.
+func (m *MockTxQuerier) ListWebhookDeliveries(ctx context.Context, arg db.ListWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", ctx, arg)
+	ret0, _ := ret[0].([]db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockTxQuerierMockRecorder) ListWebhookDeliveries(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockTxQuerier)(nil).ListWebhookDeliveries), ctx, arg)
+}
+
+// ListWebhooks mocks base method.
+func (m *MockTxQuerier) ListWebhooks(ctx context.Context, arg db.ListWebhooksParams) ([]db.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhooks", ctx, arg)
+	ret0, _ := ret[0].([]db.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhooks indicates an expected call of ListWebhooks.
+func (mr *MockTxQuerierMockRecorder) ListWebhooks(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhooks", reflect.TypeOf((*MockTxQuerier)(nil).ListWebhooks), ctx, arg)
+}
+
+// ListWebhooksForEvent mocks base method.
+func (m *MockTxQuerier) ListWebhooksForEvent(ctx context.Context, event string) ([]db.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhooksForEvent", ctx, event)
+	ret0, _ := ret[0].([]db.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhooksForEvent indicates an expected call of ListWebhooksForEvent.
+func (mr *MockTxQuerierMockRecorder) ListWebhooksForEvent(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhooksForEvent", reflect.TypeOf((*MockTxQuerier)(nil).ListWebhooksForEvent), ctx, event)
+}
+
+// MarkExpiredURLs mocks base method.
+func (m *MockTxQuerier) MarkExpiredURLs(ctx context.Context, limit int32) ([]db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkExpiredURLs", ctx, limit)
+	ret0, _ := ret[0].([]db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkExpiredURLs indicates an expected call of MarkExpiredURLs.
+func (mr *MockTxQuerierMockRecorder) MarkExpiredURLs(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkExpiredURLs", reflect.TypeOf((*MockTxQuerier)(nil).MarkExpiredURLs), ctx, limit)
+}
+
+// PurgeDeletedURLs mocks base method.
+func (m *MockTxQuerier) PurgeDeletedURLs(ctx context.Context, arg db.PurgeDeletedURLsParams) ([]db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeletedURLs", ctx, arg)
+	ret0, _ := ret[0].([]db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeletedURLs indicates an expected call of PurgeDeletedURLs.
+func (mr *MockTxQuerierMockRecorder) PurgeDeletedURLs(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeletedURLs", reflect.TypeOf((*MockTxQuerier)(nil).PurgeDeletedURLs), ctx, arg)
+}
+
+// RedeliverWebhookDelivery mocks base method.
+func (m *MockTxQuerier) RedeliverWebhookDelivery(ctx context.Context, id int64) (db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedeliverWebhookDelivery", ctx, id)
+	ret0, _ := ret[0].(db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RedeliverWebhookDelivery indicates an expected call of RedeliverWebhookDelivery.
+func (mr *MockTxQuerierMockRecorder) RedeliverWebhookDelivery(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeliverWebhookDelivery", reflect.TypeOf((*MockTxQuerier)(nil).RedeliverWebhookDelivery), ctx, id)
+}
+
+// RestoreURL mocks base method.
+func (m *MockTxQuerier) RestoreURL(ctx context.Context, arg db.RestoreURLParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreURL", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreURL indicates an expected call of RestoreURL.
+func (mr *MockTxQuerierMockRecorder) RestoreURL(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreURL", reflect.TypeOf((*MockTxQuerier)(nil).RestoreURL), ctx, arg)
+}
+
+// SearchURLs mocks base method.
+func (m *MockTxQuerier) SearchURLs(ctx context.Context, arg db.SearchURLsParams) ([]db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchURLs", ctx, arg)
+	ret0, _ := ret[0].([]db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchURLs indicates an expected call of SearchURLs.
+func (mr *MockTxQuerierMockRecorder) SearchURLs(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchURLs", reflect.TypeOf((*MockTxQuerier)(nil).SearchURLs), ctx, arg)
+}
+
+// SetURLArchived mocks base method.
+func (m *MockTxQuerier) SetURLArchived(ctx context.Context, arg db.SetURLArchivedParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetURLArchived", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetURLArchived indicates an expected call of SetURLArchived.
+func (mr *MockTxQuerierMockRecorder) SetURLArchived(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetURLArchived", reflect.TypeOf((*MockTxQuerier)(nil).SetURLArchived), ctx, arg)
+}
+
+// SoftDeleteURL mocks base method.
+func (m *MockTxQuerier) SoftDeleteURL(ctx context.Context, id int64) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SoftDeleteURL", ctx, id)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SoftDeleteURL indicates an expected call of SoftDeleteURL.
+func (mr *MockTxQuerierMockRecorder) SoftDeleteURL(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftDeleteURL", reflect.TypeOf((*MockTxQuerier)(nil).SoftDeleteURL), ctx, id)
+}
+
+// UpdateURL mocks base method.
+func (m *MockTxQuerier) UpdateURL(ctx context.Context, arg db.UpdateURLParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateURL", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateURL indicates an expected call of UpdateURL.
+func (mr *MockTxQuerierMockRecorder) UpdateURL(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateURL", reflect.TypeOf((*MockTxQuerier)(nil).UpdateURL), ctx, arg)
+}
+
+// UpdateURLCheck mocks base method.
+func (m *MockTxQuerier) UpdateURLCheck(ctx context.Context, arg db.UpdateURLCheckParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateURLCheck", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateURLCheck indicates an expected call of UpdateURLCheck.
+func (mr *MockTxQuerierMockRecorder) UpdateURLCheck(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateURLCheck", reflect.TypeOf((*MockTxQuerier)(nil).UpdateURLCheck), ctx, arg)
+}
+
+// UpdateURLClickCount mocks base method.
+func (m *MockTxQuerier) UpdateURLClickCount(ctx context.Context, arg db.UpdateURLClickCountParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateURLClickCount", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateURLClickCount indicates an expected call of UpdateURLClickCount.
+func (mr *MockTxQuerierMockRecorder) UpdateURLClickCount(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateURLClickCount", reflect.TypeOf((*MockTxQuerier)(nil).UpdateURLClickCount), ctx, arg)
+}
+
+// UpdateURLMetadata mocks base method.
+func (m *MockTxQuerier) UpdateURLMetadata(ctx context.Context, arg db.UpdateURLMetadataParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateURLMetadata", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateURLMetadata indicates an expected call of UpdateURLMetadata.
+func (mr *MockTxQuerierMockRecorder) UpdateURLMetadata(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateURLMetadata", reflect.TypeOf((*MockTxQuerier)(nil).UpdateURLMetadata), ctx, arg)
+}
+
+// UpdateURLSettings mocks base method.
+func (m *MockTxQuerier) UpdateURLSettings(ctx context.Context, arg db.UpdateURLSettingsParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateURLSettings", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateURLSettings indicates an expected call of UpdateURLSettings.
+func (mr *MockTxQuerierMockRecorder) UpdateURLSettings(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateURLSettings", reflect.TypeOf((*MockTxQuerier)(nil).UpdateURLSettings), ctx, arg)
+}
+
+// UpdateWebhookDelivery mocks base method.
+func (m *MockTxQuerier) UpdateWebhookDelivery(ctx context.Context, arg db.UpdateWebhookDeliveryParams) (db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookDelivery", ctx, arg)
+	ret0, _ := ret[0].(db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWebhookDelivery indicates an expected call of UpdateWebhookDelivery.
+func (mr *MockTxQuerierMockRecorder) UpdateWebhookDelivery(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookDelivery", reflect.TypeOf((*MockTxQuerier)(nil).UpdateWebhookDelivery), ctx, arg)
+}
+
+// MockStore is a mock of Store interface.
+type MockStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockStoreMockRecorder
+}
+
+// MockStoreMockRecorder is the mock recorder for MockStore.
+type MockStoreMockRecorder struct {
+	mock *MockStore
+}
+
+// NewMockStore creates a new mock instance.
+func NewMockStore(ctrl *gomock.Controller) *MockStore {
+	mock := &MockStore{ctrl: ctrl}
+	mock.recorder = &MockStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStore) EXPECT() *MockStoreMockRecorder {
+	return m.recorder
+}
+
+// AddURLTag mocks base method.
+func (m *MockStore) AddURLTag(ctx context.Context, arg db.AddURLTagParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddURLTag", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddURLTag indicates an expected call of AddURLTag.
+func (mr *MockStoreMockRecorder) AddURLTag(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddURLTag", reflect.TypeOf((*MockStore)(nil).AddURLTag), ctx, arg)
+}
+
+// ClaimWebhookDeliveries mocks base method.
+func (m *MockStore) ClaimWebhookDeliveries(ctx context.Context, arg db.ClaimWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimWebhookDeliveries", ctx, arg)
+	ret0, _ := ret[0].([]db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimWebhookDeliveries indicates an expected call of ClaimWebhookDeliveries.
+func (mr *MockStoreMockRecorder) ClaimWebhookDeliveries(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimWebhookDeliveries", reflect.TypeOf((*MockStore)(nil).ClaimWebhookDeliveries), ctx, arg)
+}
+
+// CopyDomain mocks base method.
+func (m *MockStore) CopyDomain(ctx context.Context, arg db.CopyDomainParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CopyDomain", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CopyDomain indicates an expected call of CopyDomain.
+func (mr *MockStoreMockRecorder) CopyDomain(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyDomain", reflect.TypeOf((*MockStore)(nil).CopyDomain), ctx, arg)
+}
+
+// CopyFolder mocks base method.
+func (m *MockStore) CopyFolder(ctx context.Context, arg db.CopyFolderParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CopyFolder", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CopyFolder indicates an expected call of CopyFolder.
+func (mr *MockStoreMockRecorder) CopyFolder(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyFolder", reflect.TypeOf((*MockStore)(nil).CopyFolder), ctx, arg)
+}
+
+// CreateAuditEvent mocks base method.
+func (m *MockStore) CreateAuditEvent(ctx context.Context, arg db.CreateAuditEventParams) (db.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditEvent", ctx, arg)
+	ret0, _ := ret[0].(db.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAuditEvent indicates an expected call of CreateAuditEvent.
+func (mr *MockStoreMockRecorder) CreateAuditEvent(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditEvent", reflect.TypeOf((*MockStore)(nil).CreateAuditEvent), ctx, arg)
+}
+
+// CreateDomain mocks base method.
+func (m *MockStore) CreateDomain(ctx context.Context, arg db.CreateDomainParams) (db.Domain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDomain", ctx, arg)
+	ret0, _ := ret[0].(db.Domain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDomain indicates an expected call of CreateDomain.
+func (mr *MockStoreMockRecorder) CreateDomain(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDomain", reflect.TypeOf((*MockStore)(nil).CreateDomain), ctx, arg)
+}
+
+// CreateFolder mocks base method.
+func (m *MockStore) CreateFolder(ctx context.Context, name string) (db.Folder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFolder", ctx, name)
+	ret0, _ := ret[0].(db.Folder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFolder indicates an expected call of CreateFolder.
+func (mr *MockStoreMockRecorder) CreateFolder(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFolder", reflect.TypeOf((*MockStore)(nil).CreateFolder), ctx, name)
+}
+
+// CreateURL mocks base method.
+func (m *MockStore) CreateURL(ctx context.Context, arg db.CreateURLParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateURL", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateURL indicates an expected call of CreateURL.
+func (mr *MockStoreMockRecorder) CreateURL(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateURL", reflect.TypeOf((*MockStore)(nil).CreateURL), ctx, arg)
+}
+
+// CreateURLBatchTx mocks base method.
+func (m *MockStore) CreateURLBatchTx(ctx context.Context, args []db.CreateURLTxParams) ([]db.URLBatchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateURLBatchTx", ctx, args)
+	ret0, _ := ret[0].([]db.URLBatchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateURLBatchTx indicates an expected call of CreateURLBatchTx.
+func (mr *MockStoreMockRecorder) CreateURLBatchTx(ctx, args interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateURLBatchTx", reflect.TypeOf((*MockStore)(nil).CreateURLBatchTx), ctx, args)
+}
+
+// CreateURLRevision mocks base method.
+func (m *MockStore) CreateURLRevision(ctx context.Context, arg db.CreateURLRevisionParams) (db.UrlRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateURLRevision", ctx, arg)
+	ret0, _ := ret[0].(db.UrlRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateURLRevision indicates an expected call of CreateURLRevision.
+func (mr *MockStoreMockRecorder) CreateURLRevision(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateURLRevision", reflect.TypeOf((*MockStore)(nil).CreateURLRevision), ctx, arg)
+}
+
+// CreateURLTx mocks base method.
+func (m *MockStore) CreateURLTx(ctx context.Context, arg db.CreateURLTxParams) (db.URLTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateURLTx", ctx, arg)
+	ret0, _ := ret[0].(db.URLTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateURLTx indicates an expected call of CreateURLTx.
+func (mr *MockStoreMockRecorder) CreateURLTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateURLTx", reflect.TypeOf((*MockStore)(nil).CreateURLTx), ctx, arg)
+}
+
+// CreateWebhook mocks base method.
+func (m *MockStore) CreateWebhook(ctx context.Context, arg db.CreateWebhookParams) (db.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", ctx, arg)
+	ret0, _ := ret[0].(db.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockStoreMockRecorder) CreateWebhook(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockStore)(nil).CreateWebhook), ctx, arg)
+}
+
+// CreateWebhookDelivery mocks base method.
+func (m *MockStore) CreateWebhookDelivery(ctx context.Context, arg db.CreateWebhookDeliveryParams) (db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookDelivery", ctx, arg)
+	ret0, _ := ret[0].(db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhookDelivery indicates an expected call of CreateWebhookDelivery.
+func (mr *MockStoreMockRecorder) CreateWebhookDelivery(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookDelivery", reflect.TypeOf((*MockStore)(nil).CreateWebhookDelivery), ctx, arg)
+}
+
+// DeleteURL mocks base method.
+func (m *MockStore) DeleteURL(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteURL", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteURL indicates an expected call of DeleteURL.
+func (mr *MockStoreMockRecorder) DeleteURL(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteURL", reflect.TypeOf((*MockStore)(nil).DeleteURL), ctx, id)
+}
+
+// DeleteURLTags mocks base method.
+func (m *MockStore) DeleteURLTags(ctx context.Context, urlID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteURLTags", ctx, urlID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteURLTags indicates an expected call of DeleteURLTags.
+func (mr *MockStoreMockRecorder) DeleteURLTags(ctx, urlID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteURLTags", reflect.TypeOf((*MockStore)(nil).DeleteURLTags), ctx, urlID)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockStore) DeleteWebhook(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockStoreMockRecorder) DeleteWebhook(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockStore)(nil).DeleteWebhook), ctx, id)
+}
+
+// ExecTx mocks base method.
+func (m *MockStore) ExecTx(ctx context.Context, fn func(db.Querier) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExecTx indicates an expected call of ExecTx.
+func (mr *MockStoreMockRecorder) ExecTx(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecTx", reflect.TypeOf((*MockStore)(nil).ExecTx), ctx, fn)
+}
+
+// GetDeletedURL mocks base method.
+func (m *MockStore) GetDeletedURL(ctx context.Context, arg db.GetDeletedURLParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedURL", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedURL indicates an expected call of GetDeletedURL.
+func (mr *MockStoreMockRecorder) GetDeletedURL(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedURL", reflect.TypeOf((*MockStore)(nil).GetDeletedURL), ctx, arg)
+}
+
+// GetDomainByHost mocks base method.
+func (m *MockStore) GetDomainByHost(ctx context.Context, host string) (db.Domain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDomainByHost", ctx, host)
+	ret0, _ := ret[0].(db.Domain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDomainByHost indicates an expected call of GetDomainByHost.
+func (mr *MockStoreMockRecorder) GetDomainByHost(ctx, host interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDomainByHost", reflect.TypeOf((*MockStore)(nil).GetDomainByHost), ctx, host)
+}
+
+// GetFolder mocks base method.
+func (m *MockStore) GetFolder(ctx context.Context, id int64) (db.Folder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFolder", ctx, id)
+	ret0, _ := ret[0].(db.Folder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFolder indicates an expected call of GetFolder.
+func (mr *MockStoreMockRecorder) GetFolder(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFolder", reflect.TypeOf((*MockStore)(nil).GetFolder), ctx, id)
+}
+
+// GetURL mocks base method.
+func (m *MockStore) GetURL(ctx context.Context, arg db.GetURLParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetURL", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetURL indicates an expected call of GetURL.
+func (mr *MockStoreMockRecorder) GetURL(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURL", reflect.TypeOf((*MockStore)(nil).GetURL), ctx, arg)
+}
+
+// GetURLForUpdate mocks base method.
+func (m *MockStore) GetURLForUpdate(ctx context.Context, id int64) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetURLForUpdate", ctx, id)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetURLForUpdate indicates an expected call of GetURLForUpdate.
+func (mr *MockStoreMockRecorder) GetURLForUpdate(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURLForUpdate", reflect.TypeOf((*MockStore)(nil).GetURLForUpdate), ctx, id)
+}
+
+// GetURLRevision mocks base method.
+func (m *MockStore) GetURLRevision(ctx context.Context, arg db.GetURLRevisionParams) (db.UrlRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetURLRevision", ctx, arg)
+	ret0, _ := ret[0].(db.UrlRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetURLRevision indicates an expected call of GetURLRevision.
+func (mr *MockStoreMockRecorder) GetURLRevision(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURLRevision", reflect.TypeOf((*MockStore)(nil).GetURLRevision), ctx, arg)
+}
+
+// GetWebhook mocks base method.
+func (m *MockStore) GetWebhook(ctx context.Context, id int64) (db.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhook", ctx, id)
+	ret0, _ := ret[0].(db.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhook indicates an expected call of GetWebhook.
+func (mr *MockStoreMockRecorder) GetWebhook(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhook", reflect.TypeOf((*MockStore)(nil).GetWebhook), ctx, id)
+}
+
+// GetWebhookDelivery mocks base method.
+func (m *MockStore) GetWebhookDelivery(ctx context.Context, id int64) (db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDelivery", ctx, id)
+	ret0, _ := ret[0].(db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDelivery indicates an expected call of GetWebhookDelivery.
+func (mr *MockStoreMockRecorder) GetWebhookDelivery(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDelivery", reflect.TypeOf((*MockStore)(nil).GetWebhookDelivery), ctx, id)
+}
+
+// ImportURL mocks base method.
+func (m *MockStore) ImportURL(ctx context.Context, arg db.ImportURLParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportURL", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportURL indicates an expected call of ImportURL.
+func (mr *MockStoreMockRecorder) ImportURL(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportURL", reflect.TypeOf((*MockStore)(nil).ImportURL), ctx, arg)
+}
+
+// ImportURLRevision mocks base method.
+func (m *MockStore) ImportURLRevision(ctx context.Context, arg db.ImportURLRevisionParams) (db.UrlRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportURLRevision", ctx, arg)
+	ret0, _ := ret[0].(db.UrlRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportURLRevision indicates an expected call of ImportURLRevision.
+func (mr *MockStoreMockRecorder) ImportURLRevision(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportURLRevision", reflect.TypeOf((*MockStore)(nil).ImportURLRevision), ctx, arg)
+}
+
+// ListAuditEvents mocks base method.
+func (m *MockStore) ListAuditEvents(ctx context.Context, arg db.ListAuditEventsParams) ([]db.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditEvents", ctx, arg)
+	ret0, _ := ret[0].([]db.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockStoreMockRecorder) ListAuditEvents(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockStore)(nil).ListAuditEvents), ctx, arg)
+}
+
+// ListDomains mocks base method.
+func (m *MockStore) ListDomains(ctx context.Context, arg db.ListDomainsParams) ([]db.Domain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDomains", ctx, arg)
+	ret0, _ := ret[0].([]db.Domain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDomains indicates an expected call of ListDomains.
+func (mr *MockStoreMockRecorder) ListDomains(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDomains", reflect.TypeOf((*MockStore)(nil).ListDomains), ctx, arg)
+}
+
+// ListFolders mocks base method.
+func (m *MockStore) ListFolders(ctx context.Context, arg db.ListFoldersParams) ([]db.Folder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFolders", ctx, arg)
+	ret0, _ := ret[0].([]db.Folder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFolders indicates an expected call of ListFolders.
+func (mr *MockStoreMockRecorder) ListFolders(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFolders", reflect.TypeOf((*MockStore)(nil).ListFolders), ctx, arg)
+}
+
+// ListTagsByURLs mocks base method.
+func (m *MockStore) ListTagsByURLs(ctx context.Context, urlIds []int64) ([]db.UrlTag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTagsByURLs", ctx, urlIds)
+	ret0, _ := ret[0].([]db.UrlTag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTagsByURLs indicates an expected call of ListTagsByURLs.
+func (mr *MockStoreMockRecorder) ListTagsByURLs(ctx, urlIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTagsByURLs", reflect.TypeOf((*MockStore)(nil).ListTagsByURLs), ctx, urlIds)
+}
+
+// ListURLRevisions mocks base method.
+func (m *MockStore) ListURLRevisions(ctx context.Context, arg db.ListURLRevisionsParams) ([]db.UrlRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListURLRevisions", ctx, arg)
+	ret0, _ := ret[0].([]db.UrlRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListURLRevisions indicates an expected call of ListURLRevisions.
+func (mr *MockStoreMockRecorder) ListURLRevisions(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListURLRevisions", reflect.TypeOf((*MockStore)(nil).ListURLRevisions), ctx, arg)
+}
+
+// ListURLTags mocks base method.
+func (m *MockStore) ListURLTags(ctx context.Context, urlID int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListURLTags", ctx, urlID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListURLTags indicates an expected call of ListURLTags.
+func (mr *MockStoreMockRecorder) ListURLTags(ctx, urlID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListURLTags", reflect.TypeOf((*MockStore)(nil).ListURLTags), ctx, urlID)
+}
+
+// ListURLs mocks base method.
+func (m *MockStore) ListURLs(ctx context.Context, arg db.ListURLsParams) ([]db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListURLs", ctx, arg)
+	ret0, _ := ret[0].([]db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListURLs indicates an expected call of ListURLs.
+func (mr *MockStoreMockRecorder) ListURLs(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListURLs", reflect.TypeOf((*MockStore)(nil).ListURLs), ctx, arg)
+}
+
+// ListURLsToCheck mocks base method.
+func (m *MockStore) ListURLsToCheck(ctx context.Context, arg db.ListURLsToCheckParams) ([]db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListURLsToCheck", ctx, arg)
+	ret0, _ := ret[0].([]db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListURLsToCheck indicates an expected call of ListURLsToCheck.
+func (mr *MockStoreMockRecorder) ListURLsToCheck(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListURLsToCheck", reflect.TypeOf((*MockStore)(nil).ListURLsToCheck), ctx, arg)
+}
+
+// ListWebhookDeliveries mocks base method.
+func (m *MockStore) ListWebhookDeliveries(ctx context.Context, arg db.ListWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", ctx, arg)
+	ret0, _ := ret[0].([]db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockStoreMockRecorder) ListWebhookDeliveries(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockStore)(nil).ListWebhookDeliveries), ctx, arg)
+}
+
+// ListWebhooks mocks base method.
+func (m *MockStore) ListWebhooks(ctx context.Context, arg db.ListWebhooksParams) ([]db.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhooks", ctx, arg)
+	ret0, _ := ret[0].([]db.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhooks indicates an expected call of ListWebhooks.
+func (mr *MockStoreMockRecorder) ListWebhooks(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhooks", reflect.TypeOf((*MockStore)(nil).ListWebhooks), ctx, arg)
+}
+
+// ListWebhooksForEvent mocks base method.
+func (m *MockStore) ListWebhooksForEvent(ctx context.Context, event string) ([]db.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhooksForEvent", ctx, event)
+	ret0, _ := ret[0].([]db.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhooksForEvent indicates an expected call of ListWebhooksForEvent.
+func (mr *MockStoreMockRecorder) ListWebhooksForEvent(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhooksForEvent", reflect.TypeOf((*MockStore)(nil).ListWebhooksForEvent), ctx, event)
+}
+
+// MarkExpiredURLs mocks base method.
+func (m *MockStore) MarkExpiredURLs(ctx context.Context, limit int32) ([]db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkExpiredURLs", ctx, limit)
+	ret0, _ := ret[0].([]db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkExpiredURLs indicates an expected call of MarkExpiredURLs.
+func (mr *MockStoreMockRecorder) MarkExpiredURLs(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkExpiredURLs", reflect.TypeOf((*MockStore)(nil).MarkExpiredURLs), ctx, limit)
+}
+
+// PurgeDeletedURLs mocks base method.
+func (m *MockStore) PurgeDeletedURLs(ctx context.Context, arg db.PurgeDeletedURLsParams) ([]db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeletedURLs", ctx, arg)
+	ret0, _ := ret[0].([]db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeletedURLs indicates an expected call of PurgeDeletedURLs.
+func (mr *MockStoreMockRecorder) PurgeDeletedURLs(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeletedURLs", reflect.TypeOf((*MockStore)(nil).PurgeDeletedURLs), ctx, arg)
+}
+
+// PurgeDeletedURLsTx mocks base method.
+func (m *MockStore) PurgeDeletedURLsTx(ctx context.Context, arg db.PurgeDeletedURLsParams) ([]db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeletedURLsTx", ctx, arg)
+	ret0, _ := ret[0].([]db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeletedURLsTx indicates an expected call of PurgeDeletedURLsTx.
+func (mr *MockStoreMockRecorder) PurgeDeletedURLsTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeletedURLsTx", reflect.TypeOf((*MockStore)(nil).PurgeDeletedURLsTx), ctx, arg)
+}
+
+// RedeliverWebhookDelivery mocks base method.
+func (m *MockStore) RedeliverWebhookDelivery(ctx context.Context, id int64) (db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedeliverWebhookDelivery", ctx, id)
+	ret0, _ := ret[0].(db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RedeliverWebhookDelivery indicates an expected call of RedeliverWebhookDelivery.
+func (mr *MockStoreMockRecorder) RedeliverWebhookDelivery(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeliverWebhookDelivery", reflect.TypeOf((*MockStore)(nil).RedeliverWebhookDelivery), ctx, id)
+}
+
+// RestoreURL mocks base method.
+func (m *MockStore) RestoreURL(ctx context.Context, arg db.RestoreURLParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreURL", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreURL indicates an expected call of RestoreURL.
+func (mr *MockStoreMockRecorder) RestoreURL(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreURL", reflect.TypeOf((*MockStore)(nil).RestoreURL), ctx, arg)
+}
+
+// RestoreURLTx mocks base method.
+func (m *MockStore) RestoreURLTx(ctx context.Context, arg db.RestoreURLParams, audit db.AuditInfo) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreURLTx", ctx, arg, audit)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreURLTx indicates an expected call of RestoreURLTx.
+func (mr *MockStoreMockRecorder) RestoreURLTx(ctx, arg, audit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreURLTx", reflect.TypeOf((*MockStore)(nil).RestoreURLTx), ctx, arg, audit)
+}
+
+// SearchURLs mocks base method.
+func (m *MockStore) SearchURLs(ctx context.Context, arg db.SearchURLsParams) ([]db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchURLs", ctx, arg)
+	ret0, _ := ret[0].([]db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchURLs indicates an expected call of SearchURLs.
+func (mr *MockStoreMockRecorder) SearchURLs(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchURLs", reflect.TypeOf((*MockStore)(nil).SearchURLs), ctx, arg)
+}
+
+// SetURLArchived mocks base method.
+func (m *MockStore) SetURLArchived(ctx context.Context, arg db.SetURLArchivedParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetURLArchived", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetURLArchived indicates an expected call of SetURLArchived.
+func (mr *MockStoreMockRecorder) SetURLArchived(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetURLArchived", reflect.TypeOf((*MockStore)(nil).SetURLArchived), ctx, arg)
+}
+
+// SetURLArchivedTx mocks base method.
+func (m *MockStore) SetURLArchivedTx(ctx context.Context, arg db.SetURLArchivedParams, audit db.AuditInfo) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetURLArchivedTx", ctx, arg, audit)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetURLArchivedTx indicates an expected call of SetURLArchivedTx.
+func (mr *MockStoreMockRecorder) SetURLArchivedTx(ctx, arg, audit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetURLArchivedTx", reflect.TypeOf((*MockStore)(nil).SetURLArchivedTx), ctx, arg, audit)
+}
+
+// SoftDeleteURL mocks base method.
+func (m *MockStore) SoftDeleteURL(ctx context.Context, id int64) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SoftDeleteURL", ctx, id)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SoftDeleteURL indicates an expected call of SoftDeleteURL.
+func (mr *MockStoreMockRecorder) SoftDeleteURL(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftDeleteURL", reflect.TypeOf((*MockStore)(nil).SoftDeleteURL), ctx, id)
+}
+
+// SoftDeleteURLTx mocks base method.
+func (m *MockStore) SoftDeleteURLTx(ctx context.Context, id int64, audit db.AuditInfo) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SoftDeleteURLTx", ctx, id, audit)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SoftDeleteURLTx indicates an expected call of SoftDeleteURLTx.
+func (mr *MockStoreMockRecorder) SoftDeleteURLTx(ctx, id, audit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftDeleteURLTx", reflect.TypeOf((*MockStore)(nil).SoftDeleteURLTx), ctx, id, audit)
+}
+
+// UpdateURL mocks base method.
+func (m *MockStore) UpdateURL(ctx context.Context, arg db.UpdateURLParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateURL", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateURL indicates an expected call of UpdateURL.
+func (mr *MockStoreMockRecorder) UpdateURL(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateURL", reflect.TypeOf((*MockStore)(nil).UpdateURL), ctx, arg)
+}
+
+// UpdateURLCheck mocks base method.
+func (m *MockStore) UpdateURLCheck(ctx context.Context, arg db.UpdateURLCheckParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateURLCheck", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateURLCheck indicates an expected call of UpdateURLCheck.
+func (mr *MockStoreMockRecorder) UpdateURLCheck(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateURLCheck", reflect.TypeOf((*MockStore)(nil).UpdateURLCheck), ctx, arg)
+}
+
+// UpdateURLClickCount mocks base method.
+func (m *MockStore) UpdateURLClickCount(ctx context.Context, arg db.UpdateURLClickCountParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateURLClickCount", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateURLClickCount indicates an expected call of UpdateURLClickCount.
+func (mr *MockStoreMockRecorder) UpdateURLClickCount(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateURLClickCount", reflect.TypeOf((*MockStore)(nil).UpdateURLClickCount), ctx, arg)
+}
+
+// UpdateURLMetadata mocks base method.
+func (m *MockStore) UpdateURLMetadata(ctx context.Context, arg db.UpdateURLMetadataParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateURLMetadata", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateURLMetadata indicates an expected call of UpdateURLMetadata.
+func (mr *MockStoreMockRecorder) UpdateURLMetadata(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateURLMetadata", reflect.TypeOf((*MockStore)(nil).UpdateURLMetadata), ctx, arg)
+}
+
+// UpdateURLSettings mocks base method.
+func (m *MockStore) UpdateURLSettings(ctx context.Context, arg db.UpdateURLSettingsParams) (db.Url, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateURLSettings", ctx, arg)
+	ret0, _ := ret[0].(db.Url)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateURLSettings indicates an expected call of UpdateURLSettings.
+func (mr *MockStoreMockRecorder) UpdateURLSettings(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateURLSettings", reflect.TypeOf((*MockStore)(nil).UpdateURLSettings), ctx, arg)
+}
+
+// UpdateURLTx mocks base method.
+func (m *MockStore) UpdateURLTx(ctx context.Context, arg db.UpdateURLTxParams) (db.URLTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateURLTx", ctx, arg)
+	ret0, _ := ret[0].(db.URLTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateURLTx indicates an expected call of UpdateURLTx.
+func (mr *MockStoreMockRecorder) UpdateURLTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateURLTx", reflect.TypeOf((*MockStore)(nil).UpdateURLTx), ctx, arg)
+}
+
+// UpdateWebhookDelivery mocks base method.
+func (m *MockStore) UpdateWebhookDelivery(ctx context.Context, arg db.UpdateWebhookDeliveryParams) (db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookDelivery", ctx, arg)
+	ret0, _ := ret[0].(db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWebhookDelivery indicates an expected call of UpdateWebhookDelivery.
+func (mr *MockStoreMockRecorder) UpdateWebhookDelivery(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookDelivery", reflect.TypeOf((*MockStore)(nil).UpdateWebhookDelivery), ctx, arg)
+}
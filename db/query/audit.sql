@@ -0,0 +1,25 @@
+-- name: CreateAuditEvent :one
+INSERT INTO audit_events (
+  url_id,
+  domain_id,
+  short_url,
+  actor,
+  action,
+  before,
+  after,
+  request_id,
+  client_ip
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING *;
+
+-- name: ListAuditEvents :many
+SELECT * FROM audit_events
+WHERE domain_id = sqlc.arg(domain_id)
+  AND (sqlc.arg(short_url)::text = '' OR short_url = sqlc.arg(short_url)::text)
+  AND (sqlc.arg(actor)::text = '' OR actor = sqlc.arg(actor)::text)
+  AND (sqlc.narg(created_after)::timestamptz IS NULL OR created_at >= sqlc.narg(created_after)::timestamptz)
+  AND (sqlc.narg(created_before)::timestamptz IS NULL OR created_at < sqlc.narg(created_before)::timestamptz)
+ORDER BY id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
@@ -0,0 +1,28 @@
+-- name: CreateDomain :one
+INSERT INTO domains (
+  host,
+  fallback_url
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: GetDomainByHost :one
+SELECT * FROM domains
+WHERE host = $1 LIMIT 1;
+
+-- name: ListDomains :many
+SELECT * FROM domains
+WHERE id > 0
+ORDER BY id
+LIMIT $1
+OFFSET $2;
+
+-- name: CopyDomain :exec
+INSERT INTO domains (
+  id,
+  host,
+  fallback_url,
+  created_at
+) VALUES (
+  $1, $2, $3, $4
+) ON CONFLICT DO NOTHING;
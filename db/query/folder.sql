@@ -0,0 +1,25 @@
+-- name: CreateFolder :one
+INSERT INTO folders (
+  name
+) VALUES (
+  $1
+) RETURNING *;
+
+-- name: GetFolder :one
+SELECT * FROM folders
+WHERE id = $1 LIMIT 1;
+
+-- name: ListFolders :many
+SELECT * FROM folders
+ORDER BY name
+LIMIT $1
+OFFSET $2;
+
+-- name: CopyFolder :exec
+INSERT INTO folders (
+  id,
+  name,
+  created_at
+) VALUES (
+  $1, $2, $3
+) ON CONFLICT DO NOTHING;
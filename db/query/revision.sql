@@ -0,0 +1,33 @@
+-- name: CreateURLRevision :one
+INSERT INTO url_revisions (
+  url_id,
+  revision,
+  origin_url,
+  actor
+)
+SELECT sqlc.arg(url_id), COALESCE(MAX(revision), 0) + 1, sqlc.arg(origin_url), sqlc.arg(actor)
+FROM url_revisions
+WHERE url_id = sqlc.arg(url_id)
+RETURNING *;
+
+-- name: GetURLRevision :one
+SELECT * FROM url_revisions
+WHERE url_id = $1 AND revision = $2 LIMIT 1;
+
+-- name: ListURLRevisions :many
+SELECT * FROM url_revisions
+WHERE url_id = $1
+ORDER BY revision DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: ImportURLRevision :one
+INSERT INTO url_revisions (
+  url_id,
+  revision,
+  origin_url,
+  actor,
+  created_at
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
@@ -0,0 +1,21 @@
+-- name: AddURLTag :exec
+INSERT INTO url_tags (
+  url_id,
+  tag
+) VALUES (
+  $1, $2
+) ON CONFLICT DO NOTHING;
+
+-- name: DeleteURLTags :exec
+DELETE FROM url_tags
+WHERE url_id = $1;
+
+-- name: ListURLTags :many
+SELECT tag FROM url_tags
+WHERE url_id = $1
+ORDER BY tag;
+
+-- name: ListTagsByURLs :many
+SELECT * FROM url_tags
+WHERE url_id = ANY(sqlc.arg(url_ids)::bigint[])
+ORDER BY url_id, tag;
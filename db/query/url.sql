@@ -1,14 +1,41 @@
 -- name: CreateURL :one
 INSERT INTO urls (
   origin_url,
-  short_url
+  short_url,
+  redirect_type,
+  not_after,
+  domain_id,
+  password_hash,
+  not_before,
+  max_clicks,
+  rules,
+  variants,
+  query_mode,
+  utm,
+  is_prefix,
+  title,
+  description,
+  owner,
+  folder_id
 ) VALUES (
-  $1, $2
-) RETURNING *;
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
+) ON CONFLICT DO NOTHING
+RETURNING *;
 
 -- name: GetURL :one
 SELECT * FROM urls
-WHERE short_url = $1 LIMIT 1;
+WHERE domain_id = $1 AND short_url = $2 AND deleted_at IS NULL
+LIMIT 1;
+
+-- name: GetURLForUpdate :one
+SELECT * FROM urls
+WHERE id = $1 LIMIT 1
+FOR UPDATE;
+
+-- name: GetDeletedURL :one
+SELECT * FROM urls
+WHERE domain_id = $1 AND short_url = $2 AND deleted_at IS NOT NULL
+LIMIT 1;
 
 -- name: UpdateURL :one
 UPDATE urls
@@ -16,6 +43,180 @@ SET short_url = $2
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdateURLSettings :one
+UPDATE urls
+SET origin_url = $2,
+  redirect_type = $3,
+  rules = $4,
+  variants = $5,
+  query_mode = $6,
+  utm = $7,
+  is_prefix = $8,
+  title = $9,
+  description = $10,
+  folder_id = $11
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateURLMetadata :exec
+UPDATE urls
+SET page_title = $2,
+  page_description = $3,
+  favicon_url = $4,
+  og_image_url = $5,
+  metadata_fetched_at = now()
+WHERE id = $1;
+
+-- name: UpdateURLClickCount :exec
+UPDATE urls
+SET click_count = GREATEST(click_count, sqlc.arg(click_count)::bigint)
+WHERE id = $1;
+
+-- name: SearchURLs :many
+SELECT urls.* FROM urls
+LEFT JOIN url_search ON url_search.url_id = urls.id
+WHERE urls.domain_id = sqlc.arg(domain_id)
+  AND (sqlc.arg(query)::text = '' OR url_search.document @@ websearch_to_tsquery('simple', sqlc.arg(query)::text))
+  AND (sqlc.arg(tag)::text = '' OR EXISTS (
+    SELECT 1 FROM url_tags WHERE url_tags.url_id = urls.id AND url_tags.tag = sqlc.arg(tag)::text
+  ))
+  AND (sqlc.arg(owner)::text = '' OR urls.owner = sqlc.arg(owner)::text)
+  AND (sqlc.narg(folder_id)::bigint IS NULL OR urls.folder_id = sqlc.narg(folder_id)::bigint)
+  AND (sqlc.narg(created_after)::timestamptz IS NULL OR urls.created_at >= sqlc.narg(created_after)::timestamptz)
+  AND (sqlc.narg(created_before)::timestamptz IS NULL OR urls.created_at < sqlc.narg(created_before)::timestamptz)
+  AND (urls.deleted_at IS NULL) = (sqlc.arg(status)::text <> 'deleted')
+  AND (sqlc.arg(status)::text IN ('', 'deleted')
+    OR (sqlc.arg(status)::text = 'active'
+      AND urls.archived_at IS NULL
+      AND (urls.not_before IS NULL OR urls.not_before <= now())
+      AND (urls.not_after IS NULL OR urls.not_after > now())
+      AND (urls.max_clicks = 0 OR urls.click_count < urls.max_clicks))
+    OR (sqlc.arg(status)::text = 'scheduled' AND urls.not_before > now())
+    OR (sqlc.arg(status)::text = 'expired'
+      AND (urls.not_after <= now() OR (urls.max_clicks > 0 AND urls.click_count >= urls.max_clicks)))
+    OR (sqlc.arg(status)::text = 'broken' AND urls.broken_at IS NOT NULL)
+    OR (sqlc.arg(status)::text = 'archived' AND urls.archived_at IS NOT NULL))
+ORDER BY
+  CASE WHEN sqlc.arg(query)::text = '' THEN 0
+  ELSE ts_rank(url_search.document, websearch_to_tsquery('simple', sqlc.arg(query)::text)) END DESC,
+  urls.id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: ListURLsToCheck :many
+SELECT * FROM urls
+WHERE deleted_at IS NULL
+  AND archived_at IS NULL
+  AND (last_checked_at IS NULL OR last_checked_at < sqlc.arg(checked_before)::timestamptz)
+ORDER BY last_checked_at NULLS FIRST, id
+LIMIT sqlc.arg('limit');
+
+-- name: UpdateURLCheck :one
+UPDATE urls
+SET last_status_code = $2,
+  redirect_chain = $3,
+  check_error = $4,
+  last_checked_at = now(),
+  check_failures = CASE WHEN sqlc.arg(failed)::boolean THEN check_failures + 1 ELSE 0 END,
+  broken_at = CASE
+    WHEN NOT sqlc.arg(failed)::boolean THEN NULL
+    WHEN broken_at IS NULL AND check_failures + 1 >= sqlc.arg(failure_threshold)::integer THEN now()
+    ELSE broken_at
+  END
+WHERE id = $1
+RETURNING *;
+
+-- name: MarkExpiredURLs :many
+UPDATE urls
+SET expiry_notified_at = now()
+WHERE id IN (
+  SELECT id FROM urls
+  WHERE expiry_notified_at IS NULL
+    AND deleted_at IS NULL
+    AND (not_after <= now() OR (max_clicks > 0 AND click_count >= max_clicks))
+  ORDER BY id
+  LIMIT $1
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: SetURLArchived :one
+UPDATE urls
+SET archived_at = CASE WHEN sqlc.arg(archived)::boolean THEN COALESCE(archived_at, now()) ELSE NULL END
+WHERE id = $1
+RETURNING *;
+
+-- name: SoftDeleteURL :one
+UPDATE urls
+SET deleted_at = now()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: RestoreURL :one
+UPDATE urls
+SET deleted_at = NULL
+WHERE id = $1 AND deleted_at > sqlc.arg(deleted_after)::timestamptz
+RETURNING *;
+
+-- name: PurgeDeletedURLs :many
+DELETE FROM urls
+WHERE id IN (
+  SELECT id FROM urls
+  WHERE deleted_at < sqlc.arg(deleted_before)::timestamptz
+  ORDER BY id
+  LIMIT sqlc.arg('limit')
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
 -- name: DeleteURL :exec
 DELETE FROM urls
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: ImportURL :one
+INSERT INTO urls (
+  origin_url,
+  short_url,
+  created_at,
+  redirect_type,
+  not_after,
+  domain_id,
+  password_hash,
+  not_before,
+  max_clicks,
+  click_count,
+  rules,
+  variants,
+  query_mode,
+  utm,
+  is_prefix,
+  title,
+  description,
+  owner,
+  folder_id,
+  page_title,
+  page_description,
+  favicon_url,
+  og_image_url,
+  metadata_fetched_at,
+  last_status_code,
+  redirect_chain,
+  check_error,
+  check_failures,
+  last_checked_at,
+  broken_at,
+  expiry_notified_at,
+  archived_at,
+  deleted_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11,
+  $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22,
+  $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33
+) ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: ListURLs :many
+SELECT * FROM urls
+WHERE id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,79 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (
+  url,
+  secret,
+  events
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: GetWebhook :one
+SELECT * FROM webhooks
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhooks :many
+SELECT * FROM webhooks
+ORDER BY id
+LIMIT $1
+OFFSET $2;
+
+-- name: ListWebhooksForEvent :many
+SELECT * FROM webhooks
+WHERE sqlc.arg(event)::text = ANY(events)
+ORDER BY id;
+
+-- name: DeleteWebhook :exec
+DELETE FROM webhooks
+WHERE id = $1;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (
+  webhook_id,
+  event,
+  payload
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: ClaimWebhookDeliveries :many
+UPDATE webhook_deliveries
+SET next_attempt_at = sqlc.arg(lease_until)::timestamptz
+WHERE id IN (
+  SELECT id FROM webhook_deliveries
+  WHERE status = 'pending' AND next_attempt_at <= now()
+  ORDER BY next_attempt_at
+  LIMIT sqlc.arg('limit')
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: UpdateWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = $2,
+  attempts = $3,
+  next_attempt_at = $4,
+  last_status_code = $5,
+  last_error = $6,
+  delivered_at = $7
+WHERE id = $1
+RETURNING *;
+
+-- name: RedeliverWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = 'pending',
+  attempts = 0,
+  next_attempt_at = now(),
+  last_error = ''
+WHERE id = $1
+RETURNING *;
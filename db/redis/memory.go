@@ -0,0 +1,448 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	db "shortURL/db/sqlc"
+)
+
+// MemoryQueries implements RedisQuerier in process memory for tests and single instance deployments.
+// The Bloom filter is an exact set, so it never reports false positives.
+type MemoryQueries struct {
+	mu            sync.Mutex
+	bloom         map[string]bool
+	urls          map[string]memoryEntry
+	clicks        map[string]int64
+	variantClicks map[string]map[string]int64
+	dirtyClicks   map[string]int64
+	qrCodes       map[string]memoryQRCodes
+	unlockTries   map[string]memoryCounter
+	queues        map[string][][]byte
+	pendingURLs   map[string]memoryPending
+	pendingData   map[string][]byte
+	failedURLs    map[string]bool
+	// pushed 在放入工作時關閉，喚醒等待中的 PopTask
+	pushed chan struct{}
+}
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+type memoryQRCodes struct {
+	images    map[string][]byte
+	expiresAt time.Time
+}
+
+type memoryPending struct {
+	due      time.Time
+	attempts int64
+}
+
+type memoryCounter struct {
+	count     int64
+	expiresAt time.Time
+}
+
+var _ RedisQuerier = (*MemoryQueries)(nil)
+
+func NewMemoryQuery() *MemoryQueries {
+	return &MemoryQueries{
+		bloom:         make(map[string]bool),
+		urls:          make(map[string]memoryEntry),
+		clicks:        make(map[string]int64),
+		variantClicks: make(map[string]map[string]int64),
+		dirtyClicks:   make(map[string]int64),
+		qrCodes:       make(map[string]memoryQRCodes),
+		unlockTries:   make(map[string]memoryCounter),
+		queues:        make(map[string][][]byte),
+		pendingURLs:   make(map[string]memoryPending),
+		pendingData:   make(map[string][]byte),
+		failedURLs:    make(map[string]bool),
+		pushed:        make(chan struct{}),
+	}
+}
+
+func expired(expiresAt time.Time) bool {
+	return !expiresAt.IsZero() && !time.Now().Before(expiresAt)
+}
+
+// memoryTTL 與 redis 的 PTTL 相同，不存在時為 -2，沒有期限時為 -1
+func memoryTTL(ok bool, expiresAt time.Time) time.Duration {
+	switch {
+	case !ok || expired(expiresAt):
+		return -2
+	case expiresAt.IsZero():
+		return -1
+	}
+	return time.Until(expiresAt)
+}
+
+// 放置進布隆過濾器， true 代表過濾器內無相同資料且成功放入資料
+func (m *MemoryQueries) SetBloom(ctx context.Context, shortUrl string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.bloom[shortUrl] {
+		return false, nil
+	}
+
+	m.bloom[shortUrl] = true
+	return true, nil
+}
+
+// 檢查過濾器內是否有資料
+func (m *MemoryQueries) ExistBloom(ctx context.Context, shortUrl string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.bloom[shortUrl], nil
+}
+
+// 設置資料
+func (m *MemoryQueries) SetData(ctx context.Context, shortUrl string, url db.Url) error {
+	urlByte, err := json.Marshal(url)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.urls[shortUrl] = memoryEntry{value: urlByte, expiresAt: time.Now().Add(CacheTTL)}
+	return nil
+}
+
+// DataTTL returns how long the cached link of shortUrl is kept, negative when it never expires or is not cached.
+func (m *MemoryQueries) DataTTL(ctx context.Context, shortUrl string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.urls[shortUrl]
+	return memoryTTL(ok, entry.expiresAt), nil
+}
+
+// 取出資料
+func (m *MemoryQueries) GetData(ctx context.Context, shortUrl string) (db.Url, bool, error) {
+	m.mu.Lock()
+	entry, ok := m.urls[shortUrl]
+	if ok && expired(entry.expiresAt) {
+		delete(m.urls, shortUrl)
+		ok = false
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		return db.Url{}, false, nil
+	}
+
+	url := db.Url{}
+
+	err := json.Unmarshal(entry.value, &url)
+	if err != nil {
+		return db.Url{}, false, err
+	}
+
+	return url, true, nil
+}
+
+// 刪除資料，連結更新後讓快取失效
+func (m *MemoryQueries) DelData(ctx context.Context, shortUrl string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.urls, shortUrl)
+	return nil
+}
+
+// 點擊數加一，計數器不存在時以資料庫的點擊數為起始值
+func (m *MemoryQueries) IncrClick(ctx context.Context, shortUrl string, url db.Url) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count, ok := m.clicks[shortUrl]
+	if !ok {
+		count = url.ClickCount
+	}
+	count++
+
+	m.clicks[shortUrl] = count
+	m.dirtyClicks[shortUrl] = url.ID
+	return count, nil
+}
+
+// 取得累計點擊數
+func (m *MemoryQueries) GetClick(ctx context.Context, shortUrl string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.clicks[shortUrl], nil
+}
+
+// 各分流版本的點擊數加一
+func (m *MemoryQueries) IncrVariantClick(ctx context.Context, shortUrl string, variant string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.variantClicks[shortUrl] == nil {
+		m.variantClicks[shortUrl] = make(map[string]int64)
+	}
+	m.variantClicks[shortUrl][variant]++
+	return nil
+}
+
+// 取得各分流版本的點擊數
+func (m *MemoryQueries) GetVariantClicks(ctx context.Context, shortUrl string) (map[string]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	clicks := make(map[string]int64, len(m.variantClicks[shortUrl]))
+	for variant, count := range m.variantClicks[shortUrl] {
+		clicks[variant] = count
+	}
+
+	return clicks, nil
+}
+
+// 標記點擊數待寫回資料庫
+func (m *MemoryQueries) MarkDirtyClick(ctx context.Context, shortUrl string, urlID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.dirtyClicks[shortUrl] = urlID
+	return nil
+}
+
+// 取出所有待寫回資料庫的點擊數，key 為短網址，value 為連結 ID
+func (m *MemoryQueries) PopDirtyClicks(ctx context.Context) (map[string]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dirty := m.dirtyClicks
+	m.dirtyClicks = make(map[string]int64)
+	return dirty, nil
+}
+
+// 設置 QR code 圖檔，與連結資料的快取時間相同
+func (m *MemoryQueries) SetQRCode(ctx context.Context, shortUrl string, key string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	codes, ok := m.qrCodes[shortUrl]
+	if !ok || expired(codes.expiresAt) {
+		codes = memoryQRCodes{images: make(map[string][]byte)}
+	}
+
+	codes.images[key] = append([]byte(nil), data...)
+	codes.expiresAt = time.Now().Add(CacheTTL)
+	m.qrCodes[shortUrl] = codes
+	return nil
+}
+
+// QRCodeTTL returns how long the cached QR codes of shortUrl are kept, negative when they never expire or are not cached.
+func (m *MemoryQueries) QRCodeTTL(ctx context.Context, shortUrl string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	codes, ok := m.qrCodes[shortUrl]
+	return memoryTTL(ok, codes.expiresAt), nil
+}
+
+// 取出 QR code 圖檔
+func (m *MemoryQueries) GetQRCode(ctx context.Context, shortUrl string, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	codes, ok := m.qrCodes[shortUrl]
+	if !ok || expired(codes.expiresAt) {
+		return nil, false, nil
+	}
+
+	data, ok := codes.images[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	return append([]byte(nil), data...), true, nil
+}
+
+// 解鎖嘗試次數加一，回傳時間窗內的累計次數
+func (m *MemoryQueries) IncrUnlockAttempt(ctx context.Context, key string, window time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counter, ok := m.unlockTries[key]
+	if !ok || expired(counter.expiresAt) {
+		// 第一次嘗試時開始計算時間窗
+		counter = memoryCounter{expiresAt: time.Now().Add(window)}
+	}
+
+	counter.count++
+	m.unlockTries[key] = counter
+	return counter.count, nil
+}
+
+// 清除解鎖嘗試次數
+func (m *MemoryQueries) DelUnlockAttempt(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.unlockTries, key)
+	return nil
+}
+
+// 放入工作佇列
+func (m *MemoryQueries) PushTask(ctx context.Context, queue string, payload []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.queues[queue] = append(m.queues[queue], append([]byte(nil), payload...))
+
+	close(m.pushed)
+	m.pushed = make(chan struct{})
+	return nil
+}
+
+// 從工作佇列取出工作，佇列皆為空時最多等待 timeout，timeout 為 0 時一直等待
+func (m *MemoryQueries) PopTask(ctx context.Context, timeout time.Duration, queues ...string) (Task, bool, error) {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	for {
+		m.mu.Lock()
+		// 依序檢查佇列，先放入的工作先取出
+		for _, queue := range queues {
+			if tasks := m.queues[queue]; len(tasks) > 0 {
+				m.queues[queue] = tasks[1:]
+				m.mu.Unlock()
+				return Task{Queue: queue, Payload: tasks[0]}, true, nil
+			}
+		}
+		pushed := m.pushed
+		m.mu.Unlock()
+
+		select {
+		case <-pushed:
+		case <-deadline:
+			return Task{}, false, nil
+		case <-ctx.Done():
+			return Task{}, false, ctx.Err()
+		}
+	}
+}
+
+// 保留短網址、排入待寫入的佇列並快取連結，短網址已在佇列中時回傳 false
+func (m *MemoryQueries) EnqueuePendingURL(ctx context.Context, shortUrl string, payload []byte, url db.Url) (bool, error) {
+	urlByte, err := json.Marshal(url)
+	if err != nil {
+		return false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.pendingData[shortUrl]; ok {
+		return false, nil
+	}
+
+	m.pendingData[shortUrl] = append([]byte(nil), payload...)
+	m.pendingURLs[shortUrl] = memoryPending{due: time.Now()}
+	m.urls[shortUrl] = memoryEntry{value: urlByte, expiresAt: time.Now().Add(CacheTTL)}
+	return true, nil
+}
+
+// 取得尚未寫入資料庫的連結內容，寫入失敗的連結仍然保留
+func (m *MemoryQueries) GetPendingURL(ctx context.Context, shortUrl string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	payload, ok := m.pendingData[shortUrl]
+	if !ok {
+		return nil, false, nil
+	}
+
+	return append([]byte(nil), payload...), true, nil
+}
+
+// 依排入順序取出最多 limit 個待寫入的連結，lease 內不會再被取出
+func (m *MemoryQueries) ClaimPendingURLs(ctx context.Context, limit int64, lease time.Duration) ([]PendingURL, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var due []string
+	for shortUrl, pending := range m.pendingURLs {
+		if !pending.due.After(now) {
+			due = append(due, shortUrl)
+		}
+	}
+
+	// 與 sorted set 相同，時間相同時依短網址排序
+	sort.Slice(due, func(i, j int) bool {
+		a, b := m.pendingURLs[due[i]], m.pendingURLs[due[j]]
+		if !a.due.Equal(b.due) {
+			return a.due.Before(b.due)
+		}
+		return due[i] < due[j]
+	})
+	if int64(len(due)) > limit {
+		due = due[:limit]
+	}
+
+	urls := make([]PendingURL, 0, len(due))
+	for _, shortUrl := range due {
+		pending := m.pendingURLs[shortUrl]
+		pending.due = now.Add(lease)
+		pending.attempts++
+		m.pendingURLs[shortUrl] = pending
+
+		urls = append(urls, PendingURL{
+			ShortUrl: shortUrl,
+			Payload:  append([]byte(nil), m.pendingData[shortUrl]...),
+			Attempts: pending.attempts,
+		})
+	}
+
+	return urls, nil
+}
+
+// 確認連結已寫入資料庫，移出佇列並刪除內容
+func (m *MemoryQueries) AckPendingURLs(ctx context.Context, shortUrls ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, shortUrl := range shortUrls {
+		delete(m.pendingURLs, shortUrl)
+		delete(m.pendingData, shortUrl)
+		delete(m.failedURLs, shortUrl)
+	}
+
+	return nil
+}
+
+// 放棄寫入連結，移到寫入失敗的集合並保留內容供人工處理
+func (m *MemoryQueries) FailPendingURL(ctx context.Context, shortUrl string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.pendingURLs, shortUrl)
+	m.failedURLs[shortUrl] = true
+	return nil
+}
+
+// 計算待寫入與寫入失敗的連結數
+func (m *MemoryQueries) CountPendingURLs(ctx context.Context) (int64, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return int64(len(m.pendingURLs)), int64(len(m.failedURLs)), nil
+}
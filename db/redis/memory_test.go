@@ -0,0 +1,14 @@
+package redis_test
+
+import (
+	"testing"
+
+	"shortURL/db/dbtest"
+	"shortURL/db/redis"
+)
+
+func TestMemoryQuerierSuite(t *testing.T) {
+	dbtest.RunRedisQuerierSuite(t, func(t *testing.T) redis.RedisQuerier {
+		return redis.NewMemoryQuery()
+	})
+}
@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"time"
 
 	db "shortURL/db/sqlc"
 )
@@ -11,6 +12,39 @@ type RedisQuerier interface {
 	ExistBloom(ctx context.Context, shortUrl string) (bool, error)
 	SetData(ctx context.Context, shortUrl string, url db.Url) error
 	GetData(ctx context.Context, shortUrl string) (db.Url, bool, error)
+	DelData(ctx context.Context, shortUrl string) error
+	IncrClick(ctx context.Context, shortUrl string, url db.Url) (int64, error)
+	GetClick(ctx context.Context, shortUrl string) (int64, error)
+	IncrVariantClick(ctx context.Context, shortUrl string, variant string) error
+	GetVariantClicks(ctx context.Context, shortUrl string) (map[string]int64, error)
+	MarkDirtyClick(ctx context.Context, shortUrl string, urlID int64) error
+	PopDirtyClicks(ctx context.Context) (map[string]int64, error)
+	SetQRCode(ctx context.Context, shortUrl string, key string, data []byte) error
+	GetQRCode(ctx context.Context, shortUrl string, key string) ([]byte, bool, error)
+	IncrUnlockAttempt(ctx context.Context, key string, window time.Duration) (int64, error)
+	DelUnlockAttempt(ctx context.Context, key string) error
+	PushTask(ctx context.Context, queue string, payload []byte) error
+	PopTask(ctx context.Context, timeout time.Duration, queues ...string) (Task, bool, error)
+	EnqueuePendingURL(ctx context.Context, shortUrl string, payload []byte, url db.Url) (bool, error)
+	GetPendingURL(ctx context.Context, shortUrl string) ([]byte, bool, error)
+	ClaimPendingURLs(ctx context.Context, limit int64, lease time.Duration) ([]PendingURL, error)
+	AckPendingURLs(ctx context.Context, shortUrls ...string) error
+	FailPendingURL(ctx context.Context, shortUrl string) error
+	CountPendingURLs(ctx context.Context) (pending int64, failed int64, err error)
+}
+
+// Task is a background task taken from a queue.
+type Task struct {
+	Queue   string
+	Payload []byte
+}
+
+// PendingURL is a link created in write-behind mode which is not written to the database yet.
+type PendingURL struct {
+	ShortUrl string
+	Payload  []byte
+	// Attempts counts the claims of the link, including this one.
+	Attempts int64
 }
 
 var _ RedisQuerier = (*RedisQueries)(nil)
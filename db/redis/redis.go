@@ -3,6 +3,8 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"strconv"
+	"strings"
 	"time"
 
 	db "shortURL/db/sqlc"
@@ -10,17 +12,31 @@ import (
 	redis "github.com/go-redis/redis/v8"
 )
 
+// CacheTTL is how long a cached link and its QR codes are kept.
+const CacheTTL = time.Hour
+
 func NewRedisQuery(client *redis.Client) *RedisQueries {
 	return &RedisQueries{client: client}
 }
 
+// NewRedisQueryWithPrefix creates a querier whose keys and queues all start with prefix,
+// so several queriers, such as tests, can share a Redis server without seeing each other's data.
+func NewRedisQueryWithPrefix(client *redis.Client, prefix string) *RedisQueries {
+	return &RedisQueries{client: client, prefix: prefix}
+}
+
 type RedisQueries struct {
 	client *redis.Client
+	prefix string
+}
+
+func (r *RedisQueries) key(name string) string {
+	return r.prefix + name
 }
 
 // 放置進布隆過濾器， true 代表過濾器內無相同資料且成功放入資料
 func (q *RedisQueries) SetBloom(ctx context.Context, shortUrl string) (bool, error) {
-	ret, err := q.client.Do(ctx, "BF.ADD", "url-filter", shortUrl).Result()
+	ret, err := q.client.Do(ctx, "BF.ADD", q.key("url-filter"), shortUrl).Result()
 	if err != nil {
 		return false, err
 	}
@@ -37,7 +53,7 @@ func (q *RedisQueries) SetBloom(ctx context.Context, shortUrl string) (bool, err
 
 // 檢查過濾器內是否有資料
 func (r *RedisQueries) ExistBloom(ctx context.Context, shortUrl string) (bool, error) {
-	ret, err := r.client.Do(ctx, "bf.exists", "url-filter", shortUrl).Result()
+	ret, err := r.client.Do(ctx, "bf.exists", r.key("url-filter"), shortUrl).Result()
 	if err != nil {
 		return false, err
 	}
@@ -58,7 +74,7 @@ func (r *RedisQueries) SetData(ctx context.Context, shortUrl string, url db.Url)
 		return err
 	}
 
-	_, err = r.client.Set(ctx, shortUrl, urlByte, time.Hour).Result()
+	_, err = r.client.Set(ctx, r.key(shortUrl), urlByte, CacheTTL).Result()
 	if err != nil {
 		return err
 	}
@@ -68,7 +84,7 @@ func (r *RedisQueries) SetData(ctx context.Context, shortUrl string, url db.Url)
 
 // 取出資料
 func (r *RedisQueries) GetData(ctx context.Context, shortUrl string) (db.Url, bool, error) {
-	ret, err := r.client.Get(ctx, shortUrl).Result()
+	ret, err := r.client.Get(ctx, r.key(shortUrl)).Result()
 	if err != nil {
 		if err == redis.Nil {
 			return db.Url{}, false, nil
@@ -85,3 +101,324 @@ func (r *RedisQueries) GetData(ctx context.Context, shortUrl string) (db.Url, bo
 
 	return url, true, nil
 }
+
+// 刪除資料，連結更新後讓快取失效
+func (r *RedisQueries) DelData(ctx context.Context, shortUrl string) error {
+	return r.client.Del(ctx, r.key(shortUrl)).Err()
+}
+
+// DataTTL returns how long the cached link of shortUrl is kept, negative when it never expires or is not cached.
+func (r *RedisQueries) DataTTL(ctx context.Context, shortUrl string) (time.Duration, error) {
+	return r.client.PTTL(ctx, r.key(shortUrl)).Result()
+}
+
+// 計數器不存在時以資料庫的點擊數為起始值，加一後標記為待寫回資料庫
+var incrClickScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+  redis.call("SET", KEYS[1], ARGV[1])
+end
+local count = redis.call("INCR", KEYS[1])
+redis.call("HSET", KEYS[2], ARGV[2], ARGV[3])
+return count
+`)
+
+// 取出並清空待寫回的點擊數
+var popDirtyClicksScript = redis.NewScript(`
+local ret = redis.call("HGETALL", KEYS[1])
+redis.call("DEL", KEYS[1])
+return ret
+`)
+
+// 點擊數加一，回傳累計點擊數
+func (r *RedisQueries) IncrClick(ctx context.Context, shortUrl string, url db.Url) (int64, error) {
+	keys := []string{r.key(clickKey(shortUrl)), r.key(dirtyClicksKey)}
+	return incrClickScript.Run(ctx, r.client, keys, url.ClickCount, shortUrl, url.ID).Int64()
+}
+
+// 標記點擊數待寫回資料庫
+func (r *RedisQueries) MarkDirtyClick(ctx context.Context, shortUrl string, urlID int64) error {
+	return r.client.HSet(ctx, r.key(dirtyClicksKey), shortUrl, urlID).Err()
+}
+
+// 取出所有待寫回資料庫的點擊數，key 為短網址，value 為連結 ID
+func (r *RedisQueries) PopDirtyClicks(ctx context.Context) (map[string]int64, error) {
+	ret, err := popDirtyClicksScript.Run(ctx, r.client, []string{r.key(dirtyClicksKey)}).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	dirty := make(map[string]int64, len(ret)/2)
+	for i := 0; i+1 < len(ret); i += 2 {
+		urlID, err := strconv.ParseInt(ret[i+1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		dirty[ret[i]] = urlID
+	}
+
+	return dirty, nil
+}
+
+// 取得累計點擊數
+func (r *RedisQueries) GetClick(ctx context.Context, shortUrl string) (int64, error) {
+	ret, err := r.client.Get(ctx, r.key(clickKey(shortUrl))).Int64()
+	if err != nil {
+		if err == redis.Nil {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return ret, nil
+}
+
+// 各分流版本的點擊數加一
+func (r *RedisQueries) IncrVariantClick(ctx context.Context, shortUrl string, variant string) error {
+	return r.client.HIncrBy(ctx, r.key(variantClickKey(shortUrl)), variant, 1).Err()
+}
+
+// 取得各分流版本的點擊數
+func (r *RedisQueries) GetVariantClicks(ctx context.Context, shortUrl string) (map[string]int64, error) {
+	ret, err := r.client.HGetAll(ctx, r.key(variantClickKey(shortUrl))).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	clicks := make(map[string]int64, len(ret))
+	for variant, value := range ret {
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		clicks[variant] = count
+	}
+
+	return clicks, nil
+}
+
+func variantClickKey(shortUrl string) string {
+	return "variant-click:" + shortUrl
+}
+
+// 待寫回資料庫的點擊數
+const dirtyClicksKey = "click-dirty"
+
+func clickKey(shortUrl string) string {
+	return "click:" + shortUrl
+}
+
+// 設置 QR code 圖檔，與連結資料的快取時間相同
+func (r *RedisQueries) SetQRCode(ctx context.Context, shortUrl string, key string, data []byte) error {
+	pipe := r.client.TxPipeline()
+	pipe.HSet(ctx, r.key(qrCodeKey(shortUrl)), key, data)
+	pipe.Expire(ctx, r.key(qrCodeKey(shortUrl)), CacheTTL)
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// QRCodeTTL returns how long the cached QR codes of shortUrl are kept, negative when they never expire or are not cached.
+func (r *RedisQueries) QRCodeTTL(ctx context.Context, shortUrl string) (time.Duration, error) {
+	return r.client.PTTL(ctx, r.key(qrCodeKey(shortUrl))).Result()
+}
+
+// 取出 QR code 圖檔
+func (r *RedisQueries) GetQRCode(ctx context.Context, shortUrl string, key string) ([]byte, bool, error) {
+	ret, err := r.client.HGet(ctx, r.key(qrCodeKey(shortUrl)), key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	return ret, true, nil
+}
+
+func qrCodeKey(shortUrl string) string {
+	return "qr:" + shortUrl
+}
+
+// 加一與設定時間窗在同一個腳本內完成，避免 INCR 後 EXPIRE 失敗留下永不過期的計數器
+// 計數器沒有過期時間時（例如舊版留下的）也會補上時間窗
+var incrUnlockAttemptScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if redis.call("PTTL", KEYS[1]) < 0 then
+  redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// 解鎖嘗試次數加一，回傳時間窗內的累計次數
+func (r *RedisQueries) IncrUnlockAttempt(ctx context.Context, key string, window time.Duration) (int64, error) {
+	return incrUnlockAttemptScript.Run(ctx, r.client, []string{r.key(unlockAttemptKey(key))}, window.Milliseconds()).Int64()
+}
+
+// 清除解鎖嘗試次數
+func (r *RedisQueries) DelUnlockAttempt(ctx context.Context, key string) error {
+	return r.client.Del(ctx, r.key(unlockAttemptKey(key))).Err()
+}
+
+func unlockAttemptKey(key string) string {
+	return "unlock-attempt:" + key
+}
+
+// 放入工作佇列
+func (r *RedisQueries) PushTask(ctx context.Context, queue string, payload []byte) error {
+	return r.client.LPush(ctx, r.key(queue), payload).Err()
+}
+
+// 從工作佇列取出工作，佇列皆為空時最多等待 timeout
+func (r *RedisQueries) PopTask(ctx context.Context, timeout time.Duration, queues ...string) (Task, bool, error) {
+	keys := make([]string, len(queues))
+	for i, queue := range queues {
+		keys[i] = r.key(queue)
+	}
+
+	ret, err := r.client.BRPop(ctx, timeout, keys...).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return Task{}, false, nil
+		}
+		return Task{}, false, err
+	}
+
+	// 回傳值依序為佇列名稱與內容
+	return Task{Queue: strings.TrimPrefix(ret[0], r.prefix), Payload: []byte(ret[1])}, true, nil
+}
+
+// 待寫入資料庫的連結，pendingURLsKey 依下次可取出的時間排序，內容以短網址為欄位存在 pendingPayloadsKey
+// 腳本用到的 key 都由 KEYS 傳入，不在腳本內組出 key
+const (
+	pendingURLsKey     = "url-pending"
+	pendingPayloadsKey = "url-pending-payloads"
+	pendingAttemptsKey = "url-pending-attempts"
+	pendingFailedKey   = "url-pending-failed"
+)
+
+// 短網址尚未保留時寫入內容、排入待寫入的佇列並快取連結，避免排入後沒有快取可以轉址
+var enqueuePendingURLScript = redis.NewScript(`
+if redis.call("HSETNX", KEYS[1], ARGV[3], ARGV[1]) == 0 then
+  return 0
+end
+redis.call("ZADD", KEYS[2], ARGV[2], ARGV[3])
+redis.call("SET", KEYS[3], ARGV[4], "PX", ARGV[5])
+return 1
+`)
+
+// 取出到期的連結並延後到租約結束，租約結束前沒有確認寫入的連結會再被取出
+var claimPendingURLsScript = redis.NewScript(`
+local members = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, ARGV[2])
+local ret = {}
+for _, member in ipairs(members) do
+  local payload = redis.call("HGET", KEYS[3], member)
+  if payload then
+    redis.call("ZADD", KEYS[1], ARGV[3], member)
+    local attempts = redis.call("HINCRBY", KEYS[2], member, 1)
+    table.insert(ret, member)
+    table.insert(ret, payload)
+    table.insert(ret, tostring(attempts))
+  else
+    redis.call("ZREM", KEYS[1], member)
+    redis.call("HDEL", KEYS[2], member)
+  end
+end
+return ret
+`)
+
+// 保留短網址、排入待寫入的佇列並快取連結，短網址已在佇列中時回傳 false
+func (r *RedisQueries) EnqueuePendingURL(ctx context.Context, shortUrl string, payload []byte, url db.Url) (bool, error) {
+	urlByte, err := json.Marshal(url)
+	if err != nil {
+		return false, err
+	}
+
+	keys := []string{r.key(pendingPayloadsKey), r.key(pendingURLsKey), r.key(shortUrl)}
+	ret, err := enqueuePendingURLScript.Run(ctx, r.client, keys, payload, time.Now().UnixMilli(), shortUrl, urlByte, CacheTTL.Milliseconds()).Int64()
+	if err != nil {
+		return false, err
+	}
+
+	return ret == 1, nil
+}
+
+// 取得尚未寫入資料庫的連結內容，寫入失敗的連結仍然保留
+func (r *RedisQueries) GetPendingURL(ctx context.Context, shortUrl string) ([]byte, bool, error) {
+	ret, err := r.client.HGet(ctx, r.key(pendingPayloadsKey), shortUrl).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	return ret, true, nil
+}
+
+// 依排入順序取出最多 limit 個待寫入的連結，lease 內不會再被取出
+func (r *RedisQueries) ClaimPendingURLs(ctx context.Context, limit int64, lease time.Duration) ([]PendingURL, error) {
+	now := time.Now()
+	keys := []string{r.key(pendingURLsKey), r.key(pendingAttemptsKey), r.key(pendingPayloadsKey)}
+	ret, err := claimPendingURLsScript.Run(ctx, r.client, keys, now.UnixMilli(), limit, now.Add(lease).UnixMilli()).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	// 回傳值依序為短網址、內容與取出次數
+	urls := make([]PendingURL, 0, len(ret)/3)
+	for i := 0; i+2 < len(ret); i += 3 {
+		attempts, err := strconv.ParseInt(ret[i+2], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		urls = append(urls, PendingURL{ShortUrl: ret[i], Payload: []byte(ret[i+1]), Attempts: attempts})
+	}
+
+	return urls, nil
+}
+
+// 確認連結已寫入資料庫，移出佇列並刪除內容
+func (r *RedisQueries) AckPendingURLs(ctx context.Context, shortUrls ...string) error {
+	if len(shortUrls) == 0 {
+		return nil
+	}
+
+	members := make([]interface{}, len(shortUrls))
+	for i, shortUrl := range shortUrls {
+		members[i] = shortUrl
+	}
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, r.key(pendingURLsKey), members...)
+		pipe.ZRem(ctx, r.key(pendingFailedKey), members...)
+		pipe.HDel(ctx, r.key(pendingAttemptsKey), shortUrls...)
+		pipe.HDel(ctx, r.key(pendingPayloadsKey), shortUrls...)
+		return nil
+	})
+	return err
+}
+
+// 放棄寫入連結，移到寫入失敗的集合並保留內容供人工處理
+func (r *RedisQueries) FailPendingURL(ctx context.Context, shortUrl string) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, r.key(pendingURLsKey), shortUrl)
+		pipe.HDel(ctx, r.key(pendingAttemptsKey), shortUrl)
+		pipe.ZAdd(ctx, r.key(pendingFailedKey), &redis.Z{Score: float64(time.Now().UnixMilli()), Member: shortUrl})
+		return nil
+	})
+	return err
+}
+
+// 計算待寫入與寫入失敗的連結數
+func (r *RedisQueries) CountPendingURLs(ctx context.Context) (int64, int64, error) {
+	pipe := r.client.Pipeline()
+	pending := pipe.ZCard(ctx, r.key(pendingURLsKey))
+	failed := pipe.ZCard(ctx, r.key(pendingFailedKey))
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return pending.Val(), failed.Val(), nil
+}
@@ -0,0 +1,43 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+
+	"shortURL/db/dbtest"
+	"shortURL/db/redis"
+	"shortURL/util"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisQuerierSuite(t *testing.T) {
+	config, err := util.LoadConfig("../..")
+	require.NoError(t, err)
+
+	client := goredis.NewClient(&goredis.Options{
+		Addr: config.RedisAddress,
+	})
+	defer client.Close()
+
+	// 每個測試使用自己的前綴，不會取走共用 Redis 上其他程式的點擊數與待寫入連結
+	dbtest.RunRedisQuerierSuite(t, func(t *testing.T) redis.RedisQuerier {
+		prefix := "test:" + util.RandomString(12) + ":"
+		t.Cleanup(func() {
+			deleteKeys(t, client, prefix)
+		})
+
+		return redis.NewRedisQueryWithPrefix(client, prefix)
+	})
+}
+
+func deleteKeys(t *testing.T, client *goredis.Client, prefix string) {
+	ctx := context.Background()
+
+	iter := client.Scan(ctx, 0, prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		require.NoError(t, client.Del(ctx, iter.Val()).Err())
+	}
+	require.NoError(t, iter.Err())
+}
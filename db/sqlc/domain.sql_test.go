@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"shortURL/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomDomain(t *testing.T) Domain {
+	arg := CreateDomainParams{
+		Host:        util.RandomLongURL(),
+		FallbackUrl: "https://" + util.RandomLongURL(),
+	}
+
+	domain, err := testQueries.CreateDomain(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, domain)
+
+	require.Equal(t, arg.Host, domain.Host)
+	require.Equal(t, arg.FallbackUrl, domain.FallbackUrl)
+	require.NotZero(t, domain.ID)
+	require.NotZero(t, domain.CreatedAt)
+
+	return domain
+}
+
+func TestCreateDomain(t *testing.T) {
+	createRandomDomain(t)
+}
+
+func TestGetDomainByHost(t *testing.T) {
+	domain1 := createRandomDomain(t)
+	domain2, err := testQueries.GetDomainByHost(context.Background(), domain1.Host)
+	require.NoError(t, err)
+	require.NotEmpty(t, domain2)
+
+	require.Equal(t, domain1.ID, domain2.ID)
+	require.Equal(t, domain1.Host, domain2.Host)
+	require.Equal(t, domain1.FallbackUrl, domain2.FallbackUrl)
+	require.WithinDuration(t, domain1.CreatedAt, domain2.CreatedAt, time.Second)
+}
+
+func TestListDomains(t *testing.T) {
+	for i := 0; i < 5; i++ {
+		createRandomDomain(t)
+	}
+
+	arg := ListDomainsParams{
+		Limit:  5,
+		Offset: 0,
+	}
+
+	domains, err := testQueries.ListDomains(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, domains, 5)
+
+	for _, domain := range domains {
+		require.NotZero(t, domain.ID)
+		require.NotEmpty(t, domain.Host)
+	}
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"shortURL/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomFolder(t *testing.T) Folder {
+	name := util.RandomString(12)
+
+	folder, err := testQueries.CreateFolder(context.Background(), name)
+	require.NoError(t, err)
+	require.NotEmpty(t, folder)
+
+	require.Equal(t, name, folder.Name)
+	require.NotZero(t, folder.ID)
+	require.NotZero(t, folder.CreatedAt)
+
+	return folder
+}
+
+func TestCreateFolder(t *testing.T) {
+	createRandomFolder(t)
+}
+
+func TestGetFolder(t *testing.T) {
+	folder1 := createRandomFolder(t)
+	folder2, err := testQueries.GetFolder(context.Background(), folder1.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, folder2)
+
+	require.Equal(t, folder1.ID, folder2.ID)
+	require.Equal(t, folder1.Name, folder2.Name)
+	require.WithinDuration(t, folder1.CreatedAt, folder2.CreatedAt, time.Second)
+}
+
+func TestListFolders(t *testing.T) {
+	for i := 0; i < 5; i++ {
+		createRandomFolder(t)
+	}
+
+	arg := ListFoldersParams{
+		Limit:  5,
+		Offset: 0,
+	}
+
+	folders, err := testQueries.ListFolders(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, folders, 5)
+
+	for _, folder := range folders {
+		require.NotZero(t, folder.ID)
+		require.NotEmpty(t, folder.Name)
+	}
+}
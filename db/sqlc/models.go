@@ -5,12 +5,118 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
 )
 
-type Url struct {
+type AuditEvent struct {
+	ID        int64           `json:"id"`
+	UrlID     int64           `json:"url_id"`
+	DomainID  int64           `json:"domain_id"`
+	ShortUrl  string          `json:"short_url"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	RequestID string          `json:"request_id"`
+	ClientIp  string          `json:"client_ip"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type Domain struct {
+	ID          int64     `json:"id"`
+	Host        string    `json:"host"`
+	FallbackUrl string    `json:"fallback_url"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type Folder struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type UrlRevision struct {
 	ID        int64     `json:"id"`
+	UrlID     int64     `json:"url_id"`
+	Revision  int32     `json:"revision"`
 	OriginUrl string    `json:"origin_url"`
-	ShortUrl  string    `json:"short_url"`
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type UrlSearch struct {
+	UrlID    int64       `json:"url_id"`
+	Document interface{} `json:"document"`
+}
+
+type UrlSlug struct {
+	DomainID int64  `json:"domain_id"`
+	ShortUrl string `json:"short_url"`
+	UrlID    int64  `json:"url_id"`
+}
+
+type UrlTag struct {
+	UrlID int64  `json:"url_id"`
+	Tag   string `json:"tag"`
+}
+
+type Url struct {
+	ID                int64           `json:"id"`
+	OriginUrl         string          `json:"origin_url"`
+	ShortUrl          string          `json:"short_url"`
+	CreatedAt         time.Time       `json:"created_at"`
+	RedirectType      string          `json:"redirect_type"`
+	NotAfter          sql.NullTime    `json:"not_after"`
+	DomainID          int64           `json:"domain_id"`
+	PasswordHash      string          `json:"password_hash"`
+	NotBefore         sql.NullTime    `json:"not_before"`
+	MaxClicks         int64           `json:"max_clicks"`
+	ClickCount        int64           `json:"click_count"`
+	Rules             json.RawMessage `json:"rules"`
+	Variants          json.RawMessage `json:"variants"`
+	QueryMode         string          `json:"query_mode"`
+	Utm               json.RawMessage `json:"utm"`
+	IsPrefix          bool            `json:"is_prefix"`
+	Title             string          `json:"title"`
+	Description       string          `json:"description"`
+	Owner             string          `json:"owner"`
+	FolderID          sql.NullInt64   `json:"folder_id"`
+	PageTitle         string          `json:"page_title"`
+	PageDescription   string          `json:"page_description"`
+	FaviconUrl        string          `json:"favicon_url"`
+	OgImageUrl        string          `json:"og_image_url"`
+	MetadataFetchedAt sql.NullTime    `json:"metadata_fetched_at"`
+	LastStatusCode    int32           `json:"last_status_code"`
+	RedirectChain     json.RawMessage `json:"redirect_chain"`
+	CheckError        string          `json:"check_error"`
+	CheckFailures     int32           `json:"check_failures"`
+	LastCheckedAt     sql.NullTime    `json:"last_checked_at"`
+	BrokenAt          sql.NullTime    `json:"broken_at"`
+	ExpiryNotifiedAt  sql.NullTime    `json:"expiry_notified_at"`
+	ArchivedAt        sql.NullTime    `json:"archived_at"`
+	DeletedAt         sql.NullTime    `json:"deleted_at"`
+}
+
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	WebhookID      int64           `json:"webhook_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastStatusCode int32           `json:"last_status_code"`
+	LastError      string          `json:"last_error"`
+	DeliveredAt    sql.NullTime    `json:"delivered_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+type Webhook struct {
+	ID        int64     `json:"id"`
+	Url       string    `json:"url"`
+	Secret    string    `json:"secret"`
+	Events    []string  `json:"events"`
 	CreatedAt time.Time `json:"created_at"`
 }
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// The partition maintenance queries only exist on Postgres, so they are not part of Querier.
+
+// URLPartition is an attached partition of the urls table.
+type URLPartition struct {
+	Name  string `json:"name"`
+	Bound string `json:"bound"`
+}
+
+const listURLPartitions = `
+SELECT child.relname::text, pg_get_expr(child.relpartbound, child.oid)
+FROM pg_inherits
+JOIN pg_class child ON child.oid = pg_inherits.inhrelid
+WHERE pg_inherits.inhparent = 'urls'::regclass
+ORDER BY child.relname
+`
+
+// ListURLPartitions lists the partitions attached to the urls table with their bounds.
+func (q *Queries) ListURLPartitions(ctx context.Context) ([]URLPartition, error) {
+	rows, err := q.db.QueryContext(ctx, listURLPartitions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []URLPartition{}
+	for rows.Next() {
+		var i URLPartition
+		if err := rows.Scan(&i.Name, &i.Bound); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createURLPartitions = `
+SELECT create_url_partitions(now(), $1)
+`
+
+// CreateURLPartitions creates the monthly partitions of the current month and the next monthsAhead months
+// and returns the names of the created ones.
+// It fails unless the urls table is partitioned by created_at range.
+func (q *Queries) CreateURLPartitions(ctx context.Context, monthsAhead int32) ([]string, error) {
+	return q.partitionNames(ctx, createURLPartitions, monthsAhead)
+}
+
+const detachURLPartitions = `
+SELECT detach_url_partitions($1)
+`
+
+// DetachURLPartitions detaches the partitions whose range ends before olderThan and returns their names.
+// The detached partitions are kept as plain tables and their links no longer resolve.
+// It fails unless the urls table is partitioned by created_at range.
+func (q *Queries) DetachURLPartitions(ctx context.Context, olderThan time.Time) ([]string, error) {
+	return q.partitionNames(ctx, detachURLPartitions, olderThan)
+}
+
+func (q *Queries) partitionNames(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"shortURL/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListURLPartitions(t *testing.T) {
+	partitions, err := testQueries.ListURLPartitions(context.Background())
+	require.NoError(t, err)
+
+	// 預設不分區時沒有分區
+	for _, partition := range partitions {
+		require.True(t, strings.HasPrefix(partition.Name, "urls_"))
+		require.True(t, strings.HasPrefix(partition.Bound, "FOR VALUES"))
+	}
+}
+
+func TestURLPartitionMaintenance(t *testing.T) {
+	partitions, err := testQueries.ListURLPartitions(context.Background())
+	require.NoError(t, err)
+
+	// 不分區或依 short_url 的雜湊分區時，不需要建立或卸離分區
+	if len(partitions) == 0 || strings.Contains(partitions[0].Bound, "MODULUS") {
+		_, err = testQueries.CreateURLPartitions(context.Background(), 1)
+		require.Error(t, err)
+
+		_, err = testQueries.DetachURLPartitions(context.Background(), time.Now())
+		require.Error(t, err)
+		return
+	}
+
+	_, err = testQueries.CreateURLPartitions(context.Background(), 3)
+	require.NoError(t, err)
+
+	// 已存在的分區不重新建立
+	names, err := testQueries.CreateURLPartitions(context.Background(), 3)
+	require.NoError(t, err)
+	require.Empty(t, names)
+
+	names, err = testQueries.DetachURLPartitions(context.Background(), time.Time{})
+	require.NoError(t, err)
+	require.Empty(t, names)
+}
+
+func TestUpdateURLAcrossPartitions(t *testing.T) {
+	url1 := createRandomURL(t)
+
+	err := testQueries.AddURLTag(context.Background(), AddURLTagParams{UrlID: url1.ID, Tag: "moved"})
+	require.NoError(t, err)
+
+	_, err = testQueries.CreateURLRevision(context.Background(), CreateURLRevisionParams{
+		UrlID:     url1.ID,
+		OriginUrl: url1.OriginUrl,
+	})
+	require.NoError(t, err)
+
+	// 更換短網址通常會將資料搬到其他分區，標籤與版本紀錄需保留
+	url2, err := testQueries.UpdateURL(context.Background(), UpdateURLParams{
+		ID:       url1.ID,
+		ShortUrl: util.RandomString(8),
+	})
+	require.NoError(t, err)
+	require.Equal(t, url1.ID, url2.ID)
+
+	tags, err := testQueries.ListURLTags(context.Background(), url1.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"moved"}, tags)
+
+	revisions, err := testQueries.ListURLRevisions(context.Background(), ListURLRevisionsParams{UrlID: url1.ID, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+
+	url3, err := testQueries.GetURL(context.Background(), GetURLParams{ShortUrl: url2.ShortUrl})
+	require.NoError(t, err)
+	require.Equal(t, url1.ID, url3.ID)
+
+	_, err = testQueries.GetURL(context.Background(), GetURLParams{ShortUrl: url1.ShortUrl})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// 舊的短網址可以再使用
+	url4, err := testQueries.CreateURL(context.Background(), CreateURLParams{
+		OriginUrl: util.RandomLongURL(),
+		ShortUrl:  url1.ShortUrl,
+		Rules:     json.RawMessage("[]"),
+		Variants:  json.RawMessage("[]"),
+		Utm:       json.RawMessage("{}"),
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, url1.ID, url4.ID)
+
+	// 永久刪除連結時一併刪除標籤並釋出短網址
+	err = testQueries.DeleteURL(context.Background(), url1.ID)
+	require.NoError(t, err)
+
+	tags, err = testQueries.ListURLTags(context.Background(), url1.ID)
+	require.NoError(t, err)
+	require.Empty(t, tags)
+
+	_, err = testQueries.CreateURL(context.Background(), CreateURLParams{
+		OriginUrl: util.RandomLongURL(),
+		ShortUrl:  url2.ShortUrl,
+		Rules:     json.RawMessage("[]"),
+		Variants:  json.RawMessage("[]"),
+		Utm:       json.RawMessage("{}"),
+	})
+	require.NoError(t, err)
+}
@@ -9,10 +9,54 @@ import (
 )
 
 type Querier interface {
+	AddURLTag(ctx context.Context, arg AddURLTagParams) error
+	ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	CopyDomain(ctx context.Context, arg CopyDomainParams) error
+	CopyFolder(ctx context.Context, arg CopyFolderParams) error
+	CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) (AuditEvent, error)
+	CreateDomain(ctx context.Context, arg CreateDomainParams) (Domain, error)
+	CreateFolder(ctx context.Context, name string) (Folder, error)
 	CreateURL(ctx context.Context, arg CreateURLParams) (Url, error)
+	CreateURLRevision(ctx context.Context, arg CreateURLRevisionParams) (UrlRevision, error)
+	CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error)
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error)
 	DeleteURL(ctx context.Context, id int64) error
-	GetURL(ctx context.Context, shortUrl string) (Url, error)
+	DeleteURLTags(ctx context.Context, urlID int64) error
+	DeleteWebhook(ctx context.Context, id int64) error
+	GetDeletedURL(ctx context.Context, arg GetDeletedURLParams) (Url, error)
+	GetDomainByHost(ctx context.Context, host string) (Domain, error)
+	GetFolder(ctx context.Context, id int64) (Folder, error)
+	GetURL(ctx context.Context, arg GetURLParams) (Url, error)
+	GetURLForUpdate(ctx context.Context, id int64) (Url, error)
+	GetURLRevision(ctx context.Context, arg GetURLRevisionParams) (UrlRevision, error)
+	GetWebhook(ctx context.Context, id int64) (Webhook, error)
+	GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error)
+	ImportURL(ctx context.Context, arg ImportURLParams) (Url, error)
+	ImportURLRevision(ctx context.Context, arg ImportURLRevisionParams) (UrlRevision, error)
+	ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error)
+	ListDomains(ctx context.Context, arg ListDomainsParams) ([]Domain, error)
+	ListFolders(ctx context.Context, arg ListFoldersParams) ([]Folder, error)
+	ListTagsByURLs(ctx context.Context, urlIds []int64) ([]UrlTag, error)
+	ListURLRevisions(ctx context.Context, arg ListURLRevisionsParams) ([]UrlRevision, error)
+	ListURLTags(ctx context.Context, urlID int64) ([]string, error)
+	ListURLs(ctx context.Context, arg ListURLsParams) ([]Url, error)
+	ListURLsToCheck(ctx context.Context, arg ListURLsToCheckParams) ([]Url, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListWebhooks(ctx context.Context, arg ListWebhooksParams) ([]Webhook, error)
+	ListWebhooksForEvent(ctx context.Context, event string) ([]Webhook, error)
+	MarkExpiredURLs(ctx context.Context, limit int32) ([]Url, error)
+	PurgeDeletedURLs(ctx context.Context, arg PurgeDeletedURLsParams) ([]Url, error)
+	RedeliverWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error)
+	RestoreURL(ctx context.Context, arg RestoreURLParams) (Url, error)
+	SearchURLs(ctx context.Context, arg SearchURLsParams) ([]Url, error)
+	SetURLArchived(ctx context.Context, arg SetURLArchivedParams) (Url, error)
+	SoftDeleteURL(ctx context.Context, id int64) (Url, error)
 	UpdateURL(ctx context.Context, arg UpdateURLParams) (Url, error)
+	UpdateURLCheck(ctx context.Context, arg UpdateURLCheckParams) (Url, error)
+	UpdateURLClickCount(ctx context.Context, arg UpdateURLClickCountParams) error
+	UpdateURLMetadata(ctx context.Context, arg UpdateURLMetadataParams) error
+	UpdateURLSettings(ctx context.Context, arg UpdateURLSettingsParams) (Url, error)
+	UpdateWebhookDelivery(ctx context.Context, arg UpdateWebhookDeliveryParams) (WebhookDelivery, error)
 }
 
 var _ Querier = (*Queries)(nil)
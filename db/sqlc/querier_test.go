@@ -0,0 +1,25 @@
+package db_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"shortURL/db/dbtest"
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuerierSuite(t *testing.T) {
+	config, err := util.LoadConfig("../..")
+	require.NoError(t, err)
+
+	conn, err := sql.Open(config.DBDriver, config.DBSource)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	dbtest.RunQuerierSuite(t, func(t *testing.T) db.Store {
+		return db.NewStore(conn)
+	})
+}
@@ -0,0 +1,367 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Pinger checks whether a database accepts connections.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+type replica struct {
+	Querier
+	pinger  Pinger
+	healthy int32
+}
+
+func (r *replica) isHealthy() bool {
+	return atomic.LoadInt32(&r.healthy) == 1
+}
+
+func (r *replica) setHealthy(healthy bool) {
+	var v int32
+	if healthy {
+		v = 1
+	}
+	atomic.StoreInt32(&r.healthy, v)
+}
+
+// RoutingQuerier sends GetURL and the list and search queries of the API to read replicas in round-robin
+// and everything else, including transactions, to the primary.
+// Reads of a link written by this process within the read-your-writes window go to the primary,
+// so do list and search queries within the window after any write.
+type RoutingQuerier struct {
+	TxQuerier
+	replicas       []*replica
+	next           uint32
+	window         time.Duration
+	healthInterval time.Duration
+
+	mu        sync.Mutex
+	written   map[urlKey]time.Time
+	lastWrite time.Time
+}
+
+// defaultHealthInterval is used when the health check interval is not positive.
+const defaultHealthInterval = 5 * time.Second
+
+type primaryKey struct{}
+
+// ReadPrimary returns a context whose reads through a RoutingQuerier go to the primary.
+// Use it for reads that are written back, a replica may return values older than the primary.
+func ReadPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func readsPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
+
+type urlKey struct {
+	domainID int64
+	shortUrl string
+}
+
+var _ TxQuerier = (*RoutingQuerier)(nil)
+
+// NewRoutingQuerier creates a router that writes to primary.
+// window is how long reads stay on the primary after a write, healthInterval is how often replicas are pinged,
+// defaultHealthInterval when it is not positive.
+func NewRoutingQuerier(primary TxQuerier, window, healthInterval time.Duration) *RoutingQuerier {
+	if healthInterval <= 0 {
+		healthInterval = defaultHealthInterval
+	}
+
+	return &RoutingQuerier{
+		TxQuerier:      primary,
+		window:         window,
+		healthInterval: healthInterval,
+		written:        make(map[urlKey]time.Time),
+	}
+}
+
+// AddReplica adds a read replica, it receives reads until a health check or a query fails.
+func (r *RoutingQuerier) AddReplica(q Querier, pinger Pinger) {
+	rep := &replica{Querier: q, pinger: pinger}
+	rep.setHealthy(true)
+	r.replicas = append(r.replicas, rep)
+}
+
+// NewReplicatedStore creates a store that writes to primary and reads from replicas.
+// Call Start on the returned router to run the health checks.
+func NewReplicatedStore(primary *sql.DB, replicas []*sql.DB, window, healthInterval time.Duration) (Store, *RoutingQuerier) {
+	router := NewRoutingQuerier(NewSQLStore(primary), window, healthInterval)
+	for _, conn := range replicas {
+		router.AddReplica(NewQuery(conn), conn)
+	}
+
+	return NewTxStore(router), router
+}
+
+// Start pings the replicas periodically until ctx is done.
+// A replica that fails the ping stops receiving reads until it answers again.
+func (r *RoutingQuerier) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.healthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.CheckHealth(ctx)
+		}
+	}
+}
+
+// CheckHealth pings every replica once and forgets the writes older than the window.
+func (r *RoutingQuerier) CheckHealth(ctx context.Context) {
+	for _, rep := range r.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, r.healthInterval)
+		rep.setHealthy(rep.pinger.PingContext(pingCtx) == nil)
+		cancel()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, at := range r.written {
+		if time.Since(at) >= r.window {
+			delete(r.written, key)
+		}
+	}
+}
+
+// pickReplica returns the next healthy replica, nil when there is none.
+func (r *RoutingQuerier) pickReplica() *replica {
+	n := len(r.replicas)
+	if n == 0 {
+		return nil
+	}
+
+	start := int(atomic.AddUint32(&r.next, 1))
+	for i := 0; i < n; i++ {
+		rep := r.replicas[(start+i)%n]
+		if rep.isHealthy() {
+			return rep
+		}
+	}
+
+	return nil
+}
+
+func (r *RoutingQuerier) markWrite(urls ...Url) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.lastWrite = now
+	for _, url := range urls {
+		r.written[urlKey{domainID: url.DomainID, shortUrl: url.ShortUrl}] = now
+	}
+}
+
+func (r *RoutingQuerier) wroteURLRecently(domainID int64, shortUrl string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	at, ok := r.written[urlKey{domainID: domainID, shortUrl: shortUrl}]
+	return ok && time.Since(at) < r.window
+}
+
+func (r *RoutingQuerier) wroteRecently() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return time.Since(r.lastWrite) < r.window
+}
+
+// readReplica runs read on a replica, or on the primary when there is no healthy replica.
+// A replica that returns an error is taken out of rotation and the read is retried on the primary.
+func readReplica[T any](ctx context.Context, r *RoutingQuerier, read func(Querier) (T, error)) (T, error) {
+	if readsPrimary(ctx) {
+		return read(r.TxQuerier)
+	}
+
+	rep := r.pickReplica()
+	if rep == nil {
+		return read(r.TxQuerier)
+	}
+
+	result, err := read(rep)
+	if err == nil || err == sql.ErrNoRows || ctx.Err() != nil {
+		return result, err
+	}
+
+	rep.setHealthy(false)
+	return read(r.TxQuerier)
+}
+
+// readList runs a list query on a replica unless this process wrote within the window.
+func readList[T any](ctx context.Context, r *RoutingQuerier, read func(Querier) (T, error)) (T, error) {
+	if r.wroteRecently() {
+		return read(r.TxQuerier)
+	}
+
+	return readReplica(ctx, r, read)
+}
+
+// GetURL reads the link from a replica, or from the primary when ctx comes from ReadPrimary.
+// A link missing on the replica may not have been replicated yet, so it is looked up again on the primary.
+func (r *RoutingQuerier) GetURL(ctx context.Context, arg GetURLParams) (Url, error) {
+	if readsPrimary(ctx) || r.wroteURLRecently(arg.DomainID, arg.ShortUrl) {
+		return r.TxQuerier.GetURL(ctx, arg)
+	}
+
+	url, err := readReplica(ctx, r, func(q Querier) (Url, error) {
+		return q.GetURL(ctx, arg)
+	})
+	if err == sql.ErrNoRows && len(r.replicas) > 0 {
+		return r.TxQuerier.GetURL(ctx, arg)
+	}
+
+	return url, err
+}
+
+func (r *RoutingQuerier) SearchURLs(ctx context.Context, arg SearchURLsParams) ([]Url, error) {
+	return readList(ctx, r, func(q Querier) ([]Url, error) {
+		return q.SearchURLs(ctx, arg)
+	})
+}
+
+func (r *RoutingQuerier) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error) {
+	return readList(ctx, r, func(q Querier) ([]AuditEvent, error) {
+		return q.ListAuditEvents(ctx, arg)
+	})
+}
+
+func (r *RoutingQuerier) ListDomains(ctx context.Context, arg ListDomainsParams) ([]Domain, error) {
+	return readList(ctx, r, func(q Querier) ([]Domain, error) {
+		return q.ListDomains(ctx, arg)
+	})
+}
+
+func (r *RoutingQuerier) ListFolders(ctx context.Context, arg ListFoldersParams) ([]Folder, error) {
+	return readList(ctx, r, func(q Querier) ([]Folder, error) {
+		return q.ListFolders(ctx, arg)
+	})
+}
+
+func (r *RoutingQuerier) ListTagsByURLs(ctx context.Context, urlIds []int64) ([]UrlTag, error) {
+	return readList(ctx, r, func(q Querier) ([]UrlTag, error) {
+		return q.ListTagsByURLs(ctx, urlIds)
+	})
+}
+
+func (r *RoutingQuerier) ListURLRevisions(ctx context.Context, arg ListURLRevisionsParams) ([]UrlRevision, error) {
+	return readList(ctx, r, func(q Querier) ([]UrlRevision, error) {
+		return q.ListURLRevisions(ctx, arg)
+	})
+}
+
+func (r *RoutingQuerier) ListURLTags(ctx context.Context, urlID int64) ([]string, error) {
+	return readList(ctx, r, func(q Querier) ([]string, error) {
+		return q.ListURLTags(ctx, urlID)
+	})
+}
+
+func (r *RoutingQuerier) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	return readList(ctx, r, func(q Querier) ([]WebhookDelivery, error) {
+		return q.ListWebhookDeliveries(ctx, arg)
+	})
+}
+
+func (r *RoutingQuerier) ListWebhooks(ctx context.Context, arg ListWebhooksParams) ([]Webhook, error) {
+	return readList(ctx, r, func(q Querier) ([]Webhook, error) {
+		return q.ListWebhooks(ctx, arg)
+	})
+}
+
+// ExecTx runs fn on the primary and marks the links written by fn once the transaction commits.
+func (r *RoutingQuerier) ExecTx(ctx context.Context, fn func(Querier) error) error {
+	var written []Url
+
+	err := r.TxQuerier.ExecTx(ctx, func(q Querier) error {
+		// 交易重試時重新記錄
+		written = written[:0]
+		return fn(&writeRecorder{Querier: q, written: &written})
+	})
+	if err != nil {
+		return err
+	}
+
+	r.markWrite(written...)
+	return nil
+}
+
+func (r *RoutingQuerier) CreateDomain(ctx context.Context, arg CreateDomainParams) (Domain, error) {
+	defer r.markWrite()
+	return r.TxQuerier.CreateDomain(ctx, arg)
+}
+
+func (r *RoutingQuerier) CreateFolder(ctx context.Context, name string) (Folder, error) {
+	defer r.markWrite()
+	return r.TxQuerier.CreateFolder(ctx, name)
+}
+
+func (r *RoutingQuerier) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
+	defer r.markWrite()
+	return r.TxQuerier.CreateWebhook(ctx, arg)
+}
+
+func (r *RoutingQuerier) DeleteWebhook(ctx context.Context, id int64) error {
+	defer r.markWrite()
+	return r.TxQuerier.DeleteWebhook(ctx, id)
+}
+
+func (r *RoutingQuerier) RedeliverWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	defer r.markWrite()
+	return r.TxQuerier.RedeliverWebhookDelivery(ctx, id)
+}
+
+// writeRecorder records the links changed inside a transaction.
+type writeRecorder struct {
+	Querier
+	written *[]Url
+}
+
+func (w *writeRecorder) record(url Url, err error) (Url, error) {
+	if err == nil {
+		*w.written = append(*w.written, url)
+	}
+	return url, err
+}
+
+func (w *writeRecorder) CreateURL(ctx context.Context, arg CreateURLParams) (Url, error) {
+	return w.record(w.Querier.CreateURL(ctx, arg))
+}
+
+func (w *writeRecorder) ImportURL(ctx context.Context, arg ImportURLParams) (Url, error) {
+	return w.record(w.Querier.ImportURL(ctx, arg))
+}
+
+func (w *writeRecorder) UpdateURL(ctx context.Context, arg UpdateURLParams) (Url, error) {
+	return w.record(w.Querier.UpdateURL(ctx, arg))
+}
+
+func (w *writeRecorder) UpdateURLSettings(ctx context.Context, arg UpdateURLSettingsParams) (Url, error) {
+	return w.record(w.Querier.UpdateURLSettings(ctx, arg))
+}
+
+func (w *writeRecorder) SetURLArchived(ctx context.Context, arg SetURLArchivedParams) (Url, error) {
+	return w.record(w.Querier.SetURLArchived(ctx, arg))
+}
+
+func (w *writeRecorder) SoftDeleteURL(ctx context.Context, id int64) (Url, error) {
+	return w.record(w.Querier.SoftDeleteURL(ctx, id))
+}
+
+func (w *writeRecorder) RestoreURL(ctx context.Context, arg RestoreURLParams) (Url, error) {
+	return w.record(w.Querier.RestoreURL(ctx, arg))
+}
@@ -0,0 +1,300 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	mockdb "shortURL/db/mock"
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePinger struct {
+	err error
+}
+
+func (p *fakePinger) PingContext(ctx context.Context) error {
+	return p.err
+}
+
+func newTestRouter(t *testing.T, window time.Duration, replicas int) (*db.RoutingQuerier, *mockdb.MockStore, []*mockdb.MockQuerier, []*fakePinger) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	primary := mockdb.NewMockStore(ctrl)
+	router := db.NewRoutingQuerier(primary, window, time.Second)
+
+	var mocks []*mockdb.MockQuerier
+	var pingers []*fakePinger
+	for i := 0; i < replicas; i++ {
+		mock := mockdb.NewMockQuerier(ctrl)
+		pinger := &fakePinger{}
+		router.AddReplica(mock, pinger)
+
+		mocks = append(mocks, mock)
+		pingers = append(pingers, pinger)
+	}
+
+	return router, primary, mocks, pingers
+}
+
+func TestRoutingQuerierRoundRobin(t *testing.T) {
+	router, primary, replicas, _ := newTestRouter(t, time.Minute, 2)
+	arg := db.GetURLParams{ShortUrl: util.RandomString(6)}
+
+	primary.EXPECT().GetURL(gomock.Any(), gomock.Any()).Times(0)
+	for _, replica := range replicas {
+		replica.EXPECT().
+			GetURL(gomock.Any(), gomock.Eq(arg)).
+			Times(2).
+			Return(db.Url{ShortUrl: arg.ShortUrl}, nil)
+	}
+
+	for i := 0; i < 4; i++ {
+		url, err := router.GetURL(context.Background(), arg)
+		require.NoError(t, err)
+		require.Equal(t, arg.ShortUrl, url.ShortUrl)
+	}
+}
+
+func TestRoutingQuerierNotReplicated(t *testing.T) {
+	router, primary, replicas, _ := newTestRouter(t, time.Minute, 1)
+	arg := db.GetURLParams{ShortUrl: util.RandomString(6)}
+
+	// 副本還沒同步到的連結改從主資料庫讀取
+	replicas[0].EXPECT().
+		GetURL(gomock.Any(), gomock.Eq(arg)).
+		Times(1).
+		Return(db.Url{}, sql.ErrNoRows)
+	primary.EXPECT().
+		GetURL(gomock.Any(), gomock.Eq(arg)).
+		Times(1).
+		Return(db.Url{ShortUrl: arg.ShortUrl}, nil)
+
+	url, err := router.GetURL(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ShortUrl, url.ShortUrl)
+}
+
+func TestRoutingQuerierReplicaFailure(t *testing.T) {
+	router, primary, replicas, _ := newTestRouter(t, time.Minute, 2)
+	arg := db.ListDomainsParams{Limit: 5}
+
+	// 第一個被選到的副本故障後不再使用
+	replicas[1].EXPECT().
+		ListDomains(gomock.Any(), gomock.Eq(arg)).
+		Times(1).
+		Return(nil, errors.New("connection reset"))
+	primary.EXPECT().
+		ListDomains(gomock.Any(), gomock.Eq(arg)).
+		Times(1).
+		Return([]db.Domain{}, nil)
+	replicas[0].EXPECT().
+		ListDomains(gomock.Any(), gomock.Eq(arg)).
+		Times(3).
+		Return([]db.Domain{}, nil)
+
+	for i := 0; i < 4; i++ {
+		_, err := router.ListDomains(context.Background(), arg)
+		require.NoError(t, err)
+	}
+}
+
+func TestRoutingQuerierHealthCheck(t *testing.T) {
+	router, primary, replicas, pingers := newTestRouter(t, time.Minute, 1)
+	arg := db.GetURLParams{ShortUrl: util.RandomString(6)}
+
+	pingers[0].err = errors.New("connection refused")
+	router.CheckHealth(context.Background())
+
+	// 沒有可用的副本時讀取主資料庫
+	primary.EXPECT().
+		GetURL(gomock.Any(), gomock.Eq(arg)).
+		Times(1).
+		Return(db.Url{}, nil)
+	_, err := router.GetURL(context.Background(), arg)
+	require.NoError(t, err)
+
+	pingers[0].err = nil
+	router.CheckHealth(context.Background())
+
+	replicas[0].EXPECT().
+		GetURL(gomock.Any(), gomock.Eq(arg)).
+		Times(1).
+		Return(db.Url{}, nil)
+	_, err = router.GetURL(context.Background(), arg)
+	require.NoError(t, err)
+}
+
+func TestRoutingQuerierZeroHealthInterval(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// 未設定檢查間隔時使用預設值，不會讓 Start 建立 ticker 時 panic
+	router := db.NewRoutingQuerier(mockdb.NewMockStore(ctrl), time.Minute, 0)
+	router.AddReplica(mockdb.NewMockQuerier(ctrl), &fakePinger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	require.NotPanics(t, func() {
+		router.Start(ctx)
+	})
+}
+
+func TestRoutingQuerierReadYourWrites(t *testing.T) {
+	window := 100 * time.Millisecond
+	router, primary, replicas, _ := newTestRouter(t, window, 1)
+	store := db.NewTxStore(router)
+
+	url := db.Url{ID: 1, ShortUrl: util.RandomString(6)}
+	other := db.GetURLParams{ShortUrl: util.RandomString(6)}
+
+	primary.EXPECT().
+		ExecTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, fn func(db.Querier) error) error {
+			return fn(primary)
+		})
+	primary.EXPECT().CreateURL(gomock.Any(), gomock.Any()).Times(1).Return(url, nil)
+	primary.EXPECT().AddURLTag(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+	primary.EXPECT().CreateURLRevision(gomock.Any(), gomock.Any()).Times(1).Return(db.UrlRevision{}, nil)
+	primary.EXPECT().CreateAuditEvent(gomock.Any(), gomock.Any()).Times(1).Return(db.AuditEvent{}, nil)
+
+	_, err := store.CreateURLTx(context.Background(), db.CreateURLTxParams{
+		CreateURLParams: db.CreateURLParams{ShortUrl: url.ShortUrl},
+	})
+	require.NoError(t, err)
+
+	// 剛建立的連結與列表在時間窗內讀取主資料庫
+	primary.EXPECT().
+		GetURL(gomock.Any(), gomock.Eq(db.GetURLParams{ShortUrl: url.ShortUrl})).
+		Times(1).
+		Return(url, nil)
+	primary.EXPECT().
+		ListFolders(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.Folder{}, nil)
+	replicas[0].EXPECT().
+		GetURL(gomock.Any(), gomock.Eq(other)).
+		Times(1).
+		Return(db.Url{}, nil)
+
+	_, err = store.GetURL(context.Background(), db.GetURLParams{ShortUrl: url.ShortUrl})
+	require.NoError(t, err)
+	_, err = store.ListFolders(context.Background(), db.ListFoldersParams{Limit: 5})
+	require.NoError(t, err)
+	_, err = store.GetURL(context.Background(), other)
+	require.NoError(t, err)
+
+	// 時間窗過後回到副本
+	time.Sleep(window)
+
+	replicas[0].EXPECT().
+		GetURL(gomock.Any(), gomock.Eq(db.GetURLParams{ShortUrl: url.ShortUrl})).
+		Times(1).
+		Return(url, nil)
+	replicas[0].EXPECT().
+		ListFolders(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.Folder{}, nil)
+
+	_, err = store.GetURL(context.Background(), db.GetURLParams{ShortUrl: url.ShortUrl})
+	require.NoError(t, err)
+	_, err = store.ListFolders(context.Background(), db.ListFoldersParams{Limit: 5})
+	require.NoError(t, err)
+}
+
+func TestRoutingQuerierFailedTx(t *testing.T) {
+	router, primary, replicas, _ := newTestRouter(t, time.Minute, 1)
+	arg := db.GetURLParams{ShortUrl: util.RandomString(6)}
+	errTx := errors.New("tx failed")
+
+	primary.EXPECT().
+		ExecTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, fn func(db.Querier) error) error {
+			return fn(primary)
+		})
+	primary.EXPECT().CreateURL(gomock.Any(), gomock.Any()).Times(1).Return(db.Url{ShortUrl: arg.ShortUrl}, nil)
+
+	err := router.ExecTx(context.Background(), func(q db.Querier) error {
+		_, err := q.CreateURL(context.Background(), db.CreateURLParams{ShortUrl: arg.ShortUrl})
+		require.NoError(t, err)
+		return errTx
+	})
+	require.ErrorIs(t, err, errTx)
+
+	// 回滾的交易不影響讀取
+	replicas[0].EXPECT().
+		GetURL(gomock.Any(), gomock.Eq(arg)).
+		Times(1).
+		Return(db.Url{}, sql.ErrNoRows)
+	primary.EXPECT().
+		GetURL(gomock.Any(), gomock.Eq(arg)).
+		Times(1).
+		Return(db.Url{}, sql.ErrNoRows)
+
+	_, err = router.GetURL(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestRoutingQuerierReadPrimary(t *testing.T) {
+	router, primary, replicas, _ := newTestRouter(t, time.Minute, 1)
+	arg := db.GetURLParams{ShortUrl: util.RandomString(6)}
+
+	// 會寫回的讀取不經過副本
+	replicas[0].EXPECT().GetURL(gomock.Any(), gomock.Any()).Times(0)
+	replicas[0].EXPECT().ListFolders(gomock.Any(), gomock.Any()).Times(0)
+	primary.EXPECT().
+		GetURL(gomock.Any(), gomock.Eq(arg)).
+		Times(1).
+		Return(db.Url{ShortUrl: arg.ShortUrl}, nil)
+	primary.EXPECT().
+		ListFolders(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.Folder{}, nil)
+
+	ctx := db.ReadPrimary(context.Background())
+	url, err := router.GetURL(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ShortUrl, url.ShortUrl)
+
+	_, err = router.ListFolders(ctx, db.ListFoldersParams{Limit: 5})
+	require.NoError(t, err)
+}
+
+func TestRoutingQuerierImportedURL(t *testing.T) {
+	router, primary, replicas, _ := newTestRouter(t, time.Minute, 1)
+	arg := db.GetURLParams{ShortUrl: util.RandomString(6)}
+
+	primary.EXPECT().
+		ExecTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, fn func(db.Querier) error) error {
+			return fn(primary)
+		})
+	primary.EXPECT().ImportURL(gomock.Any(), gomock.Any()).Times(1).Return(db.Url{ShortUrl: arg.ShortUrl}, nil)
+
+	err := router.ExecTx(context.Background(), func(q db.Querier) error {
+		_, err := q.ImportURL(context.Background(), db.ImportURLParams{ShortUrl: arg.ShortUrl})
+		return err
+	})
+	require.NoError(t, err)
+
+	// 匯入的連結在時間窗內也讀取主資料庫
+	replicas[0].EXPECT().GetURL(gomock.Any(), gomock.Any()).Times(0)
+	primary.EXPECT().
+		GetURL(gomock.Any(), gomock.Eq(arg)).
+		Times(1).
+		Return(db.Url{ShortUrl: arg.ShortUrl}, nil)
+
+	_, err = router.GetURL(context.Background(), arg)
+	require.NoError(t, err)
+}
@@ -0,0 +1,236 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// reshardPageSize is the page size used to read the domains, folders and revisions to copy.
+const reshardPageSize = 100
+
+// SyncShardCatalog copies the domains and folders of shard 0 to the other shards,
+// the ones that already exist on a shard are kept.
+func SyncShardCatalog(ctx context.Context, shards []TxQuerier) error {
+	for offset := int32(0); ; offset += reshardPageSize {
+		domains, err := shards[0].ListDomains(ctx, ListDomainsParams{Limit: reshardPageSize, Offset: offset})
+		if err != nil {
+			return err
+		}
+
+		for _, domain := range domains {
+			for _, shard := range shards[1:] {
+				err := shard.CopyDomain(ctx, CopyDomainParams{
+					ID:          domain.ID,
+					Host:        domain.Host,
+					FallbackUrl: domain.FallbackUrl,
+					CreatedAt:   domain.CreatedAt,
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if len(domains) < reshardPageSize {
+			break
+		}
+	}
+
+	for offset := int32(0); ; offset += reshardPageSize {
+		folders, err := shards[0].ListFolders(ctx, ListFoldersParams{Limit: reshardPageSize, Offset: offset})
+		if err != nil {
+			return err
+		}
+
+		for _, folder := range folders {
+			for _, shard := range shards[1:] {
+				err := shard.CopyFolder(ctx, CopyFolderParams{
+					ID:        folder.ID,
+					Name:      folder.Name,
+					CreatedAt: folder.CreatedAt,
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if len(folders) < reshardPageSize {
+			return nil
+		}
+	}
+}
+
+// URLMove describes a link stored on a shard other than the owner of its short url.
+type URLMove struct {
+	// Url is the link on the source shard, OldID and NewID are the ids seen through ShardedQuerier.
+	Url   Url
+	From  int
+	To    int
+	OldID int64
+	NewID int64
+	// Err is a *ShortURLConflictError when another link on the owner uses the short url,
+	// the link then stays on its shard.
+	Err error
+}
+
+// MoveURLs moves the links of every shard to the shard that owns their short url,
+// which is needed after adding a shard or renaming a link.
+// A link is copied with its tags and revisions, then deleted from its old shard, so it keeps resolving while it moves
+// but gets a new id. Audit events stay on the old shard.
+// moved is called for every link to move, with dryRun the links are only reported.
+func MoveURLs(ctx context.Context, shards []TxQuerier, batch int32, dryRun bool, moved func(URLMove)) error {
+	ring := NewRing(len(shards))
+
+	for from, shard := range shards {
+		var afterID int64
+
+		for {
+			urls, err := shard.ListURLs(ctx, ListURLsParams{AfterID: afterID, Limit: batch})
+			if err != nil {
+				return err
+			}
+
+			for _, url := range urls {
+				to := ring.Locate(url.ShortUrl)
+				if to == from {
+					continue
+				}
+
+				move := URLMove{Url: url, From: from, To: to, OldID: globalID(from, url.ID)}
+				if !dryRun {
+					var newURL Url
+					newURL, err = moveURL(ctx, shard, shards[to], url)
+					if _, ok := err.(*ShortURLConflictError); ok {
+						move.Err = err
+					} else if err != nil {
+						return err
+					}
+					move.NewID = globalID(to, newURL.ID)
+				}
+				moved(move)
+			}
+
+			if int32(len(urls)) < batch {
+				break
+			}
+			afterID = urls[len(urls)-1].ID
+		}
+	}
+
+	return nil
+}
+
+// moveURL copies the link to the shard that owns its short url and deletes it from its old shard.
+// A copy left by an interrupted move is recognized by its creation time and only the old link is deleted.
+func moveURL(ctx context.Context, from, to TxQuerier, url Url) (Url, error) {
+	tags, err := from.ListURLTags(ctx, url.ID)
+	if err != nil {
+		return Url{}, err
+	}
+
+	var revisions []UrlRevision
+	for offset := int32(0); ; offset += reshardPageSize {
+		page, err := from.ListURLRevisions(ctx, ListURLRevisionsParams{UrlID: url.ID, Limit: reshardPageSize, Offset: offset})
+		if err != nil {
+			return Url{}, err
+		}
+
+		revisions = append(revisions, page...)
+		if len(page) < reshardPageSize {
+			break
+		}
+	}
+
+	var newURL Url
+	err = to.ExecTx(ctx, func(q Querier) error {
+		var err error
+		newURL, err = q.ImportURL(ctx, importURLParams(url))
+		if err != nil {
+			return err
+		}
+
+		err = addURLTags(ctx, q, newURL.ID, tags)
+		if err != nil {
+			return err
+		}
+
+		// 版本紀錄由新到舊排列
+		for i := len(revisions) - 1; i >= 0; i-- {
+			_, err = q.ImportURLRevision(ctx, ImportURLRevisionParams{
+				UrlID:     newURL.ID,
+				Revision:  revisions[i].Revision,
+				OriginUrl: revisions[i].OriginUrl,
+				Actor:     revisions[i].Actor,
+				CreatedAt: revisions[i].CreatedAt,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == sql.ErrNoRows {
+		newURL, err = findMovedURL(ctx, to, url)
+	}
+	if err != nil {
+		return Url{}, err
+	}
+
+	return newURL, from.DeleteURL(ctx, url.ID)
+}
+
+// findMovedURL returns the copy of the link on the shard, or a *ShortURLConflictError when the short url is used by another link.
+func findMovedURL(ctx context.Context, to TxQuerier, url Url) (Url, error) {
+	existing, err := to.GetURL(ctx, GetURLParams{DomainID: url.DomainID, ShortUrl: url.ShortUrl})
+	if err == sql.ErrNoRows {
+		existing, err = to.GetDeletedURL(ctx, GetDeletedURLParams{DomainID: url.DomainID, ShortUrl: url.ShortUrl})
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return Url{}, err
+	}
+
+	if err == sql.ErrNoRows || !existing.CreatedAt.Equal(url.CreatedAt) {
+		return Url{}, &ShortURLConflictError{DomainID: url.DomainID, ShortUrl: url.ShortUrl}
+	}
+
+	return existing, nil
+}
+
+func importURLParams(url Url) ImportURLParams {
+	return ImportURLParams{
+		OriginUrl:         url.OriginUrl,
+		ShortUrl:          url.ShortUrl,
+		CreatedAt:         url.CreatedAt,
+		RedirectType:      url.RedirectType,
+		NotAfter:          url.NotAfter,
+		DomainID:          url.DomainID,
+		PasswordHash:      url.PasswordHash,
+		NotBefore:         url.NotBefore,
+		MaxClicks:         url.MaxClicks,
+		ClickCount:        url.ClickCount,
+		Rules:             url.Rules,
+		Variants:          url.Variants,
+		QueryMode:         url.QueryMode,
+		Utm:               url.Utm,
+		IsPrefix:          url.IsPrefix,
+		Title:             url.Title,
+		Description:       url.Description,
+		Owner:             url.Owner,
+		FolderID:          url.FolderID,
+		PageTitle:         url.PageTitle,
+		PageDescription:   url.PageDescription,
+		FaviconUrl:        url.FaviconUrl,
+		OgImageUrl:        url.OgImageUrl,
+		MetadataFetchedAt: url.MetadataFetchedAt,
+		LastStatusCode:    url.LastStatusCode,
+		RedirectChain:     url.RedirectChain,
+		CheckError:        url.CheckError,
+		CheckFailures:     url.CheckFailures,
+		LastCheckedAt:     url.LastCheckedAt,
+		BrokenAt:          url.BrokenAt,
+		ExpiryNotifiedAt:  url.ExpiryNotifiedAt,
+		ArchivedAt:        url.ArchivedAt,
+		DeletedAt:         url.DeletedAt,
+	}
+}
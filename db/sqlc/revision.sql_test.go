@@ -0,0 +1,40 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"shortURL/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLRevisions(t *testing.T) {
+	url := createRandomURL(t)
+
+	for i := 1; i <= 3; i++ {
+		revision, err := testQueries.CreateURLRevision(context.Background(), CreateURLRevisionParams{
+			UrlID:     url.ID,
+			OriginUrl: util.RandomLongURL(),
+			Actor:     "alice",
+		})
+		require.NoError(t, err)
+		require.Equal(t, int32(i), revision.Revision)
+	}
+
+	revisions, err := testQueries.ListURLRevisions(context.Background(), ListURLRevisionsParams{
+		UrlID: url.ID,
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+	require.Equal(t, int32(3), revisions[0].Revision)
+
+	revision, err := testQueries.GetURLRevision(context.Background(), GetURLRevisionParams{UrlID: url.ID, Revision: 2})
+	require.NoError(t, err)
+	require.Equal(t, revisions[1], revision)
+
+	_, err = testQueries.GetURLRevision(context.Background(), GetURLRevisionParams{UrlID: url.ID, Revision: 4})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
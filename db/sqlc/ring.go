@@ -0,0 +1,75 @@
+package db
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"sort"
+	"strconv"
+)
+
+// ringReplicas is the number of points of each shard on the ring,
+// more points spread the slugs more evenly.
+const ringReplicas = 128
+
+// Ring maps slugs to shards by consistent hashing.
+// Adding a shard only moves the slugs that the new shard takes over, the others keep their shard.
+type Ring struct {
+	points []uint64
+	shards []int
+}
+
+type ringPoint struct {
+	hash  uint64
+	shard int
+}
+
+// NewRing creates a ring of the shards numbered 0 to n-1.
+func NewRing(n int) *Ring {
+	points := make([]ringPoint, 0, n*ringReplicas)
+	for shard := 0; shard < n; shard++ {
+		for i := 0; i < ringReplicas; i++ {
+			points = append(points, ringPoint{
+				hash:  ringHash("shard-" + strconv.Itoa(shard) + "-" + strconv.Itoa(i)),
+				shard: shard,
+			})
+		}
+	}
+
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].hash != points[j].hash {
+			return points[i].hash < points[j].hash
+		}
+		return points[i].shard < points[j].shard
+	})
+
+	ring := &Ring{
+		points: make([]uint64, len(points)),
+		shards: make([]int, len(points)),
+	}
+	for i, point := range points {
+		ring.points[i] = point.hash
+		ring.shards[i] = point.shard
+	}
+
+	return ring
+}
+
+// Locate returns the shard that owns the slug, the first point clockwise from the hash of the slug.
+func (r *Ring) Locate(shortUrl string) int {
+	if len(r.points) == 0 {
+		return 0
+	}
+
+	hash := ringHash(shortUrl)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= hash })
+	if i == len(r.points) {
+		i = 0
+	}
+
+	return r.shards[i]
+}
+
+func ringHash(key string) uint64 {
+	sum := md5.Sum([]byte(key))
+	return binary.BigEndian.Uint64(sum[:8])
+}
@@ -0,0 +1,942 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+
+	"github.com/lib/pq"
+)
+
+// shardIDBits is the number of low bits of an id that hold the id within its shard,
+// the bits above hold the shard number.
+// The ids of shard 0 are unchanged, so an existing database becomes shard 0 without rewriting its ids.
+// The ids of the other shards are above 2^53 and lose precision when read as a JavaScript number.
+const shardIDBits = 48
+
+const shardIDMask = 1<<shardIDBits - 1
+
+// ErrCrossShard is returned when a transaction touches rows on more than one shard.
+var ErrCrossShard = errors.New("transaction spans more than one shard")
+
+// ShardedQuerier spreads the links over several databases by consistent hashing on the short url.
+// A link is created on the shard that owns its short url and its id encodes the shard,
+// so lookups by short url or by id go to a single shard without a directory.
+// Domains, folders and webhooks live on shard 0, domains and folders are copied to the other shards
+// because the links reference them. Searches and listings query every shard and merge the results.
+//
+// Outside resharding every link is on the shard that owns its short url.
+// While resharding, a lookup that misses the owner also queries the other shards,
+// which hold the links not moved yet after adding a shard, and a link can be renamed to a short url of another shard.
+type ShardedQuerier struct {
+	*shardQueries
+	shards []TxQuerier
+}
+
+var _ TxQuerier = (*ShardedQuerier)(nil)
+
+// NewShardedQuerier creates a router over the shards, shards[i] is shard i.
+// The order must not change once links are created and shards can only be appended.
+// resharding turns on the lookups on the other shards, it is needed until the reshard move command has run.
+func NewShardedQuerier(resharding bool, shards ...TxQuerier) *ShardedQuerier {
+	return &ShardedQuerier{
+		shardQueries: &shardQueries{
+			ring:       NewRing(len(shards)),
+			n:          len(shards),
+			resharding: resharding,
+			on: func(shard int) (Querier, error) {
+				return shards[shard], nil
+			},
+		},
+		shards: shards,
+	}
+}
+
+// NewShardedStore creates a store over Postgres shards, conns[i] is shard i.
+func NewShardedStore(conns []*sql.DB, resharding bool) Store {
+	shards := make([]TxQuerier, len(conns))
+	for i, conn := range conns {
+		shards[i] = NewSQLStore(conn)
+	}
+
+	return NewShardedTxStore(resharding, shards...)
+}
+
+// shardedStore runs the link transactions through a ShardedQuerier.
+type shardedStore struct {
+	Store
+	ring *Ring
+	n    int
+}
+
+// NewShardedTxStore creates a store over the shards, shards[i] is shard i.
+func NewShardedTxStore(resharding bool, shards ...TxQuerier) Store {
+	router := NewShardedQuerier(resharding, shards...)
+	return &shardedStore{Store: NewTxStore(router), ring: router.ring, n: router.n}
+}
+
+// PurgeDeletedURLsTx purges the links of each shard in a transaction on that shard,
+// until arg.Limit links are purged.
+func (store *shardedStore) PurgeDeletedURLsTx(ctx context.Context, arg PurgeDeletedURLsParams) ([]Url, error) {
+	items := []Url{}
+
+	for shard := 0; shard < store.n && int32(len(items)) < arg.Limit; shard++ {
+		urls, err := store.Store.PurgeDeletedURLsTx(withShard(ctx, shard), PurgeDeletedURLsParams{
+			DeletedBefore: arg.DeletedBefore,
+			Limit:         arg.Limit - int32(len(items)),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, urls...)
+	}
+
+	return items, nil
+}
+
+type shardKey struct{}
+
+// withShard limits the queries over all shards run with ctx to one shard.
+func withShard(ctx context.Context, shard int) context.Context {
+	return context.WithValue(ctx, shardKey{}, shard)
+}
+
+// shardRange returns the shards a query over all shards runs on.
+func (q *shardQueries) shardRange(ctx context.Context) (int, int) {
+	if shard, ok := ctx.Value(shardKey{}).(int); ok && shard < q.n {
+		return shard, shard + 1
+	}
+	return 0, q.n
+}
+
+// CreateURLBatchTx creates the links of each shard in a transaction on that shard,
+// because a transaction cannot span shards. An error on one shard does not roll back the links
+// already created on the others.
+func (store *shardedStore) CreateURLBatchTx(ctx context.Context, args []CreateURLTxParams) ([]URLBatchResult, error) {
+	var shards []int
+	batches := make(map[int][]int)
+	for i, arg := range args {
+		shard := store.ring.Locate(arg.ShortUrl)
+		if _, ok := batches[shard]; !ok {
+			shards = append(shards, shard)
+		}
+		batches[shard] = append(batches[shard], i)
+	}
+
+	results := make([]URLBatchResult, len(args))
+	for _, shard := range shards {
+		batch := make([]CreateURLTxParams, len(batches[shard]))
+		for j, i := range batches[shard] {
+			batch[j] = args[i]
+		}
+
+		created, err := store.Store.CreateURLBatchTx(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+
+		for j, i := range batches[shard] {
+			results[i] = created[j]
+		}
+	}
+
+	return results, nil
+}
+
+// ExecTx runs fn in a transaction on the shard of the first row fn touches.
+// Queries for rows on another shard return ErrCrossShard.
+func (r *ShardedQuerier) ExecTx(ctx context.Context, fn func(Querier) error) error {
+	tx := &shardTx{shard: -1}
+	q := &shardQueries{ring: r.ring, n: r.n, resharding: r.resharding, inTx: true, on: func(s int) (Querier, error) {
+		if tx.shard < 0 {
+			err := tx.begin(ctx, r.shards[s])
+			if err != nil {
+				return nil, err
+			}
+			tx.shard = s
+		}
+		if s != tx.shard {
+			return nil, ErrCrossShard
+		}
+		return tx.q, nil
+	}}
+
+	finished := false
+	defer func() {
+		// fn panic 時結束分片上的交易
+		if !finished && tx.shard >= 0 {
+			tx.done <- errShardTxAborted
+			<-tx.result
+		}
+	}()
+
+	err := fn(q)
+	if tx.shard < 0 {
+		// fn 沒有執行任何查詢
+		finished = true
+		return err
+	}
+
+	for {
+		tx.done <- err
+		select {
+		case err = <-tx.result:
+			finished = true
+			return err
+		case tx.q = <-tx.queries:
+			// 分片的交易重試時重新執行 fn
+			err = fn(q)
+		}
+	}
+}
+
+// errShardTxAborted rolls back the transaction of a shard when fn panics.
+var errShardTxAborted = errors.New("transaction aborted")
+
+// shardTx runs the transaction of a shard in its own goroutine and hands its querier over,
+// so fn keeps running on the goroutine that called ExecTx.
+type shardTx struct {
+	shard   int
+	q       Querier
+	queries chan Querier
+	done    chan error
+	result  chan error
+}
+
+func (tx *shardTx) begin(ctx context.Context, shard TxQuerier) error {
+	tx.queries = make(chan Querier)
+	tx.done = make(chan error)
+	tx.result = make(chan error, 1)
+
+	go func() {
+		tx.result <- shard.ExecTx(ctx, func(q Querier) error {
+			tx.queries <- q
+			return <-tx.done
+		})
+	}()
+
+	select {
+	case tx.q = <-tx.queries:
+		return nil
+	case err := <-tx.result:
+		return err
+	}
+}
+
+// shardQueries routes every query to a shard through on.
+type shardQueries struct {
+	ring       *Ring
+	n          int
+	resharding bool
+	inTx       bool
+	on         func(shard int) (Querier, error)
+}
+
+// globalID returns the id of a row of the shard as seen outside the shard.
+// Ids that are not positive, like the zero value returned with an error, are unchanged.
+func globalID(shard int, id int64) int64 {
+	if id <= 0 {
+		return id
+	}
+	return int64(shard)<<shardIDBits | id
+}
+
+// splitID returns the shard of an id and the id within the shard.
+// Ids that do not belong to a shard are sent to shard 0 unchanged, where they match nothing.
+func (q *shardQueries) splitID(id int64) (int, int64) {
+	shard := int(id >> shardIDBits)
+	if id < 0 || shard >= q.n {
+		return 0, id
+	}
+
+	return shard, id & shardIDMask
+}
+
+func globalURL(shard int, url Url) Url {
+	url.ID = globalID(shard, url.ID)
+	return url
+}
+
+func globalURLs(shard int, urls []Url) []Url {
+	for i := range urls {
+		urls[i].ID = globalID(shard, urls[i].ID)
+	}
+	return urls
+}
+
+func globalRevision(shard int, revision UrlRevision) UrlRevision {
+	revision.ID = globalID(shard, revision.ID)
+	revision.UrlID = globalID(shard, revision.UrlID)
+	return revision
+}
+
+func globalAuditEvent(shard int, event AuditEvent) AuditEvent {
+	event.ID = globalID(shard, event.ID)
+	event.UrlID = globalID(shard, event.UrlID)
+	return event
+}
+
+func onShard[T any](q *shardQueries, shard int, call func(Querier) (T, error)) (T, error) {
+	target, err := q.on(shard)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return call(target)
+}
+
+func (q *shardQueries) exec(shard int, call func(Querier) error) error {
+	target, err := q.on(shard)
+	if err != nil {
+		return err
+	}
+
+	return call(target)
+}
+
+// execAll runs call on every shard, like the copies of domains and folders.
+func (q *shardQueries) execAll(call func(Querier) error) error {
+	for shard := 0; shard < q.n; shard++ {
+		if err := q.exec(shard, call); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// gather runs call on every shard and returns the rows of each shard.
+func gather[T any](q *shardQueries, call func(shard int, target Querier) ([]T, error)) ([][]T, error) {
+	results := make([][]T, q.n)
+	for shard := 0; shard < q.n; shard++ {
+		target, err := q.on(shard)
+		if err != nil {
+			return nil, err
+		}
+
+		results[shard], err = call(shard, target)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+// mergeSorted merges the rows of every shard ordered by less.
+func mergeSorted[T any](results [][]T, less func(a, b T) bool) []T {
+	items := []T{}
+	for _, rows := range results {
+		items = append(items, rows...)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return less(items[i], items[j]) })
+	return items
+}
+
+func page[T any](items []T, limit, offset int32) []T {
+	if int(offset) >= len(items) {
+		return items[:0]
+	}
+
+	items = items[offset:]
+	if int(limit) < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+func (q *shardQueries) urlByID(id int64, call func(target Querier, id int64) (Url, error)) (Url, error) {
+	shard, local := q.splitID(id)
+	url, err := onShard(q, shard, func(target Querier) (Url, error) {
+		return call(target, local)
+	})
+	if err != nil {
+		return Url{}, err
+	}
+
+	return globalURL(shard, url), nil
+}
+
+// findURL looks for the link on the shard that owns the short url and, while resharding, then on the others,
+// which hold the links renamed to a short url of another shard and the links not moved yet after adding a shard.
+// A transaction only looks on its own shard.
+func (q *shardQueries) findURL(shortUrl string, call func(target Querier) (Url, error)) (Url, error) {
+	owner := q.ring.Locate(shortUrl)
+
+	url, err := onShard(q, owner, call)
+	if err != sql.ErrNoRows || !q.resharding || q.inTx {
+		return globalURL(owner, url), err
+	}
+
+	for shard := 0; shard < q.n; shard++ {
+		if shard == owner {
+			continue
+		}
+
+		url, err = onShard(q, shard, call)
+		if err != sql.ErrNoRows {
+			return globalURL(shard, url), err
+		}
+	}
+
+	return Url{}, sql.ErrNoRows
+}
+
+func (q *shardQueries) AddURLTag(ctx context.Context, arg AddURLTagParams) error {
+	shard, local := q.splitID(arg.UrlID)
+	arg.UrlID = local
+
+	return q.exec(shard, func(target Querier) error {
+		return target.AddURLTag(ctx, arg)
+	})
+}
+
+func (q *shardQueries) ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	return onShard(q, 0, func(target Querier) ([]WebhookDelivery, error) {
+		return target.ClaimWebhookDeliveries(ctx, arg)
+	})
+}
+
+func (q *shardQueries) CopyDomain(ctx context.Context, arg CopyDomainParams) error {
+	return q.execAll(func(target Querier) error {
+		return target.CopyDomain(ctx, arg)
+	})
+}
+
+func (q *shardQueries) CopyFolder(ctx context.Context, arg CopyFolderParams) error {
+	return q.execAll(func(target Querier) error {
+		return target.CopyFolder(ctx, arg)
+	})
+}
+
+// CreateAuditEvent stores the event on the shard of its link.
+func (q *shardQueries) CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) (AuditEvent, error) {
+	shard, local := q.splitID(arg.UrlID)
+	arg.UrlID = local
+
+	event, err := onShard(q, shard, func(target Querier) (AuditEvent, error) {
+		return target.CreateAuditEvent(ctx, arg)
+	})
+	return globalAuditEvent(shard, event), err
+}
+
+// CreateDomain creates the domain on shard 0 and copies it to the other shards.
+// When a copy fails the domain stays on shard 0, run the reshard sync command to copy it again.
+func (q *shardQueries) CreateDomain(ctx context.Context, arg CreateDomainParams) (Domain, error) {
+	domain, err := onShard(q, 0, func(target Querier) (Domain, error) {
+		return target.CreateDomain(ctx, arg)
+	})
+	if err != nil {
+		return domain, err
+	}
+
+	err = q.CopyDomain(ctx, CopyDomainParams{
+		ID:          domain.ID,
+		Host:        domain.Host,
+		FallbackUrl: domain.FallbackUrl,
+		CreatedAt:   domain.CreatedAt,
+	})
+	return domain, err
+}
+
+// CreateFolder creates the folder on shard 0 and copies it to the other shards.
+func (q *shardQueries) CreateFolder(ctx context.Context, name string) (Folder, error) {
+	folder, err := onShard(q, 0, func(target Querier) (Folder, error) {
+		return target.CreateFolder(ctx, name)
+	})
+	if err != nil {
+		return folder, err
+	}
+
+	err = q.CopyFolder(ctx, CopyFolderParams{
+		ID:        folder.ID,
+		Name:      folder.Name,
+		CreatedAt: folder.CreatedAt,
+	})
+	return folder, err
+}
+
+// CreateURL creates the link on the shard that owns its short url.
+func (q *shardQueries) CreateURL(ctx context.Context, arg CreateURLParams) (Url, error) {
+	shard := q.ring.Locate(arg.ShortUrl)
+
+	url, err := onShard(q, shard, func(target Querier) (Url, error) {
+		return target.CreateURL(ctx, arg)
+	})
+	return globalURL(shard, url), err
+}
+
+func (q *shardQueries) CreateURLRevision(ctx context.Context, arg CreateURLRevisionParams) (UrlRevision, error) {
+	shard, local := q.splitID(arg.UrlID)
+	arg.UrlID = local
+
+	revision, err := onShard(q, shard, func(target Querier) (UrlRevision, error) {
+		return target.CreateURLRevision(ctx, arg)
+	})
+	return globalRevision(shard, revision), err
+}
+
+func (q *shardQueries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
+	return onShard(q, 0, func(target Querier) (Webhook, error) {
+		return target.CreateWebhook(ctx, arg)
+	})
+}
+
+func (q *shardQueries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error) {
+	return onShard(q, 0, func(target Querier) (WebhookDelivery, error) {
+		return target.CreateWebhookDelivery(ctx, arg)
+	})
+}
+
+func (q *shardQueries) DeleteURL(ctx context.Context, id int64) error {
+	shard, local := q.splitID(id)
+	return q.exec(shard, func(target Querier) error {
+		return target.DeleteURL(ctx, local)
+	})
+}
+
+func (q *shardQueries) DeleteURLTags(ctx context.Context, urlID int64) error {
+	shard, local := q.splitID(urlID)
+	return q.exec(shard, func(target Querier) error {
+		return target.DeleteURLTags(ctx, local)
+	})
+}
+
+func (q *shardQueries) DeleteWebhook(ctx context.Context, id int64) error {
+	return q.exec(0, func(target Querier) error {
+		return target.DeleteWebhook(ctx, id)
+	})
+}
+
+func (q *shardQueries) GetDeletedURL(ctx context.Context, arg GetDeletedURLParams) (Url, error) {
+	return q.findURL(arg.ShortUrl, func(target Querier) (Url, error) {
+		return target.GetDeletedURL(ctx, arg)
+	})
+}
+
+func (q *shardQueries) GetDomainByHost(ctx context.Context, host string) (Domain, error) {
+	return onShard(q, 0, func(target Querier) (Domain, error) {
+		return target.GetDomainByHost(ctx, host)
+	})
+}
+
+func (q *shardQueries) GetFolder(ctx context.Context, id int64) (Folder, error) {
+	return onShard(q, 0, func(target Querier) (Folder, error) {
+		return target.GetFolder(ctx, id)
+	})
+}
+
+func (q *shardQueries) GetURL(ctx context.Context, arg GetURLParams) (Url, error) {
+	return q.findURL(arg.ShortUrl, func(target Querier) (Url, error) {
+		return target.GetURL(ctx, arg)
+	})
+}
+
+func (q *shardQueries) GetURLForUpdate(ctx context.Context, id int64) (Url, error) {
+	return q.urlByID(id, func(target Querier, id int64) (Url, error) {
+		return target.GetURLForUpdate(ctx, id)
+	})
+}
+
+func (q *shardQueries) GetURLRevision(ctx context.Context, arg GetURLRevisionParams) (UrlRevision, error) {
+	shard, local := q.splitID(arg.UrlID)
+	arg.UrlID = local
+
+	revision, err := onShard(q, shard, func(target Querier) (UrlRevision, error) {
+		return target.GetURLRevision(ctx, arg)
+	})
+	return globalRevision(shard, revision), err
+}
+
+func (q *shardQueries) GetWebhook(ctx context.Context, id int64) (Webhook, error) {
+	return onShard(q, 0, func(target Querier) (Webhook, error) {
+		return target.GetWebhook(ctx, id)
+	})
+}
+
+func (q *shardQueries) GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	return onShard(q, 0, func(target Querier) (WebhookDelivery, error) {
+		return target.GetWebhookDelivery(ctx, id)
+	})
+}
+
+// ImportURL imports the link on the shard that owns its short url.
+func (q *shardQueries) ImportURL(ctx context.Context, arg ImportURLParams) (Url, error) {
+	shard := q.ring.Locate(arg.ShortUrl)
+
+	url, err := onShard(q, shard, func(target Querier) (Url, error) {
+		return target.ImportURL(ctx, arg)
+	})
+	return globalURL(shard, url), err
+}
+
+func (q *shardQueries) ImportURLRevision(ctx context.Context, arg ImportURLRevisionParams) (UrlRevision, error) {
+	shard, local := q.splitID(arg.UrlID)
+	arg.UrlID = local
+
+	revision, err := onShard(q, shard, func(target Querier) (UrlRevision, error) {
+		return target.ImportURLRevision(ctx, arg)
+	})
+	return globalRevision(shard, revision), err
+}
+
+// ListAuditEvents merges the events of every shard, newest id first.
+func (q *shardQueries) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error) {
+	shardArg := arg
+	shardArg.Limit = arg.Offset + arg.Limit
+	shardArg.Offset = 0
+
+	results, err := gather(q, func(shard int, target Querier) ([]AuditEvent, error) {
+		events, err := target.ListAuditEvents(ctx, shardArg)
+		for i := range events {
+			events[i] = globalAuditEvent(shard, events[i])
+		}
+		return events, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	events := mergeSorted(results, func(a, b AuditEvent) bool { return a.ID > b.ID })
+	return page(events, arg.Limit, arg.Offset), nil
+}
+
+func (q *shardQueries) ListDomains(ctx context.Context, arg ListDomainsParams) ([]Domain, error) {
+	return onShard(q, 0, func(target Querier) ([]Domain, error) {
+		return target.ListDomains(ctx, arg)
+	})
+}
+
+func (q *shardQueries) ListFolders(ctx context.Context, arg ListFoldersParams) ([]Folder, error) {
+	return onShard(q, 0, func(target Querier) ([]Folder, error) {
+		return target.ListFolders(ctx, arg)
+	})
+}
+
+// ListTagsByURLs asks each shard for the tags of its own links.
+func (q *shardQueries) ListTagsByURLs(ctx context.Context, urlIds []int64) ([]UrlTag, error) {
+	ids := make([][]int64, q.n)
+	for _, id := range urlIds {
+		shard, local := q.splitID(id)
+		ids[shard] = append(ids[shard], local)
+	}
+
+	results := make([][]UrlTag, q.n)
+	for shard := range ids {
+		if len(ids[shard]) == 0 {
+			continue
+		}
+
+		tags, err := onShard(q, shard, func(target Querier) ([]UrlTag, error) {
+			return target.ListTagsByURLs(ctx, ids[shard])
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range tags {
+			tags[i].UrlID = globalID(shard, tags[i].UrlID)
+		}
+		results[shard] = tags
+	}
+
+	return mergeSorted(results, func(a, b UrlTag) bool {
+		if a.UrlID != b.UrlID {
+			return a.UrlID < b.UrlID
+		}
+		return a.Tag < b.Tag
+	}), nil
+}
+
+func (q *shardQueries) ListURLRevisions(ctx context.Context, arg ListURLRevisionsParams) ([]UrlRevision, error) {
+	shard, local := q.splitID(arg.UrlID)
+	arg.UrlID = local
+
+	revisions, err := onShard(q, shard, func(target Querier) ([]UrlRevision, error) {
+		return target.ListURLRevisions(ctx, arg)
+	})
+	for i := range revisions {
+		revisions[i] = globalRevision(shard, revisions[i])
+	}
+	return revisions, err
+}
+
+func (q *shardQueries) ListURLTags(ctx context.Context, urlID int64) ([]string, error) {
+	shard, local := q.splitID(urlID)
+	return onShard(q, shard, func(target Querier) ([]string, error) {
+		return target.ListURLTags(ctx, local)
+	})
+}
+
+// ListURLs walks the shards in order, so the links come back ordered by their global id.
+func (q *shardQueries) ListURLs(ctx context.Context, arg ListURLsParams) ([]Url, error) {
+	items := []Url{}
+
+	first, afterID := q.splitID(arg.AfterID)
+
+	for shard := first; shard < q.n && int32(len(items)) < arg.Limit; shard++ {
+		urls, err := onShard(q, shard, func(target Querier) ([]Url, error) {
+			return target.ListURLs(ctx, ListURLsParams{
+				AfterID: afterID,
+				Limit:   arg.Limit - int32(len(items)),
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, globalURLs(shard, urls)...)
+		afterID = 0
+	}
+
+	return items, nil
+}
+
+// ListURLsToCheck merges the links of every shard, the never checked ones first.
+func (q *shardQueries) ListURLsToCheck(ctx context.Context, arg ListURLsToCheckParams) ([]Url, error) {
+	results, err := gather(q, func(shard int, target Querier) ([]Url, error) {
+		urls, err := target.ListURLsToCheck(ctx, arg)
+		return globalURLs(shard, urls), err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	urls := mergeSorted(results, func(a, b Url) bool {
+		if a.LastCheckedAt.Valid != b.LastCheckedAt.Valid {
+			return !a.LastCheckedAt.Valid
+		}
+		if !a.LastCheckedAt.Time.Equal(b.LastCheckedAt.Time) {
+			return a.LastCheckedAt.Time.Before(b.LastCheckedAt.Time)
+		}
+		return a.ID < b.ID
+	})
+	return page(urls, arg.Limit, 0), nil
+}
+
+func (q *shardQueries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	return onShard(q, 0, func(target Querier) ([]WebhookDelivery, error) {
+		return target.ListWebhookDeliveries(ctx, arg)
+	})
+}
+
+func (q *shardQueries) ListWebhooks(ctx context.Context, arg ListWebhooksParams) ([]Webhook, error) {
+	return onShard(q, 0, func(target Querier) ([]Webhook, error) {
+		return target.ListWebhooks(ctx, arg)
+	})
+}
+
+func (q *shardQueries) ListWebhooksForEvent(ctx context.Context, event string) ([]Webhook, error) {
+	return onShard(q, 0, func(target Querier) ([]Webhook, error) {
+		return target.ListWebhooksForEvent(ctx, event)
+	})
+}
+
+// MarkExpiredURLs marks the expired links shard by shard until limit links are marked.
+func (q *shardQueries) MarkExpiredURLs(ctx context.Context, limit int32) ([]Url, error) {
+	items := []Url{}
+
+	for shard := 0; shard < q.n && int32(len(items)) < limit; shard++ {
+		urls, err := onShard(q, shard, func(target Querier) ([]Url, error) {
+			return target.MarkExpiredURLs(ctx, limit-int32(len(items)))
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, globalURLs(shard, urls)...)
+	}
+
+	return items, nil
+}
+
+// PurgeDeletedURLs purges the links shard by shard until limit links are purged.
+func (q *shardQueries) PurgeDeletedURLs(ctx context.Context, arg PurgeDeletedURLsParams) ([]Url, error) {
+	items := []Url{}
+
+	first, end := q.shardRange(ctx)
+	for shard := first; shard < end && int32(len(items)) < arg.Limit; shard++ {
+		urls, err := onShard(q, shard, func(target Querier) ([]Url, error) {
+			return target.PurgeDeletedURLs(ctx, PurgeDeletedURLsParams{
+				DeletedBefore: arg.DeletedBefore,
+				Limit:         arg.Limit - int32(len(items)),
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, globalURLs(shard, urls)...)
+	}
+
+	return items, nil
+}
+
+func (q *shardQueries) RedeliverWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	return onShard(q, 0, func(target Querier) (WebhookDelivery, error) {
+		return target.RedeliverWebhookDelivery(ctx, id)
+	})
+}
+
+func (q *shardQueries) RestoreURL(ctx context.Context, arg RestoreURLParams) (Url, error) {
+	return q.urlByID(arg.ID, func(target Querier, id int64) (Url, error) {
+		arg.ID = id
+		return target.RestoreURL(ctx, arg)
+	})
+}
+
+// SearchURLs merges the links of every shard, newest id first.
+// Search ranks of different shards cannot be compared, so with a query the results of the shards are interleaved.
+func (q *shardQueries) SearchURLs(ctx context.Context, arg SearchURLsParams) ([]Url, error) {
+	shardArg := arg
+	shardArg.Limit = arg.Offset + arg.Limit
+	shardArg.Offset = 0
+
+	results, err := gather(q, func(shard int, target Querier) ([]Url, error) {
+		urls, err := target.SearchURLs(ctx, shardArg)
+		return globalURLs(shard, urls), err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if arg.Query == "" {
+		urls := mergeSorted(results, func(a, b Url) bool { return a.ID > b.ID })
+		return page(urls, arg.Limit, arg.Offset), nil
+	}
+
+	urls := []Url{}
+	for i := 0; ; i++ {
+		added := false
+		for _, rows := range results {
+			if i < len(rows) {
+				urls = append(urls, rows[i])
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+
+	return page(urls, arg.Limit, arg.Offset), nil
+}
+
+func (q *shardQueries) SetURLArchived(ctx context.Context, arg SetURLArchivedParams) (Url, error) {
+	return q.urlByID(arg.ID, func(target Querier, id int64) (Url, error) {
+		arg.ID = id
+		return target.SetURLArchived(ctx, arg)
+	})
+}
+
+func (q *shardQueries) SoftDeleteURL(ctx context.Context, id int64) (Url, error) {
+	return q.urlByID(id, func(target Querier, id int64) (Url, error) {
+		return target.SoftDeleteURL(ctx, id)
+	})
+}
+
+// UpdateURL keeps the link on its shard so that its id does not change,
+// the reshard move command later moves it to the shard that owns the new short url.
+// Outside resharding the link would not be found until it is moved, so a short url of another shard returns ErrCrossShard.
+// The other shards are checked first because each shard only keeps its own short urls unique.
+func (q *shardQueries) UpdateURL(ctx context.Context, arg UpdateURLParams) (Url, error) {
+	shard, local := q.splitID(arg.ID)
+
+	if !q.resharding && q.ring.Locate(arg.ShortUrl) != shard {
+		return Url{}, ErrCrossShard
+	}
+
+	if q.n > 1 {
+		url, err := onShard(q, shard, func(target Querier) (Url, error) {
+			return target.GetURLForUpdate(ctx, local)
+		})
+		if err != nil {
+			return Url{}, err
+		}
+
+		for other := 0; other < q.n; other++ {
+			if other == shard {
+				continue
+			}
+
+			taken, err := q.slugTaken(ctx, other, url.DomainID, arg.ShortUrl)
+			if err != nil {
+				return Url{}, err
+			}
+			if taken {
+				return Url{}, &pq.Error{
+					Code:       "23505",
+					Message:    `duplicate key value violates unique constraint "url_slugs_pkey"`,
+					Constraint: "url_slugs_pkey",
+				}
+			}
+		}
+	}
+
+	return q.urlByID(arg.ID, func(target Querier, id int64) (Url, error) {
+		arg.ID = id
+		return target.UpdateURL(ctx, arg)
+	})
+}
+
+// slugTaken reports whether the short url is used by a link on the shard, deleted or not.
+func (q *shardQueries) slugTaken(ctx context.Context, shard int, domainID int64, shortUrl string) (bool, error) {
+	target, err := q.on(shard)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = target.GetURL(ctx, GetURLParams{DomainID: domainID, ShortUrl: shortUrl})
+	if err != sql.ErrNoRows {
+		return err == nil, err
+	}
+
+	_, err = target.GetDeletedURL(ctx, GetDeletedURLParams{DomainID: domainID, ShortUrl: shortUrl})
+	if err != sql.ErrNoRows {
+		return err == nil, err
+	}
+
+	return false, nil
+}
+
+func (q *shardQueries) UpdateURLCheck(ctx context.Context, arg UpdateURLCheckParams) (Url, error) {
+	return q.urlByID(arg.ID, func(target Querier, id int64) (Url, error) {
+		arg.ID = id
+		return target.UpdateURLCheck(ctx, arg)
+	})
+}
+
+func (q *shardQueries) UpdateURLClickCount(ctx context.Context, arg UpdateURLClickCountParams) error {
+	shard, local := q.splitID(arg.ID)
+	arg.ID = local
+
+	return q.exec(shard, func(target Querier) error {
+		return target.UpdateURLClickCount(ctx, arg)
+	})
+}
+
+func (q *shardQueries) UpdateURLMetadata(ctx context.Context, arg UpdateURLMetadataParams) error {
+	shard, local := q.splitID(arg.ID)
+	arg.ID = local
+
+	return q.exec(shard, func(target Querier) error {
+		return target.UpdateURLMetadata(ctx, arg)
+	})
+}
+
+func (q *shardQueries) UpdateURLSettings(ctx context.Context, arg UpdateURLSettingsParams) (Url, error) {
+	return q.urlByID(arg.ID, func(target Querier, id int64) (Url, error) {
+		arg.ID = id
+		return target.UpdateURLSettings(ctx, arg)
+	})
+}
+
+func (q *shardQueries) UpdateWebhookDelivery(ctx context.Context, arg UpdateWebhookDeliveryParams) (WebhookDelivery, error) {
+	return onShard(q, 0, func(target Querier) (WebhookDelivery, error) {
+		return target.UpdateWebhookDelivery(ctx, arg)
+	})
+}
@@ -0,0 +1,297 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"shortURL/db/dbtest"
+	"shortURL/db/kv"
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newBoltShards(t *testing.T, n int) []db.TxQuerier {
+	shards := make([]db.TxQuerier, n)
+	for i := range shards {
+		backend, err := kv.OpenBolt(filepath.Join(t.TempDir(), fmt.Sprintf("shard%d.db", i)))
+		require.NoError(t, err)
+		t.Cleanup(func() { backend.Close() })
+
+		shards[i], err = kv.NewStore(backend)
+		require.NoError(t, err)
+	}
+
+	return shards
+}
+
+func TestShardedStore(t *testing.T) {
+	dbtest.RunQuerierSuite(t, func(t *testing.T) db.Store {
+		return db.NewShardedTxStore(true, newBoltShards(t, 3)...)
+	})
+}
+
+func TestRingDistribution(t *testing.T) {
+	ring := db.NewRing(4)
+
+	counts := make([]int, 4)
+	for i := 0; i < 4000; i++ {
+		counts[ring.Locate(util.RandomString(8))]++
+	}
+
+	for _, count := range counts {
+		require.InDelta(t, 1000, count, 300)
+	}
+}
+
+func TestRingAddShard(t *testing.T) {
+	before := db.NewRing(3)
+	after := db.NewRing(4)
+
+	moved := 0
+	for i := 0; i < 4000; i++ {
+		shortUrl := util.RandomString(8)
+		if before.Locate(shortUrl) != after.Locate(shortUrl) {
+			// 只有新的分片接手的短網址需要搬移
+			require.Equal(t, 3, after.Locate(shortUrl))
+			moved++
+		}
+	}
+
+	require.InDelta(t, 1000, moved, 300)
+}
+
+func createShardedURL(t *testing.T, q db.Querier) db.Url {
+	url, err := q.CreateURL(context.Background(), db.CreateURLParams{
+		OriginUrl: "https://" + util.RandomLongURL(),
+		ShortUrl:  util.RandomString(8),
+		Rules:     json.RawMessage("[]"),
+		Variants:  json.RawMessage("[]"),
+		Utm:       json.RawMessage("{}"),
+	})
+	require.NoError(t, err)
+
+	return url
+}
+
+func TestShardedQuerierIDs(t *testing.T) {
+	ctx := context.Background()
+	shards := newBoltShards(t, 3)
+	router := db.NewShardedQuerier(false, shards...)
+	ring := db.NewRing(3)
+
+	for i := 0; i < 30; i++ {
+		url := createShardedURL(t, router)
+
+		// id 的高位元是連結所在的分片
+		shard := ring.Locate(url.ShortUrl)
+		require.Equal(t, int64(shard), url.ID>>48)
+
+		local, err := shards[shard].GetURL(ctx, db.GetURLParams{ShortUrl: url.ShortUrl})
+		require.NoError(t, err)
+		require.Equal(t, url.ID&(1<<48-1), local.ID)
+
+		got, err := router.GetURLForUpdate(ctx, url.ID)
+		require.NoError(t, err)
+		require.Equal(t, url.ShortUrl, got.ShortUrl)
+	}
+}
+
+func TestShardedQuerierAddShard(t *testing.T) {
+	ctx := context.Background()
+	shards := newBoltShards(t, 3)
+
+	var urls []db.Url
+	router := db.NewShardedQuerier(false, shards[:2]...)
+	for i := 0; i < 30; i++ {
+		urls = append(urls, createShardedURL(t, router))
+	}
+
+	// 沒有開啟重新分片時只查詢擁有短網址的分片
+	router = db.NewShardedQuerier(false, shards...)
+	ring := db.NewRing(3)
+	for _, url := range urls {
+		_, err := router.GetURL(ctx, db.GetURLParams{ShortUrl: url.ShortUrl})
+		if ring.Locate(url.ShortUrl) == int(url.ID>>48) {
+			require.NoError(t, err)
+		} else {
+			require.ErrorIs(t, err, sql.ErrNoRows)
+		}
+	}
+
+	// 重新分片期間尚未搬移的連結仍然可以轉址
+	router = db.NewShardedQuerier(true, shards...)
+	for _, url := range urls {
+		got, err := router.GetURL(ctx, db.GetURLParams{ShortUrl: url.ShortUrl})
+		require.NoError(t, err)
+		require.Equal(t, url.ID, got.ID)
+
+		got, err = router.GetURLForUpdate(ctx, url.ID)
+		require.NoError(t, err)
+		require.Equal(t, url.ShortUrl, got.ShortUrl)
+	}
+}
+
+func TestShardedQuerierRename(t *testing.T) {
+	ctx := context.Background()
+	shards := newBoltShards(t, 3)
+	router := db.NewShardedQuerier(true, shards...)
+	ring := db.NewRing(3)
+
+	url := createShardedURL(t, router)
+	other := createShardedURL(t, router)
+
+	// 改成其他分片的短網址時連結留在原本的分片
+	shortUrl := util.RandomString(8)
+	for ring.Locate(shortUrl) == ring.Locate(url.ShortUrl) {
+		shortUrl = util.RandomString(8)
+	}
+
+	updated, err := router.UpdateURL(ctx, db.UpdateURLParams{ID: url.ID, ShortUrl: shortUrl})
+	require.NoError(t, err)
+	require.Equal(t, url.ID, updated.ID)
+
+	got, err := router.GetURL(ctx, db.GetURLParams{ShortUrl: shortUrl})
+	require.NoError(t, err)
+	require.Equal(t, url.ID, got.ID)
+
+	// 其他分片上已使用的短網址不能再使用
+	_, err = router.UpdateURL(ctx, db.UpdateURLParams{ID: other.ID, ShortUrl: shortUrl})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "url_slugs_pkey")
+
+	// 沒有開啟重新分片時不能改成其他分片的短網址
+	router = db.NewShardedQuerier(false, shards...)
+	shortUrl = util.RandomString(8)
+	for ring.Locate(shortUrl) == ring.Locate(other.ShortUrl) {
+		shortUrl = util.RandomString(8)
+	}
+
+	_, err = router.UpdateURL(ctx, db.UpdateURLParams{ID: other.ID, ShortUrl: shortUrl})
+	require.ErrorIs(t, err, db.ErrCrossShard)
+
+	shortUrl = util.RandomString(8)
+	for ring.Locate(shortUrl) != ring.Locate(other.ShortUrl) {
+		shortUrl = util.RandomString(8)
+	}
+
+	updated, err = router.UpdateURL(ctx, db.UpdateURLParams{ID: other.ID, ShortUrl: shortUrl})
+	require.NoError(t, err)
+	require.Equal(t, other.ID, updated.ID)
+}
+
+func TestShardedQuerierCrossShardTx(t *testing.T) {
+	ctx := context.Background()
+	router := db.NewShardedQuerier(false, newBoltShards(t, 3)...)
+	ring := db.NewRing(3)
+
+	url := createShardedURL(t, router)
+	other := createShardedURL(t, router)
+	for ring.Locate(other.ShortUrl) == ring.Locate(url.ShortUrl) {
+		other = createShardedURL(t, router)
+	}
+
+	err := router.ExecTx(ctx, func(q db.Querier) error {
+		err := q.AddURLTag(ctx, db.AddURLTagParams{UrlID: url.ID, Tag: "a"})
+		if err != nil {
+			return err
+		}
+
+		return q.AddURLTag(ctx, db.AddURLTagParams{UrlID: other.ID, Tag: "a"})
+	})
+	require.True(t, errors.Is(err, db.ErrCrossShard))
+
+	// 交易復原，第一個標籤也沒有寫入
+	tags, err := router.ListURLTags(ctx, url.ID)
+	require.NoError(t, err)
+	require.Empty(t, tags)
+
+	err = router.ExecTx(ctx, func(q db.Querier) error {
+		return q.AddURLTag(ctx, db.AddURLTagParams{UrlID: other.ID, Tag: "b"})
+	})
+	require.NoError(t, err)
+
+	tagged, err := router.ListTagsByURLs(ctx, []int64{url.ID, other.ID})
+	require.NoError(t, err)
+	require.Equal(t, []db.UrlTag{{UrlID: other.ID, Tag: "b"}}, tagged)
+
+	_, err = router.GetURL(ctx, db.GetURLParams{ShortUrl: util.RandomString(12)})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestMoveURLs(t *testing.T) {
+	ctx := context.Background()
+	shards := newBoltShards(t, 3)
+
+	router := db.NewShardedQuerier(false, shards[:2]...)
+	domain, err := router.CreateDomain(ctx, db.CreateDomainParams{Host: util.RandomString(10) + ".example.com"})
+	require.NoError(t, err)
+
+	var urls []db.Url
+	for i := 0; i < 30; i++ {
+		url := createShardedURL(t, router)
+		require.NoError(t, router.AddURLTag(ctx, db.AddURLTagParams{UrlID: url.ID, Tag: "moved"}))
+
+		_, err := router.CreateURLRevision(ctx, db.CreateURLRevisionParams{UrlID: url.ID, OriginUrl: url.OriginUrl})
+		require.NoError(t, err)
+		urls = append(urls, url)
+	}
+
+	// 加入的分片需要先複製網域與資料夾
+	require.NoError(t, db.SyncShardCatalog(ctx, shards))
+	got, err := shards[2].GetDomainByHost(ctx, domain.Host)
+	require.NoError(t, err)
+	require.Equal(t, domain.ID, got.ID)
+
+	var planned []db.URLMove
+	err = db.MoveURLs(ctx, shards, 7, true, func(move db.URLMove) {
+		planned = append(planned, move)
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, planned)
+	for _, move := range planned {
+		require.Equal(t, 2, move.To)
+	}
+
+	var moves []db.URLMove
+	err = db.MoveURLs(ctx, shards, 7, false, func(move db.URLMove) {
+		require.NoError(t, move.Err)
+		moves = append(moves, move)
+	})
+	require.NoError(t, err)
+	require.Len(t, moves, len(planned))
+
+	// 搬移完成後不需要查詢其他分片
+	router = db.NewShardedQuerier(false, shards...)
+	for _, url := range urls {
+		got, err := router.GetURL(ctx, db.GetURLParams{ShortUrl: url.ShortUrl})
+		require.NoError(t, err)
+		require.Equal(t, url.OriginUrl, got.OriginUrl)
+		require.Equal(t, int64(db.NewRing(3).Locate(url.ShortUrl)), got.ID>>48)
+
+		tags, err := router.ListURLTags(ctx, got.ID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"moved"}, tags)
+
+		revisions, err := router.ListURLRevisions(ctx, db.ListURLRevisionsParams{UrlID: got.ID, Limit: 5})
+		require.NoError(t, err)
+		require.Len(t, revisions, 1)
+	}
+
+	for _, move := range moves {
+		_, err := router.GetURLForUpdate(ctx, move.OldID)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	}
+
+	// 搬移完成後沒有需要搬移的連結
+	err = db.MoveURLs(ctx, shards, 7, false, func(move db.URLMove) {
+		t.Errorf("unexpected move of %s", move.Url.ShortUrl)
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,469 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Audit actions recorded for link mutations.
+const (
+	AuditActionCreate    = "create"
+	AuditActionUpdate    = "update"
+	AuditActionDelete    = "delete"
+	AuditActionArchive   = "archive"
+	AuditActionUnarchive = "unarchive"
+	AuditActionRestore   = "restore"
+	AuditActionPurge     = "purge"
+	// AuditActionDiscard is recorded when a link created in write-behind mode is dropped
+	// because its short url was taken before it was written.
+	AuditActionDiscard = "discard"
+)
+
+// maxTxAttempts is how many times a transaction runs before a serialization conflict is returned.
+const maxTxAttempts = 3
+
+// TxQuerier is a Querier that can run several queries in one transaction.
+type TxQuerier interface {
+	Querier
+	ExecTx(ctx context.Context, fn func(Querier) error) error
+}
+
+// Store provides all functions to execute db queries and transactions.
+type Store interface {
+	TxQuerier
+	CreateURLTx(ctx context.Context, arg CreateURLTxParams) (URLTxResult, error)
+	CreateURLBatchTx(ctx context.Context, args []CreateURLTxParams) ([]URLBatchResult, error)
+	UpdateURLTx(ctx context.Context, arg UpdateURLTxParams) (URLTxResult, error)
+	SoftDeleteURLTx(ctx context.Context, id int64, audit AuditInfo) (Url, error)
+	SetURLArchivedTx(ctx context.Context, arg SetURLArchivedParams, audit AuditInfo) (Url, error)
+	RestoreURLTx(ctx context.Context, arg RestoreURLParams, audit AuditInfo) (Url, error)
+	PurgeDeletedURLsTx(ctx context.Context, arg PurgeDeletedURLsParams) ([]Url, error)
+}
+
+// SQLStore provides all functions to execute SQL queries and transactions.
+type SQLStore struct {
+	*Queries
+	db *sql.DB
+}
+
+// NewStore creates a new store.
+func NewStore(db *sql.DB) Store {
+	return NewTxStore(NewSQLStore(db))
+}
+
+// NewSQLStore creates the queries and transactions of db without the link transactions.
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{
+		Queries: NewQuery(db),
+		db:      db,
+	}
+}
+
+// txStore builds the link transactions on top of any TxQuerier.
+type txStore struct {
+	TxQuerier
+}
+
+// NewTxStore creates a store that runs the link transactions through q.
+func NewTxStore(q TxQuerier) Store {
+	return &txStore{TxQuerier: q}
+}
+
+// AuditInfo describes who made a change and from where.
+type AuditInfo struct {
+	Actor     string
+	RequestID string
+	ClientIP  string
+}
+
+// ExecTx executes a function within a serializable transaction.
+// The transaction is retried when it conflicts with a concurrent one,
+// so fn may run more than once and must not have side effects outside the transaction.
+func (store *SQLStore) ExecTx(ctx context.Context, fn func(Querier) error) error {
+	return retryTx(func() error {
+		return store.execTx(ctx, fn)
+	})
+}
+
+// retryTx runs tx until it does not fail with a serialization conflict, at most maxTxAttempts times.
+func retryTx(tx func() error) error {
+	var err error
+
+	for attempt := 1; attempt <= maxTxAttempts; attempt++ {
+		err = tx()
+		if !isSerializationFailure(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (store *SQLStore) execTx(ctx context.Context, fn func(Querier) error) error {
+	tx, err := store.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return err
+	}
+
+	q := store.Queries.WithTx(tx)
+	err = fn(q)
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("tx err: %w, rb err: %v", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// isSerializationFailure reports whether the transaction can succeed when retried.
+func isSerializationFailure(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
+
+// ShortURLConflictError is returned when the short url is already taken in the domain.
+type ShortURLConflictError struct {
+	DomainID int64
+	ShortUrl string
+}
+
+func (e *ShortURLConflictError) Error() string {
+	return fmt.Sprintf("short url %q already exists in domain %d", e.ShortUrl, e.DomainID)
+}
+
+// CreateURLTxParams contains the input parameters of creating a link.
+type CreateURLTxParams struct {
+	CreateURLParams
+	Tags  []string
+	Audit AuditInfo
+	// CreatedAt keeps the creation time of a link created before it is written, zero uses the current time.
+	CreatedAt time.Time
+}
+
+// UpdateURLTxParams contains the input parameters of updating a link.
+type UpdateURLTxParams struct {
+	UpdateURLSettingsParams
+	// Tags replaces the tags of the link, nil keeps the current tags.
+	Tags  *[]string
+	Audit AuditInfo
+}
+
+// URLTxResult is the result of a link transaction.
+type URLTxResult struct {
+	Url  Url
+	Tags []string
+}
+
+// CreateURLTx creates a link with its tags, first revision and audit event in a single transaction.
+// It returns a *ShortURLConflictError when the short url is already taken.
+func (store *txStore) CreateURLTx(ctx context.Context, arg CreateURLTxParams) (URLTxResult, error) {
+	var result URLTxResult
+
+	err := store.ExecTx(ctx, func(q Querier) error {
+		var err error
+		result, err = insertURL(ctx, q, arg)
+		return err
+	})
+
+	return result, err
+}
+
+// URLBatchResult is the result of a link in CreateURLBatchTx.
+type URLBatchResult struct {
+	URLTxResult
+	// Err is a *ShortURLConflictError when the short url is already taken, the link is then not created.
+	Err error
+}
+
+// CreateURLBatchTx creates the links like CreateURLTx in a single transaction.
+// The links whose short url is already taken are skipped, any other error rolls back the whole batch.
+func (store *txStore) CreateURLBatchTx(ctx context.Context, args []CreateURLTxParams) ([]URLBatchResult, error) {
+	var results []URLBatchResult
+
+	err := store.ExecTx(ctx, func(q Querier) error {
+		// 交易重試時重新計算所有結果
+		results = make([]URLBatchResult, len(args))
+
+		for i, arg := range args {
+			result, err := insertURL(ctx, q, arg)
+			if _, ok := err.(*ShortURLConflictError); ok {
+				results[i].Err = err
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			results[i].URLTxResult = result
+		}
+
+		return nil
+	})
+
+	return results, err
+}
+
+// insertURL creates a link with its tags, first revision and audit event through q.
+func insertURL(ctx context.Context, q Querier, arg CreateURLTxParams) (URLTxResult, error) {
+	var result URLTxResult
+	var err error
+
+	if arg.CreatedAt.IsZero() {
+		result.Url, err = q.CreateURL(ctx, arg.CreateURLParams)
+	} else {
+		result.Url, err = q.ImportURL(ctx, ImportURLParams{
+			OriginUrl:     arg.OriginUrl,
+			ShortUrl:      arg.ShortUrl,
+			CreatedAt:     arg.CreatedAt,
+			RedirectType:  arg.RedirectType,
+			NotAfter:      arg.NotAfter,
+			DomainID:      arg.DomainID,
+			PasswordHash:  arg.PasswordHash,
+			NotBefore:     arg.NotBefore,
+			MaxClicks:     arg.MaxClicks,
+			Rules:         arg.Rules,
+			Variants:      arg.Variants,
+			QueryMode:     arg.QueryMode,
+			Utm:           arg.Utm,
+			IsPrefix:      arg.IsPrefix,
+			Title:         arg.Title,
+			Description:   arg.Description,
+			Owner:         arg.Owner,
+			FolderID:      arg.FolderID,
+			RedirectChain: json.RawMessage("[]"),
+		})
+	}
+	if err != nil {
+		// 短網址已被使用時不會新增任何資料
+		if err == sql.ErrNoRows {
+			return URLTxResult{}, &ShortURLConflictError{DomainID: arg.DomainID, ShortUrl: arg.ShortUrl}
+		}
+		return URLTxResult{}, err
+	}
+
+	err = addURLTags(ctx, q, result.Url.ID, arg.Tags)
+	if err != nil {
+		return URLTxResult{}, err
+	}
+	result.Tags = arg.Tags
+
+	_, err = q.CreateURLRevision(ctx, CreateURLRevisionParams{
+		UrlID:     result.Url.ID,
+		OriginUrl: result.Url.OriginUrl,
+		Actor:     arg.Audit.Actor,
+	})
+	if err != nil {
+		return URLTxResult{}, err
+	}
+
+	err = recordAuditEvent(ctx, q, AuditActionCreate, nil, &result.Url, arg.Audit)
+	if err != nil {
+		return URLTxResult{}, err
+	}
+
+	return result, nil
+}
+
+// UpdateURLTx updates a link and its tags and records its state before and after.
+// A new revision is recorded when the destination changes.
+func (store *txStore) UpdateURLTx(ctx context.Context, arg UpdateURLTxParams) (URLTxResult, error) {
+	var result URLTxResult
+
+	err := store.ExecTx(ctx, func(q Querier) error {
+		url, err := mutateURL(ctx, q, arg.ID, AuditActionUpdate, arg.Audit, func(before Url) (Url, error) {
+			url, err := q.UpdateURLSettings(ctx, arg.UpdateURLSettingsParams)
+			if err != nil || url.OriginUrl == before.OriginUrl {
+				return url, err
+			}
+
+			_, err = q.CreateURLRevision(ctx, CreateURLRevisionParams{
+				UrlID:     url.ID,
+				OriginUrl: url.OriginUrl,
+				Actor:     arg.Audit.Actor,
+			})
+			return url, err
+		})
+		if err != nil {
+			return err
+		}
+		result.Url = url
+
+		if arg.Tags == nil {
+			result.Tags, err = q.ListURLTags(ctx, url.ID)
+			return err
+		}
+
+		err = q.DeleteURLTags(ctx, url.ID)
+		if err != nil {
+			return err
+		}
+
+		result.Tags = *arg.Tags
+		return addURLTags(ctx, q, url.ID, result.Tags)
+	})
+
+	return result, err
+}
+
+// SoftDeleteURLTx leaves a tombstone on the link and records the audit event.
+// The tombstone keeps the slug taken until the link is purged.
+func (store *txStore) SoftDeleteURLTx(ctx context.Context, id int64, audit AuditInfo) (Url, error) {
+	return store.mutateURLTx(ctx, id, AuditActionDelete, audit, func(q Querier) (Url, error) {
+		return q.SoftDeleteURL(ctx, id)
+	})
+}
+
+// SetURLArchivedTx archives or unarchives a link and records the audit event.
+func (store *txStore) SetURLArchivedTx(ctx context.Context, arg SetURLArchivedParams, audit AuditInfo) (Url, error) {
+	action := AuditActionUnarchive
+	if arg.Archived {
+		action = AuditActionArchive
+	}
+
+	return store.mutateURLTx(ctx, arg.ID, action, audit, func(q Querier) (Url, error) {
+		return q.SetURLArchived(ctx, arg)
+	})
+}
+
+// RestoreURLTx restores a deleted link and records the audit event.
+func (store *txStore) RestoreURLTx(ctx context.Context, arg RestoreURLParams, audit AuditInfo) (Url, error) {
+	return store.mutateURLTx(ctx, arg.ID, AuditActionRestore, audit, func(q Querier) (Url, error) {
+		return q.RestoreURL(ctx, arg)
+	})
+}
+
+// PurgeActor is the actor of the audit events recorded when deleted links are purged.
+const PurgeActor = "system:purge"
+
+// PurgeDeletedURLsTx hard-deletes the links deleted before arg.DeletedBefore, up to arg.Limit,
+// and records an audit event for each of them in the same transaction.
+func (store *txStore) PurgeDeletedURLsTx(ctx context.Context, arg PurgeDeletedURLsParams) ([]Url, error) {
+	var urls []Url
+
+	err := store.ExecTx(ctx, func(q Querier) error {
+		var err error
+
+		urls, err = q.PurgeDeletedURLs(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		for i := range urls {
+			err = recordAuditEvent(ctx, q, AuditActionPurge, &urls[i], nil, AuditInfo{Actor: PurgeActor})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return urls, err
+}
+
+// mutateURLTx applies a single mutation to a link in its own transaction.
+func (store *txStore) mutateURLTx(ctx context.Context, id int64, action string, audit AuditInfo, mutate func(Querier) (Url, error)) (Url, error) {
+	var url Url
+
+	err := store.ExecTx(ctx, func(q Querier) error {
+		var err error
+
+		url, err = mutateURL(ctx, q, id, action, audit, func(Url) (Url, error) {
+			return mutate(q)
+		})
+		return err
+	})
+
+	return url, err
+}
+
+// mutateURL locks the link, applies the mutation and records both states.
+func mutateURL(ctx context.Context, q Querier, id int64, action string, audit AuditInfo, mutate func(before Url) (Url, error)) (Url, error) {
+	before, err := q.GetURLForUpdate(ctx, id)
+	if err != nil {
+		return Url{}, err
+	}
+
+	url, err := mutate(before)
+	if err != nil {
+		return Url{}, err
+	}
+
+	err = recordAuditEvent(ctx, q, action, &before, &url, audit)
+	return url, err
+}
+
+func addURLTags(ctx context.Context, q Querier, urlID int64, tags []string) error {
+	for _, tag := range tags {
+		err := q.AddURLTag(ctx, AddURLTagParams{
+			UrlID: urlID,
+			Tag:   tag,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecordAuditEvent records a change of a link made outside the link transactions of the store.
+// before or after is nil when the link did not exist before or after the change.
+func RecordAuditEvent(ctx context.Context, q Querier, action string, before, after *Url, audit AuditInfo) error {
+	return recordAuditEvent(ctx, q, action, before, after, audit)
+}
+
+func recordAuditEvent(ctx context.Context, q Querier, action string, before, after *Url, audit AuditInfo) error {
+	url := after
+	if url == nil {
+		url = before
+	}
+
+	beforeJSON, err := auditSnapshot(before)
+	if err != nil {
+		return err
+	}
+
+	afterJSON, err := auditSnapshot(after)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateAuditEvent(ctx, CreateAuditEventParams{
+		UrlID:     url.ID,
+		DomainID:  url.DomainID,
+		ShortUrl:  url.ShortUrl,
+		Actor:     audit.Actor,
+		Action:    action,
+		Before:    beforeJSON,
+		After:     afterJSON,
+		RequestID: audit.RequestID,
+		ClientIp:  audit.ClientIP,
+	})
+	return err
+}
+
+// auditSnapshot marshals a link for the audit log, the password hash is never recorded.
+func auditSnapshot(url *Url) (json.RawMessage, error) {
+	if url == nil {
+		return json.RawMessage("null"), nil
+	}
+
+	snapshot := *url
+	if snapshot.PasswordHash != "" {
+		snapshot.PasswordHash = "[redacted]"
+	}
+
+	return json.Marshal(snapshot)
+}
@@ -0,0 +1,321 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"shortURL/util"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func listURLAuditEvents(t *testing.T, url Url) []AuditEvent {
+	events, err := testQueries.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		DomainID: url.DomainID,
+		ShortUrl: url.ShortUrl,
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	return events
+}
+
+func TestCreateURLTx(t *testing.T) {
+	store := NewStore(testDB)
+	audit := AuditInfo{Actor: util.RandomString(6), RequestID: util.RandomString(32), ClientIP: "127.0.0.1"}
+
+	arg := CreateURLTxParams{
+		CreateURLParams: CreateURLParams{
+			OriginUrl:    util.RandomLongURL(),
+			ShortUrl:     util.RandomString(6),
+			PasswordHash: "secret-hash",
+			Rules:        json.RawMessage("[]"),
+			Variants:     json.RawMessage("[]"),
+			Utm:          json.RawMessage("{}"),
+		},
+		Tags:  []string{"docs", "product"},
+		Audit: audit,
+	}
+
+	result, err := store.CreateURLTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Tags, result.Tags)
+
+	url := result.Url
+	tags, err := store.ListURLTags(context.Background(), url.ID)
+	require.NoError(t, err)
+	require.Equal(t, arg.Tags, tags)
+
+	events := listURLAuditEvents(t, url)
+	require.Len(t, events, 1)
+	require.Equal(t, url.ID, events[0].UrlID)
+	require.Equal(t, AuditActionCreate, events[0].Action)
+	require.Equal(t, audit.Actor, events[0].Actor)
+	require.Equal(t, audit.RequestID, events[0].RequestID)
+	require.Equal(t, audit.ClientIP, events[0].ClientIp)
+	require.Equal(t, "null", string(events[0].Before))
+
+	revision, err := store.GetURLRevision(context.Background(), GetURLRevisionParams{UrlID: url.ID, Revision: 1})
+	require.NoError(t, err)
+	require.Equal(t, url.OriginUrl, revision.OriginUrl)
+
+	// 不記錄密碼雜湊
+	var after Url
+	require.NoError(t, json.Unmarshal(events[0].After, &after))
+	require.Equal(t, url.OriginUrl, after.OriginUrl)
+	require.NotContains(t, string(events[0].After), arg.PasswordHash)
+}
+
+func TestURLMutationTx(t *testing.T) {
+	store := NewStore(testDB)
+	audit := AuditInfo{Actor: util.RandomString(6)}
+	url1 := createRandomURL(t)
+
+	tags := []string{"sale"}
+	result, err := store.UpdateURLTx(context.Background(), UpdateURLTxParams{
+		UpdateURLSettingsParams: UpdateURLSettingsParams{
+			ID:           url1.ID,
+			OriginUrl:    util.RandomLongURL(),
+			RedirectType: url1.RedirectType,
+			QueryMode:    url1.QueryMode,
+			Rules:        url1.Rules,
+			Variants:     url1.Variants,
+			Utm:          url1.Utm,
+		},
+		Tags:  &tags,
+		Audit: audit,
+	})
+	require.NoError(t, err)
+	require.Equal(t, tags, result.Tags)
+	url2 := result.Url
+
+	_, err = store.SetURLArchivedTx(context.Background(), SetURLArchivedParams{ID: url1.ID, Archived: true}, audit)
+	require.NoError(t, err)
+
+	_, err = store.SoftDeleteURLTx(context.Background(), url1.ID, audit)
+	require.NoError(t, err)
+
+	_, err = store.RestoreURLTx(context.Background(), RestoreURLParams{ID: url1.ID, DeletedAfter: time.Now().Add(-time.Minute)}, audit)
+	require.NoError(t, err)
+
+	// 新的在前
+	events := listURLAuditEvents(t, url1)
+	require.Len(t, events, 4)
+	require.Equal(t, AuditActionRestore, events[0].Action)
+	require.Equal(t, AuditActionDelete, events[1].Action)
+	require.Equal(t, AuditActionArchive, events[2].Action)
+	require.Equal(t, AuditActionUpdate, events[3].Action)
+
+	var before, after Url
+	require.NoError(t, json.Unmarshal(events[3].Before, &before))
+	require.NoError(t, json.Unmarshal(events[3].After, &after))
+	require.Equal(t, url1.OriginUrl, before.OriginUrl)
+	require.Equal(t, url2.OriginUrl, after.OriginUrl)
+
+	// 只有長連結改變時記錄新的版本
+	revisions, err := testQueries.ListURLRevisions(context.Background(), ListURLRevisionsParams{UrlID: url1.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	require.Equal(t, url2.OriginUrl, revisions[0].OriginUrl)
+	require.Equal(t, audit.Actor, revisions[0].Actor)
+}
+
+func TestURLMutationTxRollback(t *testing.T) {
+	store := NewStore(testDB)
+	url1 := createRandomURL(t)
+
+	// 還原失敗時不留下稽核紀錄
+	_, err := store.RestoreURLTx(context.Background(), RestoreURLParams{ID: url1.ID, DeletedAfter: time.Now()}, AuditInfo{})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.Empty(t, listURLAuditEvents(t, url1))
+}
+
+func TestCreateURLTxRollback(t *testing.T) {
+	store := NewStore(testDB)
+
+	arg := CreateURLTxParams{
+		CreateURLParams: CreateURLParams{
+			OriginUrl: util.RandomLongURL(),
+			ShortUrl:  util.RandomString(6),
+			Rules:     json.RawMessage("[]"),
+			Variants:  json.RawMessage("[]"),
+			Utm:       json.RawMessage("{}"),
+		},
+		// 重複的標籤違反主鍵
+		Tags: []string{"docs", "docs"},
+	}
+
+	_, err := store.CreateURLTx(context.Background(), arg)
+	require.Error(t, err)
+
+	// 標籤寫入失敗時連結也不會建立
+	_, err = store.GetURL(context.Background(), GetURLParams{ShortUrl: arg.ShortUrl})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestCreateURLTxConflict(t *testing.T) {
+	store := NewStore(testDB)
+	url1 := createRandomURL(t)
+
+	arg := CreateURLTxParams{
+		CreateURLParams: CreateURLParams{
+			OriginUrl: util.RandomLongURL(),
+			ShortUrl:  url1.ShortUrl,
+			DomainID:  url1.DomainID,
+			Rules:     json.RawMessage("[]"),
+			Variants:  json.RawMessage("[]"),
+			Utm:       json.RawMessage("{}"),
+		},
+		Tags: []string{"docs"},
+	}
+
+	_, err := store.CreateURLTx(context.Background(), arg)
+
+	var conflict *ShortURLConflictError
+	require.ErrorAs(t, err, &conflict)
+	require.Equal(t, url1.ShortUrl, conflict.ShortUrl)
+
+	// 原本的連結不受影響
+	url2, err := store.GetURL(context.Background(), GetURLParams{DomainID: url1.DomainID, ShortUrl: url1.ShortUrl})
+	require.NoError(t, err)
+	require.Equal(t, url1.OriginUrl, url2.OriginUrl)
+
+	// 已刪除的連結在永久刪除前仍佔用短網址
+	_, err = store.SoftDeleteURL(context.Background(), url1.ID)
+	require.NoError(t, err)
+
+	_, err = store.CreateURLTx(context.Background(), arg)
+	require.ErrorAs(t, err, &conflict)
+}
+
+func TestExecTxRetry(t *testing.T) {
+	store := NewStore(testDB)
+
+	attempts := 0
+	err := store.ExecTx(context.Background(), func(q Querier) error {
+		attempts++
+		if attempts == 1 {
+			return &pq.Error{Code: "40001"}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, attempts)
+
+	// 一般錯誤不重試
+	attempts = 0
+	err = store.ExecTx(context.Background(), func(q Querier) error {
+		attempts++
+		return sql.ErrConnDone
+	})
+	require.ErrorIs(t, err, sql.ErrConnDone)
+	require.Equal(t, 1, attempts)
+
+	// 超過次數時回傳衝突
+	attempts = 0
+	err = store.ExecTx(context.Background(), func(q Querier) error {
+		attempts++
+		return &pq.Error{Code: "40001"}
+	})
+	require.True(t, isSerializationFailure(err))
+	require.Equal(t, maxTxAttempts, attempts)
+}
+
+func TestRetryTx(t *testing.T) {
+	testCases := []struct {
+		name     string
+		errs     []error
+		attempts int
+		check    func(t *testing.T, err error)
+	}{
+		{
+			name:     "Serialization failure",
+			errs:     []error{&pq.Error{Code: "40001"}, nil},
+			attempts: 2,
+			check: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:     "Deadlock",
+			errs:     []error{&pq.Error{Code: "40P01"}, &pq.Error{Code: "40001"}, nil},
+			attempts: 3,
+			check: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:     "Wrapped conflict",
+			errs:     []error{fmt.Errorf("tx err: %w", &pq.Error{Code: "40P01"}), nil},
+			attempts: 2,
+			check: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:     "Other error",
+			errs:     []error{&pq.Error{Code: "23505"}},
+			attempts: 1,
+			check: func(t *testing.T, err error) {
+				require.False(t, isSerializationFailure(err))
+			},
+		},
+		{
+			name:     "Too many conflicts",
+			errs:     []error{&pq.Error{Code: "40001"}, &pq.Error{Code: "40P01"}, &pq.Error{Code: "40001"}, nil},
+			attempts: maxTxAttempts,
+			check: func(t *testing.T, err error) {
+				require.True(t, isSerializationFailure(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			attempts := 0
+			err := retryTx(func() error {
+				err := tc.errs[attempts]
+				attempts++
+				return err
+			})
+			tc.check(t, err)
+			require.Equal(t, tc.attempts, attempts)
+		})
+	}
+}
+
+func TestListAuditEvents(t *testing.T) {
+	store := NewStore(testDB)
+	url1 := createRandomURL(t)
+	actor := util.RandomString(6)
+
+	for i := 0; i < 2; i++ {
+		_, err := store.SetURLArchivedTx(context.Background(), SetURLArchivedParams{ID: url1.ID, Archived: i == 0}, AuditInfo{Actor: actor})
+		require.NoError(t, err)
+	}
+
+	events, err := testQueries.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		DomainID: url1.DomainID,
+		Actor:    actor,
+		Limit:    1,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, AuditActionUnarchive, events[0].Action)
+
+	events, err = testQueries.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		DomainID:     url1.DomainID,
+		Actor:        actor,
+		CreatedAfter: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+		Limit:        10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, events)
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLTags(t *testing.T) {
+	url := createRandomURL(t)
+
+	for _, tag := range []string{"spring", "campaign", "spring"} {
+		err := testQueries.AddURLTag(context.Background(), AddURLTagParams{
+			UrlID: url.ID,
+			Tag:   tag,
+		})
+		require.NoError(t, err)
+	}
+
+	tags, err := testQueries.ListURLTags(context.Background(), url.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"campaign", "spring"}, tags)
+
+	err = testQueries.DeleteURLTags(context.Background(), url.ID)
+	require.NoError(t, err)
+
+	tags, err = testQueries.ListURLTags(context.Background(), url.ID)
+	require.NoError(t, err)
+	require.Empty(t, tags)
+}
+
+func TestListTagsByURLs(t *testing.T) {
+	url1 := createRandomURL(t)
+	url2 := createRandomURL(t)
+
+	for _, arg := range []AddURLTagParams{
+		{UrlID: url1.ID, Tag: "a"},
+		{UrlID: url2.ID, Tag: "b"},
+		{UrlID: url2.ID, Tag: "c"},
+	} {
+		err := testQueries.AddURLTag(context.Background(), arg)
+		require.NoError(t, err)
+	}
+
+	urlTags, err := testQueries.ListTagsByURLs(context.Background(), []int64{url1.ID, url2.ID})
+	require.NoError(t, err)
+	require.Equal(t, []UrlTag{
+		{UrlID: url1.ID, Tag: "a"},
+		{UrlID: url2.ID, Tag: "b"},
+		{UrlID: url2.ID, Tag: "c"},
+	}, urlTags)
+}
@@ -7,30 +7,112 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
 )
 
 const createURL = `-- name: CreateURL :one
 INSERT INTO urls (
   origin_url,
-  short_url
+  short_url,
+  redirect_type,
+  not_after,
+  domain_id,
+  password_hash,
+  not_before,
+  max_clicks,
+  rules,
+  variants,
+  query_mode,
+  utm,
+  is_prefix,
+  title,
+  description,
+  owner,
+  folder_id
 ) VALUES (
-  $1, $2
-) RETURNING id, origin_url, short_url, created_at
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
+) ON CONFLICT DO NOTHING
+RETURNING id, origin_url, short_url, created_at, redirect_type, not_after, domain_id, password_hash, not_before, max_clicks, click_count, rules, variants, query_mode, utm, is_prefix, title, description, owner, folder_id, page_title, page_description, favicon_url, og_image_url, metadata_fetched_at, last_status_code, redirect_chain, check_error, check_failures, last_checked_at, broken_at, expiry_notified_at, archived_at, deleted_at
 `
 
 type CreateURLParams struct {
-	OriginUrl string `json:"origin_url"`
-	ShortUrl  string `json:"short_url"`
+	OriginUrl    string          `json:"origin_url"`
+	ShortUrl     string          `json:"short_url"`
+	RedirectType string          `json:"redirect_type"`
+	NotAfter     sql.NullTime    `json:"not_after"`
+	DomainID     int64           `json:"domain_id"`
+	PasswordHash string          `json:"password_hash"`
+	NotBefore    sql.NullTime    `json:"not_before"`
+	MaxClicks    int64           `json:"max_clicks"`
+	Rules        json.RawMessage `json:"rules"`
+	Variants     json.RawMessage `json:"variants"`
+	QueryMode    string          `json:"query_mode"`
+	Utm          json.RawMessage `json:"utm"`
+	IsPrefix     bool            `json:"is_prefix"`
+	Title        string          `json:"title"`
+	Description  string          `json:"description"`
+	Owner        string          `json:"owner"`
+	FolderID     sql.NullInt64   `json:"folder_id"`
 }
 
 func (q *Queries) CreateURL(ctx context.Context, arg CreateURLParams) (Url, error) {
-	row := q.db.QueryRowContext(ctx, createURL, arg.OriginUrl, arg.ShortUrl)
+	row := q.db.QueryRowContext(ctx, createURL,
+		arg.OriginUrl,
+		arg.ShortUrl,
+		arg.RedirectType,
+		arg.NotAfter,
+		arg.DomainID,
+		arg.PasswordHash,
+		arg.NotBefore,
+		arg.MaxClicks,
+		arg.Rules,
+		arg.Variants,
+		arg.QueryMode,
+		arg.Utm,
+		arg.IsPrefix,
+		arg.Title,
+		arg.Description,
+		arg.Owner,
+		arg.FolderID,
+	)
 	var i Url
 	err := row.Scan(
 		&i.ID,
 		&i.OriginUrl,
 		&i.ShortUrl,
 		&i.CreatedAt,
+		&i.RedirectType,
+		&i.NotAfter,
+		&i.DomainID,
+		&i.PasswordHash,
+		&i.NotBefore,
+		&i.MaxClicks,
+		&i.ClickCount,
+		&i.Rules,
+		&i.Variants,
+		&i.QueryMode,
+		&i.Utm,
+		&i.IsPrefix,
+		&i.Title,
+		&i.Description,
+		&i.Owner,
+		&i.FolderID,
+		&i.PageTitle,
+		&i.PageDescription,
+		&i.FaviconUrl,
+		&i.OgImageUrl,
+		&i.MetadataFetchedAt,
+		&i.LastStatusCode,
+		&i.RedirectChain,
+		&i.CheckError,
+		&i.CheckFailures,
+		&i.LastCheckedAt,
+		&i.BrokenAt,
+		&i.ExpiryNotifiedAt,
+		&i.ArchivedAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
@@ -45,19 +127,872 @@ func (q *Queries) DeleteURL(ctx context.Context, id int64) error {
 	return err
 }
 
+const getDeletedURL = `-- name: GetDeletedURL :one
+SELECT id, origin_url, short_url, created_at, redirect_type, not_after, domain_id, password_hash, not_before, max_clicks, click_count, rules, variants, query_mode, utm, is_prefix, title, description, owner, folder_id, page_title, page_description, favicon_url, og_image_url, metadata_fetched_at, last_status_code, redirect_chain, check_error, check_failures, last_checked_at, broken_at, expiry_notified_at, archived_at, deleted_at FROM urls
+WHERE domain_id = $1 AND short_url = $2 AND deleted_at IS NOT NULL
+LIMIT 1
+`
+
+type GetDeletedURLParams struct {
+	DomainID int64  `json:"domain_id"`
+	ShortUrl string `json:"short_url"`
+}
+
+func (q *Queries) GetDeletedURL(ctx context.Context, arg GetDeletedURLParams) (Url, error) {
+	row := q.db.QueryRowContext(ctx, getDeletedURL, arg.DomainID, arg.ShortUrl)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.OriginUrl,
+		&i.ShortUrl,
+		&i.CreatedAt,
+		&i.RedirectType,
+		&i.NotAfter,
+		&i.DomainID,
+		&i.PasswordHash,
+		&i.NotBefore,
+		&i.MaxClicks,
+		&i.ClickCount,
+		&i.Rules,
+		&i.Variants,
+		&i.QueryMode,
+		&i.Utm,
+		&i.IsPrefix,
+		&i.Title,
+		&i.Description,
+		&i.Owner,
+		&i.FolderID,
+		&i.PageTitle,
+		&i.PageDescription,
+		&i.FaviconUrl,
+		&i.OgImageUrl,
+		&i.MetadataFetchedAt,
+		&i.LastStatusCode,
+		&i.RedirectChain,
+		&i.CheckError,
+		&i.CheckFailures,
+		&i.LastCheckedAt,
+		&i.BrokenAt,
+		&i.ExpiryNotifiedAt,
+		&i.ArchivedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
 const getURL = `-- name: GetURL :one
-SELECT id, origin_url, short_url, created_at FROM urls
-WHERE short_url = $1 LIMIT 1
+SELECT id, origin_url, short_url, created_at, redirect_type, not_after, domain_id, password_hash, not_before, max_clicks, click_count, rules, variants, query_mode, utm, is_prefix, title, description, owner, folder_id, page_title, page_description, favicon_url, og_image_url, metadata_fetched_at, last_status_code, redirect_chain, check_error, check_failures, last_checked_at, broken_at, expiry_notified_at, archived_at, deleted_at FROM urls
+WHERE domain_id = $1 AND short_url = $2 AND deleted_at IS NULL
+LIMIT 1
+`
+
+type GetURLParams struct {
+	DomainID int64  `json:"domain_id"`
+	ShortUrl string `json:"short_url"`
+}
+
+func (q *Queries) GetURL(ctx context.Context, arg GetURLParams) (Url, error) {
+	row := q.db.QueryRowContext(ctx, getURL, arg.DomainID, arg.ShortUrl)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.OriginUrl,
+		&i.ShortUrl,
+		&i.CreatedAt,
+		&i.RedirectType,
+		&i.NotAfter,
+		&i.DomainID,
+		&i.PasswordHash,
+		&i.NotBefore,
+		&i.MaxClicks,
+		&i.ClickCount,
+		&i.Rules,
+		&i.Variants,
+		&i.QueryMode,
+		&i.Utm,
+		&i.IsPrefix,
+		&i.Title,
+		&i.Description,
+		&i.Owner,
+		&i.FolderID,
+		&i.PageTitle,
+		&i.PageDescription,
+		&i.FaviconUrl,
+		&i.OgImageUrl,
+		&i.MetadataFetchedAt,
+		&i.LastStatusCode,
+		&i.RedirectChain,
+		&i.CheckError,
+		&i.CheckFailures,
+		&i.LastCheckedAt,
+		&i.BrokenAt,
+		&i.ExpiryNotifiedAt,
+		&i.ArchivedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getURLForUpdate = `-- name: GetURLForUpdate :one
+SELECT id, origin_url, short_url, created_at, redirect_type, not_after, domain_id, password_hash, not_before, max_clicks, click_count, rules, variants, query_mode, utm, is_prefix, title, description, owner, folder_id, page_title, page_description, favicon_url, og_image_url, metadata_fetched_at, last_status_code, redirect_chain, check_error, check_failures, last_checked_at, broken_at, expiry_notified_at, archived_at, deleted_at FROM urls
+WHERE id = $1 LIMIT 1
+FOR UPDATE
+`
+
+func (q *Queries) GetURLForUpdate(ctx context.Context, id int64) (Url, error) {
+	row := q.db.QueryRowContext(ctx, getURLForUpdate, id)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.OriginUrl,
+		&i.ShortUrl,
+		&i.CreatedAt,
+		&i.RedirectType,
+		&i.NotAfter,
+		&i.DomainID,
+		&i.PasswordHash,
+		&i.NotBefore,
+		&i.MaxClicks,
+		&i.ClickCount,
+		&i.Rules,
+		&i.Variants,
+		&i.QueryMode,
+		&i.Utm,
+		&i.IsPrefix,
+		&i.Title,
+		&i.Description,
+		&i.Owner,
+		&i.FolderID,
+		&i.PageTitle,
+		&i.PageDescription,
+		&i.FaviconUrl,
+		&i.OgImageUrl,
+		&i.MetadataFetchedAt,
+		&i.LastStatusCode,
+		&i.RedirectChain,
+		&i.CheckError,
+		&i.CheckFailures,
+		&i.LastCheckedAt,
+		&i.BrokenAt,
+		&i.ExpiryNotifiedAt,
+		&i.ArchivedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const importURL = `-- name: ImportURL :one
+INSERT INTO urls (
+  origin_url,
+  short_url,
+  created_at,
+  redirect_type,
+  not_after,
+  domain_id,
+  password_hash,
+  not_before,
+  max_clicks,
+  click_count,
+  rules,
+  variants,
+  query_mode,
+  utm,
+  is_prefix,
+  title,
+  description,
+  owner,
+  folder_id,
+  page_title,
+  page_description,
+  favicon_url,
+  og_image_url,
+  metadata_fetched_at,
+  last_status_code,
+  redirect_chain,
+  check_error,
+  check_failures,
+  last_checked_at,
+  broken_at,
+  expiry_notified_at,
+  archived_at,
+  deleted_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11,
+  $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22,
+  $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33
+) ON CONFLICT DO NOTHING
+RETURNING id, origin_url, short_url, created_at, redirect_type, not_after, domain_id, password_hash, not_before, max_clicks, click_count, rules, variants, query_mode, utm, is_prefix, title, description, owner, folder_id, page_title, page_description, favicon_url, og_image_url, metadata_fetched_at, last_status_code, redirect_chain, check_error, check_failures, last_checked_at, broken_at, expiry_notified_at, archived_at, deleted_at
 `
 
-func (q *Queries) GetURL(ctx context.Context, shortUrl string) (Url, error) {
-	row := q.db.QueryRowContext(ctx, getURL, shortUrl)
+type ImportURLParams struct {
+	OriginUrl         string          `json:"origin_url"`
+	ShortUrl          string          `json:"short_url"`
+	CreatedAt         time.Time       `json:"created_at"`
+	RedirectType      string          `json:"redirect_type"`
+	NotAfter          sql.NullTime    `json:"not_after"`
+	DomainID          int64           `json:"domain_id"`
+	PasswordHash      string          `json:"password_hash"`
+	NotBefore         sql.NullTime    `json:"not_before"`
+	MaxClicks         int64           `json:"max_clicks"`
+	ClickCount        int64           `json:"click_count"`
+	Rules             json.RawMessage `json:"rules"`
+	Variants          json.RawMessage `json:"variants"`
+	QueryMode         string          `json:"query_mode"`
+	Utm               json.RawMessage `json:"utm"`
+	IsPrefix          bool            `json:"is_prefix"`
+	Title             string          `json:"title"`
+	Description       string          `json:"description"`
+	Owner             string          `json:"owner"`
+	FolderID          sql.NullInt64   `json:"folder_id"`
+	PageTitle         string          `json:"page_title"`
+	PageDescription   string          `json:"page_description"`
+	FaviconUrl        string          `json:"favicon_url"`
+	OgImageUrl        string          `json:"og_image_url"`
+	MetadataFetchedAt sql.NullTime    `json:"metadata_fetched_at"`
+	LastStatusCode    int32           `json:"last_status_code"`
+	RedirectChain     json.RawMessage `json:"redirect_chain"`
+	CheckError        string          `json:"check_error"`
+	CheckFailures     int32           `json:"check_failures"`
+	LastCheckedAt     sql.NullTime    `json:"last_checked_at"`
+	BrokenAt          sql.NullTime    `json:"broken_at"`
+	ExpiryNotifiedAt  sql.NullTime    `json:"expiry_notified_at"`
+	ArchivedAt        sql.NullTime    `json:"archived_at"`
+	DeletedAt         sql.NullTime    `json:"deleted_at"`
+}
+
+func (q *Queries) ImportURL(ctx context.Context, arg ImportURLParams) (Url, error) {
+	row := q.db.QueryRowContext(ctx, importURL,
+		arg.OriginUrl,
+		arg.ShortUrl,
+		arg.CreatedAt,
+		arg.RedirectType,
+		arg.NotAfter,
+		arg.DomainID,
+		arg.PasswordHash,
+		arg.NotBefore,
+		arg.MaxClicks,
+		arg.ClickCount,
+		arg.Rules,
+		arg.Variants,
+		arg.QueryMode,
+		arg.Utm,
+		arg.IsPrefix,
+		arg.Title,
+		arg.Description,
+		arg.Owner,
+		arg.FolderID,
+		arg.PageTitle,
+		arg.PageDescription,
+		arg.FaviconUrl,
+		arg.OgImageUrl,
+		arg.MetadataFetchedAt,
+		arg.LastStatusCode,
+		arg.RedirectChain,
+		arg.CheckError,
+		arg.CheckFailures,
+		arg.LastCheckedAt,
+		arg.BrokenAt,
+		arg.ExpiryNotifiedAt,
+		arg.ArchivedAt,
+		arg.DeletedAt,
+	)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.OriginUrl,
+		&i.ShortUrl,
+		&i.CreatedAt,
+		&i.RedirectType,
+		&i.NotAfter,
+		&i.DomainID,
+		&i.PasswordHash,
+		&i.NotBefore,
+		&i.MaxClicks,
+		&i.ClickCount,
+		&i.Rules,
+		&i.Variants,
+		&i.QueryMode,
+		&i.Utm,
+		&i.IsPrefix,
+		&i.Title,
+		&i.Description,
+		&i.Owner,
+		&i.FolderID,
+		&i.PageTitle,
+		&i.PageDescription,
+		&i.FaviconUrl,
+		&i.OgImageUrl,
+		&i.MetadataFetchedAt,
+		&i.LastStatusCode,
+		&i.RedirectChain,
+		&i.CheckError,
+		&i.CheckFailures,
+		&i.LastCheckedAt,
+		&i.BrokenAt,
+		&i.ExpiryNotifiedAt,
+		&i.ArchivedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const listURLs = `-- name: ListURLs :many
+SELECT id, origin_url, short_url, created_at, redirect_type, not_after, domain_id, password_hash, not_before, max_clicks, click_count, rules, variants, query_mode, utm, is_prefix, title, description, owner, folder_id, page_title, page_description, favicon_url, og_image_url, metadata_fetched_at, last_status_code, redirect_chain, check_error, check_failures, last_checked_at, broken_at, expiry_notified_at, archived_at, deleted_at FROM urls
+WHERE id > $1
+ORDER BY id
+LIMIT $2
+`
+
+type ListURLsParams struct {
+	AfterID int64 `json:"after_id"`
+	Limit   int32 `json:"limit"`
+}
+
+func (q *Queries) ListURLs(ctx context.Context, arg ListURLsParams) ([]Url, error) {
+	rows, err := q.db.QueryContext(ctx, listURLs, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Url{}
+	for rows.Next() {
+		var i Url
+		if err := rows.Scan(
+			&i.ID,
+			&i.OriginUrl,
+			&i.ShortUrl,
+			&i.CreatedAt,
+			&i.RedirectType,
+			&i.NotAfter,
+			&i.DomainID,
+			&i.PasswordHash,
+			&i.NotBefore,
+			&i.MaxClicks,
+			&i.ClickCount,
+			&i.Rules,
+			&i.Variants,
+			&i.QueryMode,
+			&i.Utm,
+			&i.IsPrefix,
+			&i.Title,
+			&i.Description,
+			&i.Owner,
+			&i.FolderID,
+			&i.PageTitle,
+			&i.PageDescription,
+			&i.FaviconUrl,
+			&i.OgImageUrl,
+			&i.MetadataFetchedAt,
+			&i.LastStatusCode,
+			&i.RedirectChain,
+			&i.CheckError,
+			&i.CheckFailures,
+			&i.LastCheckedAt,
+			&i.BrokenAt,
+			&i.ExpiryNotifiedAt,
+			&i.ArchivedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listURLsToCheck = `-- name: ListURLsToCheck :many
+SELECT id, origin_url, short_url, created_at, redirect_type, not_after, domain_id, password_hash, not_before, max_clicks, click_count, rules, variants, query_mode, utm, is_prefix, title, description, owner, folder_id, page_title, page_description, favicon_url, og_image_url, metadata_fetched_at, last_status_code, redirect_chain, check_error, check_failures, last_checked_at, broken_at, expiry_notified_at, archived_at, deleted_at FROM urls
+WHERE deleted_at IS NULL
+  AND archived_at IS NULL
+  AND (last_checked_at IS NULL OR last_checked_at < $1::timestamptz)
+ORDER BY last_checked_at NULLS FIRST, id
+LIMIT $2
+`
+
+type ListURLsToCheckParams struct {
+	CheckedBefore time.Time `json:"checked_before"`
+	Limit         int32     `json:"limit"`
+}
+
+func (q *Queries) ListURLsToCheck(ctx context.Context, arg ListURLsToCheckParams) ([]Url, error) {
+	rows, err := q.db.QueryContext(ctx, listURLsToCheck, arg.CheckedBefore, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Url{}
+	for rows.Next() {
+		var i Url
+		if err := rows.Scan(
+			&i.ID,
+			&i.OriginUrl,
+			&i.ShortUrl,
+			&i.CreatedAt,
+			&i.RedirectType,
+			&i.NotAfter,
+			&i.DomainID,
+			&i.PasswordHash,
+			&i.NotBefore,
+			&i.MaxClicks,
+			&i.ClickCount,
+			&i.Rules,
+			&i.Variants,
+			&i.QueryMode,
+			&i.Utm,
+			&i.IsPrefix,
+			&i.Title,
+			&i.Description,
+			&i.Owner,
+			&i.FolderID,
+			&i.PageTitle,
+			&i.PageDescription,
+			&i.FaviconUrl,
+			&i.OgImageUrl,
+			&i.MetadataFetchedAt,
+			&i.LastStatusCode,
+			&i.RedirectChain,
+			&i.CheckError,
+			&i.CheckFailures,
+			&i.LastCheckedAt,
+			&i.BrokenAt,
+			&i.ExpiryNotifiedAt,
+			&i.ArchivedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markExpiredURLs = `-- name: MarkExpiredURLs :many
+UPDATE urls
+SET expiry_notified_at = now()
+WHERE id IN (
+  SELECT id FROM urls
+  WHERE expiry_notified_at IS NULL
+    AND deleted_at IS NULL
+    AND (not_after <= now() OR (max_clicks > 0 AND click_count >= max_clicks))
+  ORDER BY id
+  LIMIT $1
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, origin_url, short_url, created_at, redirect_type, not_after, domain_id, password_hash, not_before, max_clicks, click_count, rules, variants, query_mode, utm, is_prefix, title, description, owner, folder_id, page_title, page_description, favicon_url, og_image_url, metadata_fetched_at, last_status_code, redirect_chain, check_error, check_failures, last_checked_at, broken_at, expiry_notified_at, archived_at, deleted_at
+`
+
+func (q *Queries) MarkExpiredURLs(ctx context.Context, limit int32) ([]Url, error) {
+	rows, err := q.db.QueryContext(ctx, markExpiredURLs, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Url{}
+	for rows.Next() {
+		var i Url
+		if err := rows.Scan(
+			&i.ID,
+			&i.OriginUrl,
+			&i.ShortUrl,
+			&i.CreatedAt,
+			&i.RedirectType,
+			&i.NotAfter,
+			&i.DomainID,
+			&i.PasswordHash,
+			&i.NotBefore,
+			&i.MaxClicks,
+			&i.ClickCount,
+			&i.Rules,
+			&i.Variants,
+			&i.QueryMode,
+			&i.Utm,
+			&i.IsPrefix,
+			&i.Title,
+			&i.Description,
+			&i.Owner,
+			&i.FolderID,
+			&i.PageTitle,
+			&i.PageDescription,
+			&i.FaviconUrl,
+			&i.OgImageUrl,
+			&i.MetadataFetchedAt,
+			&i.LastStatusCode,
+			&i.RedirectChain,
+			&i.CheckError,
+			&i.CheckFailures,
+			&i.LastCheckedAt,
+			&i.BrokenAt,
+			&i.ExpiryNotifiedAt,
+			&i.ArchivedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeDeletedURLs = `-- name: PurgeDeletedURLs :many
+DELETE FROM urls
+WHERE id IN (
+  SELECT id FROM urls
+  WHERE deleted_at < $1::timestamptz
+  ORDER BY id
+  LIMIT $2
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, origin_url, short_url, created_at, redirect_type, not_after, domain_id, password_hash, not_before, max_clicks, click_count, rules, variants, query_mode, utm, is_prefix, title, description, owner, folder_id, page_title, page_description, favicon_url, og_image_url, metadata_fetched_at, last_status_code, redirect_chain, check_error, check_failures, last_checked_at, broken_at, expiry_notified_at, archived_at, deleted_at
+`
+
+type PurgeDeletedURLsParams struct {
+	DeletedBefore time.Time `json:"deleted_before"`
+	Limit         int32     `json:"limit"`
+}
+
+func (q *Queries) PurgeDeletedURLs(ctx context.Context, arg PurgeDeletedURLsParams) ([]Url, error) {
+	rows, err := q.db.QueryContext(ctx, purgeDeletedURLs, arg.DeletedBefore, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Url{}
+	for rows.Next() {
+		var i Url
+		if err := rows.Scan(
+			&i.ID,
+			&i.OriginUrl,
+			&i.ShortUrl,
+			&i.CreatedAt,
+			&i.RedirectType,
+			&i.NotAfter,
+			&i.DomainID,
+			&i.PasswordHash,
+			&i.NotBefore,
+			&i.MaxClicks,
+			&i.ClickCount,
+			&i.Rules,
+			&i.Variants,
+			&i.QueryMode,
+			&i.Utm,
+			&i.IsPrefix,
+			&i.Title,
+			&i.Description,
+			&i.Owner,
+			&i.FolderID,
+			&i.PageTitle,
+			&i.PageDescription,
+			&i.FaviconUrl,
+			&i.OgImageUrl,
+			&i.MetadataFetchedAt,
+			&i.LastStatusCode,
+			&i.RedirectChain,
+			&i.CheckError,
+			&i.CheckFailures,
+			&i.LastCheckedAt,
+			&i.BrokenAt,
+			&i.ExpiryNotifiedAt,
+			&i.ArchivedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const restoreURL = `-- name: RestoreURL :one
+UPDATE urls
+SET deleted_at = NULL
+WHERE id = $1 AND deleted_at > $2::timestamptz
+RETURNING id, origin_url, short_url, created_at, redirect_type, not_after, domain_id, password_hash, not_before, max_clicks, click_count, rules, variants, query_mode, utm, is_prefix, title, description, owner, folder_id, page_title, page_description, favicon_url, og_image_url, metadata_fetched_at, last_status_code, redirect_chain, check_error, check_failures, last_checked_at, broken_at, expiry_notified_at, archived_at, deleted_at
+`
+
+type RestoreURLParams struct {
+	ID           int64     `json:"id"`
+	DeletedAfter time.Time `json:"deleted_after"`
+}
+
+func (q *Queries) RestoreURL(ctx context.Context, arg RestoreURLParams) (Url, error) {
+	row := q.db.QueryRowContext(ctx, restoreURL, arg.ID, arg.DeletedAfter)
 	var i Url
 	err := row.Scan(
 		&i.ID,
 		&i.OriginUrl,
 		&i.ShortUrl,
 		&i.CreatedAt,
+		&i.RedirectType,
+		&i.NotAfter,
+		&i.DomainID,
+		&i.PasswordHash,
+		&i.NotBefore,
+		&i.MaxClicks,
+		&i.ClickCount,
+		&i.Rules,
+		&i.Variants,
+		&i.QueryMode,
+		&i.Utm,
+		&i.IsPrefix,
+		&i.Title,
+		&i.Description,
+		&i.Owner,
+		&i.FolderID,
+		&i.PageTitle,
+		&i.PageDescription,
+		&i.FaviconUrl,
+		&i.OgImageUrl,
+		&i.MetadataFetchedAt,
+		&i.LastStatusCode,
+		&i.RedirectChain,
+		&i.CheckError,
+		&i.CheckFailures,
+		&i.LastCheckedAt,
+		&i.BrokenAt,
+		&i.ExpiryNotifiedAt,
+		&i.ArchivedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const searchURLs = `-- name: SearchURLs :many
+SELECT urls.id, urls.origin_url, urls.short_url, urls.created_at, urls.redirect_type, urls.not_after, urls.domain_id, urls.password_hash, urls.not_before, urls.max_clicks, urls.click_count, urls.rules, urls.variants, urls.query_mode, urls.utm, urls.is_prefix, urls.title, urls.description, urls.owner, urls.folder_id, urls.page_title, urls.page_description, urls.favicon_url, urls.og_image_url, urls.metadata_fetched_at, urls.last_status_code, urls.redirect_chain, urls.check_error, urls.check_failures, urls.last_checked_at, urls.broken_at, urls.expiry_notified_at, urls.archived_at, urls.deleted_at FROM urls
+LEFT JOIN url_search ON url_search.url_id = urls.id
+WHERE urls.domain_id = $1
+  AND ($2::text = '' OR url_search.document @@ websearch_to_tsquery('simple', $2::text))
+  AND ($3::text = '' OR EXISTS (
+    SELECT 1 FROM url_tags WHERE url_tags.url_id = urls.id AND url_tags.tag = $3::text
+  ))
+  AND ($4::text = '' OR urls.owner = $4::text)
+  AND ($5::bigint IS NULL OR urls.folder_id = $5::bigint)
+  AND ($6::timestamptz IS NULL OR urls.created_at >= $6::timestamptz)
+  AND ($7::timestamptz IS NULL OR urls.created_at < $7::timestamptz)
+  AND (urls.deleted_at IS NULL) = ($8::text <> 'deleted')
+  AND ($8::text IN ('', 'deleted')
+    OR ($8::text = 'active'
+      AND urls.archived_at IS NULL
+      AND (urls.not_before IS NULL OR urls.not_before <= now())
+      AND (urls.not_after IS NULL OR urls.not_after > now())
+      AND (urls.max_clicks = 0 OR urls.click_count < urls.max_clicks))
+    OR ($8::text = 'scheduled' AND urls.not_before > now())
+    OR ($8::text = 'expired'
+      AND (urls.not_after <= now() OR (urls.max_clicks > 0 AND urls.click_count >= urls.max_clicks)))
+    OR ($8::text = 'broken' AND urls.broken_at IS NOT NULL)
+    OR ($8::text = 'archived' AND urls.archived_at IS NOT NULL))
+ORDER BY
+  CASE WHEN $2::text = '' THEN 0
+  ELSE ts_rank(url_search.document, websearch_to_tsquery('simple', $2::text)) END DESC,
+  urls.id DESC
+LIMIT $9
+OFFSET $10
+`
+
+type SearchURLsParams struct {
+	DomainID      int64         `json:"domain_id"`
+	Query         string        `json:"query"`
+	Tag           string        `json:"tag"`
+	Owner         string        `json:"owner"`
+	FolderID      sql.NullInt64 `json:"folder_id"`
+	CreatedAfter  sql.NullTime  `json:"created_after"`
+	CreatedBefore sql.NullTime  `json:"created_before"`
+	Status        string        `json:"status"`
+	Limit         int32         `json:"limit"`
+	Offset        int32         `json:"offset"`
+}
+
+func (q *Queries) SearchURLs(ctx context.Context, arg SearchURLsParams) ([]Url, error) {
+	rows, err := q.db.QueryContext(ctx, searchURLs,
+		arg.DomainID,
+		arg.Query,
+		arg.Tag,
+		arg.Owner,
+		arg.FolderID,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.Status,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Url{}
+	for rows.Next() {
+		var i Url
+		if err := rows.Scan(
+			&i.ID,
+			&i.OriginUrl,
+			&i.ShortUrl,
+			&i.CreatedAt,
+			&i.RedirectType,
+			&i.NotAfter,
+			&i.DomainID,
+			&i.PasswordHash,
+			&i.NotBefore,
+			&i.MaxClicks,
+			&i.ClickCount,
+			&i.Rules,
+			&i.Variants,
+			&i.QueryMode,
+			&i.Utm,
+			&i.IsPrefix,
+			&i.Title,
+			&i.Description,
+			&i.Owner,
+			&i.FolderID,
+			&i.PageTitle,
+			&i.PageDescription,
+			&i.FaviconUrl,
+			&i.OgImageUrl,
+			&i.MetadataFetchedAt,
+			&i.LastStatusCode,
+			&i.RedirectChain,
+			&i.CheckError,
+			&i.CheckFailures,
+			&i.LastCheckedAt,
+			&i.BrokenAt,
+			&i.ExpiryNotifiedAt,
+			&i.ArchivedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setURLArchived = `-- name: SetURLArchived :one
+UPDATE urls
+SET archived_at = CASE WHEN $2::boolean THEN COALESCE(archived_at, now()) ELSE NULL END
+WHERE id = $1
+RETURNING id, origin_url, short_url, created_at, redirect_type, not_after, domain_id, password_hash, not_before, max_clicks, click_count, rules, variants, query_mode, utm, is_prefix, title, description, owner, folder_id, page_title, page_description, favicon_url, og_image_url, metadata_fetched_at, last_status_code, redirect_chain, check_error, check_failures, last_checked_at, broken_at, expiry_notified_at, archived_at, deleted_at
+`
+
+type SetURLArchivedParams struct {
+	ID       int64 `json:"id"`
+	Archived bool  `json:"archived"`
+}
+
+func (q *Queries) SetURLArchived(ctx context.Context, arg SetURLArchivedParams) (Url, error) {
+	row := q.db.QueryRowContext(ctx, setURLArchived, arg.ID, arg.Archived)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.OriginUrl,
+		&i.ShortUrl,
+		&i.CreatedAt,
+		&i.RedirectType,
+		&i.NotAfter,
+		&i.DomainID,
+		&i.PasswordHash,
+		&i.NotBefore,
+		&i.MaxClicks,
+		&i.ClickCount,
+		&i.Rules,
+		&i.Variants,
+		&i.QueryMode,
+		&i.Utm,
+		&i.IsPrefix,
+		&i.Title,
+		&i.Description,
+		&i.Owner,
+		&i.FolderID,
+		&i.PageTitle,
+		&i.PageDescription,
+		&i.FaviconUrl,
+		&i.OgImageUrl,
+		&i.MetadataFetchedAt,
+		&i.LastStatusCode,
+		&i.RedirectChain,
+		&i.CheckError,
+		&i.CheckFailures,
+		&i.LastCheckedAt,
+		&i.BrokenAt,
+		&i.ExpiryNotifiedAt,
+		&i.ArchivedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const softDeleteURL = `-- name: SoftDeleteURL :one
+UPDATE urls
+SET deleted_at = now()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, origin_url, short_url, created_at, redirect_type, not_after, domain_id, password_hash, not_before, max_clicks, click_count, rules, variants, query_mode, utm, is_prefix, title, description, owner, folder_id, page_title, page_description, favicon_url, og_image_url, metadata_fetched_at, last_status_code, redirect_chain, check_error, check_failures, last_checked_at, broken_at, expiry_notified_at, archived_at, deleted_at
+`
+
+func (q *Queries) SoftDeleteURL(ctx context.Context, id int64) (Url, error) {
+	row := q.db.QueryRowContext(ctx, softDeleteURL, id)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.OriginUrl,
+		&i.ShortUrl,
+		&i.CreatedAt,
+		&i.RedirectType,
+		&i.NotAfter,
+		&i.DomainID,
+		&i.PasswordHash,
+		&i.NotBefore,
+		&i.MaxClicks,
+		&i.ClickCount,
+		&i.Rules,
+		&i.Variants,
+		&i.QueryMode,
+		&i.Utm,
+		&i.IsPrefix,
+		&i.Title,
+		&i.Description,
+		&i.Owner,
+		&i.FolderID,
+		&i.PageTitle,
+		&i.PageDescription,
+		&i.FaviconUrl,
+		&i.OgImageUrl,
+		&i.MetadataFetchedAt,
+		&i.LastStatusCode,
+		&i.RedirectChain,
+		&i.CheckError,
+		&i.CheckFailures,
+		&i.LastCheckedAt,
+		&i.BrokenAt,
+		&i.ExpiryNotifiedAt,
+		&i.ArchivedAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
@@ -66,7 +1001,7 @@ const updateURL = `-- name: UpdateURL :one
 UPDATE urls
 SET short_url = $2
 WHERE id = $1
-RETURNING id, origin_url, short_url, created_at
+RETURNING id, origin_url, short_url, created_at, redirect_type, not_after, domain_id, password_hash, not_before, max_clicks, click_count, rules, variants, query_mode, utm, is_prefix, title, description, owner, folder_id, page_title, page_description, favicon_url, og_image_url, metadata_fetched_at, last_status_code, redirect_chain, check_error, check_failures, last_checked_at, broken_at, expiry_notified_at, archived_at, deleted_at
 `
 
 type UpdateURLParams struct {
@@ -82,6 +1017,239 @@ func (q *Queries) UpdateURL(ctx context.Context, arg UpdateURLParams) (Url, erro
 		&i.OriginUrl,
 		&i.ShortUrl,
 		&i.CreatedAt,
+		&i.RedirectType,
+		&i.NotAfter,
+		&i.DomainID,
+		&i.PasswordHash,
+		&i.NotBefore,
+		&i.MaxClicks,
+		&i.ClickCount,
+		&i.Rules,
+		&i.Variants,
+		&i.QueryMode,
+		&i.Utm,
+		&i.IsPrefix,
+		&i.Title,
+		&i.Description,
+		&i.Owner,
+		&i.FolderID,
+		&i.PageTitle,
+		&i.PageDescription,
+		&i.FaviconUrl,
+		&i.OgImageUrl,
+		&i.MetadataFetchedAt,
+		&i.LastStatusCode,
+		&i.RedirectChain,
+		&i.CheckError,
+		&i.CheckFailures,
+		&i.LastCheckedAt,
+		&i.BrokenAt,
+		&i.ExpiryNotifiedAt,
+		&i.ArchivedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const updateURLCheck = `-- name: UpdateURLCheck :one
+UPDATE urls
+SET last_status_code = $2,
+  redirect_chain = $3,
+  check_error = $4,
+  last_checked_at = now(),
+  check_failures = CASE WHEN $5::boolean THEN check_failures + 1 ELSE 0 END,
+  broken_at = CASE
+    WHEN NOT $5::boolean THEN NULL
+    WHEN broken_at IS NULL AND check_failures + 1 >= $6::integer THEN now()
+    ELSE broken_at
+  END
+WHERE id = $1
+RETURNING id, origin_url, short_url, created_at, redirect_type, not_after, domain_id, password_hash, not_before, max_clicks, click_count, rules, variants, query_mode, utm, is_prefix, title, description, owner, folder_id, page_title, page_description, favicon_url, og_image_url, metadata_fetched_at, last_status_code, redirect_chain, check_error, check_failures, last_checked_at, broken_at, expiry_notified_at, archived_at, deleted_at
+`
+
+type UpdateURLCheckParams struct {
+	ID               int64           `json:"id"`
+	LastStatusCode   int32           `json:"last_status_code"`
+	RedirectChain    json.RawMessage `json:"redirect_chain"`
+	CheckError       string          `json:"check_error"`
+	Failed           bool            `json:"failed"`
+	FailureThreshold int32           `json:"failure_threshold"`
+}
+
+func (q *Queries) UpdateURLCheck(ctx context.Context, arg UpdateURLCheckParams) (Url, error) {
+	row := q.db.QueryRowContext(ctx, updateURLCheck,
+		arg.ID,
+		arg.LastStatusCode,
+		arg.RedirectChain,
+		arg.CheckError,
+		arg.Failed,
+		arg.FailureThreshold,
+	)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.OriginUrl,
+		&i.ShortUrl,
+		&i.CreatedAt,
+		&i.RedirectType,
+		&i.NotAfter,
+		&i.DomainID,
+		&i.PasswordHash,
+		&i.NotBefore,
+		&i.MaxClicks,
+		&i.ClickCount,
+		&i.Rules,
+		&i.Variants,
+		&i.QueryMode,
+		&i.Utm,
+		&i.IsPrefix,
+		&i.Title,
+		&i.Description,
+		&i.Owner,
+		&i.FolderID,
+		&i.PageTitle,
+		&i.PageDescription,
+		&i.FaviconUrl,
+		&i.OgImageUrl,
+		&i.MetadataFetchedAt,
+		&i.LastStatusCode,
+		&i.RedirectChain,
+		&i.CheckError,
+		&i.CheckFailures,
+		&i.LastCheckedAt,
+		&i.BrokenAt,
+		&i.ExpiryNotifiedAt,
+		&i.ArchivedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const updateURLClickCount = `-- name: UpdateURLClickCount :exec
+UPDATE urls
+SET click_count = GREATEST(click_count, $2::bigint)
+WHERE id = $1
+`
+
+type UpdateURLClickCountParams struct {
+	ID         int64 `json:"id"`
+	ClickCount int64 `json:"click_count"`
+}
+
+func (q *Queries) UpdateURLClickCount(ctx context.Context, arg UpdateURLClickCountParams) error {
+	_, err := q.db.ExecContext(ctx, updateURLClickCount, arg.ID, arg.ClickCount)
+	return err
+}
+
+const updateURLMetadata = `-- name: UpdateURLMetadata :exec
+UPDATE urls
+SET page_title = $2,
+  page_description = $3,
+  favicon_url = $4,
+  og_image_url = $5,
+  metadata_fetched_at = now()
+WHERE id = $1
+`
+
+type UpdateURLMetadataParams struct {
+	ID              int64  `json:"id"`
+	PageTitle       string `json:"page_title"`
+	PageDescription string `json:"page_description"`
+	FaviconUrl      string `json:"favicon_url"`
+	OgImageUrl      string `json:"og_image_url"`
+}
+
+func (q *Queries) UpdateURLMetadata(ctx context.Context, arg UpdateURLMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, updateURLMetadata,
+		arg.ID,
+		arg.PageTitle,
+		arg.PageDescription,
+		arg.FaviconUrl,
+		arg.OgImageUrl,
+	)
+	return err
+}
+
+const updateURLSettings = `-- name: UpdateURLSettings :one
+UPDATE urls
+SET origin_url = $2,
+  redirect_type = $3,
+  rules = $4,
+  variants = $5,
+  query_mode = $6,
+  utm = $7,
+  is_prefix = $8,
+  title = $9,
+  description = $10,
+  folder_id = $11
+WHERE id = $1
+RETURNING id, origin_url, short_url, created_at, redirect_type, not_after, domain_id, password_hash, not_before, max_clicks, click_count, rules, variants, query_mode, utm, is_prefix, title, description, owner, folder_id, page_title, page_description, favicon_url, og_image_url, metadata_fetched_at, last_status_code, redirect_chain, check_error, check_failures, last_checked_at, broken_at, expiry_notified_at, archived_at, deleted_at
+`
+
+type UpdateURLSettingsParams struct {
+	ID           int64           `json:"id"`
+	OriginUrl    string          `json:"origin_url"`
+	RedirectType string          `json:"redirect_type"`
+	Rules        json.RawMessage `json:"rules"`
+	Variants     json.RawMessage `json:"variants"`
+	QueryMode    string          `json:"query_mode"`
+	Utm          json.RawMessage `json:"utm"`
+	IsPrefix     bool            `json:"is_prefix"`
+	Title        string          `json:"title"`
+	Description  string          `json:"description"`
+	FolderID     sql.NullInt64   `json:"folder_id"`
+}
+
+func (q *Queries) UpdateURLSettings(ctx context.Context, arg UpdateURLSettingsParams) (Url, error) {
+	row := q.db.QueryRowContext(ctx, updateURLSettings,
+		arg.ID,
+		arg.OriginUrl,
+		arg.RedirectType,
+		arg.Rules,
+		arg.Variants,
+		arg.QueryMode,
+		arg.Utm,
+		arg.IsPrefix,
+		arg.Title,
+		arg.Description,
+		arg.FolderID,
+	)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.OriginUrl,
+		&i.ShortUrl,
+		&i.CreatedAt,
+		&i.RedirectType,
+		&i.NotAfter,
+		&i.DomainID,
+		&i.PasswordHash,
+		&i.NotBefore,
+		&i.MaxClicks,
+		&i.ClickCount,
+		&i.Rules,
+		&i.Variants,
+		&i.QueryMode,
+		&i.Utm,
+		&i.IsPrefix,
+		&i.Title,
+		&i.Description,
+		&i.Owner,
+		&i.FolderID,
+		&i.PageTitle,
+		&i.PageDescription,
+		&i.FaviconUrl,
+		&i.OgImageUrl,
+		&i.MetadataFetchedAt,
+		&i.LastStatusCode,
+		&i.RedirectChain,
+		&i.CheckError,
+		&i.CheckFailures,
+		&i.LastCheckedAt,
+		&i.BrokenAt,
+		&i.ExpiryNotifiedAt,
+		&i.ArchivedAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
@@ -3,11 +3,14 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
 	"shortURL/util"
 
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 )
 
@@ -18,6 +21,9 @@ func createRandomURL(t *testing.T) Url {
 	arg := CreateURLParams{
 		OriginUrl: randomLongURL,
 		ShortUrl:  util.RandomString(6),
+		Rules:     json.RawMessage("[]"),
+		Variants:  json.RawMessage("[]"),
+		Utm:       json.RawMessage("{}"),
 	}
 
 	url, err := testQueries.CreateURL(context.Background(), arg)
@@ -36,6 +42,9 @@ func TestQueries_CreateURL(t *testing.T) {
 	arg := CreateURLParams{
 		OriginUrl: util.RandomLongURL(),
 		ShortUrl:  util.RandomString(6),
+		Rules:     json.RawMessage("[]"),
+		Variants:  json.RawMessage("[]"),
+		Utm:       json.RawMessage("{}"),
 	}
 
 	res, err := testQueries.CreateURL(context.Background(), arg)
@@ -48,9 +57,39 @@ func TestQueries_CreateURL(t *testing.T) {
 	require.NotZero(t, res.CreatedAt)
 }
 
+func TestCreateURLDuplicateShortURL(t *testing.T) {
+	url1 := createRandomURL(t)
+
+	arg := CreateURLParams{
+		OriginUrl: util.RandomLongURL(),
+		ShortUrl:  url1.ShortUrl,
+		DomainID:  url1.DomainID,
+		Rules:     json.RawMessage("[]"),
+		Variants:  json.RawMessage("[]"),
+		Utm:       json.RawMessage("{}"),
+	}
+
+	// 短網址已被使用時不新增連結
+	_, err := testQueries.CreateURL(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// 不經過 ON CONFLICT 直接新增時由唯一索引拒絕
+	_, err = testDB.ExecContext(context.Background(),
+		`INSERT INTO urls (origin_url, short_url, domain_id) VALUES ($1, $2, $3)`,
+		arg.OriginUrl, arg.ShortUrl, arg.DomainID)
+	var pqErr *pq.Error
+	require.ErrorAs(t, err, &pqErr)
+	require.Equal(t, "unique_violation", pqErr.Code.Name())
+}
+
 func TestGetURL(t *testing.T) {
 	url1 := createRandomURL(t)
-	url2, err := testQueries.GetURL(context.Background(), url1.ShortUrl)
+	arg := GetURLParams{
+		DomainID: url1.DomainID,
+		ShortUrl: url1.ShortUrl,
+	}
+
+	url2, err := testQueries.GetURL(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, url2)
 
@@ -78,13 +117,352 @@ func TestUpdateURL(t *testing.T) {
 	require.WithinDuration(t, url1.CreatedAt, url2.CreatedAt, time.Second)
 }
 
+func TestUpdateURLSettings(t *testing.T) {
+	url1 := createRandomURL(t)
+
+	arg := UpdateURLSettingsParams{
+		ID:           url1.ID,
+		OriginUrl:    util.RandomLongURL(),
+		RedirectType: "302",
+		Rules:        json.RawMessage(`[{"destination":"https://example.com","devices":["mobile"]}]`),
+		Variants:     json.RawMessage(`[{"name":"a","destination":"https://example.com/a","weight":70}]`),
+		QueryMode:    "merge",
+		Utm:          json.RawMessage(`{"source":"newsletter"}`),
+		IsPrefix:     true,
+		Title:        "Example",
+		Description:  "An example link",
+	}
+
+	url2, err := testQueries.UpdateURLSettings(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, url2)
+
+	require.Equal(t, url1.ID, url2.ID)
+	require.Equal(t, url1.ShortUrl, url2.ShortUrl)
+	require.Equal(t, arg.OriginUrl, url2.OriginUrl)
+	require.Equal(t, arg.RedirectType, url2.RedirectType)
+	require.JSONEq(t, string(arg.Rules), string(url2.Rules))
+	require.JSONEq(t, string(arg.Variants), string(url2.Variants))
+	require.Equal(t, arg.QueryMode, url2.QueryMode)
+	require.JSONEq(t, string(arg.Utm), string(url2.Utm))
+	require.True(t, url2.IsPrefix)
+	require.Equal(t, arg.Title, url2.Title)
+	require.Equal(t, arg.Description, url2.Description)
+}
+
 func TestDeleteAccount(t *testing.T) {
 	url1 := createRandomURL(t)
 	err := testQueries.DeleteURL(context.Background(), url1.ID)
 	require.NoError(t, err)
 
-	url2, err := testQueries.GetURL(context.Background(), url1.ShortUrl)
+	arg := GetURLParams{
+		DomainID: url1.DomainID,
+		ShortUrl: url1.ShortUrl,
+	}
+
+	url2, err := testQueries.GetURL(context.Background(), arg)
 	require.Error(t, err)
 	require.EqualError(t, err, sql.ErrNoRows.Error())
 	require.Empty(t, url2)
 }
+
+func TestGetURLScopedByDomain(t *testing.T) {
+	domain := createRandomDomain(t)
+	url1 := createRandomURL(t)
+
+	arg := CreateURLParams{
+		OriginUrl: util.RandomLongURL(),
+		ShortUrl:  url1.ShortUrl,
+		DomainID:  domain.ID,
+		Rules:     json.RawMessage("[]"),
+		Variants:  json.RawMessage("[]"),
+		Utm:       json.RawMessage("{}"),
+	}
+
+	url2, err := testQueries.CreateURL(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, domain.ID, url2.DomainID)
+
+	url3, err := testQueries.GetURL(context.Background(), GetURLParams{
+		DomainID: domain.ID,
+		ShortUrl: url1.ShortUrl,
+	})
+	require.NoError(t, err)
+	require.Equal(t, url2.ID, url3.ID)
+	require.Equal(t, arg.OriginUrl, url3.OriginUrl)
+}
+
+func TestUpdateURLClickCount(t *testing.T) {
+	url1 := createRandomURL(t)
+
+	err := testQueries.UpdateURLClickCount(context.Background(), UpdateURLClickCountParams{
+		ID:         url1.ID,
+		ClickCount: 10,
+	})
+	require.NoError(t, err)
+
+	// 點擊數只會增加，不會被較舊的計數覆蓋
+	err = testQueries.UpdateURLClickCount(context.Background(), UpdateURLClickCountParams{
+		ID:         url1.ID,
+		ClickCount: 5,
+	})
+	require.NoError(t, err)
+
+	url2, err := testQueries.GetURL(context.Background(), GetURLParams{
+		DomainID: url1.DomainID,
+		ShortUrl: url1.ShortUrl,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(10), url2.ClickCount)
+}
+
+func TestSearchURLs(t *testing.T) {
+	folder := createRandomFolder(t)
+	owner := util.RandomString(8)
+	keyword := strings.ToLower(util.RandomString(10))
+
+	arg := CreateURLParams{
+		OriginUrl: "https://example.com/" + keyword,
+		ShortUrl:  util.RandomString(6),
+		Rules:     json.RawMessage("[]"),
+		Variants:  json.RawMessage("[]"),
+		Utm:       json.RawMessage("{}"),
+		Title:     "Spring sale",
+		Owner:     owner,
+		FolderID:  sql.NullInt64{Int64: folder.ID, Valid: true},
+	}
+
+	url1, err := testQueries.CreateURL(context.Background(), arg)
+	require.NoError(t, err)
+
+	err = testQueries.AddURLTag(context.Background(), AddURLTagParams{UrlID: url1.ID, Tag: keyword + "-tag"})
+	require.NoError(t, err)
+
+	search := func(arg SearchURLsParams) []Url {
+		arg.Limit = 10
+		urls, err := testQueries.SearchURLs(context.Background(), arg)
+		require.NoError(t, err)
+		return urls
+	}
+
+	// 以目的網址、標籤搜尋
+	require.Len(t, search(SearchURLsParams{Query: keyword}), 1)
+	require.Len(t, search(SearchURLsParams{Query: keyword + "-tag"}), 1)
+	require.Len(t, search(SearchURLsParams{Tag: keyword + "-tag"}), 1)
+
+	// 篩選條件
+	urls := search(SearchURLsParams{Owner: owner, Query: "spring"})
+	require.Len(t, urls, 1)
+	require.Equal(t, url1.ID, urls[0].ID)
+
+	require.Len(t, search(SearchURLsParams{FolderID: sql.NullInt64{Int64: folder.ID, Valid: true}}), 1)
+	require.Len(t, search(SearchURLsParams{Owner: owner, Status: "active"}), 1)
+	require.Empty(t, search(SearchURLsParams{Owner: owner, Status: "expired"}))
+	require.Empty(t, search(SearchURLsParams{
+		Owner:         owner,
+		CreatedBefore: sql.NullTime{Time: url1.CreatedAt.Add(-time.Hour), Valid: true},
+	}))
+}
+
+func TestUpdateURLMetadata(t *testing.T) {
+	url1 := createRandomURL(t)
+	require.False(t, url1.MetadataFetchedAt.Valid)
+
+	arg := UpdateURLMetadataParams{
+		ID:              url1.ID,
+		PageTitle:       "Example Domain",
+		PageDescription: "An example page",
+		FaviconUrl:      "https://example.com/favicon.ico",
+		OgImageUrl:      "https://example.com/og.png",
+	}
+
+	err := testQueries.UpdateURLMetadata(context.Background(), arg)
+	require.NoError(t, err)
+
+	url2, err := testQueries.GetURL(context.Background(), GetURLParams{
+		DomainID: url1.DomainID,
+		ShortUrl: url1.ShortUrl,
+	})
+	require.NoError(t, err)
+	require.Equal(t, arg.PageTitle, url2.PageTitle)
+	require.Equal(t, arg.PageDescription, url2.PageDescription)
+	require.Equal(t, arg.FaviconUrl, url2.FaviconUrl)
+	require.Equal(t, arg.OgImageUrl, url2.OgImageUrl)
+	require.True(t, url2.MetadataFetchedAt.Valid)
+}
+
+func TestListURLsToCheck(t *testing.T) {
+	url1 := createRandomURL(t)
+
+	// 從未檢查過的連結排在最前面
+	urls, err := testQueries.ListURLsToCheck(context.Background(), ListURLsToCheckParams{
+		CheckedBefore: time.Now(),
+		Limit:         1000,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, urls)
+
+	found := false
+	for _, url := range urls {
+		if url.ID == url1.ID {
+			found = true
+		}
+	}
+	require.True(t, found)
+}
+
+func TestUpdateURLCheck(t *testing.T) {
+	url1 := createRandomURL(t)
+
+	arg := UpdateURLCheckParams{
+		ID:               url1.ID,
+		LastStatusCode:   404,
+		RedirectChain:    json.RawMessage(`[{"url":"https://example.com","status_code":301}]`),
+		CheckError:       "",
+		Failed:           true,
+		FailureThreshold: 2,
+	}
+
+	// 第一次失敗還未達門檻
+	url2, err := testQueries.UpdateURLCheck(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(404), url2.LastStatusCode)
+	require.JSONEq(t, string(arg.RedirectChain), string(url2.RedirectChain))
+	require.Equal(t, int32(1), url2.CheckFailures)
+	require.True(t, url2.LastCheckedAt.Valid)
+	require.False(t, url2.BrokenAt.Valid)
+
+	url3, err := testQueries.UpdateURLCheck(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), url3.CheckFailures)
+	require.True(t, url3.BrokenAt.Valid)
+
+	// 失效時間維持第一次達到門檻的時間
+	url4, err := testQueries.UpdateURLCheck(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), url4.CheckFailures)
+	require.WithinDuration(t, url3.BrokenAt.Time, url4.BrokenAt.Time, time.Microsecond)
+
+	arg.LastStatusCode = 200
+	arg.RedirectChain = json.RawMessage("[]")
+	arg.Failed = false
+
+	url5, err := testQueries.UpdateURLCheck(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(0), url5.CheckFailures)
+	require.False(t, url5.BrokenAt.Valid)
+}
+
+func TestMarkExpiredURLs(t *testing.T) {
+	arg := CreateURLParams{
+		OriginUrl: util.RandomLongURL(),
+		ShortUrl:  util.RandomString(6),
+		NotAfter:  sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true},
+		Rules:     json.RawMessage("[]"),
+		Variants:  json.RawMessage("[]"),
+		Utm:       json.RawMessage("{}"),
+	}
+
+	url1, err := testQueries.CreateURL(context.Background(), arg)
+	require.NoError(t, err)
+
+	urls, err := testQueries.MarkExpiredURLs(context.Background(), 1000)
+	require.NoError(t, err)
+
+	found := false
+	for _, url := range urls {
+		if url.ID == url1.ID {
+			found = true
+			require.True(t, url.ExpiryNotifiedAt.Valid)
+		}
+	}
+	require.True(t, found)
+
+	// 同一個連結只會通知一次
+	urls, err = testQueries.MarkExpiredURLs(context.Background(), 1000)
+	require.NoError(t, err)
+	for _, url := range urls {
+		require.NotEqual(t, url1.ID, url.ID)
+	}
+}
+
+func TestSetURLArchived(t *testing.T) {
+	url1 := createRandomURL(t)
+
+	url2, err := testQueries.SetURLArchived(context.Background(), SetURLArchivedParams{ID: url1.ID, Archived: true})
+	require.NoError(t, err)
+	require.True(t, url2.ArchivedAt.Valid)
+
+	// 封存的連結仍然可以取得
+	url3, err := testQueries.GetURL(context.Background(), GetURLParams{DomainID: url1.DomainID, ShortUrl: url1.ShortUrl})
+	require.NoError(t, err)
+	require.True(t, url3.ArchivedAt.Valid)
+
+	url4, err := testQueries.SetURLArchived(context.Background(), SetURLArchivedParams{ID: url1.ID, Archived: false})
+	require.NoError(t, err)
+	require.False(t, url4.ArchivedAt.Valid)
+}
+
+func TestSoftDeleteAndRestoreURL(t *testing.T) {
+	url1 := createRandomURL(t)
+
+	url2, err := testQueries.SoftDeleteURL(context.Background(), url1.ID)
+	require.NoError(t, err)
+	require.True(t, url2.DeletedAt.Valid)
+
+	_, err = testQueries.GetURL(context.Background(), GetURLParams{DomainID: url1.DomainID, ShortUrl: url1.ShortUrl})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// 已刪除的連結不能再刪除
+	_, err = testQueries.SoftDeleteURL(context.Background(), url1.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleted, err := testQueries.GetDeletedURL(context.Background(), GetDeletedURLParams{DomainID: url1.DomainID, ShortUrl: url1.ShortUrl})
+	require.NoError(t, err)
+	require.Equal(t, url1.ID, deleted.ID)
+
+	// 超過保留期限時無法還原
+	_, err = testQueries.RestoreURL(context.Background(), RestoreURLParams{
+		ID:           url1.ID,
+		DeletedAfter: time.Now().Add(time.Minute),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	url3, err := testQueries.RestoreURL(context.Background(), RestoreURLParams{
+		ID:           url1.ID,
+		DeletedAfter: time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+	require.False(t, url3.DeletedAt.Valid)
+
+	_, err = testQueries.GetURL(context.Background(), GetURLParams{DomainID: url1.DomainID, ShortUrl: url1.ShortUrl})
+	require.NoError(t, err)
+}
+
+func TestPurgeDeletedURLs(t *testing.T) {
+	url1 := createRandomURL(t)
+	url2 := createRandomURL(t)
+
+	_, err := testQueries.SoftDeleteURL(context.Background(), url1.ID)
+	require.NoError(t, err)
+
+	urls, err := testQueries.PurgeDeletedURLs(context.Background(), PurgeDeletedURLsParams{
+		DeletedBefore: time.Now().Add(time.Minute),
+		Limit:         1000,
+	})
+	require.NoError(t, err)
+
+	purged := make(map[int64]bool)
+	for _, url := range urls {
+		purged[url.ID] = true
+	}
+	require.True(t, purged[url1.ID])
+	require.False(t, purged[url2.ID])
+
+	// 永久刪除後無法還原
+	_, err = testQueries.GetDeletedURL(context.Background(), GetDeletedURLParams{
+		DomainID: url1.DomainID,
+		ShortUrl: url1.ShortUrl,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
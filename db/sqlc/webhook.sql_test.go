@@ -0,0 +1,165 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"shortURL/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomWebhook(t *testing.T, events ...string) Webhook {
+	if len(events) == 0 {
+		events = []string{"link.created"}
+	}
+
+	arg := CreateWebhookParams{
+		Url:    "https://" + util.RandomLongURL(),
+		Secret: util.RandomString(32),
+		Events: events,
+	}
+
+	webhook, err := testQueries.CreateWebhook(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, webhook)
+
+	require.Equal(t, arg.Url, webhook.Url)
+	require.Equal(t, arg.Secret, webhook.Secret)
+	require.Equal(t, arg.Events, webhook.Events)
+	require.NotZero(t, webhook.ID)
+	require.NotZero(t, webhook.CreatedAt)
+
+	return webhook
+}
+
+func createRandomWebhookDelivery(t *testing.T, webhook Webhook) WebhookDelivery {
+	arg := CreateWebhookDeliveryParams{
+		WebhookID: webhook.ID,
+		Event:     webhook.Events[0],
+		Payload:   json.RawMessage(`{"event":"link.created"}`),
+	}
+
+	delivery, err := testQueries.CreateWebhookDelivery(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, delivery)
+
+	require.Equal(t, arg.WebhookID, delivery.WebhookID)
+	require.Equal(t, arg.Event, delivery.Event)
+	require.JSONEq(t, string(arg.Payload), string(delivery.Payload))
+	require.Equal(t, "pending", delivery.Status)
+	require.Zero(t, delivery.Attempts)
+	require.False(t, delivery.DeliveredAt.Valid)
+
+	return delivery
+}
+
+func TestCreateWebhook(t *testing.T) {
+	createRandomWebhook(t)
+}
+
+func TestGetWebhook(t *testing.T) {
+	webhook1 := createRandomWebhook(t, "link.created", "link.clicked")
+	webhook2, err := testQueries.GetWebhook(context.Background(), webhook1.ID)
+	require.NoError(t, err)
+
+	require.Equal(t, webhook1.ID, webhook2.ID)
+	require.Equal(t, webhook1.Url, webhook2.Url)
+	require.Equal(t, webhook1.Events, webhook2.Events)
+	require.WithinDuration(t, webhook1.CreatedAt, webhook2.CreatedAt, time.Second)
+}
+
+func TestListWebhooksForEvent(t *testing.T) {
+	event := "test." + util.RandomString(8)
+	webhook1 := createRandomWebhook(t, event, "link.created")
+	createRandomWebhook(t, "link.created")
+
+	webhooks, err := testQueries.ListWebhooksForEvent(context.Background(), event)
+	require.NoError(t, err)
+	require.Len(t, webhooks, 1)
+	require.Equal(t, webhook1.ID, webhooks[0].ID)
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	webhook1 := createRandomWebhook(t)
+	delivery := createRandomWebhookDelivery(t, webhook1)
+
+	err := testQueries.DeleteWebhook(context.Background(), webhook1.ID)
+	require.NoError(t, err)
+
+	_, err = testQueries.GetWebhook(context.Background(), webhook1.ID)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	// 投遞紀錄一併刪除
+	_, err = testQueries.GetWebhookDelivery(context.Background(), delivery.ID)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestClaimWebhookDeliveries(t *testing.T) {
+	webhook := createRandomWebhook(t)
+	delivery := createRandomWebhookDelivery(t, webhook)
+
+	leaseUntil := time.Now().Add(time.Minute)
+	deliveries, err := testQueries.ClaimWebhookDeliveries(context.Background(), ClaimWebhookDeliveriesParams{
+		LeaseUntil: leaseUntil,
+		Limit:      1000,
+	})
+	require.NoError(t, err)
+
+	var claimed *WebhookDelivery
+	for i := range deliveries {
+		if deliveries[i].ID == delivery.ID {
+			claimed = &deliveries[i]
+		}
+	}
+	require.NotNil(t, claimed)
+	require.WithinDuration(t, leaseUntil, claimed.NextAttemptAt, time.Second)
+
+	// 租約期間不會再被取出
+	deliveries, err = testQueries.ClaimWebhookDeliveries(context.Background(), ClaimWebhookDeliveriesParams{
+		LeaseUntil: leaseUntil,
+		Limit:      1000,
+	})
+	require.NoError(t, err)
+	for _, d := range deliveries {
+		require.NotEqual(t, delivery.ID, d.ID)
+	}
+}
+
+func TestUpdateWebhookDelivery(t *testing.T) {
+	webhook := createRandomWebhook(t)
+	delivery1 := createRandomWebhookDelivery(t, webhook)
+
+	arg := UpdateWebhookDeliveryParams{
+		ID:             delivery1.ID,
+		Status:         "dead",
+		Attempts:       8,
+		NextAttemptAt:  time.Now(),
+		LastStatusCode: 500,
+		LastError:      "unexpected status",
+	}
+
+	delivery2, err := testQueries.UpdateWebhookDelivery(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Status, delivery2.Status)
+	require.Equal(t, arg.Attempts, delivery2.Attempts)
+	require.Equal(t, arg.LastStatusCode, delivery2.LastStatusCode)
+	require.Equal(t, arg.LastError, delivery2.LastError)
+
+	delivery3, err := testQueries.RedeliverWebhookDelivery(context.Background(), delivery1.ID)
+	require.NoError(t, err)
+	require.Equal(t, "pending", delivery3.Status)
+	require.Zero(t, delivery3.Attempts)
+	require.Empty(t, delivery3.LastError)
+
+	deliveries, err := testQueries.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+		WebhookID: webhook.ID,
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, delivery1.ID, deliveries[0].ID)
+}
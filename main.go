@@ -1,13 +1,21 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"expvar"
+	"fmt"
 	"log"
+	"net/http"
 
 	"shortURL/api"
+	"shortURL/db/kv"
 	"shortURL/db/redis"
 	db "shortURL/db/sqlc"
+	"shortURL/rule"
 	"shortURL/util"
+	"shortURL/worker"
 
 	goredis "github.com/go-redis/redis/v8"
 	_ "github.com/lib/pq"
@@ -19,22 +27,181 @@ func main() {
 		log.Fatal("cannot load config:", err)
 	}
 
-	conn, err := sql.Open(config.DBDriver, config.DBSource)
+	store, err := openStore(config)
 	if err != nil {
 		log.Fatal("cannot connect to db:", err)
 	}
 
-	redisClient := goredis.NewClient(&goredis.Options{
-		Addr: "localhost:6379",
-	})
+	redisQuery, err := openRedis(config)
+	if err != nil {
+		log.Fatal("cannot open redis:", err)
+	}
+
+	// 定期將 redis 的點擊數寫回資料庫
+	clickPersister := worker.NewClickPersister(store, redisQuery, config.ClickPersistPeriod)
+	go clickPersister.Start(context.Background())
+
+	// 背景工作，包含抓取目的網頁的標題、圖示等資訊與建立 webhook 投遞紀錄
+	distributor := worker.NewRedisTaskDistributor(redisQuery)
+	fetcher := worker.NewMetadataFetcher(config.MetadataTimeout, config.MetadataMaxBodySize)
+	taskProcessor := worker.NewTaskProcessor(store, redisQuery, fetcher)
+	go taskProcessor.Start(context.Background())
 
-	store := db.NewQuery(conn)
-	redisQuery := redis.NewRedisQuery(redisClient)
+	// 定期送出 webhook
+	webhookDispatcher := worker.NewWebhookDispatcher(store, config)
+	go webhookDispatcher.Start(context.Background())
 
-	server := api.NewServer(store, redisQuery)
+	// 定期將延遲寫入的連結批次寫入資料庫，關閉延遲寫入後仍會寫入剩下的連結
+	if config.WriteBehind && config.RedisDriver == "memory" {
+		log.Fatal("WRITE_BEHIND requires REDIS_DRIVER=redis, links kept in memory are lost on restart")
+	}
+	urlFlusher := worker.NewURLFlusher(store, redisQuery, distributor, config)
+	go urlFlusher.Start(context.Background())
+	expvar.Publish("write_behind", expvar.Func(func() interface{} {
+		return urlFlusher.Stats()
+	}))
+
+	// 定期檢查長連結是否失效
+	linkChecker := worker.NewLinkChecker(store, distributor, config)
+	go linkChecker.Start(context.Background())
+
+	// 定期通知過期的連結
+	expiryNotifier := worker.NewExpiryNotifier(store, distributor, config.ExpiryCheckInterval)
+	go expiryNotifier.Start(context.Background())
+
+	// 定期永久刪除超過保留期限的連結
+	urlPurger := worker.NewURLPurger(store, config.PurgeInterval, config.DeleteRetention)
+	go urlPurger.Start(context.Background())
+
+	// 有設定 GeoIP 資料庫時才能依國家導向
+	var countries rule.CountryResolver
+	if config.GeoIPDatabase != "" {
+		geoIP, err := rule.NewGeoIP(config.GeoIPDatabase)
+		if err != nil {
+			log.Fatal("cannot open geoip database:", err)
+		}
+		defer geoIP.Close()
+
+		countries = geoIP
+	}
+
+	// 執行狀態只在管理用的位址公開，不經過對外的 API
+	if config.AdminHTTPServerAddress != "" {
+		go startAdminServer(config.AdminHTTPServerAddress)
+	}
+
+	server, err := api.NewServer(config, store, redisQuery, countries, distributor)
+	if err != nil {
+		log.Fatal("cannot create server:", err)
+	}
 
 	err = server.Start(config.HTTPServerAddress)
 	if err != nil {
 		log.Fatal("cannot start server:", err)
 	}
 }
+
+// startAdminServer serves the expvar variables, including the write-behind progress, on GET /debug/vars.
+func startAdminServer(address string) {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	err := http.ListenAndServe(address, mux)
+	if err != nil {
+		log.Fatal("cannot start admin server:", err)
+	}
+}
+
+// openRedis connects to REDIS_ADDRESS.
+// With REDIS_DRIVER=memory the cache and queues are kept in memory of this instance, for single instance deployments.
+func openRedis(config util.Config) (redis.RedisQuerier, error) {
+	switch config.RedisDriver {
+	case "memory":
+		return redis.NewMemoryQuery(), nil
+	case "", "redis":
+	default:
+		return nil, fmt.Errorf("unsupported REDIS_DRIVER %q", config.RedisDriver)
+	}
+
+	// 未設定位址時不改用記憶體，避免多個實例各自保存快取與工作佇列
+	if config.RedisAddress == "" {
+		return nil, errors.New("REDIS_ADDRESS is required, set REDIS_DRIVER=memory to keep the cache and queues in memory")
+	}
+
+	redisClient := goredis.NewClient(&goredis.Options{
+		Addr: config.RedisAddress,
+	})
+
+	return redis.NewRedisQuery(redisClient), nil
+}
+
+// openStore opens the database selected by DB_DRIVER.
+// With DB_REPLICA_SOURCES the list and search queries are read from the Postgres replicas.
+// With DB_SHARD_SOURCES the links are spread over DB_SOURCE, which is shard 0, and the listed databases.
+// bolt and sqlite are embedded databases for small deployments, DB_SOURCE is the path of the database file.
+func openStore(config util.Config) (db.Store, error) {
+	switch config.DBDriver {
+	case "bolt":
+		backend, err := kv.OpenBolt(config.DBSource)
+		if err != nil {
+			return nil, err
+		}
+		return kv.NewStore(backend)
+	case "sqlite":
+		backend, err := kv.OpenSQLite(config.DBSource)
+		if err != nil {
+			return nil, err
+		}
+		return kv.NewStore(backend)
+	}
+
+	conn, err := sql.Open(config.DBDriver, config.DBSource)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(config.DBShardSources) > 0 {
+		if len(config.DBReplicaSources) > 0 {
+			return nil, errors.New("DB_REPLICA_SOURCES cannot be used with DB_SHARD_SOURCES")
+		}
+
+		shards, err := openShards(config, conn)
+		if err != nil {
+			return nil, err
+		}
+		return db.NewShardedStore(shards, config.DBShardResharding), nil
+	}
+
+	if len(config.DBReplicaSources) == 0 {
+		return db.NewStore(conn), nil
+	}
+
+	replicas := make([]*sql.DB, len(config.DBReplicaSources))
+	for i, source := range config.DBReplicaSources {
+		replicas[i], err = sql.Open(config.DBDriver, source)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	store, router := db.NewReplicatedStore(conn, replicas, config.ReadYourWritesWindow, config.DBReplicaCheckInterval)
+
+	// 定期檢查唯讀副本是否可用
+	go router.Start(context.Background())
+
+	return store, nil
+}
+
+// openShards opens the shards in order, shard 0 is DB_SOURCE.
+func openShards(config util.Config, conn *sql.DB) ([]*sql.DB, error) {
+	shards := []*sql.DB{conn}
+	for _, source := range config.DBShardSources {
+		shard, err := sql.Open(config.DBDriver, source)
+		if err != nil {
+			return nil, err
+		}
+		shards = append(shards, shard)
+	}
+
+	return shards, nil
+}
@@ -0,0 +1,43 @@
+package rule
+
+import (
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// GeoIP resolves countries from an offline MaxMind GeoIP2 / GeoLite2 country database.
+type GeoIP struct {
+	reader *maxminddb.Reader
+}
+
+// NewGeoIP opens the GeoIP database file.
+func NewGeoIP(path string) (*GeoIP, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GeoIP{reader: reader}, nil
+}
+
+// Country returns the ISO country code of the IP address.
+func (g *GeoIP) Country(ip net.IP) (string, error) {
+	var record struct {
+		Country struct {
+			ISOCode string `maxminddb:"iso_code"`
+		} `maxminddb:"country"`
+	}
+
+	err := g.reader.Lookup(ip, &record)
+	if err != nil {
+		return "", err
+	}
+
+	return record.Country.ISOCode, nil
+}
+
+// Close closes the GeoIP database file.
+func (g *GeoIP) Close() error {
+	return g.reader.Close()
+}
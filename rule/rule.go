@@ -0,0 +1,233 @@
+// Package rule evaluates the conditional redirect rules and weighted variants of a link.
+package rule
+
+import (
+	"net"
+	"strings"
+	"time"
+	_ "time/tzdata" // 確保沒有系統時區資料的環境也能載入時區
+)
+
+// Constants for all supported devices
+const (
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceDesktop = "desktop"
+	DeviceBot     = "bot"
+)
+
+// Constants for all supported operating systems
+const (
+	OSiOS     = "ios"
+	OSAndroid = "android"
+	OSWindows = "windows"
+	OSMacOS   = "macos"
+	OSLinux   = "linux"
+	OSOther   = "other"
+)
+
+// Rule sends the request to its destination when all of its conditions match.
+// A condition which is not set always matches.
+type Rule struct {
+	Destination string            `json:"destination" binding:"required,web_url"`
+	Devices     []string          `json:"devices,omitempty" binding:"omitempty,dive,oneof=mobile tablet desktop bot"`
+	OS          []string          `json:"os,omitempty" binding:"omitempty,dive,oneof=ios android windows macos linux other"`
+	Languages   []string          `json:"languages,omitempty" binding:"omitempty,dive,min=2"`
+	Query       map[string]string `json:"query,omitempty"`
+	Hours       *HourRange        `json:"hours,omitempty"`
+	Countries   []string          `json:"countries,omitempty" binding:"omitempty,dive,len=2,alpha"`
+}
+
+// HourRange matches the hours in [From, To) of the time zone.
+// A range such as 22 to 6 wraps around midnight.
+type HourRange struct {
+	From     int    `json:"from" binding:"min=0,max=23"`
+	To       int    `json:"to" binding:"min=0,max=24"`
+	Timezone string `json:"timezone,omitempty" binding:"omitempty,timezone"`
+}
+
+// CountryResolver resolves the ISO country code of an IP address.
+type CountryResolver interface {
+	Country(ip net.IP) (string, error)
+}
+
+// Request holds the request attributes the rules are matched against.
+type Request struct {
+	UserAgent      string
+	AcceptLanguage string
+	Query          map[string][]string
+	IP             net.IP
+	Time           time.Time
+}
+
+// Match returns the destination of the first rule which matches the request.
+func Match(rules []Rule, req Request, countries CountryResolver) (string, bool) {
+	device, os := ParseUserAgent(req.UserAgent)
+	languages := parseAcceptLanguage(req.AcceptLanguage)
+
+	// 國家只在有規則需要時才查詢
+	country, countryResolved := "", false
+
+	for _, rule := range rules {
+		if len(rule.Devices) > 0 && !contains(rule.Devices, device) {
+			continue
+		}
+
+		if len(rule.OS) > 0 && !contains(rule.OS, os) {
+			continue
+		}
+
+		if len(rule.Languages) > 0 && !matchLanguages(rule.Languages, languages) {
+			continue
+		}
+
+		if !matchQuery(rule.Query, req.Query) {
+			continue
+		}
+
+		if rule.Hours != nil && !rule.Hours.contains(req.Time) {
+			continue
+		}
+
+		if len(rule.Countries) > 0 {
+			if !countryResolved {
+				country = resolveCountry(countries, req.IP)
+				countryResolved = true
+			}
+
+			if !contains(rule.Countries, country) {
+				continue
+			}
+		}
+
+		return rule.Destination, true
+	}
+
+	return "", false
+}
+
+// ParseUserAgent returns the device and operating system of the User-Agent.
+func ParseUserAgent(userAgent string) (device string, os string) {
+	ua := strings.ToLower(userAgent)
+
+	switch {
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipod"), strings.Contains(ua, "ipad"):
+		os = OSiOS
+	case strings.Contains(ua, "android"):
+		os = OSAndroid
+	case strings.Contains(ua, "windows"):
+		os = OSWindows
+	case strings.Contains(ua, "macintosh"), strings.Contains(ua, "mac os x"):
+		os = OSMacOS
+	case strings.Contains(ua, "linux"), strings.Contains(ua, "x11"):
+		os = OSLinux
+	default:
+		os = OSOther
+	}
+
+	switch {
+	case strings.Contains(ua, "bot"), strings.Contains(ua, "crawler"), strings.Contains(ua, "spider"):
+		device = DeviceBot
+	case strings.Contains(ua, "ipad"), strings.Contains(ua, "tablet"),
+		os == OSAndroid && !strings.Contains(ua, "mobile"):
+		device = DeviceTablet
+	case strings.Contains(ua, "mobile"), strings.Contains(ua, "iphone"), strings.Contains(ua, "ipod"):
+		device = DeviceMobile
+	default:
+		device = DeviceDesktop
+	}
+
+	return device, os
+}
+
+func (h HourRange) contains(t time.Time) bool {
+	if h.Timezone != "" {
+		location, err := time.LoadLocation(h.Timezone)
+		if err != nil {
+			return false
+		}
+		t = t.In(location)
+	}
+
+	hour := t.Hour()
+	if h.From <= h.To {
+		return hour >= h.From && hour < h.To
+	}
+
+	// 跨越午夜，例如 22 點到 6 點
+	return hour >= h.From || hour < h.To
+}
+
+// 解析 Accept-Language，忽略權重為 0 的語言
+func parseAcceptLanguage(header string) []string {
+	var languages []string
+
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		if q := strings.TrimSpace(params); strings.HasPrefix(q, "q=0") && strings.Trim(q[3:], ".0") == "" {
+			continue
+		}
+
+		languages = append(languages, strings.ToLower(tag))
+	}
+
+	return languages
+}
+
+// 規則的語言為 en 時符合 en 與 en-US，為 en-US 時只符合 en-US
+func matchLanguages(ruleLanguages []string, languages []string) bool {
+	for _, ruleLanguage := range ruleLanguages {
+		ruleLanguage = strings.ToLower(ruleLanguage)
+
+		for _, language := range languages {
+			if language == ruleLanguage || strings.HasPrefix(language, ruleLanguage+"-") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// 查詢參數值為空字串時只要求參數存在
+func matchQuery(ruleQuery map[string]string, query map[string][]string) bool {
+	for key, value := range ruleQuery {
+		values, ok := query[key]
+		if !ok {
+			return false
+		}
+
+		if value != "" && !contains(values, value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func resolveCountry(countries CountryResolver, ip net.IP) string {
+	if countries == nil || ip == nil {
+		return ""
+	}
+
+	country, err := countries.Country(ip)
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToUpper(country)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
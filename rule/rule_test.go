@@ -0,0 +1,191 @@
+package rule
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	iPhoneUA  = "Mozilla/5.0 (iPhone; CPU iPhone OS 15_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.0 Mobile/15E148 Safari/604.1"
+	iPadUA    = "Mozilla/5.0 (iPad; CPU OS 15_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.0 Mobile/15E148 Safari/604.1"
+	androidUA = "Mozilla/5.0 (Linux; Android 12; Pixel 6) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/100.0.4896.127 Mobile Safari/537.36"
+	tabletUA  = "Mozilla/5.0 (Linux; Android 12; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/100.0.4896.127 Safari/537.36"
+	windowsUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/100.0.4896.127 Safari/537.36"
+	macUA     = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.0 Safari/605.1.15"
+	linuxUA   = "Mozilla/5.0 (X11; Linux x86_64; rv:99.0) Gecko/20100101 Firefox/99.0"
+	botUA     = "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+)
+
+type fakeCountries map[string]string
+
+func (f fakeCountries) Country(ip net.IP) (string, error) {
+	country, ok := f[ip.String()]
+	if !ok {
+		return "", errors.New("not found")
+	}
+
+	return country, nil
+}
+
+func TestParseUserAgent(t *testing.T) {
+	testCases := []struct {
+		userAgent string
+		device    string
+		os        string
+	}{
+		{iPhoneUA, DeviceMobile, OSiOS},
+		{iPadUA, DeviceTablet, OSiOS},
+		{androidUA, DeviceMobile, OSAndroid},
+		{tabletUA, DeviceTablet, OSAndroid},
+		{windowsUA, DeviceDesktop, OSWindows},
+		{macUA, DeviceDesktop, OSMacOS},
+		{linuxUA, DeviceDesktop, OSLinux},
+		{botUA, DeviceBot, OSOther},
+		{"", DeviceDesktop, OSOther},
+	}
+
+	for _, tc := range testCases {
+		device, os := ParseUserAgent(tc.userAgent)
+		require.Equal(t, tc.device, device, tc.userAgent)
+		require.Equal(t, tc.os, os, tc.userAgent)
+	}
+}
+
+func TestMatchAppStores(t *testing.T) {
+	rules := []Rule{
+		{Destination: "https://apps.apple.com/app/id1", OS: []string{OSiOS}},
+		{Destination: "https://play.google.com/store/apps", OS: []string{OSAndroid}},
+	}
+
+	destination, ok := Match(rules, Request{UserAgent: iPhoneUA}, nil)
+	require.True(t, ok)
+	require.Equal(t, "https://apps.apple.com/app/id1", destination)
+
+	destination, ok = Match(rules, Request{UserAgent: androidUA}, nil)
+	require.True(t, ok)
+	require.Equal(t, "https://play.google.com/store/apps", destination)
+
+	_, ok = Match(rules, Request{UserAgent: windowsUA}, nil)
+	require.False(t, ok)
+}
+
+func TestMatchFirstRuleWins(t *testing.T) {
+	rules := []Rule{
+		{Destination: "https://example.com/mobile", Devices: []string{DeviceMobile}},
+		{Destination: "https://example.com/ios", OS: []string{OSiOS}},
+	}
+
+	destination, ok := Match(rules, Request{UserAgent: iPhoneUA}, nil)
+	require.True(t, ok)
+	require.Equal(t, "https://example.com/mobile", destination)
+}
+
+func TestMatchLanguages(t *testing.T) {
+	rules := []Rule{
+		{Destination: "https://example.com/zh-tw", Languages: []string{"zh-TW"}},
+		{Destination: "https://example.com/en", Languages: []string{"en"}},
+	}
+
+	testCases := []struct {
+		acceptLanguage string
+		destination    string
+		ok             bool
+	}{
+		{"zh-TW,zh;q=0.9,en;q=0.8", "https://example.com/zh-tw", true},
+		{"en-US,en;q=0.9", "https://example.com/en", true},
+		{"zh-CN,zh;q=0.9", "", false},
+		{"fr, en;q=0", "", false},
+		{"", "", false},
+	}
+
+	for _, tc := range testCases {
+		destination, ok := Match(rules, Request{AcceptLanguage: tc.acceptLanguage}, nil)
+		require.Equal(t, tc.ok, ok, tc.acceptLanguage)
+		require.Equal(t, tc.destination, destination, tc.acceptLanguage)
+	}
+}
+
+func TestMatchQuery(t *testing.T) {
+	rules := []Rule{
+		{Destination: "https://example.com/spring", Query: map[string]string{"campaign": "spring"}},
+		{Destination: "https://example.com/ref", Query: map[string]string{"ref": ""}},
+	}
+
+	destination, ok := Match(rules, Request{Query: map[string][]string{"campaign": {"spring"}}}, nil)
+	require.True(t, ok)
+	require.Equal(t, "https://example.com/spring", destination)
+
+	destination, ok = Match(rules, Request{Query: map[string][]string{"campaign": {"fall"}, "ref": {"mail"}}}, nil)
+	require.True(t, ok)
+	require.Equal(t, "https://example.com/ref", destination)
+
+	_, ok = Match(rules, Request{Query: map[string][]string{"campaign": {"fall"}}}, nil)
+	require.False(t, ok)
+}
+
+func TestMatchHours(t *testing.T) {
+	rules := []Rule{
+		{Destination: "https://example.com/night", Hours: &HourRange{From: 22, To: 6, Timezone: "Asia/Taipei"}},
+		{Destination: "https://example.com/office", Hours: &HourRange{From: 9, To: 18}},
+	}
+
+	// 台北時間 23 點
+	night := time.Date(2022, 5, 1, 15, 0, 0, 0, time.UTC)
+	destination, ok := Match(rules, Request{Time: night}, nil)
+	require.True(t, ok)
+	require.Equal(t, "https://example.com/night", destination)
+
+	office := time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+	destination, ok = Match(rules, Request{Time: office}, nil)
+	require.True(t, ok)
+	require.Equal(t, "https://example.com/office", destination)
+
+	morning := time.Date(2022, 5, 1, 23, 0, 0, 0, time.UTC)
+	_, ok = Match(rules, Request{Time: morning}, nil)
+	require.False(t, ok)
+}
+
+func TestMatchCountries(t *testing.T) {
+	rules := []Rule{
+		{Destination: "https://example.com/tw", Countries: []string{"tw"}},
+	}
+
+	countries := fakeCountries{"1.2.3.4": "TW", "5.6.7.8": "JP"}
+
+	destination, ok := Match(rules, Request{IP: net.ParseIP("1.2.3.4")}, countries)
+	require.True(t, ok)
+	require.Equal(t, "https://example.com/tw", destination)
+
+	_, ok = Match(rules, Request{IP: net.ParseIP("5.6.7.8")}, countries)
+	require.False(t, ok)
+
+	_, ok = Match(rules, Request{IP: net.ParseIP("9.9.9.9")}, countries)
+	require.False(t, ok)
+
+	// 沒有 GeoIP 資料庫時國家規則不會符合
+	_, ok = Match(rules, Request{IP: net.ParseIP("1.2.3.4")}, nil)
+	require.False(t, ok)
+}
+
+func TestMatchAllConditions(t *testing.T) {
+	rules := []Rule{
+		{
+			Destination: "https://example.com/tw-ios",
+			OS:          []string{OSiOS},
+			Countries:   []string{"TW"},
+		},
+	}
+
+	countries := fakeCountries{"1.2.3.4": "TW"}
+
+	destination, ok := Match(rules, Request{UserAgent: iPhoneUA, IP: net.ParseIP("1.2.3.4")}, countries)
+	require.True(t, ok)
+	require.Equal(t, "https://example.com/tw-ios", destination)
+
+	_, ok = Match(rules, Request{UserAgent: androidUA, IP: net.ParseIP("1.2.3.4")}, countries)
+	require.False(t, ok)
+}
@@ -0,0 +1,49 @@
+package rule
+
+import (
+	"hash/fnv"
+)
+
+// Variant is one of the weighted destinations of a link.
+type Variant struct {
+	Name        string `json:"name" binding:"required,max=32,alphanum"`
+	Destination string `json:"destination" binding:"required,web_url"`
+	Weight      int    `json:"weight" binding:"required,min=1,max=1000"`
+}
+
+// PickVariant picks a variant by weight.
+// The same client ID gets the same variant as long as the variants are unchanged.
+func PickVariant(variants []Variant, clientID string) (Variant, bool) {
+	total := 0
+	for _, variant := range variants {
+		total += variant.Weight
+	}
+
+	if total <= 0 {
+		return Variant{}, false
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(clientID))
+	bucket := int(h.Sum64() % uint64(total))
+
+	for _, variant := range variants {
+		if bucket < variant.Weight {
+			return variant, true
+		}
+		bucket -= variant.Weight
+	}
+
+	return Variant{}, false
+}
+
+// FindVariant returns the variant with the name.
+func FindVariant(variants []Variant, name string) (Variant, bool) {
+	for _, variant := range variants {
+		if variant.Name == name {
+			return variant, true
+		}
+	}
+
+	return Variant{}, false
+}
@@ -0,0 +1,60 @@
+package rule
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPickVariantWeights(t *testing.T) {
+	variants := []Variant{
+		{Name: "a", Destination: "https://example.com/a", Weight: 70},
+		{Name: "b", Destination: "https://example.com/b", Weight: 30},
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		variant, ok := PickVariant(variants, fmt.Sprintf("client-%d", i))
+		require.True(t, ok)
+		counts[variant.Name]++
+	}
+
+	require.InDelta(t, 7000, counts["a"], 300)
+	require.InDelta(t, 3000, counts["b"], 300)
+}
+
+func TestPickVariantSticky(t *testing.T) {
+	variants := []Variant{
+		{Name: "a", Destination: "https://example.com/a", Weight: 50},
+		{Name: "b", Destination: "https://example.com/b", Weight: 50},
+	}
+
+	variant1, ok := PickVariant(variants, "client")
+	require.True(t, ok)
+
+	for i := 0; i < 10; i++ {
+		variant2, ok := PickVariant(variants, "client")
+		require.True(t, ok)
+		require.Equal(t, variant1, variant2)
+	}
+}
+
+func TestPickVariantEmpty(t *testing.T) {
+	_, ok := PickVariant(nil, "client")
+	require.False(t, ok)
+}
+
+func TestFindVariant(t *testing.T) {
+	variants := []Variant{
+		{Name: "a", Destination: "https://example.com/a", Weight: 70},
+		{Name: "b", Destination: "https://example.com/b", Weight: 30},
+	}
+
+	variant, ok := FindVariant(variants, "b")
+	require.True(t, ok)
+	require.Equal(t, "https://example.com/b", variant.Destination)
+
+	_, ok = FindVariant(variants, "c")
+	require.False(t, ok)
+}
@@ -1,15 +1,60 @@
 package util
 
 import (
+	"time"
+
 	"github.com/spf13/viper"
 )
 
 // Config stores all configuration of the application.
 // The values are read by viper from a config file or environment variable.
 type Config struct {
-	DBDriver          string `mapstructure:"DB_DRIVER"`
-	DBSource          string `mapstructure:"DB_SOURCE"`
-	HTTPServerAddress string `mapstructure:"HTTP_SERVER_ADDRESS"`
+	DBDriver                  string        `mapstructure:"DB_DRIVER"`
+	DBSource                  string        `mapstructure:"DB_SOURCE"`
+	DBReplicaSources          []string      `mapstructure:"DB_REPLICA_SOURCES"`
+	DBShardSources            []string      `mapstructure:"DB_SHARD_SOURCES"`
+	DBShardResharding         bool          `mapstructure:"DB_SHARD_RESHARDING"`
+	DBReplicaCheckInterval    time.Duration `mapstructure:"DB_REPLICA_CHECK_INTERVAL"`
+	ReadYourWritesWindow      time.Duration `mapstructure:"READ_YOUR_WRITES_WINDOW"`
+	RedisDriver               string        `mapstructure:"REDIS_DRIVER"`
+	RedisAddress              string        `mapstructure:"REDIS_ADDRESS"`
+	HTTPServerAddress         string        `mapstructure:"HTTP_SERVER_ADDRESS"`
+	TrustedProxies            []string      `mapstructure:"TRUSTED_PROXIES"`
+	AdminHTTPServerAddress    string        `mapstructure:"ADMIN_HTTP_SERVER_ADDRESS"`
+	BaseURL                   string        `mapstructure:"BASE_URL"`
+	DefaultRedirectType       string        `mapstructure:"DEFAULT_REDIRECT_TYPE"`
+	InterstitialDelay         time.Duration `mapstructure:"INTERSTITIAL_DELAY"`
+	TrackingPixelURL          string        `mapstructure:"TRACKING_PIXEL_URL"`
+	UnlockCookieSecret        string        `mapstructure:"UNLOCK_COOKIE_SECRET"`
+	UnlockCookieMaxAge        time.Duration `mapstructure:"UNLOCK_COOKIE_MAX_AGE"`
+	UnavailableURL            string        `mapstructure:"UNAVAILABLE_URL"`
+	ClickPersistPeriod        time.Duration `mapstructure:"CLICK_PERSIST_PERIOD"`
+	GeoIPDatabase             string        `mapstructure:"GEOIP_DATABASE"`
+	VariantCookieMaxAge       time.Duration `mapstructure:"VARIANT_COOKIE_MAX_AGE"`
+	MetadataTimeout           time.Duration `mapstructure:"METADATA_TIMEOUT"`
+	MetadataMaxBodySize       int64         `mapstructure:"METADATA_MAX_BODY_SIZE"`
+	LinkCheckInterval         time.Duration `mapstructure:"LINK_CHECK_INTERVAL"`
+	LinkCheckMaxAge           time.Duration `mapstructure:"LINK_CHECK_MAX_AGE"`
+	LinkCheckBatchSize        int32         `mapstructure:"LINK_CHECK_BATCH_SIZE"`
+	LinkCheckConcurrency      int           `mapstructure:"LINK_CHECK_CONCURRENCY"`
+	LinkCheckHostDelay        time.Duration `mapstructure:"LINK_CHECK_HOST_DELAY"`
+	LinkCheckTimeout          time.Duration `mapstructure:"LINK_CHECK_TIMEOUT"`
+	LinkCheckFailureThreshold int32         `mapstructure:"LINK_CHECK_FAILURE_THRESHOLD"`
+	WebhookPollInterval       time.Duration `mapstructure:"WEBHOOK_POLL_INTERVAL"`
+	WebhookBatchSize          int32         `mapstructure:"WEBHOOK_BATCH_SIZE"`
+	WebhookConcurrency        int           `mapstructure:"WEBHOOK_CONCURRENCY"`
+	WebhookTimeout            time.Duration `mapstructure:"WEBHOOK_TIMEOUT"`
+	WebhookMaxAttempts        int32         `mapstructure:"WEBHOOK_MAX_ATTEMPTS"`
+	WebhookRetryBase          time.Duration `mapstructure:"WEBHOOK_RETRY_BASE"`
+	WebhookRetryMax           time.Duration `mapstructure:"WEBHOOK_RETRY_MAX"`
+	ExpiryCheckInterval       time.Duration `mapstructure:"EXPIRY_CHECK_INTERVAL"`
+	DeleteRetention           time.Duration `mapstructure:"DELETE_RETENTION"`
+	PurgeInterval             time.Duration `mapstructure:"PURGE_INTERVAL"`
+	WriteBehind               bool          `mapstructure:"WRITE_BEHIND"`
+	WriteBehindFlushInterval  time.Duration `mapstructure:"WRITE_BEHIND_FLUSH_INTERVAL"`
+	WriteBehindBatchSize      int64         `mapstructure:"WRITE_BEHIND_BATCH_SIZE"`
+	WriteBehindRetryDelay     time.Duration `mapstructure:"WRITE_BEHIND_RETRY_DELAY"`
+	WriteBehindMaxAttempts    int64         `mapstructure:"WRITE_BEHIND_MAX_ATTEMPTS"`
 }
 
 // LoadConfig reads configuration from file or environment variables.
@@ -0,0 +1,51 @@
+package util
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// ErrUnsafePath is returned when a forwarded path contains dot segments.
+var ErrUnsafePath = errors.New("unsafe path")
+
+// JoinPath appends an escaped path suffix to the path of the destination.
+// The suffix keeps its original encoding, dot segments are rejected so the
+// suffix cannot climb out of the destination path.
+func JoinPath(destination string, rawSuffix string) (string, error) {
+	if rawSuffix == "" {
+		return destination, nil
+	}
+
+	if !strings.HasPrefix(rawSuffix, "/") {
+		rawSuffix = "/" + rawSuffix
+	}
+
+	for _, segment := range strings.Split(rawSuffix, "/") {
+		decoded, err := url.PathUnescape(segment)
+		if err != nil {
+			return "", err
+		}
+
+		if decoded == "." || decoded == ".." || strings.ContainsAny(decoded, "\\") {
+			return "", ErrUnsafePath
+		}
+	}
+
+	u, err := url.Parse(destination)
+	if err != nil {
+		return "", err
+	}
+
+	rawPath := strings.TrimSuffix(u.EscapedPath(), "/") + rawSuffix
+
+	path, err := url.PathUnescape(rawPath)
+	if err != nil {
+		return "", err
+	}
+
+	u.Path = path
+	u.RawPath = rawPath
+
+	return u.String(), nil
+}
@@ -0,0 +1,45 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoinPath(t *testing.T) {
+	testCases := []struct {
+		name        string
+		destination string
+		suffix      string
+		expected    string
+	}{
+		{"Empty suffix", "https://docs.example.com/guide", "", "https://docs.example.com/guide"},
+		{"Simple", "https://docs.example.com", "/getting-started", "https://docs.example.com/getting-started"},
+		{"Destination trailing slash", "https://docs.example.com/guide/", "/install/linux", "https://docs.example.com/guide/install/linux"},
+		{"Suffix trailing slash", "https://docs.example.com/guide", "/install/", "https://docs.example.com/guide/install/"},
+		{"Encoded slash kept", "https://docs.example.com", "/files/a%2Fb.txt", "https://docs.example.com/files/a%2Fb.txt"},
+		{"Encoded space kept", "https://docs.example.com", "/my%20file", "https://docs.example.com/my%20file"},
+		{"Unicode", "https://docs.example.com", "/%E4%B8%AD%E6%96%87", "https://docs.example.com/%E4%B8%AD%E6%96%87"},
+		{"Query and fragment kept", "https://docs.example.com/guide?lang=en#top", "/install", "https://docs.example.com/guide/install?lang=en#top"},
+		{"Encoded destination path", "https://docs.example.com/a%2Fb", "/c", "https://docs.example.com/a%2Fb/c"},
+		{"Double slash stays on host", "https://docs.example.com", "//evil.com/x", "https://docs.example.com//evil.com/x"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			destination, err := JoinPath(tc.destination, tc.suffix)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, destination)
+		})
+	}
+}
+
+func TestJoinPathUnsafe(t *testing.T) {
+	for _, suffix := range []string{"/../admin", "/a/./b", "/%2e%2e/admin", "/%2E%2E", "/a%5C..%5Cb"} {
+		_, err := JoinPath("https://docs.example.com/guide", suffix)
+		require.ErrorIs(t, err, ErrUnsafePath, suffix)
+	}
+
+	_, err := JoinPath("https://docs.example.com/guide", "/%zz")
+	require.Error(t, err)
+}
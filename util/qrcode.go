@@ -0,0 +1,174 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Constants for all supported QR code formats
+const (
+	QRCodePNG = "png"
+	QRCodeSVG = "svg"
+)
+
+// QRCodeOptions describes how a QR code is rendered.
+type QRCodeOptions struct {
+	Format     string // png or svg
+	Size       int    // image width and height in pixels
+	Margin     int    // quiet zone in modules
+	Level      string // error correction level: L, M, Q or H
+	Foreground string // hex color such as 000000
+	Background string // hex color such as ffffff
+}
+
+// Key returns a string which uniquely identifies the rendering options.
+func (opts QRCodeOptions) Key() string {
+	return strings.ToLower(fmt.Sprintf("%s:%d:%d:%s:%s:%s",
+		opts.Format, opts.Size, opts.Margin, opts.Level, opts.Foreground, opts.Background))
+}
+
+// ContentType returns the MIME type of the rendered QR code.
+func (opts QRCodeOptions) ContentType() string {
+	if opts.Format == QRCodeSVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// IsHexColor returns true if hex is exactly six hexadecimal digits such as ff0000.
+func IsHexColor(hex string) bool {
+	if len(hex) != 6 {
+		return false
+	}
+	for _, c := range hex {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// RenderQRCode encodes content as a QR code and renders it with the given options.
+func RenderQRCode(content string, opts QRCodeOptions) ([]byte, error) {
+	level, err := parseRecoveryLevel(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	fg, err := parseHexColor(opts.Foreground)
+	if err != nil {
+		return nil, err
+	}
+
+	bg, err := parseHexColor(opts.Background)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := qrcode.New(content, level)
+	if err != nil {
+		return nil, err
+	}
+	code.DisableBorder = true
+
+	bitmap := code.Bitmap()
+
+	switch opts.Format {
+	case QRCodePNG:
+		return renderPNG(bitmap, opts.Size, opts.Margin, fg, bg)
+	case QRCodeSVG:
+		return renderSVG(bitmap, opts.Size, opts.Margin, opts.Foreground, opts.Background), nil
+	}
+
+	return nil, fmt.Errorf("unsupported QR code format %q", opts.Format)
+}
+
+func renderPNG(bitmap [][]bool, size, margin int, fg, bg color.Color) ([]byte, error) {
+	modules := len(bitmap) + 2*margin
+
+	// 每個模組至少 1 像素，圖片不足時置中補上背景色
+	scale := size / modules
+	if scale < 1 {
+		scale = 1
+	}
+	if size < modules {
+		size = modules
+	}
+	offset := (size-modules*scale)/2 + margin*scale
+
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{bg, fg})
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex(offset+x*scale+dx, offset+y*scale+dy, 1)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func renderSVG(bitmap [][]bool, size, margin int, fg, bg string) []byte {
+	modules := len(bitmap) + 2*margin
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, modules, modules)
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%" fill="#%s"/>`, bg)
+	fmt.Fprintf(&buf, `<path fill="#%s" d="`, fg)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&buf, "M%d %dh1v1h-1z", x+margin, y+margin)
+			}
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+
+	return buf.Bytes()
+}
+
+func parseRecoveryLevel(level string) (qrcode.RecoveryLevel, error) {
+	switch strings.ToUpper(level) {
+	case "L":
+		return qrcode.Low, nil
+	case "M":
+		return qrcode.Medium, nil
+	case "Q":
+		return qrcode.High, nil
+	case "H":
+		return qrcode.Highest, nil
+	}
+	return 0, fmt.Errorf("unsupported error correction level %q", level)
+}
+
+func parseHexColor(hex string) (color.Color, error) {
+	if !IsHexColor(hex) {
+		return nil, fmt.Errorf("invalid color %q", hex)
+	}
+
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid color %q", hex)
+	}
+
+	return color.RGBA{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value), A: 0xff}, nil
+}
@@ -0,0 +1,70 @@
+package util
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func randomQRCodeOptions(format string) QRCodeOptions {
+	return QRCodeOptions{
+		Format:     format,
+		Size:       256,
+		Margin:     4,
+		Level:      "M",
+		Foreground: "112233",
+		Background: "ffffff",
+	}
+}
+
+func TestRenderQRCodePNG(t *testing.T) {
+	opts := randomQRCodeOptions(QRCodePNG)
+
+	data, err := RenderQRCode("https://"+RandomLongURL(), opts)
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, opts.Size, img.Bounds().Dx())
+	require.Equal(t, opts.Size, img.Bounds().Dy())
+
+	// 左上角為留白區，應為背景色
+	r, g, b, _ := img.At(0, 0).RGBA()
+	require.Equal(t, []uint32{0xffff, 0xffff, 0xffff}, []uint32{r, g, b})
+}
+
+func TestRenderQRCodeSVG(t *testing.T) {
+	opts := randomQRCodeOptions(QRCodeSVG)
+
+	data, err := RenderQRCode("https://"+RandomLongURL(), opts)
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(data, []byte("<svg")))
+	require.Contains(t, string(data), `fill="#112233"`)
+	require.Contains(t, string(data), `fill="#ffffff"`)
+}
+
+func TestRenderQRCodeInvalidOptions(t *testing.T) {
+	opts := randomQRCodeOptions(QRCodePNG)
+	opts.Level = "X"
+	_, err := RenderQRCode(RandomLongURL(), opts)
+	require.Error(t, err)
+
+	opts = randomQRCodeOptions(QRCodePNG)
+	opts.Foreground = "zzzzzz"
+	_, err = RenderQRCode(RandomLongURL(), opts)
+	require.Error(t, err)
+
+	opts = randomQRCodeOptions("gif")
+	_, err = RenderQRCode(RandomLongURL(), opts)
+	require.Error(t, err)
+}
+
+func TestIsHexColor(t *testing.T) {
+	require.True(t, IsHexColor("000000"))
+	require.True(t, IsHexColor("FfA0c9"))
+	require.False(t, IsHexColor("0xffff"))
+	require.False(t, IsHexColor("fffff"))
+	require.False(t, IsHexColor("gggggg"))
+}
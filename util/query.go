@@ -0,0 +1,197 @@
+package util
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Constants for all supported query passthrough modes
+const (
+	QueryPassthroughNone     = ""
+	QueryPassthroughMerge    = "merge"
+	QueryPassthroughOverride = "override"
+)
+
+// IsSupportedQueryMode returns true if the query passthrough mode is supported
+func IsSupportedQueryMode(mode string) bool {
+	switch mode {
+	case QueryPassthroughNone, QueryPassthroughMerge, QueryPassthroughOverride:
+		return true
+	}
+	return false
+}
+
+// UTMTemplate holds the UTM parameters attached to the destination.
+// The values may contain the {slug} and {variant} placeholders.
+type UTMTemplate struct {
+	Source   string `json:"source,omitempty" binding:"max=200"`
+	Medium   string `json:"medium,omitempty" binding:"max=200"`
+	Campaign string `json:"campaign,omitempty" binding:"max=200"`
+	Term     string `json:"term,omitempty" binding:"max=200"`
+	Content  string `json:"content,omitempty" binding:"max=200"`
+}
+
+// Params returns the UTM parameters in their canonical order with the placeholders replaced.
+func (t UTMTemplate) Params(slug string, variant string) []QueryParam {
+	replacer := strings.NewReplacer("{slug}", slug, "{variant}", variant)
+
+	var params []QueryParam
+	for _, p := range []QueryParam{
+		{Key: "utm_source", Value: t.Source},
+		{Key: "utm_medium", Value: t.Medium},
+		{Key: "utm_campaign", Value: t.Campaign},
+		{Key: "utm_term", Value: t.Term},
+		{Key: "utm_content", Value: t.Content},
+	} {
+		if p.Value != "" {
+			p.Value = replacer.Replace(p.Value)
+			params = append(params, p)
+		}
+	}
+
+	return params
+}
+
+// QueryParam is a decoded query parameter.
+type QueryParam struct {
+	Key   string
+	Value string
+	// 沒有等號的參數，例如 ?debug
+	NoValue bool
+}
+
+// ParseQuery parses a raw query string keeping the order of the parameters.
+// Malformed parameters are dropped.
+func ParseQuery(rawQuery string) []QueryParam {
+	var params []QueryParam
+
+	for _, segment := range strings.Split(rawQuery, "&") {
+		if segment == "" {
+			continue
+		}
+
+		rawKey, rawValue, hasValue := strings.Cut(segment, "=")
+
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil || key == "" {
+			continue
+		}
+
+		value, err := url.QueryUnescape(rawValue)
+		if err != nil {
+			continue
+		}
+
+		params = append(params, QueryParam{Key: key, Value: value, NoValue: !hasValue})
+	}
+
+	return params
+}
+
+// BuildDestination attaches the UTM parameters and the incoming query to the destination.
+//
+// UTM parameters are only added when the destination does not set them already.
+// In merge mode incoming parameters are only added when the destination does not have them,
+// in override mode they replace the parameters of the destination.
+// Untouched parameters and the fragment of the destination keep their original encoding.
+func BuildDestination(destination string, incoming []QueryParam, mode string, utm []QueryParam) (string, error) {
+	if len(utm) == 0 && (mode == QueryPassthroughNone || len(incoming) == 0) {
+		return destination, nil
+	}
+
+	u, err := url.Parse(destination)
+	if err != nil {
+		return "", err
+	}
+
+	segments := rawSegments(u.RawQuery)
+	existing := make(map[string]bool, len(segments))
+	for _, segment := range segments {
+		existing[segment.key] = true
+	}
+
+	var added []QueryParam
+
+	switch mode {
+	case QueryPassthroughMerge:
+		for _, p := range incoming {
+			if !existing[p.Key] {
+				added = append(added, p)
+			}
+		}
+	case QueryPassthroughOverride:
+		overridden := make(map[string]bool, len(incoming))
+		for _, p := range incoming {
+			overridden[p.Key] = true
+		}
+
+		kept := segments[:0]
+		for _, segment := range segments {
+			if !overridden[segment.key] {
+				kept = append(kept, segment)
+			}
+		}
+		segments = kept
+		added = append(added, incoming...)
+	}
+
+	// UTM 參數以目的網址與帶入的參數為優先
+	for _, p := range utm {
+		if existing[p.Key] || containsKey(added, p.Key) {
+			continue
+		}
+		added = append(added, p)
+	}
+
+	parts := make([]string, 0, len(segments)+len(added))
+	for _, segment := range segments {
+		parts = append(parts, segment.raw)
+	}
+	for _, p := range added {
+		if p.NoValue {
+			parts = append(parts, url.QueryEscape(p.Key))
+			continue
+		}
+		parts = append(parts, url.QueryEscape(p.Key)+"="+url.QueryEscape(p.Value))
+	}
+
+	u.RawQuery = strings.Join(parts, "&")
+	u.ForceQuery = false
+
+	return u.String(), nil
+}
+
+type rawSegment struct {
+	key string
+	raw string
+}
+
+// 保留目的網址原本的編碼，只解碼參數名稱用來比對
+func rawSegments(rawQuery string) []rawSegment {
+	var segments []rawSegment
+
+	for _, raw := range strings.Split(rawQuery, "&") {
+		if raw == "" {
+			continue
+		}
+
+		rawKey, _, _ := strings.Cut(raw, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+
+		segments = append(segments, rawSegment{key: key, raw: raw})
+	}
+
+	return segments
+}
+
+func containsKey(params []QueryParam, key string) bool {
+	for _, p := range params {
+		if p.Key == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSupportedQueryMode(t *testing.T) {
+	require.True(t, IsSupportedQueryMode(QueryPassthroughNone))
+	require.True(t, IsSupportedQueryMode(QueryPassthroughMerge))
+	require.True(t, IsSupportedQueryMode(QueryPassthroughOverride))
+	require.False(t, IsSupportedQueryMode("append"))
+}
+
+func TestParseQuery(t *testing.T) {
+	params := ParseQuery("ref=newsletter&q=a+b%26c&debug&empty=&%zz=1&&name=%E4%B8%AD%E6%96%87")
+
+	require.Equal(t, []QueryParam{
+		{Key: "ref", Value: "newsletter"},
+		{Key: "q", Value: "a b&c"},
+		{Key: "debug", NoValue: true},
+		{Key: "empty", Value: ""},
+		{Key: "name", Value: "中文"},
+	}, params)
+}
+
+func TestBuildDestination(t *testing.T) {
+	testCases := []struct {
+		name        string
+		destination string
+		incoming    string
+		mode        string
+		utm         []QueryParam
+		expected    string
+	}{
+		{
+			name:        "No passthrough",
+			destination: "https://example.com/page?a=1",
+			incoming:    "ref=newsletter",
+			mode:        QueryPassthroughNone,
+			expected:    "https://example.com/page?a=1",
+		},
+		{
+			name:        "Merge",
+			destination: "https://example.com/page?a=1",
+			incoming:    "a=2&ref=newsletter",
+			mode:        QueryPassthroughMerge,
+			expected:    "https://example.com/page?a=1&ref=newsletter",
+		},
+		{
+			name:        "Override",
+			destination: "https://example.com/page?a=1&b=2",
+			incoming:    "a=3&ref=newsletter",
+			mode:        QueryPassthroughOverride,
+			expected:    "https://example.com/page?b=2&a=3&ref=newsletter",
+		},
+		{
+			name:        "Destination without query",
+			destination: "https://example.com/page",
+			incoming:    "ref=newsletter",
+			mode:        QueryPassthroughMerge,
+			expected:    "https://example.com/page?ref=newsletter",
+		},
+		{
+			name:        "Fragment kept after query",
+			destination: "https://example.com/page?a=1#section-2",
+			incoming:    "ref=newsletter",
+			mode:        QueryPassthroughMerge,
+			expected:    "https://example.com/page?a=1&ref=newsletter#section-2",
+		},
+		{
+			name:        "Fragment routing",
+			destination: "https://example.com/#/app?tab=1",
+			incoming:    "ref=newsletter",
+			mode:        QueryPassthroughMerge,
+			expected:    "https://example.com/?ref=newsletter#/app?tab=1",
+		},
+		{
+			name:        "Destination encoding preserved",
+			destination: "https://example.com/a%2Fb?redirect=https%3A%2F%2Fother.com%2F&q=a+b",
+			incoming:    "ref=newsletter",
+			mode:        QueryPassthroughMerge,
+			expected:    "https://example.com/a%2Fb?redirect=https%3A%2F%2Fother.com%2F&q=a+b&ref=newsletter",
+		},
+		{
+			name:        "Incoming values encoded",
+			destination: "https://example.com/page",
+			incoming:    "q=a+b%26c&name=%E4%B8%AD%E6%96%87&next=%2Fhome%3Fx%3D1",
+			mode:        QueryPassthroughMerge,
+			expected:    "https://example.com/page?q=a+b%26c&name=%E4%B8%AD%E6%96%87&next=%2Fhome%3Fx%3D1",
+		},
+		{
+			name:        "Incoming fragment injection",
+			destination: "https://example.com/page",
+			incoming:    "q=%23evil",
+			mode:        QueryPassthroughMerge,
+			expected:    "https://example.com/page?q=%23evil",
+		},
+		{
+			name:        "Parameter without value",
+			destination: "https://example.com/page",
+			incoming:    "debug",
+			mode:        QueryPassthroughOverride,
+			expected:    "https://example.com/page?debug",
+		},
+		{
+			name:        "UTM attached",
+			destination: "https://example.com/page?a=1#top",
+			mode:        QueryPassthroughNone,
+			utm: []QueryParam{
+				{Key: "utm_source", Value: "newsletter"},
+				{Key: "utm_campaign", Value: "spring sale"},
+			},
+			expected: "https://example.com/page?a=1&utm_source=newsletter&utm_campaign=spring+sale#top",
+		},
+		{
+			name:        "UTM does not replace destination",
+			destination: "https://example.com/page?utm_source=site",
+			mode:        QueryPassthroughNone,
+			utm: []QueryParam{
+				{Key: "utm_source", Value: "newsletter"},
+				{Key: "utm_medium", Value: "email"},
+			},
+			expected: "https://example.com/page?utm_source=site&utm_medium=email",
+		},
+		{
+			name:        "Incoming wins over UTM",
+			destination: "https://example.com/page",
+			incoming:    "utm_source=twitter",
+			mode:        QueryPassthroughOverride,
+			utm: []QueryParam{
+				{Key: "utm_source", Value: "newsletter"},
+			},
+			expected: "https://example.com/page?utm_source=twitter",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			destination, err := BuildDestination(tc.destination, ParseQuery(tc.incoming), tc.mode, tc.utm)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, destination)
+		})
+	}
+}
+
+func TestBuildDestinationInvalidURL(t *testing.T) {
+	_, err := BuildDestination("http://[::1", ParseQuery("a=1"), QueryPassthroughMerge, nil)
+	require.Error(t, err)
+}
+
+func TestUTMTemplateParams(t *testing.T) {
+	template := UTMTemplate{
+		Source:   "newsletter",
+		Campaign: "{slug}",
+		Content:  "variant-{variant}",
+	}
+
+	require.Equal(t, []QueryParam{
+		{Key: "utm_source", Value: "newsletter"},
+		{Key: "utm_campaign", Value: "abc123"},
+		{Key: "utm_content", Value: "variant-b"},
+	}, template.Params("abc123", "b"))
+
+	require.Empty(t, UTMTemplate{}.Params("abc123", ""))
+}
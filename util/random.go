@@ -30,3 +30,8 @@ func RandomLongURL() string {
 	random := RandomString(9)
 	return "www." + random + ".com"
 }
+
+// RandomInt generates a random integer between min and max
+func RandomInt(min, max int64) int64 {
+	return min + rand.Int63n(max-min+1)
+}
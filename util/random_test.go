@@ -15,3 +15,9 @@ func TestRandomLongURL(t *testing.T) {
 	longURL := RandomLongURL()
 	require.NotEmpty(t, longURL)
 }
+
+func TestRandomInt(t *testing.T) {
+	n := RandomInt(1, 10)
+	require.GreaterOrEqual(t, n, int64(1))
+	require.LessOrEqual(t, n, int64(10))
+}
@@ -0,0 +1,50 @@
+package util
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Constants for all supported redirect types
+const (
+	RedirectMovedPermanently  = "301"
+	RedirectFound             = "302"
+	RedirectTemporaryRedirect = "307"
+	RedirectPermanentRedirect = "308"
+	RedirectInterstitial      = "interstitial"
+)
+
+// IsSupportedRedirectType returns true if the redirect type is supported
+func IsSupportedRedirectType(redirectType string) bool {
+	switch redirectType {
+	case RedirectMovedPermanently, RedirectFound, RedirectTemporaryRedirect, RedirectPermanentRedirect, RedirectInterstitial:
+		return true
+	}
+	return false
+}
+
+// RedirectStatus returns the HTTP status code used by the redirect type
+func RedirectStatus(redirectType string) int {
+	switch redirectType {
+	case RedirectFound:
+		return http.StatusFound
+	case RedirectTemporaryRedirect:
+		return http.StatusTemporaryRedirect
+	case RedirectPermanentRedirect:
+		return http.StatusPermanentRedirect
+	}
+	return http.StatusMovedPermanently
+}
+
+// IsWebURL returns true if rawURL is an absolute http or https URL with a host.
+// Other schemes such as javascript: must never be used as a redirect destination.
+func IsWebURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return false
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	return scheme == "http" || scheme == "https"
+}
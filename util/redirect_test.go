@@ -0,0 +1,33 @@
+package util
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSupportedRedirectType(t *testing.T) {
+	require.True(t, IsSupportedRedirectType(RedirectMovedPermanently))
+	require.True(t, IsSupportedRedirectType(RedirectInterstitial))
+	require.False(t, IsSupportedRedirectType(""))
+	require.False(t, IsSupportedRedirectType("200"))
+}
+
+func TestRedirectStatus(t *testing.T) {
+	require.Equal(t, http.StatusMovedPermanently, RedirectStatus(RedirectMovedPermanently))
+	require.Equal(t, http.StatusFound, RedirectStatus(RedirectFound))
+	require.Equal(t, http.StatusTemporaryRedirect, RedirectStatus(RedirectTemporaryRedirect))
+	require.Equal(t, http.StatusPermanentRedirect, RedirectStatus(RedirectPermanentRedirect))
+}
+
+func TestIsWebURL(t *testing.T) {
+	require.True(t, IsWebURL("https://example.com/path?q=1"))
+	require.True(t, IsWebURL("HTTP://example.com"))
+	require.False(t, IsWebURL("javascript:alert(1)"))
+	require.False(t, IsWebURL("JavaScript://example.com/%0Aalert(1)"))
+	require.False(t, IsWebURL("data:text/html,<script>alert(1)</script>"))
+	require.False(t, IsWebURL("ftp://example.com"))
+	require.False(t, IsWebURL("/relative"))
+	require.False(t, IsWebURL(""))
+}
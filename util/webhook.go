@@ -0,0 +1,79 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Constants for all supported webhook events
+const (
+	EventLinkCreated = "link.created"
+	EventLinkUpdated = "link.updated"
+	EventLinkDeleted = "link.deleted"
+	EventLinkExpired = "link.expired"
+	EventLinkClicked = "link.clicked"
+	EventLinkBroken  = "link.broken"
+)
+
+// WebhookSignatureHeader is the header carrying the signature of a webhook payload.
+const WebhookSignatureHeader = "X-Webhook-Signature"
+
+// IsSupportedWebhookEvent returns true if the webhook event is supported
+func IsSupportedWebhookEvent(event string) bool {
+	switch event {
+	case EventLinkCreated, EventLinkUpdated, EventLinkDeleted, EventLinkExpired, EventLinkClicked, EventLinkBroken:
+		return true
+	}
+	return false
+}
+
+// SignWebhookPayload returns the value of the signature header for a payload sent at timestamp.
+// The signature is the hex encoded HMAC-SHA256 of "<timestamp>.<payload>" keyed by the secret,
+// so receivers can also reject replayed payloads by their timestamp.
+func SignWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, webhookMAC(secret, timestamp, payload))
+}
+
+// VerifyWebhookSignature checks a signature header created by SignWebhookPayload
+// and returns the timestamp it was signed at.
+func VerifyWebhookSignature(secret string, header string, payload []byte) (int64, bool) {
+	var timestamp int64
+	var signature string
+
+	for _, part := range strings.Split(header, ",") {
+		key, value, found := strings.Cut(part, "=")
+		if !found {
+			continue
+		}
+
+		switch key {
+		case "t":
+			t, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return 0, false
+			}
+			timestamp = t
+		case "v1":
+			signature = value
+		}
+	}
+
+	if timestamp == 0 || signature == "" {
+		return 0, false
+	}
+
+	expected := webhookMAC(secret, timestamp, payload)
+	return timestamp, hmac.Equal([]byte(signature), []byte(expected))
+}
+
+func webhookMAC(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
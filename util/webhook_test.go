@@ -0,0 +1,39 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSupportedWebhookEvent(t *testing.T) {
+	require.True(t, IsSupportedWebhookEvent(EventLinkCreated))
+	require.True(t, IsSupportedWebhookEvent(EventLinkBroken))
+	require.False(t, IsSupportedWebhookEvent(""))
+	require.False(t, IsSupportedWebhookEvent("link.unknown"))
+}
+
+func TestWebhookSignature(t *testing.T) {
+	secret := RandomString(32)
+	payload := []byte(`{"event":"link.created"}`)
+
+	header := SignWebhookPayload(secret, 1650000000, payload)
+	require.Regexp(t, `^t=1650000000,v1=[0-9a-f]{64}$`, header)
+
+	timestamp, ok := VerifyWebhookSignature(secret, header, payload)
+	require.True(t, ok)
+	require.Equal(t, int64(1650000000), timestamp)
+
+	_, ok = VerifyWebhookSignature(secret, header, []byte(`{"event":"link.deleted"}`))
+	require.False(t, ok)
+
+	_, ok = VerifyWebhookSignature(RandomString(32), header, payload)
+	require.False(t, ok)
+
+	// 竄改時間戳記
+	_, ok = VerifyWebhookSignature(secret, "t=1650000001"+header[len("t=1650000000"):], payload)
+	require.False(t, ok)
+
+	_, ok = VerifyWebhookSignature(secret, "", payload)
+	require.False(t, ok)
+}
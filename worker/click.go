@@ -0,0 +1,92 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"shortURL/db/redis"
+	db "shortURL/db/sqlc"
+)
+
+// ClickPersister periodically writes the click counters kept in Redis back to Postgres.
+type ClickPersister struct {
+	store    db.Querier
+	redis    redis.RedisQuerier
+	interval time.Duration
+}
+
+// NewClickPersister creates a new ClickPersister.
+func NewClickPersister(store db.Querier, redis redis.RedisQuerier, interval time.Duration) *ClickPersister {
+	return &ClickPersister{
+		store:    store,
+		redis:    redis,
+		interval: interval,
+	}
+}
+
+// Start persists the click counters on every interval until the context is done.
+func (p *ClickPersister) Start(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// 結束前再寫回一次
+			if err := p.Persist(context.Background()); err != nil {
+				log.Println("cannot persist clicks:", err)
+			}
+			return
+		case <-ticker.C:
+			if err := p.Persist(ctx); err != nil {
+				log.Println("cannot persist clicks:", err)
+			}
+		}
+	}
+}
+
+// Persist writes all dirty click counters to Postgres.
+// Counters which fail to be written are marked dirty again for the next run.
+func (p *ClickPersister) Persist(ctx context.Context) error {
+	dirty, err := p.redis.PopDirtyClicks(ctx)
+	if err != nil {
+		return err
+	}
+
+	var persistErr error
+	for shortUrl, urlID := range dirty {
+		// 尚未寫入資料庫的連結 ID 為 0，寫入後由 URLFlusher 以實際的 ID 重新標記
+		if urlID == 0 {
+			continue
+		}
+
+		err := p.persist(ctx, shortUrl, urlID)
+		if err == nil {
+			continue
+		}
+
+		persistErr = err
+
+		// 重新標記，下次再寫回
+		if err := p.redis.MarkDirtyClick(ctx, shortUrl, urlID); err != nil {
+			return err
+		}
+	}
+
+	return persistErr
+}
+
+func (p *ClickPersister) persist(ctx context.Context, shortUrl string, urlID int64) error {
+	clicks, err := p.redis.GetClick(ctx, shortUrl)
+	if err != nil {
+		return err
+	}
+
+	arg := db.UpdateURLClickCountParams{
+		ID:         urlID,
+		ClickCount: clicks,
+	}
+
+	return p.store.UpdateURLClickCount(ctx, arg)
+}
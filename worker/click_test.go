@@ -0,0 +1,135 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	mockdb "shortURL/db/mock"
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClickPersister_Persist(t *testing.T) {
+	shortUrl := util.RandomString(6)
+	urlID := util.RandomInt(1, 1000)
+
+	testCases := []struct {
+		name        string
+		buildStubs  func(store *mockdb.MockQuerier)
+		buildStubs2 func(redis *mockdb.MockRedisQuerier)
+		checkResult func(err error)
+	}{
+		{
+			name: "Success case",
+			buildStubs: func(store *mockdb.MockQuerier) {
+				arg := db.UpdateURLClickCountParams{
+					ID:         urlID,
+					ClickCount: 42,
+				}
+
+				store.EXPECT().
+					UpdateURLClickCount(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(nil)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					PopDirtyClicks(gomock.Any()).
+					Times(1).
+					Return(map[string]int64{shortUrl: urlID}, nil)
+				redis.EXPECT().
+					GetClick(gomock.Any(), gomock.Eq(shortUrl)).
+					Times(1).
+					Return(int64(42), nil)
+				redis.EXPECT().
+					MarkDirtyClick(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResult: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "Nothing to persist",
+			buildStubs: func(store *mockdb.MockQuerier) {
+				store.EXPECT().
+					UpdateURLClickCount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					PopDirtyClicks(gomock.Any()).
+					Times(1).
+					Return(map[string]int64{}, nil)
+			},
+			checkResult: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "Skip pending links",
+			buildStubs: func(store *mockdb.MockQuerier) {
+				store.EXPECT().
+					UpdateURLClickCount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					PopDirtyClicks(gomock.Any()).
+					Times(1).
+					Return(map[string]int64{shortUrl: 0}, nil)
+				redis.EXPECT().
+					GetClick(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResult: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "Mark dirty again on error",
+			buildStubs: func(store *mockdb.MockQuerier) {
+				store.EXPECT().
+					UpdateURLClickCount(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sql.ErrConnDone)
+			},
+			buildStubs2: func(redis *mockdb.MockRedisQuerier) {
+				redis.EXPECT().
+					PopDirtyClicks(gomock.Any()).
+					Times(1).
+					Return(map[string]int64{shortUrl: urlID}, nil)
+				redis.EXPECT().
+					GetClick(gomock.Any(), gomock.Eq(shortUrl)).
+					Times(1).
+					Return(int64(42), nil)
+				redis.EXPECT().
+					MarkDirtyClick(gomock.Any(), gomock.Eq(shortUrl), gomock.Eq(urlID)).
+					Times(1).
+					Return(nil)
+			},
+			checkResult: func(err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockQuerier(ctrl)
+			mockRedis := mockdb.NewMockRedisQuerier(ctrl)
+			tc.buildStubs(mockQueries)
+			tc.buildStubs2(mockRedis)
+
+			persister := NewClickPersister(mockQueries, mockRedis, 0)
+			tc.checkResult(persister.Persist(context.Background()))
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+
+	"shortURL/db/redis"
+)
+
+// Queues of the background tasks
+const (
+	// QueueFetchMetadata is the queue of links whose destination metadata should be fetched.
+	QueueFetchMetadata = "task:fetch-metadata"
+	// QueueWebhookEvent is the queue of events to be delivered to the subscribed webhooks.
+	QueueWebhookEvent = "task:webhook-event"
+)
+
+// PayloadFetchMetadata is the payload of a fetch metadata task.
+type PayloadFetchMetadata struct {
+	URLID     int64  `json:"url_id"`
+	OriginUrl string `json:"origin_url"`
+}
+
+// TaskDistributor enqueues background tasks.
+type TaskDistributor interface {
+	DistributeFetchMetadata(ctx context.Context, payload *PayloadFetchMetadata) error
+	DistributeWebhookEvent(ctx context.Context, payload *PayloadWebhookEvent) error
+}
+
+// RedisTaskDistributor enqueues background tasks into Redis lists.
+type RedisTaskDistributor struct {
+	redis redis.RedisQuerier
+}
+
+// NewRedisTaskDistributor creates a new RedisTaskDistributor.
+func NewRedisTaskDistributor(redis redis.RedisQuerier) TaskDistributor {
+	return &RedisTaskDistributor{redis: redis}
+}
+
+// DistributeFetchMetadata enqueues a task to fetch the metadata of a link destination.
+func (d *RedisTaskDistributor) DistributeFetchMetadata(ctx context.Context, payload *PayloadFetchMetadata) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return d.redis.PushTask(ctx, QueueFetchMetadata, data)
+}
+
+// DistributeWebhookEvent enqueues an event to be delivered to the subscribed webhooks.
+func (d *RedisTaskDistributor) DistributeWebhookEvent(ctx context.Context, payload *PayloadWebhookEvent) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return d.redis.PushTask(ctx, QueueWebhookEvent, data)
+}
@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+)
+
+const expiryBatchSize = 100
+
+// ExpiryNotifier periodically distributes a link expired event for every link
+// that has passed its expiry time or reached its click limit.
+// Each link is only notified once.
+type ExpiryNotifier struct {
+	store       db.Querier
+	distributor TaskDistributor
+	interval    time.Duration
+}
+
+// NewExpiryNotifier creates a new ExpiryNotifier.
+func NewExpiryNotifier(store db.Querier, distributor TaskDistributor, interval time.Duration) *ExpiryNotifier {
+	return &ExpiryNotifier{
+		store:       store,
+		distributor: distributor,
+		interval:    interval,
+	}
+}
+
+// Start notifies the expired links on every interval until the context is done.
+func (n *ExpiryNotifier) Start(ctx context.Context) {
+	ticker := time.NewTicker(n.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := n.Notify(ctx); err != nil {
+				log.Println("cannot notify expired links:", err)
+			}
+		}
+	}
+}
+
+// Notify distributes the events of all links which expired since the last run.
+func (n *ExpiryNotifier) Notify(ctx context.Context) error {
+	for {
+		urls, err := n.store.MarkExpiredURLs(ctx, expiryBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, url := range urls {
+			payload, err := NewPayloadWebhookEvent(util.EventLinkExpired, NewLinkEventData(url))
+			if err != nil {
+				return err
+			}
+
+			// 連結已標記為通知過，失敗時只記錄
+			if err := n.distributor.DistributeWebhookEvent(ctx, payload); err != nil {
+				log.Printf("cannot distribute link expired event of %d: %v", url.ID, err)
+			}
+		}
+
+		if len(urls) < expiryBatchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,366 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"shortURL/db/redis"
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+)
+
+// PayloadCreateURL is a link created in write-behind mode, kept in Redis until it is written to the database.
+type PayloadCreateURL struct {
+	// Url is the link served before it is written, its ID is 0.
+	Url   db.Url       `json:"url"`
+	Tags  []string     `json:"tags"`
+	Audit db.AuditInfo `json:"audit"`
+}
+
+// NewPayloadCreateURL creates the payload of the link, arg.CreatedAt is kept as the creation time.
+func NewPayloadCreateURL(arg db.CreateURLTxParams) *PayloadCreateURL {
+	return &PayloadCreateURL{
+		Url: db.Url{
+			OriginUrl:     arg.OriginUrl,
+			ShortUrl:      arg.ShortUrl,
+			CreatedAt:     arg.CreatedAt,
+			RedirectType:  arg.RedirectType,
+			NotAfter:      arg.NotAfter,
+			DomainID:      arg.DomainID,
+			PasswordHash:  arg.PasswordHash,
+			NotBefore:     arg.NotBefore,
+			MaxClicks:     arg.MaxClicks,
+			Rules:         arg.Rules,
+			Variants:      arg.Variants,
+			QueryMode:     arg.QueryMode,
+			Utm:           arg.Utm,
+			IsPrefix:      arg.IsPrefix,
+			Title:         arg.Title,
+			Description:   arg.Description,
+			Owner:         arg.Owner,
+			FolderID:      arg.FolderID,
+			RedirectChain: json.RawMessage("[]"),
+		},
+		Tags:  arg.Tags,
+		Audit: arg.Audit,
+	}
+}
+
+func (p *PayloadCreateURL) createURLTxParams() db.CreateURLTxParams {
+	url := p.Url
+
+	return db.CreateURLTxParams{
+		CreateURLParams: db.CreateURLParams{
+			OriginUrl:    url.OriginUrl,
+			ShortUrl:     url.ShortUrl,
+			RedirectType: url.RedirectType,
+			NotAfter:     url.NotAfter,
+			DomainID:     url.DomainID,
+			PasswordHash: url.PasswordHash,
+			NotBefore:    url.NotBefore,
+			MaxClicks:    url.MaxClicks,
+			Rules:        url.Rules,
+			Variants:     url.Variants,
+			QueryMode:    url.QueryMode,
+			Utm:          url.Utm,
+			IsPrefix:     url.IsPrefix,
+			Title:        url.Title,
+			Description:  url.Description,
+			Owner:        url.Owner,
+			FolderID:     url.FolderID,
+		},
+		Tags:      p.Tags,
+		Audit:     p.Audit,
+		CreatedAt: url.CreatedAt,
+	}
+}
+
+// FlushStats describes the progress of a URLFlusher.
+type FlushStats struct {
+	// Pending is the number of links waiting to be written, Failed the number of links given up.
+	Pending int64 `json:"pending"`
+	Failed  int64 `json:"failed"`
+	// Flushed, Conflicts and Retries count the links written, the links dropped because their short url
+	// was taken in the database and the failed attempts since the flusher started.
+	Flushed   int64 `json:"flushed"`
+	Conflicts int64 `json:"conflicts"`
+	Retries   int64 `json:"retries"`
+	// LagSeconds is the largest time between creating and writing a link in the last batch,
+	// MaxLagSeconds the largest since the flusher started.
+	LagSeconds    float64   `json:"lag_seconds"`
+	MaxLagSeconds float64   `json:"max_lag_seconds"`
+	LastFlushAt   time.Time `json:"last_flush_at"`
+}
+
+// URLFlusher writes the links created in write-behind mode from Redis to the database in batches.
+// A link is removed from Redis only after it is written, so a link whose batch fails is written by a later run.
+type URLFlusher struct {
+	store       db.Store
+	redis       redis.RedisQuerier
+	distributor TaskDistributor
+	interval    time.Duration
+	batchSize   int64
+	retryDelay  time.Duration
+	maxAttempts int64
+
+	mu    sync.Mutex
+	stats FlushStats
+}
+
+// NewURLFlusher creates a new URLFlusher from the WRITE_BEHIND_* settings.
+// The metadata task and the link created event of a link are distributed once it is written.
+func NewURLFlusher(store db.Store, redis redis.RedisQuerier, distributor TaskDistributor, config util.Config) *URLFlusher {
+	batchSize := config.WriteBehindBatchSize
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	return &URLFlusher{
+		store:       store,
+		redis:       redis,
+		distributor: distributor,
+		interval:    config.WriteBehindFlushInterval,
+		batchSize:   batchSize,
+		retryDelay:  config.WriteBehindRetryDelay,
+		maxAttempts: config.WriteBehindMaxAttempts,
+	}
+}
+
+// Start flushes the pending links on every interval until the context is done.
+func (f *URLFlusher) Start(ctx context.Context) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// 結束前再寫入一次
+			if err := f.Flush(context.Background()); err != nil {
+				log.Println("cannot flush links:", err)
+			}
+			return
+		case <-ticker.C:
+			if err := f.Flush(ctx); err != nil {
+				log.Println("cannot flush links:", err)
+			}
+		}
+	}
+}
+
+// Stats returns the progress of the flusher.
+func (f *URLFlusher) Stats() FlushStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.stats
+}
+
+// Flush writes the pending links in batches until no link is due.
+// A batch is written in a single transaction, when it fails the links are written one by one
+// and the links which still fail are retried after the retry delay, up to the max attempts.
+func (f *URLFlusher) Flush(ctx context.Context) error {
+	var err error
+	for {
+		var claimed int
+		claimed, err = f.flushBatch(ctx)
+		if err != nil || int64(claimed) < f.batchSize {
+			break
+		}
+	}
+
+	pending, failed, countErr := f.redis.CountPendingURLs(ctx)
+	if countErr == nil {
+		f.mu.Lock()
+		f.stats.Pending = pending
+		f.stats.Failed = failed
+		f.stats.LastFlushAt = time.Now()
+		f.mu.Unlock()
+	}
+
+	if err != nil {
+		return err
+	}
+	return countErr
+}
+
+func (f *URLFlusher) flushBatch(ctx context.Context) (int, error) {
+	claimed, err := f.redis.ClaimPendingURLs(ctx, f.batchSize, f.retryDelay)
+	if err != nil || len(claimed) == 0 {
+		return 0, err
+	}
+
+	var pendings []redis.PendingURL
+	var payloads []*PayloadCreateURL
+	var args []db.CreateURLTxParams
+	for _, pending := range claimed {
+		payload := &PayloadCreateURL{}
+		err := json.Unmarshal(pending.Payload, payload)
+		if err != nil {
+			// 無法解析的內容重試也不會成功
+			log.Printf("cannot decode pending link %s: %v", pending.ShortUrl, err)
+			if err := f.redis.FailPendingURL(ctx, pending.ShortUrl); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		pendings = append(pendings, pending)
+		payloads = append(payloads, payload)
+		args = append(args, payload.createURLTxParams())
+	}
+
+	if len(args) == 0 {
+		return len(claimed), nil
+	}
+
+	results, err := f.store.CreateURLBatchTx(ctx, args)
+	if err != nil {
+		// 整批失敗時逐筆寫入，避免一筆資料影響其他連結
+		log.Println("cannot flush links in a batch, writing them one by one:", err)
+
+		results = make([]db.URLBatchResult, len(args))
+		for i, arg := range args {
+			result, err := f.store.CreateURLTx(ctx, arg)
+			results[i] = db.URLBatchResult{URLTxResult: result, Err: err}
+		}
+	}
+
+	// key 為 redis 中的短網址，各網域分開
+	type writtenURL struct {
+		key string
+		url db.Url
+	}
+
+	var written []writtenURL
+	var acked []string
+	var conflicts, retries int64
+	for i, result := range results {
+		pending := pendings[i]
+		url := result.Url
+
+		if _, ok := result.Err.(*db.ShortURLConflictError); ok {
+			url, err = f.resolveConflict(ctx, pending.ShortUrl, payloads[i])
+			if err != nil {
+				result.Err = err
+			} else if url.ID == 0 {
+				conflicts++
+				acked = append(acked, pending.ShortUrl)
+				continue
+			} else {
+				result.Err = nil
+			}
+		}
+
+		if result.Err != nil {
+			retries++
+			log.Printf("cannot flush link %s (attempt %d): %v", pending.ShortUrl, pending.Attempts, result.Err)
+
+			// 超過次數後保留在寫入失敗的集合，不再重試
+			if pending.Attempts >= f.maxAttempts {
+				if err := f.redis.FailPendingURL(ctx, pending.ShortUrl); err != nil {
+					return 0, err
+				}
+			}
+			continue
+		}
+
+		written = append(written, writtenURL{key: pending.ShortUrl, url: url})
+		acked = append(acked, pending.ShortUrl)
+	}
+
+	// 確認寫入後才從 redis 移除，確認失敗的連結下次會被辨識為已寫入
+	err = f.redis.AckPendingURLs(ctx, acked...)
+	if err != nil {
+		return 0, err
+	}
+
+	var lag float64
+	for _, w := range written {
+		if seconds := time.Since(w.url.CreatedAt).Seconds(); seconds > lag {
+			lag = seconds
+		}
+
+		f.afterWrite(ctx, w.key, w.url)
+	}
+
+	f.mu.Lock()
+	f.stats.Flushed += int64(len(written))
+	f.stats.Conflicts += conflicts
+	f.stats.Retries += retries
+	if len(written) > 0 {
+		f.stats.LagSeconds = lag
+		if lag > f.stats.MaxLagSeconds {
+			f.stats.MaxLagSeconds = lag
+		}
+	}
+	f.mu.Unlock()
+
+	return len(claimed), nil
+}
+
+// resolveConflict returns the link in the database when it is the pending link written by an earlier run,
+// otherwise the short url is used by another link and the pending link is dropped, the returned link then has ID 0.
+// A dropped link is logged, counted in the stats and recorded as a discard audit event by the creator of the link.
+func (f *URLFlusher) resolveConflict(ctx context.Context, key string, payload *PayloadCreateURL) (db.Url, error) {
+	pending := payload.Url
+
+	url, err := f.store.GetURL(db.ReadPrimary(ctx), db.GetURLParams{DomainID: pending.DomainID, ShortUrl: pending.ShortUrl})
+	if err != nil && err != sql.ErrNoRows {
+		return db.Url{}, err
+	}
+
+	if err == nil && url.CreatedAt.Equal(pending.CreatedAt) && url.OriginUrl == pending.OriginUrl {
+		return url, nil
+	}
+
+	// 稽核紀錄寫入失敗時重試，不會無紀錄地放棄連結
+	err = db.RecordAuditEvent(ctx, f.store, db.AuditActionDiscard, &pending, nil, payload.Audit)
+	if err != nil {
+		return db.Url{}, err
+	}
+
+	log.Printf("short url %s is taken, dropping pending link to %s created by %q", pending.ShortUrl, pending.OriginUrl, payload.Audit.Actor)
+
+	// 讓短網址改為解析到資料庫中的連結
+	err = f.redis.DelData(ctx, key)
+	if err != nil {
+		return db.Url{}, err
+	}
+
+	return db.Url{}, nil
+}
+
+// afterWrite replaces the cached link by the written one and runs what creating a link runs,
+// failures are logged because the link itself is written.
+func (f *URLFlusher) afterWrite(ctx context.Context, key string, url db.Url) {
+	err := f.redis.SetData(ctx, key, url)
+	if err != nil {
+		log.Printf("cannot cache link %d: %v", url.ID, err)
+	}
+
+	// 寫入前的點擊以 ID 0 記錄，改用實際的 ID 寫回資料庫
+	clicks, err := f.redis.GetClick(ctx, key)
+	if err == nil && clicks > 0 {
+		err = f.redis.MarkDirtyClick(ctx, key, url.ID)
+	}
+	if err != nil {
+		log.Printf("cannot mark clicks of link %d: %v", url.ID, err)
+	}
+
+	err = f.distributor.DistributeFetchMetadata(ctx, &PayloadFetchMetadata{URLID: url.ID, OriginUrl: url.OriginUrl})
+	if err != nil {
+		log.Println("cannot distribute fetch metadata task:", err)
+	}
+
+	payload, err := NewPayloadWebhookEvent(util.EventLinkCreated, NewLinkEventData(url))
+	if err == nil {
+		err = f.distributor.DistributeWebhookEvent(ctx, payload)
+	}
+	if err != nil {
+		log.Println("cannot distribute webhook event:", err)
+	}
+}
@@ -0,0 +1,310 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"shortURL/db/kv"
+	mockdb "shortURL/db/mock"
+	"shortURL/db/redis"
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestURLFlusher(store db.Store, redis redis.RedisQuerier, maxAttempts int64) *URLFlusher {
+	config := util.Config{
+		WriteBehindFlushInterval: time.Second,
+		WriteBehindBatchSize:     10,
+		WriteBehindRetryDelay:    time.Minute,
+		WriteBehindMaxAttempts:   maxAttempts,
+	}
+
+	return NewURLFlusher(store, redis, NewRedisTaskDistributor(redis), config)
+}
+
+func enqueueTestURL(t *testing.T, redis redis.RedisQuerier) *PayloadCreateURL {
+	payload := NewPayloadCreateURL(db.CreateURLTxParams{
+		CreateURLParams: db.CreateURLParams{
+			OriginUrl: "https://" + util.RandomLongURL(),
+			ShortUrl:  util.RandomString(6),
+			Rules:     json.RawMessage("[]"),
+			Variants:  json.RawMessage("[]"),
+			Utm:       json.RawMessage("{}"),
+		},
+		Tags:      []string{"launch"},
+		CreatedAt: time.Now().Add(-2 * time.Second).Truncate(time.Microsecond),
+	})
+
+	data, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	ok, err := redis.EnqueuePendingURL(context.Background(), payload.Url.ShortUrl, data, payload.Url)
+	require.NoError(t, err)
+	require.True(t, ok)
+	return payload
+}
+
+// writtenURL returns the link of the payload as the database writes it.
+func writtenURL(payload *PayloadCreateURL) db.Url {
+	url := payload.Url
+	url.ID = util.RandomInt(1, 1000)
+	return url
+}
+
+func requirePendingURL(t *testing.T, redis redis.RedisQuerier, shortUrl string, want bool) {
+	_, found, err := redis.GetPendingURL(context.Background(), shortUrl)
+	require.NoError(t, err)
+	require.Equal(t, want, found)
+}
+
+func TestURLFlusher_Flush(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	memory := redis.NewMemoryQuery()
+	payloads := []*PayloadCreateURL{enqueueTestURL(t, memory), enqueueTestURL(t, memory)}
+
+	// 寫入前的點擊以 ID 0 記錄
+	_, err := memory.IncrClick(ctx, payloads[0].Url.ShortUrl, payloads[0].Url)
+	require.NoError(t, err)
+	_, err = memory.PopDirtyClicks(ctx)
+	require.NoError(t, err)
+
+	urls := []db.Url{writtenURL(payloads[0]), writtenURL(payloads[1])}
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		CreateURLBatchTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, args []db.CreateURLTxParams) ([]db.URLBatchResult, error) {
+			require.Len(t, args, 2)
+			for i, arg := range args {
+				require.Equal(t, payloads[i].Url.ShortUrl, arg.ShortUrl)
+				require.Equal(t, payloads[i].Url.OriginUrl, arg.OriginUrl)
+				require.Equal(t, []string{"launch"}, arg.Tags)
+				require.True(t, payloads[i].Url.CreatedAt.Equal(arg.CreatedAt))
+			}
+
+			return []db.URLBatchResult{
+				{URLTxResult: db.URLTxResult{Url: urls[0], Tags: []string{"launch"}}},
+				{URLTxResult: db.URLTxResult{Url: urls[1], Tags: []string{"launch"}}},
+			}, nil
+		})
+
+	flusher := newTestURLFlusher(store, memory, 3)
+	require.NoError(t, flusher.Flush(ctx))
+
+	for i, payload := range payloads {
+		requirePendingURL(t, memory, payload.Url.ShortUrl, false)
+
+		// 快取改為資料庫中的連結
+		cached, found, err := memory.GetData(ctx, payload.Url.ShortUrl)
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, urls[i].ID, cached.ID)
+	}
+
+	dirty, err := memory.PopDirtyClicks(ctx)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{payloads[0].Url.ShortUrl: urls[0].ID}, dirty)
+
+	// 寫入後才送出背景工作
+	for _, queue := range []string{QueueFetchMetadata, QueueFetchMetadata, QueueWebhookEvent, QueueWebhookEvent} {
+		_, found, err := memory.PopTask(ctx, time.Millisecond, queue)
+		require.NoError(t, err)
+		require.True(t, found)
+	}
+
+	stats := flusher.Stats()
+	require.Equal(t, int64(2), stats.Flushed)
+	require.Zero(t, stats.Pending)
+	require.Zero(t, stats.Failed)
+	require.GreaterOrEqual(t, stats.LagSeconds, 2.0)
+	require.Equal(t, stats.LagSeconds, stats.MaxLagSeconds)
+	require.WithinDuration(t, time.Now(), stats.LastFlushAt, time.Second)
+}
+
+func TestURLFlusher_FlushRetry(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	memory := redis.NewMemoryQuery()
+	ok := enqueueTestURL(t, memory)
+	bad := enqueueTestURL(t, memory)
+
+	// 整批失敗時逐筆寫入，只有失敗的連結留在佇列
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		CreateURLBatchTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil, sql.ErrConnDone)
+	store.EXPECT().
+		CreateURLTx(gomock.Any(), gomock.Any()).
+		Times(2).
+		DoAndReturn(func(_ context.Context, arg db.CreateURLTxParams) (db.URLTxResult, error) {
+			if arg.ShortUrl == bad.Url.ShortUrl {
+				return db.URLTxResult{}, sql.ErrConnDone
+			}
+			return db.URLTxResult{Url: writtenURL(ok)}, nil
+		})
+
+	flusher := newTestURLFlusher(store, memory, 2)
+	flusher.retryDelay = 100 * time.Millisecond
+	require.NoError(t, flusher.Flush(ctx))
+
+	requirePendingURL(t, memory, ok.Url.ShortUrl, false)
+	requirePendingURL(t, memory, bad.Url.ShortUrl, true)
+
+	stats := flusher.Stats()
+	require.Equal(t, int64(1), stats.Flushed)
+	require.Equal(t, int64(1), stats.Retries)
+	require.Equal(t, int64(1), stats.Pending)
+
+	// 重試延遲內不會再寫入
+	require.NoError(t, flusher.Flush(ctx))
+
+	// 超過次數後移到寫入失敗的集合，仍然可以轉址
+	time.Sleep(150 * time.Millisecond)
+	store.EXPECT().
+		CreateURLBatchTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.URLBatchResult{{Err: sql.ErrConnDone}}, nil)
+	require.NoError(t, flusher.Flush(ctx))
+
+	requirePendingURL(t, memory, bad.Url.ShortUrl, true)
+
+	stats = flusher.Stats()
+	require.Equal(t, int64(2), stats.Retries)
+	require.Zero(t, stats.Pending)
+	require.Equal(t, int64(1), stats.Failed)
+
+	time.Sleep(150 * time.Millisecond)
+	require.NoError(t, flusher.Flush(ctx))
+}
+
+func TestURLFlusher_FlushConflict(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	memory := redis.NewMemoryQuery()
+	written := enqueueTestURL(t, memory)
+	taken := enqueueTestURL(t, memory)
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		CreateURLBatchTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.URLBatchResult{
+			{Err: &db.ShortURLConflictError{ShortUrl: written.Url.ShortUrl}},
+			{Err: &db.ShortURLConflictError{ShortUrl: taken.Url.ShortUrl}},
+		}, nil)
+
+	// 上次寫入後沒有確認的連結視為已寫入
+	store.EXPECT().
+		GetURL(gomock.Any(), gomock.Eq(db.GetURLParams{ShortUrl: written.Url.ShortUrl})).
+		Times(1).
+		Return(writtenURL(written), nil)
+
+	// 短網址被其他連結使用時放棄寫入
+	other := writtenURL(taken)
+	other.OriginUrl = "https://example.com"
+	store.EXPECT().
+		GetURL(gomock.Any(), gomock.Eq(db.GetURLParams{ShortUrl: taken.Url.ShortUrl})).
+		Times(1).
+		Return(other, nil)
+
+	// 放棄的連結留下稽核紀錄
+	store.EXPECT().
+		CreateAuditEvent(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreateAuditEventParams) (db.AuditEvent, error) {
+			require.Equal(t, db.AuditActionDiscard, arg.Action)
+			require.Equal(t, taken.Url.ShortUrl, arg.ShortUrl)
+			require.Zero(t, arg.UrlID)
+			require.Contains(t, string(arg.Before), taken.Url.OriginUrl)
+			require.JSONEq(t, "null", string(arg.After))
+			return db.AuditEvent{}, nil
+		})
+
+	flusher := newTestURLFlusher(store, memory, 3)
+	require.NoError(t, flusher.Flush(ctx))
+
+	requirePendingURL(t, memory, written.Url.ShortUrl, false)
+	requirePendingURL(t, memory, taken.Url.ShortUrl, false)
+
+	cached, found, err := memory.GetData(ctx, written.Url.ShortUrl)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.NotZero(t, cached.ID)
+
+	_, found, err = memory.GetData(ctx, taken.Url.ShortUrl)
+	require.NoError(t, err)
+	require.False(t, found)
+
+	stats := flusher.Stats()
+	require.Equal(t, int64(1), stats.Flushed)
+	require.Equal(t, int64(1), stats.Conflicts)
+	require.Zero(t, stats.Pending)
+}
+
+func TestURLFlusher_FlushConflictStore(t *testing.T) {
+	ctx := context.Background()
+
+	backend, err := kv.OpenBolt(filepath.Join(t.TempDir(), "flush.db"))
+	require.NoError(t, err)
+	defer backend.Close()
+
+	store, err := kv.NewStore(backend)
+	require.NoError(t, err)
+
+	memory := redis.NewMemoryQuery()
+	written := enqueueTestURL(t, memory)
+	taken := enqueueTestURL(t, memory)
+	fresh := enqueueTestURL(t, memory)
+
+	// 上次寫入後沒有確認的連結
+	_, err = store.CreateURLTx(ctx, written.createURLTxParams())
+	require.NoError(t, err)
+
+	// 其他連結使用了相同的短網址
+	other := taken.createURLTxParams()
+	other.OriginUrl = "https://example.com"
+	other.CreatedAt = time.Time{}
+	_, err = store.CreateURLTx(ctx, other)
+	require.NoError(t, err)
+
+	flusher := newTestURLFlusher(store, memory, 3)
+	require.NoError(t, flusher.Flush(ctx))
+
+	for _, payload := range []*PayloadCreateURL{written, taken, fresh} {
+		requirePendingURL(t, memory, payload.Url.ShortUrl, false)
+	}
+
+	url, err := store.GetURL(ctx, db.GetURLParams{ShortUrl: fresh.Url.ShortUrl})
+	require.NoError(t, err)
+	require.Equal(t, fresh.Url.OriginUrl, url.OriginUrl)
+
+	url, err = store.GetURL(ctx, db.GetURLParams{ShortUrl: taken.Url.ShortUrl})
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com", url.OriginUrl)
+
+	events, err := store.ListAuditEvents(ctx, db.ListAuditEventsParams{ShortUrl: taken.Url.ShortUrl, Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, db.AuditActionDiscard, events[0].Action)
+
+	stats := flusher.Stats()
+	require.Equal(t, int64(2), stats.Flushed)
+	require.Equal(t, int64(1), stats.Conflicts)
+	require.Zero(t, stats.Retries)
+	require.Zero(t, stats.Failed)
+}
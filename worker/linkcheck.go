@@ -0,0 +1,326 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+)
+
+const (
+	maxCheckRedirects    = 10
+	maxCheckErrorLength  = 500
+	linkCheckerUserAgent = "shortURL-link-checker/1.0"
+)
+
+// RedirectHop is a single response in the redirect chain of a link check.
+type RedirectHop struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+}
+
+// LinkCheckResult is the outcome of checking a destination.
+type LinkCheckResult struct {
+	StatusCode int
+	Chain      []RedirectHop
+	Err        error
+}
+
+// Failed reports whether the destination should be counted as a failure.
+func (r LinkCheckResult) Failed() bool {
+	return r.Err != nil || r.StatusCode >= http.StatusBadRequest
+}
+
+// LinkChecker periodically checks that the destinations of links are reachable.
+// Links are marked as broken after consecutive failed checks.
+type LinkChecker struct {
+	store            db.Querier
+	distributor      TaskDistributor
+	client           *http.Client
+	interval         time.Duration
+	maxAge           time.Duration
+	batchSize        int32
+	concurrency      int
+	hostDelay        time.Duration
+	failureThreshold int32
+}
+
+// NewLinkChecker creates a new LinkChecker from the LINK_CHECK_* settings.
+// A link broken event is distributed when a link is marked as broken.
+func NewLinkChecker(store db.Querier, distributor TaskDistributor, config util.Config) *LinkChecker {
+	return newLinkChecker(store, distributor, config, checkPublicAddress)
+}
+
+func newLinkChecker(store db.Querier, distributor TaskDistributor, config util.Config, control dialControl) *LinkChecker {
+	client := newPublicClient(config.LinkCheckTimeout, control)
+	// 自行處理轉址以記錄轉址過程
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	concurrency := config.LinkCheckConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &LinkChecker{
+		store:            store,
+		distributor:      distributor,
+		client:           client,
+		interval:         config.LinkCheckInterval,
+		maxAge:           config.LinkCheckMaxAge,
+		batchSize:        config.LinkCheckBatchSize,
+		concurrency:      concurrency,
+		hostDelay:        config.LinkCheckHostDelay,
+		failureThreshold: config.LinkCheckFailureThreshold,
+	}
+}
+
+// Start checks a batch of links on every interval until the context is done.
+func (c *LinkChecker) Start(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Check(ctx); err != nil {
+				log.Println("cannot check links:", err)
+			}
+		}
+	}
+}
+
+// Check checks the links which haven't been checked within the max age.
+// Links on the same host are checked one after another with a delay in between,
+// while at most concurrency requests are in flight.
+func (c *LinkChecker) Check(ctx context.Context) error {
+	arg := db.ListURLsToCheckParams{
+		CheckedBefore: time.Now().Add(-c.maxAge),
+		Limit:         c.batchSize,
+	}
+
+	urls, err := c.store.ListURLsToCheck(ctx, arg)
+	if err != nil {
+		return err
+	}
+
+	sem := make(chan struct{}, c.concurrency)
+	var wg sync.WaitGroup
+
+	for _, group := range groupByHost(urls) {
+		wg.Add(1)
+		go func(group []db.Url) {
+			defer wg.Done()
+
+			for i, url := range group {
+				if i > 0 && !sleepContext(ctx, c.hostDelay) {
+					return
+				}
+
+				sem <- struct{}{}
+				err := c.checkURL(ctx, url)
+				<-sem
+
+				if err != nil {
+					log.Printf("cannot check link %d: %v", url.ID, err)
+				}
+			}
+		}(group)
+	}
+
+	wg.Wait()
+	return nil
+}
+
+func (c *LinkChecker) checkURL(ctx context.Context, url db.Url) error {
+	result := c.Probe(ctx, url.OriginUrl)
+
+	chain := result.Chain
+	if chain == nil {
+		chain = []RedirectHop{}
+	}
+
+	chainJSON, err := json.Marshal(chain)
+	if err != nil {
+		return err
+	}
+
+	arg := db.UpdateURLCheckParams{
+		ID:               url.ID,
+		LastStatusCode:   int32(result.StatusCode),
+		RedirectChain:    chainJSON,
+		Failed:           result.Failed(),
+		FailureThreshold: c.failureThreshold,
+	}
+	if result.Err != nil {
+		arg.CheckError = truncate(result.Err.Error(), maxCheckErrorLength)
+	}
+
+	updated, err := c.store.UpdateURLCheck(ctx, arg)
+	if err != nil {
+		return err
+	}
+
+	// 剛被標記為失效時才通知
+	if !url.BrokenAt.Valid && updated.BrokenAt.Valid {
+		c.notifyBroken(ctx, updated)
+	}
+
+	return nil
+}
+
+// Probe requests rawURL and follows its redirects.
+// HEAD is tried first, GET is used when HEAD fails since some servers don't support it.
+func (c *LinkChecker) Probe(ctx context.Context, rawURL string) LinkCheckResult {
+	result := c.follow(ctx, http.MethodHead, rawURL)
+	if result.Err == nil && result.StatusCode < http.StatusBadRequest {
+		return result
+	}
+
+	if ctx.Err() != nil {
+		return result
+	}
+
+	return c.follow(ctx, http.MethodGet, rawURL)
+}
+
+func (c *LinkChecker) follow(ctx context.Context, method string, rawURL string) LinkCheckResult {
+	var result LinkCheckResult
+	target := rawURL
+
+	for {
+		if len(result.Chain) > maxCheckRedirects {
+			result.Err = fmt.Errorf("stopped after %d redirects", maxCheckRedirects)
+			return result
+		}
+
+		u, err := url.Parse(target)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+
+		if u.Scheme != "http" && u.Scheme != "https" {
+			result.Err = fmt.Errorf("unsupported scheme %q", u.Scheme)
+			return result
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		req.Header.Set("User-Agent", linkCheckerUserAgent)
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		// 只需要狀態碼，不讀取內容
+		resp.Body.Close()
+
+		result.StatusCode = resp.StatusCode
+		result.Chain = append(result.Chain, RedirectHop{URL: u.String(), StatusCode: resp.StatusCode})
+
+		location := resp.Header.Get("Location")
+		if !isRedirectStatus(resp.StatusCode) || location == "" {
+			return result
+		}
+
+		next, err := u.Parse(location)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+
+		target = next.String()
+	}
+}
+
+// 發送連結失效事件
+func (c *LinkChecker) notifyBroken(ctx context.Context, url db.Url) {
+	data := LinkBrokenEventData{
+		Link:       NewLinkEventData(url),
+		StatusCode: url.LastStatusCode,
+		Error:      url.CheckError,
+		BrokenAt:   url.BrokenAt.Time,
+	}
+
+	payload, err := NewPayloadWebhookEvent(util.EventLinkBroken, data)
+	if err != nil {
+		log.Println("cannot create link broken event:", err)
+		return
+	}
+
+	if err := c.distributor.DistributeWebhookEvent(ctx, payload); err != nil {
+		log.Println("cannot distribute link broken event:", err)
+	}
+}
+
+// 依主機分組，保留原本的順序
+func groupByHost(urls []db.Url) [][]db.Url {
+	index := make(map[string]int)
+	var groups [][]db.Url
+
+	for _, link := range urls {
+		host := ""
+		if u, err := url.Parse(link.OriginUrl); err == nil {
+			host = strings.ToLower(u.Hostname())
+		}
+
+		i, ok := index[host]
+		if !ok {
+			i = len(groups)
+			index[host] = i
+			groups = append(groups, nil)
+		}
+
+		groups[i] = append(groups[i], link)
+	}
+
+	return groups
+}
+
+func isRedirectStatus(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+
+	return false
+}
+
+// 等待 d，context 結束時回傳 false
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func truncate(s string, maxLength int) string {
+	if len(s) <= maxLength {
+		return s
+	}
+
+	// 避免切在多位元組字元中間
+	return strings.ToValidUTF8(s[:maxLength], "")
+}
@@ -0,0 +1,259 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	mockdb "shortURL/db/mock"
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLinkChecker(store db.Querier, redis *mockdb.MockRedisQuerier) *LinkChecker {
+	config := util.Config{
+		LinkCheckMaxAge:           24 * time.Hour,
+		LinkCheckBatchSize:        100,
+		LinkCheckConcurrency:      2,
+		LinkCheckHostDelay:        10 * time.Millisecond,
+		LinkCheckTimeout:          time.Second,
+		LinkCheckFailureThreshold: 3,
+	}
+
+	return newLinkChecker(store, NewRedisTaskDistributor(redis), config, allowAllAddresses)
+}
+
+func TestLinkChecker_Probe(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/found", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/found", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusFound)
+	})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	mux.HandleFunc("/ftp", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "ftp://example.com/", http.StatusFound)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	testCases := []struct {
+		name        string
+		path        string
+		checkResult func(result LinkCheckResult)
+	}{
+		{
+			name: "Success case",
+			path: "/ok",
+			checkResult: func(result LinkCheckResult) {
+				require.NoError(t, result.Err)
+				require.False(t, result.Failed())
+				require.Equal(t, http.StatusOK, result.StatusCode)
+				require.Equal(t, []RedirectHop{{URL: server.URL + "/ok", StatusCode: http.StatusOK}}, result.Chain)
+			},
+		},
+		{
+			name: "Redirect chain",
+			path: "/moved",
+			checkResult: func(result LinkCheckResult) {
+				require.False(t, result.Failed())
+				require.Equal(t, []RedirectHop{
+					{URL: server.URL + "/moved", StatusCode: http.StatusMovedPermanently},
+					{URL: server.URL + "/found", StatusCode: http.StatusFound},
+					{URL: server.URL + "/ok", StatusCode: http.StatusOK},
+				}, result.Chain)
+			},
+		},
+		{
+			name: "HEAD not allowed",
+			path: "/no-head",
+			checkResult: func(result LinkCheckResult) {
+				require.False(t, result.Failed())
+				require.Equal(t, http.StatusOK, result.StatusCode)
+			},
+		},
+		{
+			name: "Gone",
+			path: "/gone",
+			checkResult: func(result LinkCheckResult) {
+				require.NoError(t, result.Err)
+				require.True(t, result.Failed())
+				require.Equal(t, http.StatusGone, result.StatusCode)
+			},
+		},
+		{
+			name: "Too many redirects",
+			path: "/loop",
+			checkResult: func(result LinkCheckResult) {
+				require.Error(t, result.Err)
+				require.True(t, result.Failed())
+			},
+		},
+		{
+			name: "Unsupported scheme",
+			path: "/ftp",
+			checkResult: func(result LinkCheckResult) {
+				require.Error(t, result.Err)
+				require.True(t, result.Failed())
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			checker := newTestLinkChecker(nil, nil)
+			tc.checkResult(checker.Probe(context.Background(), server.URL+tc.path))
+		})
+	}
+}
+
+func TestLinkChecker_Check(t *testing.T) {
+	var mu sync.Mutex
+	var requestTimes []time.Time
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 失敗時會再以 GET 重試，只記錄每個連結的第一個請求
+		if r.Method == http.MethodHead {
+			mu.Lock()
+			requestTimes = append(requestTimes, time.Now())
+			mu.Unlock()
+		}
+
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	okUrl := db.Url{ID: 1, OriginUrl: server.URL + "/ok", ShortUrl: util.RandomString(6)}
+	goneUrl := db.Url{ID: 2, OriginUrl: server.URL + "/gone", ShortUrl: util.RandomString(6), CheckFailures: 2}
+	brokenAt := time.Now().Truncate(time.Second)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockQuerier(ctrl)
+	store.EXPECT().
+		ListURLsToCheck(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ interface{}, arg db.ListURLsToCheckParams) ([]db.Url, error) {
+			require.Equal(t, int32(100), arg.Limit)
+			require.WithinDuration(t, time.Now().Add(-24*time.Hour), arg.CheckedBefore, time.Second)
+			return []db.Url{okUrl, goneUrl}, nil
+		})
+	store.EXPECT().
+		UpdateURLCheck(gomock.Any(), gomock.Any()).
+		Times(2).
+		DoAndReturn(func(_ interface{}, arg db.UpdateURLCheckParams) (db.Url, error) {
+			require.Equal(t, int32(3), arg.FailureThreshold)
+
+			switch arg.ID {
+			case okUrl.ID:
+				require.False(t, arg.Failed)
+				require.Equal(t, int32(http.StatusOK), arg.LastStatusCode)
+				return okUrl, nil
+			default:
+				require.True(t, arg.Failed)
+				require.Equal(t, int32(http.StatusGone), arg.LastStatusCode)
+
+				updatedUrl := goneUrl
+				updatedUrl.LastStatusCode = arg.LastStatusCode
+				updatedUrl.CheckFailures = 3
+				updatedUrl.BrokenAt = sql.NullTime{Time: brokenAt, Valid: true}
+				return updatedUrl, nil
+			}
+		})
+
+	// 剛被標記為失效時發送事件
+	redis := mockdb.NewMockRedisQuerier(ctrl)
+	redis.EXPECT().
+		PushTask(gomock.Any(), gomock.Eq(QueueWebhookEvent), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ interface{}, _ string, data []byte) error {
+			var payload PayloadWebhookEvent
+			require.NoError(t, json.Unmarshal(data, &payload))
+			require.Equal(t, util.EventLinkBroken, payload.Event)
+
+			var event LinkBrokenEventData
+			require.NoError(t, json.Unmarshal(payload.Data, &event))
+			require.Equal(t, goneUrl.ID, event.Link.ID)
+			require.Equal(t, goneUrl.ShortUrl, event.Link.ShortUrl)
+			require.Equal(t, int32(http.StatusGone), event.StatusCode)
+			require.WithinDuration(t, brokenAt, event.BrokenAt, time.Second)
+			return nil
+		})
+
+	checker := newTestLinkChecker(store, redis)
+	err := checker.Check(context.Background())
+	require.NoError(t, err)
+
+	// 同一主機的請求之間至少間隔 host delay
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, requestTimes, 2)
+	for i := 1; i < len(requestTimes); i++ {
+		require.GreaterOrEqual(t, requestTimes[i].Sub(requestTimes[i-1]), 10*time.Millisecond)
+	}
+}
+
+func TestLinkChecker_CheckAlreadyBroken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	brokenUrl := db.Url{
+		ID:        1,
+		OriginUrl: server.URL,
+		BrokenAt:  sql.NullTime{Time: time.Now().Add(-time.Hour), Valid: true},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockQuerier(ctrl)
+	store.EXPECT().
+		ListURLsToCheck(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.Url{brokenUrl}, nil)
+	store.EXPECT().
+		UpdateURLCheck(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(brokenUrl, nil)
+
+	// 已經失效的連結不會重複發送事件
+	redis := mockdb.NewMockRedisQuerier(ctrl)
+	redis.EXPECT().
+		PushTask(gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+
+	checker := newTestLinkChecker(store, redis)
+	require.NoError(t, checker.Check(context.Background()))
+}
@@ -0,0 +1,326 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	maxMetadataRedirects = 5
+	maxTitleLength       = 200
+	maxDescriptionLength = 1000
+	maxMetadataURLLength = 2048
+)
+
+var (
+	// ErrBlockedAddress is returned when the destination resolves to a non-public address.
+	ErrBlockedAddress = errors.New("destination resolves to a blocked address")
+	// ErrNotHTML is returned when the destination is not an HTML page.
+	ErrNotHTML = errors.New("destination is not an HTML page")
+)
+
+// net.IP 的方法沒有涵蓋，但不應從外部連線的位址範圍
+var blockedNetworks = []*net.IPNet{
+	// 100.64.0.0/10 為電信業者 NAT 使用
+	{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)},
+	// 198.18.0.0/15 為網路效能測試使用
+	{IP: net.IPv4(198, 18, 0, 0), Mask: net.CIDRMask(15, 32)},
+	// 240.0.0.0/4 為保留位址，包含廣播位址
+	{IP: net.IPv4(240, 0, 0, 0), Mask: net.CIDRMask(4, 32)},
+	// 64:ff9b::/96 為 NAT64 轉換的 IPv4 位址，可能對應到內部網路
+	{IP: net.ParseIP("64:ff9b::"), Mask: net.CIDRMask(96, 128)},
+}
+
+// PageMetadata is the information read from the head of an HTML page.
+type PageMetadata struct {
+	Title       string
+	Description string
+	FaviconURL  string
+	ImageURL    string
+}
+
+// MetadataFetcher fetches link destinations and reads their page metadata.
+// Only public addresses are dialed so links can't be used to probe the internal network.
+type MetadataFetcher struct {
+	client      *http.Client
+	maxBodySize int64
+}
+
+// NewMetadataFetcher creates a new MetadataFetcher.
+// Each fetch, including redirects, takes at most timeout and reads at most maxBodySize bytes of the body.
+func NewMetadataFetcher(timeout time.Duration, maxBodySize int64) *MetadataFetcher {
+	return newMetadataFetcher(timeout, maxBodySize, checkPublicAddress)
+}
+
+func newMetadataFetcher(timeout time.Duration, maxBodySize int64, control dialControl) *MetadataFetcher {
+	client := newPublicClient(timeout, control)
+	client.CheckRedirect = checkMetadataRedirect
+
+	return &MetadataFetcher{
+		client:      client,
+		maxBodySize: maxBodySize,
+	}
+}
+
+// 連線前檢查位址的函式，與 net.Dialer.Control 相同
+type dialControl func(network, address string, c syscall.RawConn) error
+
+// 建立只連線到 control 允許的位址的 http client
+func newPublicClient(timeout time.Duration, control dialControl) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		// 連線前檢查解析後的位址，避免 DNS rebinding 繞過檢查
+		Control: control,
+	}
+
+	transport := &http.Transport{
+		// 不經過環境變數設定的 proxy，否則檢查的會是 proxy 的位址
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}
+}
+
+func checkMetadataRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxMetadataRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxMetadataRedirects)
+	}
+
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return fmt.Errorf("unsupported redirect scheme %q", req.URL.Scheme)
+	}
+
+	return nil
+}
+
+// 只允許連線到公開的位址
+func checkPublicAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+	}
+
+	return nil
+}
+
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() {
+		return false
+	}
+
+	// 0.0.0.0/8
+	if ip4 := ip.To4(); ip4 != nil && ip4[0] == 0 {
+		return false
+	}
+
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Fetch retrieves rawURL and reads the metadata in the head of the page.
+// Relative URLs are resolved against the final URL after redirects.
+func (f *MetadataFetcher) Fetch(ctx context.Context, rawURL string) (PageMetadata, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return PageMetadata{}, err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return PageMetadata{}, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return PageMetadata{}, err
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	req.Header.Set("User-Agent", "shortURL-metadata-fetcher/1.0")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return PageMetadata{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return PageMetadata{}, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || (mediaType != "text/html" && mediaType != "application/xhtml+xml") {
+		return PageMetadata{}, ErrNotHTML
+	}
+
+	// 超過大小限制的部分不讀取，head 通常在最前面
+	body, err := charset.NewReader(io.LimitReader(resp.Body, f.maxBodySize), contentType)
+	if err != nil {
+		return PageMetadata{}, err
+	}
+
+	return parseHead(body, resp.Request.URL), nil
+}
+
+// 讀取 head 內的標題、描述、圖示與 OpenGraph 圖片，讀到 body 即停止
+func parseHead(r io.Reader, base *url.URL) PageMetadata {
+	var (
+		metadata      PageMetadata
+		ogTitle       string
+		ogDescription string
+		favicon       string
+	)
+
+	z := html.NewTokenizer(r)
+
+loop:
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			// 讀到結尾或超過大小限制
+			break loop
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if atom.Lookup(name) == atom.Head {
+				break loop
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			tag := atom.Lookup(name)
+
+			switch tag {
+			case atom.Body:
+				break loop
+			case atom.Title:
+				if tt == html.StartTagToken && metadata.Title == "" && z.Next() == html.TextToken {
+					metadata.Title = string(z.Text())
+				}
+			case atom.Meta:
+				attrs := tagAttrs(z, hasAttr)
+				content := attrs["content"]
+
+				switch {
+				case strings.EqualFold(attrs["name"], "description") && metadata.Description == "":
+					metadata.Description = content
+				case strings.EqualFold(attrs["property"], "og:title") && ogTitle == "":
+					ogTitle = content
+				case strings.EqualFold(attrs["property"], "og:description") && ogDescription == "":
+					ogDescription = content
+				case strings.EqualFold(attrs["property"], "og:image") && metadata.ImageURL == "":
+					metadata.ImageURL = content
+				}
+			case atom.Link:
+				attrs := tagAttrs(z, hasAttr)
+				if favicon == "" && isIconRel(attrs["rel"]) {
+					favicon = attrs["href"]
+				}
+			}
+		}
+	}
+
+	if metadata.Title == "" {
+		metadata.Title = ogTitle
+	}
+	if metadata.Description == "" {
+		metadata.Description = ogDescription
+	}
+	if favicon == "" {
+		favicon = "/favicon.ico"
+	}
+
+	metadata.Title = cleanText(metadata.Title, maxTitleLength)
+	metadata.Description = cleanText(metadata.Description, maxDescriptionLength)
+	metadata.FaviconURL = resolveURL(base, favicon)
+	metadata.ImageURL = resolveURL(base, metadata.ImageURL)
+
+	return metadata
+}
+
+func tagAttrs(z *html.Tokenizer, hasAttr bool) map[string]string {
+	attrs := make(map[string]string)
+	for hasAttr {
+		var key, val []byte
+		key, val, hasAttr = z.TagAttr()
+		attrs[strings.ToLower(string(key))] = string(val)
+	}
+
+	return attrs
+}
+
+func isIconRel(rel string) bool {
+	for _, value := range strings.Fields(rel) {
+		if strings.EqualFold(value, "icon") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// 合併空白並限制長度
+func cleanText(text string, maxLength int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= maxLength {
+		return text
+	}
+
+	return string([]rune(text)[:maxLength])
+}
+
+// 轉為絕對網址，只保留 http 與 https
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+
+	resolved := u.String()
+	if len(resolved) > maxMetadataURLLength {
+		return ""
+	}
+
+	return resolved
+}
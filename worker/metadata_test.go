@@ -0,0 +1,248 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// 測試伺服器在本機上，需允許連線到 loopback
+func allowAllAddresses(network, address string, c syscall.RawConn) error {
+	return nil
+}
+
+func newTestFetcher(maxBodySize int64) *MetadataFetcher {
+	return newMetadataFetcher(time.Second, maxBodySize, allowAllAddresses)
+}
+
+func TestMetadataFetcher_Fetch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<!DOCTYPE html>
+<html>
+<head>
+  <title>  Example
+    Domain &amp; Co </title>
+  <meta name="description" content="An example page">
+  <meta property="og:title" content="OG title">
+  <meta property="og:image" content="/images/og.png">
+  <link rel="shortcut icon" href="static/icon.png">
+</head>
+<body><title>Not this one</title></body>
+</html>`)
+	})
+	mux.HandleFunc("/og-only", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head>
+<meta property="og:title" content="OG title">
+<meta property="og:description" content="OG description">
+<meta property="og:image" content="https://cdn.example.com/og.png">
+</head></html>`)
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/docs/page", http.StatusFound)
+	})
+	mux.HandleFunc("/docs/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><title>Docs</title><link rel="icon" href="favicon.png"></head></html>`)
+	})
+	mux.HandleFunc("/big", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html><head>"+strings.Repeat("<!-- padding -->", 1000)+"<title>Too far</title></head></html>")
+	})
+	mux.HandleFunc("/big5", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=big5")
+		// 「短網址」的 Big5 編碼
+		w.Write([]byte("<html><head><title>\xb5\x75\xba\xf4\xa7\x7d</title></head></html>"))
+	})
+	mux.HandleFunc("/image", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte{0x89, 'P', 'N', 'G'})
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	testCases := []struct {
+		name        string
+		path        string
+		maxBodySize int64
+		checkResult func(metadata PageMetadata, err error)
+	}{
+		{
+			name: "Success case",
+			path: "/page",
+			checkResult: func(metadata PageMetadata, err error) {
+				require.NoError(t, err)
+				require.Equal(t, PageMetadata{
+					Title:       "Example Domain & Co",
+					Description: "An example page",
+					FaviconURL:  server.URL + "/static/icon.png",
+					ImageURL:    server.URL + "/images/og.png",
+				}, metadata)
+			},
+		},
+		{
+			name: "OpenGraph fallback",
+			path: "/og-only",
+			checkResult: func(metadata PageMetadata, err error) {
+				require.NoError(t, err)
+				require.Equal(t, PageMetadata{
+					Title:       "OG title",
+					Description: "OG description",
+					FaviconURL:  server.URL + "/favicon.ico",
+					ImageURL:    "https://cdn.example.com/og.png",
+				}, metadata)
+			},
+		},
+		{
+			name: "Relative to final url",
+			path: "/redirect",
+			checkResult: func(metadata PageMetadata, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "Docs", metadata.Title)
+				require.Equal(t, server.URL+"/docs/favicon.png", metadata.FaviconURL)
+			},
+		},
+		{
+			name:        "Body size limit",
+			path:        "/big",
+			maxBodySize: 1024,
+			checkResult: func(metadata PageMetadata, err error) {
+				require.NoError(t, err)
+				require.Empty(t, metadata.Title)
+			},
+		},
+		{
+			name: "Charset",
+			path: "/big5",
+			checkResult: func(metadata PageMetadata, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "短網址", metadata.Title)
+			},
+		},
+		{
+			name: "Not HTML",
+			path: "/image",
+			checkResult: func(metadata PageMetadata, err error) {
+				require.ErrorIs(t, err, ErrNotHTML)
+			},
+		},
+		{
+			name: "Not found",
+			path: "/missing",
+			checkResult: func(metadata PageMetadata, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			name: "Too many redirects",
+			path: "/loop",
+			checkResult: func(metadata PageMetadata, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			name: "Timeout",
+			path: "/slow",
+			checkResult: func(metadata PageMetadata, err error) {
+				require.Error(t, err)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			maxBodySize := tc.maxBodySize
+			if maxBodySize == 0 {
+				maxBodySize = 1 << 20
+			}
+
+			fetcher := newTestFetcher(maxBodySize)
+			tc.checkResult(fetcher.Fetch(context.Background(), server.URL+tc.path))
+		})
+	}
+}
+
+func TestMetadataFetcher_BlockedAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request should not reach the server")
+	}))
+	defer server.Close()
+
+	fetcher := NewMetadataFetcher(time.Second, 1<<20)
+
+	_, err := fetcher.Fetch(context.Background(), server.URL)
+	require.ErrorIs(t, err, ErrBlockedAddress)
+
+	// 轉址到不支援的 scheme
+	public := newTestFetcher(1 << 20)
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "ftp://example.com/", http.StatusFound)
+	}))
+	defer redirect.Close()
+
+	_, err = public.Fetch(context.Background(), redirect.URL)
+	require.Error(t, err)
+
+	_, err = fetcher.Fetch(context.Background(), "file:///etc/passwd")
+	require.Error(t, err)
+}
+
+func TestIsPublicIP(t *testing.T) {
+	testCases := []struct {
+		ip     string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"255.255.255.255", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"224.0.0.1", false},
+		{"198.18.0.1", false},
+		{"198.19.255.254", false},
+		{"198.20.0.1", true},
+		{"240.0.0.1", false},
+		{"64:ff9b::7f00:1", false},
+		{"64:ff9b::a00:1", false},
+		{"2001:4860:4860::8888", true},
+		{"::ffff:198.18.0.1", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.ip, func(t *testing.T) {
+			require.Equal(t, tc.public, isPublicIP(net.ParseIP(tc.ip)))
+		})
+	}
+}
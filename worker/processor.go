@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"shortURL/db/redis"
+	db "shortURL/db/sqlc"
+)
+
+// 佇列皆為空時每次等待的時間，結束時最多需等待這麼久
+const popTimeout = 5 * time.Second
+
+// TaskProcessor runs the background tasks enqueued by a TaskDistributor.
+type TaskProcessor struct {
+	store    db.Querier
+	redis    redis.RedisQuerier
+	fetcher  *MetadataFetcher
+	webhooks *webhookCache
+}
+
+// NewTaskProcessor creates a new TaskProcessor.
+func NewTaskProcessor(store db.Querier, redis redis.RedisQuerier, fetcher *MetadataFetcher) *TaskProcessor {
+	return &TaskProcessor{
+		store:    store,
+		redis:    redis,
+		fetcher:  fetcher,
+		webhooks: newWebhookCache(),
+	}
+}
+
+// Start processes tasks one at a time until the context is done.
+// Failed tasks are logged and dropped.
+func (p *TaskProcessor) Start(ctx context.Context) {
+	for {
+		task, ok, err := p.redis.PopTask(ctx, popTimeout, QueueFetchMetadata, QueueWebhookEvent)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			log.Println("cannot pop task:", err)
+
+			// 避免 redis 無法連線時不斷重試
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		if err := p.ProcessTask(ctx, task); err != nil {
+			log.Printf("cannot process task %s: %v", task.Queue, err)
+		}
+	}
+}
+
+// ProcessTask runs a single task.
+func (p *TaskProcessor) ProcessTask(ctx context.Context, task redis.Task) error {
+	switch task.Queue {
+	case QueueFetchMetadata:
+		return p.processFetchMetadata(ctx, task.Payload)
+	case QueueWebhookEvent:
+		return p.processWebhookEvent(ctx, task.Payload)
+	default:
+		return fmt.Errorf("unknown queue %s", task.Queue)
+	}
+}
+
+func (p *TaskProcessor) processFetchMetadata(ctx context.Context, data []byte) error {
+	var payload PayloadFetchMetadata
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return err
+	}
+
+	metadata, err := p.fetcher.Fetch(ctx, payload.OriginUrl)
+	if err != nil {
+		return fmt.Errorf("cannot fetch %s: %w", payload.OriginUrl, err)
+	}
+
+	arg := db.UpdateURLMetadataParams{
+		ID:              payload.URLID,
+		PageTitle:       metadata.Title,
+		PageDescription: metadata.Description,
+		FaviconUrl:      metadata.FaviconURL,
+		OgImageUrl:      metadata.ImageURL,
+	}
+
+	return p.store.UpdateURLMetadata(ctx, arg)
+}
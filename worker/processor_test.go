@@ -0,0 +1,132 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockdb "shortURL/db/mock"
+	"shortURL/db/redis"
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskProcessor_ProcessTask(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><title>Example</title><meta name="description" content="An example page"></head></html>`)
+	}))
+	defer server.Close()
+
+	urlID := util.RandomInt(1, 1000)
+
+	newTask := func(originUrl string) redis.Task {
+		payload, err := json.Marshal(PayloadFetchMetadata{URLID: urlID, OriginUrl: originUrl})
+		require.NoError(t, err)
+
+		return redis.Task{Queue: QueueFetchMetadata, Payload: payload}
+	}
+
+	testCases := []struct {
+		name        string
+		task        redis.Task
+		buildStubs  func(store *mockdb.MockQuerier)
+		checkResult func(err error)
+	}{
+		{
+			name: "Success case",
+			task: newTask(server.URL + "/"),
+			buildStubs: func(store *mockdb.MockQuerier) {
+				arg := db.UpdateURLMetadataParams{
+					ID:              urlID,
+					PageTitle:       "Example",
+					PageDescription: "An example page",
+					FaviconUrl:      server.URL + "/favicon.ico",
+				}
+
+				store.EXPECT().
+					UpdateURLMetadata(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(nil)
+			},
+			checkResult: func(err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "Fetch error",
+			task: newTask(server.URL + "/missing"),
+			buildStubs: func(store *mockdb.MockQuerier) {
+				store.EXPECT().
+					UpdateURLMetadata(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResult: func(err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			name: "Invalid payload",
+			task: redis.Task{Queue: QueueFetchMetadata, Payload: []byte("{")},
+			buildStubs: func(store *mockdb.MockQuerier) {
+				store.EXPECT().
+					UpdateURLMetadata(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResult: func(err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			name: "Unknown queue",
+			task: redis.Task{Queue: "task:unknown"},
+			buildStubs: func(store *mockdb.MockQuerier) {
+				store.EXPECT().
+					UpdateURLMetadata(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResult: func(err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			name: "InternalError",
+			task: newTask(server.URL + "/"),
+			buildStubs: func(store *mockdb.MockQuerier) {
+				store.EXPECT().
+					UpdateURLMetadata(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sql.ErrConnDone)
+			},
+			checkResult: func(err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQueries := mockdb.NewMockQuerier(ctrl)
+			mockRedis := mockdb.NewMockRedisQuerier(ctrl)
+			tc.buildStubs(mockQueries)
+
+			processor := NewTaskProcessor(mockQueries, mockRedis, newTestFetcher(1<<20))
+			tc.checkResult(processor.ProcessTask(context.Background(), tc.task))
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "shortURL/db/sqlc"
+)
+
+const purgeBatchSize = 100
+
+// URLPurger periodically hard-deletes the links which were deleted longer than the retention ago.
+// A purge audit event is recorded for every purged link in the transaction that purges it.
+// The slugs of purged links can be used again. Their Bloom filter entries stay behind
+// since a Bloom filter can't remove items, which only costs a database lookup on a miss.
+type URLPurger struct {
+	store     db.Store
+	interval  time.Duration
+	retention time.Duration
+}
+
+// NewURLPurger creates a new URLPurger.
+func NewURLPurger(store db.Store, interval time.Duration, retention time.Duration) *URLPurger {
+	return &URLPurger{
+		store:     store,
+		interval:  interval,
+		retention: retention,
+	}
+}
+
+// Start purges the deleted links on every interval until the context is done.
+func (p *URLPurger) Start(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := p.Purge(ctx); err != nil {
+				log.Println("cannot purge deleted links:", err)
+			}
+		}
+	}
+}
+
+// Purge hard-deletes the links past the retention and returns how many were purged.
+func (p *URLPurger) Purge(ctx context.Context) (int, error) {
+	arg := db.PurgeDeletedURLsParams{
+		DeletedBefore: time.Now().Add(-p.retention),
+		Limit:         purgeBatchSize,
+	}
+
+	total := 0
+	for {
+		urls, err := p.store.PurgeDeletedURLsTx(ctx, arg)
+		if err != nil {
+			return total, err
+		}
+
+		total += len(urls)
+		if len(urls) < purgeBatchSize {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "shortURL/db/mock"
+	db "shortURL/db/sqlc"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLPurger_Purge(t *testing.T) {
+	urls := make([]db.Url, purgeBatchSize)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// 一批滿了時繼續刪除下一批
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().
+			PurgeDeletedURLsTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ interface{}, arg db.PurgeDeletedURLsParams) ([]db.Url, error) {
+				require.Equal(t, int32(purgeBatchSize), arg.Limit)
+				require.WithinDuration(t, time.Now().Add(-24*time.Hour), arg.DeletedBefore, time.Second)
+				return urls, nil
+			}),
+		store.EXPECT().
+			PurgeDeletedURLsTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(urls[:3], nil),
+	)
+
+	purger := NewURLPurger(store, time.Hour, 24*time.Hour)
+	purged, err := purger.Purge(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, purgeBatchSize+3, purged)
+}
+
+func TestURLPurger_PurgeError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		PurgeDeletedURLsTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil, sql.ErrConnDone)
+
+	purger := NewURLPurger(store, time.Hour, 24*time.Hour)
+	_, err := purger.Purge(context.Background())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
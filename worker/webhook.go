@@ -0,0 +1,381 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+)
+
+// Constants for the statuses of webhook deliveries
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusDead      = "dead"
+)
+
+const (
+	webhookUserAgent     = "shortURL-webhook/1.0"
+	maxWebhookErrorSize  = 500
+	maxWebhookReadSize   = 64 << 10
+	webhookCacheTTL      = 30 * time.Second
+	webhookEventHeader   = "X-Webhook-Event"
+	webhookDeliverHeader = "X-Webhook-Delivery"
+)
+
+// PayloadWebhookEvent is the payload of a webhook event task.
+type PayloadWebhookEvent struct {
+	Event      string          `json:"event"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// NewPayloadWebhookEvent creates a webhook event occurring now with data marshaled to JSON.
+func NewPayloadWebhookEvent(event string, data interface{}) (*PayloadWebhookEvent, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PayloadWebhookEvent{
+		Event:      event,
+		OccurredAt: time.Now(),
+		Data:       raw,
+	}, nil
+}
+
+// LinkEventData is the data of link events.
+type LinkEventData struct {
+	ID        int64     `json:"id"`
+	DomainID  int64     `json:"domain_id"`
+	ShortUrl  string    `json:"short_url"`
+	OriginUrl string    `json:"origin_url"`
+	Title     string    `json:"title"`
+	Owner     string    `json:"owner"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewLinkEventData creates the event data of a link.
+func NewLinkEventData(url db.Url) LinkEventData {
+	return LinkEventData{
+		ID:        url.ID,
+		DomainID:  url.DomainID,
+		ShortUrl:  url.ShortUrl,
+		OriginUrl: url.OriginUrl,
+		Title:     url.Title,
+		Owner:     url.Owner,
+		CreatedAt: url.CreatedAt,
+	}
+}
+
+// ClickEventData is the data of link clicked events.
+type ClickEventData struct {
+	Link        LinkEventData `json:"link"`
+	Destination string        `json:"destination"`
+	Variant     string        `json:"variant"`
+	UserAgent   string        `json:"user_agent"`
+	Referer     string        `json:"referer"`
+}
+
+// LinkBrokenEventData is the data of link broken events.
+type LinkBrokenEventData struct {
+	Link       LinkEventData `json:"link"`
+	StatusCode int32         `json:"status_code"`
+	Error      string        `json:"error"`
+	BrokenAt   time.Time     `json:"broken_at"`
+}
+
+// 送出的內容，多了投遞編號讓接收端可以去除重複
+type webhookBody struct {
+	ID int64 `json:"id"`
+	PayloadWebhookEvent
+}
+
+// 建立訂閱事件的 webhook 投遞紀錄，由 WebhookDispatcher 送出
+func (p *TaskProcessor) processWebhookEvent(ctx context.Context, data []byte) error {
+	var payload PayloadWebhookEvent
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return err
+	}
+
+	webhooks, err := p.subscribers(ctx, payload.Event)
+	if err != nil {
+		return err
+	}
+
+	for _, webhook := range webhooks {
+		arg := db.CreateWebhookDeliveryParams{
+			WebhookID: webhook.ID,
+			Event:     payload.Event,
+			Payload:   data,
+		}
+
+		_, err := p.store.CreateWebhookDelivery(ctx, arg)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 訂閱事件的 webhook，點擊事件量大，短暫快取避免每個事件都查詢資料庫
+func (p *TaskProcessor) subscribers(ctx context.Context, event string) ([]db.Webhook, error) {
+	if webhooks, ok := p.webhooks.get(event); ok {
+		return webhooks, nil
+	}
+
+	webhooks, err := p.store.ListWebhooksForEvent(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+
+	p.webhooks.set(event, webhooks)
+	return webhooks, nil
+}
+
+type webhookCacheEntry struct {
+	webhooks  []db.Webhook
+	expiredAt time.Time
+}
+
+// 各事件訂閱的 webhook 的記憶體快取
+type webhookCache struct {
+	mu      sync.Mutex
+	entries map[string]webhookCacheEntry
+}
+
+func newWebhookCache() *webhookCache {
+	return &webhookCache{entries: make(map[string]webhookCacheEntry)}
+}
+
+func (c *webhookCache) get(event string) ([]db.Webhook, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[event]
+	if !ok || time.Now().After(entry.expiredAt) {
+		return nil, false
+	}
+
+	return entry.webhooks, true
+}
+
+func (c *webhookCache) set(event string, webhooks []db.Webhook) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[event] = webhookCacheEntry{
+		webhooks:  webhooks,
+		expiredAt: time.Now().Add(webhookCacheTTL),
+	}
+}
+
+// WebhookDispatcher periodically sends the pending webhook deliveries.
+// Failed deliveries are retried with exponential backoff until they run out of attempts
+// and end up dead, where they stay until redelivered.
+type WebhookDispatcher struct {
+	store       db.Querier
+	client      *http.Client
+	interval    time.Duration
+	timeout     time.Duration
+	batchSize   int32
+	concurrency int
+	maxAttempts int32
+	retryBase   time.Duration
+	retryMax    time.Duration
+}
+
+// NewWebhookDispatcher creates a new WebhookDispatcher from the WEBHOOK_* settings.
+func NewWebhookDispatcher(store db.Querier, config util.Config) *WebhookDispatcher {
+	return newWebhookDispatcher(store, config, checkPublicAddress)
+}
+
+func newWebhookDispatcher(store db.Querier, config util.Config, control dialControl) *WebhookDispatcher {
+	// webhook 網址由使用者設定，同樣只允許公開的位址，也不跟隨轉址
+	client := newPublicClient(config.WebhookTimeout, control)
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	concurrency := config.WebhookConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &WebhookDispatcher{
+		store:       store,
+		client:      client,
+		interval:    config.WebhookPollInterval,
+		timeout:     config.WebhookTimeout,
+		batchSize:   config.WebhookBatchSize,
+		concurrency: concurrency,
+		maxAttempts: config.WebhookMaxAttempts,
+		retryBase:   config.WebhookRetryBase,
+		retryMax:    config.WebhookRetryMax,
+	}
+}
+
+// Start sends the due deliveries on every interval until the context is done.
+func (d *WebhookDispatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.Dispatch(ctx); err != nil {
+				log.Println("cannot dispatch webhooks:", err)
+			}
+		}
+	}
+}
+
+// Dispatch sends a batch of due deliveries.
+func (d *WebhookDispatcher) Dispatch(ctx context.Context) error {
+	// 取出的投遞紀錄在送完前不會被其他程序重複取出
+	rounds := (int(d.batchSize) + d.concurrency - 1) / d.concurrency
+	arg := db.ClaimWebhookDeliveriesParams{
+		LeaseUntil: time.Now().Add(time.Duration(rounds+1) * d.timeout),
+		Limit:      d.batchSize,
+	}
+
+	deliveries, err := d.store.ClaimWebhookDeliveries(ctx, arg)
+	if err != nil {
+		return err
+	}
+
+	webhooks := make(map[int64]db.Webhook)
+	for _, delivery := range deliveries {
+		if _, ok := webhooks[delivery.WebhookID]; ok {
+			continue
+		}
+
+		webhook, err := d.store.GetWebhook(ctx, delivery.WebhookID)
+		if err != nil {
+			// webhook 已刪除，投遞紀錄會一併刪除
+			if err == sql.ErrNoRows {
+				continue
+			}
+			return err
+		}
+		webhooks[webhook.ID] = webhook
+	}
+
+	sem := make(chan struct{}, d.concurrency)
+	var wg sync.WaitGroup
+
+	for _, delivery := range deliveries {
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(webhook db.Webhook, delivery db.WebhookDelivery) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := d.deliver(ctx, webhook, delivery); err != nil {
+				log.Printf("cannot update webhook delivery %d: %v", delivery.ID, err)
+			}
+		}(webhook, delivery)
+	}
+
+	wg.Wait()
+	return nil
+}
+
+func (d *WebhookDispatcher) deliver(ctx context.Context, webhook db.Webhook, delivery db.WebhookDelivery) error {
+	statusCode, sendErr := d.send(ctx, webhook, delivery)
+
+	now := time.Now()
+	arg := db.UpdateWebhookDeliveryParams{
+		ID:             delivery.ID,
+		Attempts:       delivery.Attempts + 1,
+		NextAttemptAt:  now,
+		LastStatusCode: int32(statusCode),
+	}
+
+	switch {
+	case sendErr == nil:
+		arg.Status = DeliveryStatusDelivered
+		arg.DeliveredAt = sql.NullTime{Time: now, Valid: true}
+	case arg.Attempts >= d.maxAttempts:
+		arg.Status = DeliveryStatusDead
+		arg.LastError = truncate(sendErr.Error(), maxWebhookErrorSize)
+	default:
+		arg.Status = DeliveryStatusPending
+		arg.LastError = truncate(sendErr.Error(), maxWebhookErrorSize)
+		arg.NextAttemptAt = now.Add(d.Backoff(arg.Attempts))
+	}
+
+	_, err := d.store.UpdateWebhookDelivery(ctx, arg)
+	return err
+}
+
+// Backoff returns how long to wait before retrying after the given number of attempts.
+// The wait doubles after every attempt, starting from the retry base up to the retry max.
+func (d *WebhookDispatcher) Backoff(attempts int32) time.Duration {
+	wait := d.retryBase
+	for i := int32(1); i < attempts && wait < d.retryMax; i++ {
+		wait *= 2
+	}
+
+	if wait > d.retryMax {
+		return d.retryMax
+	}
+
+	return wait
+}
+
+// 送出投遞紀錄，回傳 webhook 回應的狀態碼
+func (d *WebhookDispatcher) send(ctx context.Context, webhook db.Webhook, delivery db.WebhookDelivery) (int, error) {
+	var event PayloadWebhookEvent
+	if err := json.Unmarshal(delivery.Payload, &event); err != nil {
+		return 0, err
+	}
+
+	body, err := json.Marshal(webhookBody{ID: delivery.ID, PayloadWebhookEvent: event})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", webhookUserAgent)
+	req.Header.Set(webhookEventHeader, delivery.Event)
+	req.Header.Set(webhookDeliverHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(util.WebhookSignatureHeader, util.SignWebhookPayload(webhook.Secret, time.Now().Unix(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// 讀完回應才能重複使用連線
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxWebhookReadSize))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
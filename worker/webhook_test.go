@@ -0,0 +1,258 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	mockdb "shortURL/db/mock"
+	"shortURL/db/redis"
+	db "shortURL/db/sqlc"
+	"shortURL/util"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestWebhookDispatcher(store db.Querier) *WebhookDispatcher {
+	config := util.Config{
+		WebhookBatchSize:   100,
+		WebhookConcurrency: 2,
+		WebhookTimeout:     time.Second,
+		WebhookMaxAttempts: 3,
+		WebhookRetryBase:   30 * time.Second,
+		WebhookRetryMax:    time.Hour,
+	}
+
+	return newWebhookDispatcher(store, config, allowAllAddresses)
+}
+
+func newTestDelivery(t *testing.T, webhookID int64, attempts int32) db.WebhookDelivery {
+	payload, err := NewPayloadWebhookEvent(util.EventLinkCreated, LinkEventData{ID: 1, ShortUrl: "abcdef"})
+	require.NoError(t, err)
+
+	data, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	return db.WebhookDelivery{
+		ID:        util.RandomInt(1, 1000),
+		WebhookID: webhookID,
+		Event:     util.EventLinkCreated,
+		Payload:   data,
+		Status:    DeliveryStatusPending,
+		Attempts:  attempts,
+	}
+}
+
+func TestWebhookDispatcher_Dispatch(t *testing.T) {
+	secret := util.RandomString(32)
+	statusCode := http.StatusOK
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		// 接收端可以用密鑰驗證內容
+		_, ok := util.VerifyWebhookSignature(secret, r.Header.Get(util.WebhookSignatureHeader), body)
+		require.True(t, ok)
+		require.Equal(t, util.EventLinkCreated, r.Header.Get(webhookEventHeader))
+
+		var got webhookBody
+		require.NoError(t, json.Unmarshal(body, &got))
+		require.Equal(t, r.Header.Get(webhookDeliverHeader), strconv.FormatInt(got.ID, 10))
+		require.Equal(t, util.EventLinkCreated, got.Event)
+
+		w.WriteHeader(statusCode)
+	}))
+	defer server.Close()
+
+	webhook := db.Webhook{ID: util.RandomInt(1, 1000), Url: server.URL, Secret: secret}
+
+	testCases := []struct {
+		name        string
+		statusCode  int
+		attempts    int32
+		checkUpdate func(arg db.UpdateWebhookDeliveryParams)
+	}{
+		{
+			name:       "Success case",
+			statusCode: http.StatusNoContent,
+			checkUpdate: func(arg db.UpdateWebhookDeliveryParams) {
+				require.Equal(t, DeliveryStatusDelivered, arg.Status)
+				require.Equal(t, int32(1), arg.Attempts)
+				require.Equal(t, int32(http.StatusNoContent), arg.LastStatusCode)
+				require.True(t, arg.DeliveredAt.Valid)
+				require.Empty(t, arg.LastError)
+			},
+		},
+		{
+			name:       "Retry",
+			statusCode: http.StatusInternalServerError,
+			attempts:   1,
+			checkUpdate: func(arg db.UpdateWebhookDeliveryParams) {
+				require.Equal(t, DeliveryStatusPending, arg.Status)
+				require.Equal(t, int32(2), arg.Attempts)
+				require.Equal(t, int32(http.StatusInternalServerError), arg.LastStatusCode)
+				require.NotEmpty(t, arg.LastError)
+				require.False(t, arg.DeliveredAt.Valid)
+				require.WithinDuration(t, time.Now().Add(time.Minute), arg.NextAttemptAt, time.Second)
+			},
+		},
+		{
+			name:       "Dead after max attempts",
+			statusCode: http.StatusGone,
+			attempts:   2,
+			checkUpdate: func(arg db.UpdateWebhookDeliveryParams) {
+				require.Equal(t, DeliveryStatusDead, arg.Status)
+				require.Equal(t, int32(3), arg.Attempts)
+				require.NotEmpty(t, arg.LastError)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			statusCode = tc.statusCode
+			delivery := newTestDelivery(t, webhook.ID, tc.attempts)
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockQuerier(ctrl)
+			store.EXPECT().
+				ClaimWebhookDeliveries(gomock.Any(), gomock.Any()).
+				Times(1).
+				DoAndReturn(func(_ interface{}, arg db.ClaimWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
+					require.Equal(t, int32(100), arg.Limit)
+					require.True(t, arg.LeaseUntil.After(time.Now()))
+					return []db.WebhookDelivery{delivery}, nil
+				})
+			store.EXPECT().
+				GetWebhook(gomock.Any(), gomock.Eq(webhook.ID)).
+				Times(1).
+				Return(webhook, nil)
+			store.EXPECT().
+				UpdateWebhookDelivery(gomock.Any(), gomock.Any()).
+				Times(1).
+				DoAndReturn(func(_ interface{}, arg db.UpdateWebhookDeliveryParams) (db.WebhookDelivery, error) {
+					require.Equal(t, delivery.ID, arg.ID)
+					tc.checkUpdate(arg)
+					return db.WebhookDelivery{}, nil
+				})
+
+			dispatcher := newTestWebhookDispatcher(store)
+			require.NoError(t, dispatcher.Dispatch(context.Background()))
+		})
+	}
+}
+
+func TestWebhookDispatcher_DispatchDeletedWebhook(t *testing.T) {
+	delivery := newTestDelivery(t, util.RandomInt(1, 1000), 0)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockQuerier(ctrl)
+	store.EXPECT().
+		ClaimWebhookDeliveries(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.WebhookDelivery{delivery}, nil)
+	store.EXPECT().
+		GetWebhook(gomock.Any(), gomock.Eq(delivery.WebhookID)).
+		Times(1).
+		Return(db.Webhook{}, sql.ErrNoRows)
+	store.EXPECT().
+		UpdateWebhookDelivery(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	dispatcher := newTestWebhookDispatcher(store)
+	require.NoError(t, dispatcher.Dispatch(context.Background()))
+}
+
+func TestWebhookDispatcher_Backoff(t *testing.T) {
+	dispatcher := newTestWebhookDispatcher(nil)
+
+	require.Equal(t, 30*time.Second, dispatcher.Backoff(1))
+	require.Equal(t, time.Minute, dispatcher.Backoff(2))
+	require.Equal(t, 2*time.Minute, dispatcher.Backoff(3))
+	require.Equal(t, 32*time.Minute, dispatcher.Backoff(7))
+	require.Equal(t, time.Hour, dispatcher.Backoff(8))
+	require.Equal(t, time.Hour, dispatcher.Backoff(100))
+}
+
+func TestTaskProcessor_ProcessWebhookEvent(t *testing.T) {
+	payload, err := NewPayloadWebhookEvent(util.EventLinkClicked, ClickEventData{Destination: "https://example.com"})
+	require.NoError(t, err)
+
+	data, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	webhooks := []db.Webhook{{ID: 1}, {ID: 2}}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// 訂閱的 webhook 會快取，第二個事件不再查詢
+	store := mockdb.NewMockQuerier(ctrl)
+	store.EXPECT().
+		ListWebhooksForEvent(gomock.Any(), gomock.Eq(util.EventLinkClicked)).
+		Times(1).
+		Return(webhooks, nil)
+	store.EXPECT().
+		CreateWebhookDelivery(gomock.Any(), gomock.Any()).
+		Times(4).
+		DoAndReturn(func(_ interface{}, arg db.CreateWebhookDeliveryParams) (db.WebhookDelivery, error) {
+			require.Equal(t, util.EventLinkClicked, arg.Event)
+			require.JSONEq(t, string(data), string(arg.Payload))
+			return db.WebhookDelivery{}, nil
+		})
+
+	processor := NewTaskProcessor(store, mockdb.NewMockRedisQuerier(ctrl), nil)
+	task := redis.Task{Queue: QueueWebhookEvent, Payload: data}
+
+	require.NoError(t, processor.ProcessTask(context.Background(), task))
+	require.NoError(t, processor.ProcessTask(context.Background(), task))
+}
+
+func TestExpiryNotifier_Notify(t *testing.T) {
+	urls := make([]db.Url, expiryBatchSize)
+	for i := range urls {
+		urls[i] = db.Url{ID: int64(i + 1), ShortUrl: util.RandomString(6)}
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// 一批滿了時繼續取下一批
+	store := mockdb.NewMockQuerier(ctrl)
+	gomock.InOrder(
+		store.EXPECT().
+			MarkExpiredURLs(gomock.Any(), gomock.Eq(int32(expiryBatchSize))).
+			Times(1).
+			Return(urls, nil),
+		store.EXPECT().
+			MarkExpiredURLs(gomock.Any(), gomock.Eq(int32(expiryBatchSize))).
+			Times(1).
+			Return(urls[:1], nil),
+	)
+
+	mockRedis := mockdb.NewMockRedisQuerier(ctrl)
+	mockRedis.EXPECT().
+		PushTask(gomock.Any(), gomock.Eq(QueueWebhookEvent), gomock.Any()).
+		Times(expiryBatchSize + 1).
+		DoAndReturn(func(_ interface{}, _ string, data []byte) error {
+			var payload PayloadWebhookEvent
+			require.NoError(t, json.Unmarshal(data, &payload))
+			require.Equal(t, util.EventLinkExpired, payload.Event)
+			return nil
+		})
+
+	notifier := NewExpiryNotifier(store, NewRedisTaskDistributor(mockRedis), time.Minute)
+	require.NoError(t, notifier.Notify(context.Background()))
+}